@@ -0,0 +1,145 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// AnnotationsCustomKey is the reserved key in a target's custom data under which
+// signed annotations are stored.  Other custom data should not use this key.
+const AnnotationsCustomKey = "notary.annotations"
+
+// ErrInvalidAnnotations is returned when annotations cannot be read from or
+// written to a target's custom data
+type ErrInvalidAnnotations struct {
+	Name   string
+	Reason string
+}
+
+func (err ErrInvalidAnnotations) Error() string {
+	return fmt.Sprintf("invalid annotations for target %s: %s", err.Name, err.Reason)
+}
+
+// GetTargetAnnotations returns the annotations stored in the reserved namespace
+// of the target's custom data.  A target without annotations returns an empty map.
+func GetTargetAnnotations(target *Target) (map[string]string, error) {
+	custom, err := customToMap(target.Name, target.Custom)
+	if err != nil {
+		return nil, err
+	}
+	annotations := make(map[string]string)
+	raw, ok := custom[AnnotationsCustomKey]
+	if !ok {
+		return annotations, nil
+	}
+	if err := json.Unmarshal(raw, &annotations); err != nil {
+		return nil, ErrInvalidAnnotations{Name: target.Name, Reason: err.Error()}
+	}
+	return annotations, nil
+}
+
+// SetTargetAnnotations creates a changelist entry to replace the annotations of
+// the named target in the given role when the changelist gets applied at publish
+// time.  Any other custom data on the target is preserved.  The target must
+// either be staged in the changelist or already exist in the role.
+func (r *repository) SetTargetAnnotations(role data.RoleName, name string, annotations map[string]string) error {
+	meta, err := r.stagedOrPublishedTargetMeta(role, name)
+	if err != nil {
+		return err
+	}
+
+	custom, err := customToMap(name, meta.Custom)
+	if err != nil {
+		return err
+	}
+	annotationsJSON, err := json.Marshal(annotations)
+	if err != nil {
+		return err
+	}
+	custom[AnnotationsCustomKey] = annotationsJSON
+
+	customJSON, err := json.Marshal(custom)
+	if err != nil {
+		return err
+	}
+	rawCustom := canonicaljson.RawMessage(customJSON)
+	meta.Custom = &rawCustom
+
+	logrus.Debugf("Setting %d annotations on target \"%s\" in %s", len(annotations), name, role)
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	template := changelist.NewTUFChange(
+		changelist.ActionCreate, "", changelist.TypeTargetsTarget, name, metaJSON)
+	return addChange(r.changelist, template, role)
+}
+
+// checkNoAnnotations returns ErrInvalidAnnotations if the custom data is a
+// JSON object using the reserved annotations key, which only
+// SetTargetAnnotations may set
+func checkNoAnnotations(name string, custom *canonicaljson.RawMessage) error {
+	parsed, err := customToMap(name, custom)
+	if err != nil {
+		// custom data other than an object cannot hold annotations
+		return nil
+	}
+	if _, ok := parsed[AnnotationsCustomKey]; ok {
+		return ErrInvalidAnnotations{Name: name, Reason: fmt.Sprintf(
+			"custom data may not use the reserved key %s, set annotations with SetTargetAnnotations",
+			AnnotationsCustomKey)}
+	}
+	return nil
+}
+
+// stagedOrPublishedTargetMeta returns the file meta of the named target in the
+// given role, preferring the most recent change staged in the changelist over
+// the published metadata
+func (r *repository) stagedOrPublishedTargetMeta(role data.RoleName, name string) (*data.FileMeta, error) {
+	var staged changelist.Change
+	for _, c := range r.changelist.List() {
+		if c.Scope() == role && c.Type() == changelist.TypeTargetsTarget && c.Path() == name {
+			staged = c
+		}
+	}
+	if staged != nil {
+		if staged.Action() == changelist.ActionDelete {
+			return nil, ErrNoSuchTarget(name)
+		}
+		meta := &data.FileMeta{}
+		if err := json.Unmarshal(staged.Content(), meta); err != nil {
+			return nil, err
+		}
+		return meta, nil
+	}
+
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	meta := r.tufRepo.TargetMeta(role, name)
+	if meta == nil {
+		return nil, ErrNoSuchTarget(name)
+	}
+	return meta, nil
+}
+
+// customToMap parses a target's custom data as a JSON object.  Nil custom data
+// is treated as an empty object.
+func customToMap(name string, custom *canonicaljson.RawMessage) (map[string]json.RawMessage, error) {
+	parsed := make(map[string]json.RawMessage)
+	if custom == nil {
+		return parsed, nil
+	}
+	if err := json.Unmarshal(*custom, &parsed); err != nil {
+		return nil, ErrInvalidAnnotations{Name: name, Reason: "custom data is not a JSON object"}
+	}
+	if parsed == nil {
+		parsed = make(map[string]json.RawMessage)
+	}
+	return parsed, nil
+}
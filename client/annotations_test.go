@@ -0,0 +1,100 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+func TestGetTargetAnnotationsNoCustomData(t *testing.T) {
+	annotations, err := GetTargetAnnotations(&Target{Name: "current"})
+	require.NoError(t, err)
+	require.Empty(t, annotations)
+
+	custom := json.RawMessage(`"not an object"`)
+	_, err = GetTargetAnnotations(&Target{Name: "current", Custom: &custom})
+	require.IsType(t, ErrInvalidAnnotations{}, err)
+}
+
+// Annotations set on a staged target, and then re-set on the published target,
+// are published and can be read back from the verified targets, preserving any
+// other custom data on the target
+func TestTargetAnnotationsRoundTrip(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	custom := json.RawMessage(`{"owner": "ci"}`)
+	addTargetWithCustom(t, repo, "current", "../fixtures/intermediate-ca.crt", &custom)
+	require.NoError(t, repo.SetTargetAnnotations(data.CanonicalTargetsRole, "current",
+		map[string]string{"severity": "high"}))
+	require.NoError(t, repo.Publish())
+
+	target, err := repo.GetTargetByName("current")
+	require.NoError(t, err)
+	annotations, err := GetTargetAnnotations(&target.Target)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"severity": "high"}, annotations)
+
+	// update the annotations on the already published target
+	require.NoError(t, repo.SetTargetAnnotations(data.CanonicalTargetsRole, "current",
+		map[string]string{"severity": "low", "cve": "CVE-2024-1"}))
+	require.NoError(t, repo.Publish())
+
+	otherRepo, _, otherDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(otherDir)
+	target, err = otherRepo.GetTargetByName("current")
+	require.NoError(t, err)
+	annotations, err = GetTargetAnnotations(&target.Target)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"severity": "low", "cve": "CVE-2024-1"}, annotations)
+
+	var parsedCustom map[string]interface{}
+	require.NoError(t, json.Unmarshal(*target.Custom, &parsedCustom))
+	require.Equal(t, "ci", parsedCustom["owner"])
+}
+
+// Annotations cannot be set on a target that is neither staged nor published
+func TestSetTargetAnnotationsUnknownTarget(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	err := repo.SetTargetAnnotations(data.CanonicalTargetsRole, "missing", map[string]string{"a": "b"})
+	require.IsType(t, ErrNoSuchTarget(""), err)
+
+	// a target whose latest staged change is a removal is also unknown
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.RemoveTarget("current"))
+	err = repo.SetTargetAnnotations(data.CanonicalTargetsRole, "current", map[string]string{"a": "b"})
+	require.IsType(t, ErrNoSuchTarget(""), err)
+}
+
+// AddTarget rejects custom data using the reserved annotations key, which only
+// SetTargetAnnotations may set
+func TestAddTargetRejectsReservedAnnotationsKey(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	custom := json.RawMessage(`{"owner": "ci", "notary.annotations": {"severity": "high"}}`)
+	target, err := NewTarget("current", "../fixtures/intermediate-ca.crt", &custom)
+	require.NoError(t, err)
+	require.IsType(t, ErrInvalidAnnotations{}, repo.AddTarget(target))
+	require.Empty(t, getChanges(t, repo))
+
+	// other custom data, object or not, is accepted
+	custom = json.RawMessage(`"not an object"`)
+	addTargetWithCustom(t, repo, "current", "../fixtures/intermediate-ca.crt", &custom)
+	require.Len(t, getChanges(t, repo), 1)
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// AttestationType is the type of the signed portion of an attestation
+const AttestationType = "VerificationAttestation"
+
+// Attestation records that a target was verified against a particular root
+// version at a particular time
+type Attestation struct {
+	Type        string        `json:"_type"`
+	GUN         data.GUN      `json:"gun"`
+	Target      string        `json:"target"`
+	Role        data.RoleName `json:"role"`
+	Length      int64         `json:"length"`
+	Hashes      data.Hashes   `json:"hashes"`
+	RootVersion int           `json:"root_version"`
+	VerifiedAt  time.Time     `json:"verified_at"`
+}
+
+// ErrInvalidAttestation is returned when an attestation cannot be parsed or its
+// signature is not valid for the expected key
+type ErrInvalidAttestation struct {
+	Reason string
+}
+
+func (err ErrInvalidAttestation) Error() string {
+	return fmt.Sprintf("invalid attestation: %s", err.Reason)
+}
+
+// VerifyAndAttest updates the repository and verifies the named target, and on
+// success returns a signed attestation recording the target, its hashes, the
+// root version it was verified against and the time of verification.  The
+// attestation is a data.Signed envelope signed by attestKey.  If verification
+// fails, an error is returned and no attestation is produced.
+func (r *repository) VerifyAndAttest(name string, attestKey data.PrivateKey) ([]byte, error) {
+	target, err := r.GetTargetByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	attestation := Attestation{
+		Type:        AttestationType,
+		GUN:         r.gun,
+		Target:      target.Name,
+		Role:        target.Role,
+		Length:      target.Length,
+		Hashes:      target.Hashes,
+		RootVersion: r.tufRepo.Root.Signed.Version,
+		VerifiedAt:  time.Now().UTC(),
+	}
+	return signEnvelope(attestation, attestKey)
+}
+
+// VerifyAttestation checks that an attestation produced by VerifyAndAttest is
+// signed by the given key, and returns its contents
+func VerifyAttestation(attestationJSON []byte, attestKey data.PublicKey) (*Attestation, error) {
+	content, err := openEnvelope(attestationJSON, attestKey)
+	if err != nil {
+		return nil, ErrInvalidAttestation{Reason: err.Error()}
+	}
+
+	attestation := &Attestation{}
+	if err := canonicaljson.Unmarshal(content, attestation); err != nil {
+		return nil, ErrInvalidAttestation{Reason: err.Error()}
+	}
+	if attestation.Type != AttestationType {
+		return nil, ErrInvalidAttestation{Reason: fmt.Sprintf("unexpected type %q", attestation.Type)}
+	}
+	return attestation, nil
+}
+
+// signEnvelope marshals the content canonically and returns it in a
+// data.Signed envelope signed by the key
+func signEnvelope(content interface{}, key data.PrivateKey) ([]byte, error) {
+	contentJSON, err := canonicaljson.MarshalCanonical(content)
+	if err != nil {
+		return nil, err
+	}
+	raw := canonicaljson.RawMessage(contentJSON)
+
+	sig, err := key.Sign(rand.Reader, raw, nil)
+	if err != nil {
+		return nil, err
+	}
+	return canonicaljson.Marshal(data.Signed{
+		Signed: &raw,
+		Signatures: []data.Signature{{
+			KeyID:     key.ID(),
+			Method:    key.SignatureAlgorithm(),
+			Signature: sig,
+		}},
+	})
+}
+
+// openEnvelope parses a data.Signed envelope produced by signEnvelope, and
+// returns its content if it is signed by the key
+func openEnvelope(envelopeJSON []byte, key data.PublicKey) (canonicaljson.RawMessage, error) {
+	s := &data.Signed{}
+	if err := canonicaljson.Unmarshal(envelopeJSON, s); err != nil {
+		return nil, err
+	}
+	if s.Signed == nil {
+		return nil, errors.New("no signed content")
+	}
+
+	var verified bool
+	for i, sig := range s.Signatures {
+		if sig.KeyID != key.ID() {
+			continue
+		}
+		if err := signed.VerifySignature(*s.Signed, &s.Signatures[i], key); err != nil {
+			return nil, err
+		}
+		verified = true
+	}
+	if !verified {
+		return nil, fmt.Errorf("not signed by key %s", key.ID())
+	}
+	return *s.Signed, nil
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"crypto/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+func TestVerifyAndAttest(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	target := addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	attestKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+
+	before := time.Now().Add(-time.Second)
+	attestationJSON, err := repo.VerifyAndAttest("latest", attestKey)
+	require.NoError(t, err)
+
+	attestation, err := VerifyAttestation(attestationJSON, data.PublicKeyFromPrivate(attestKey))
+	require.NoError(t, err)
+	require.Equal(t, data.GUN("docker.com/notary"), attestation.GUN)
+	require.Equal(t, "latest", attestation.Target)
+	require.Equal(t, data.CanonicalTargetsRole, attestation.Role)
+	require.Equal(t, target.Length, attestation.Length)
+	require.Equal(t, target.Hashes, attestation.Hashes)
+	require.Equal(t, repo.tufRepo.Root.Signed.Version, attestation.RootVersion)
+	require.True(t, attestation.VerifiedAt.After(before))
+
+	// the attestation does not verify with a different key
+	otherKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = VerifyAttestation(attestationJSON, data.PublicKeyFromPrivate(otherKey))
+	require.IsType(t, ErrInvalidAttestation{}, err)
+
+	// nor once it has been tampered with
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(attestationJSON, s))
+	tampered := json.RawMessage([]byte(`{"_type":"VerificationAttestation","target":"other"}`))
+	s.Signed = &tampered
+	tamperedJSON, err := json.Marshal(s)
+	require.NoError(t, err)
+	_, err = VerifyAttestation(tamperedJSON, data.PublicKeyFromPrivate(attestKey))
+	require.IsType(t, ErrInvalidAttestation{}, err)
+}
+
+func TestVerifyAndAttestInvalidTarget(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	attestKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+
+	attestationJSON, err := repo.VerifyAndAttest("nonexistent", attestKey)
+	require.IsType(t, ErrNoSuchTarget(""), err)
+	require.Nil(t, attestationJSON)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf"
+)
+
+// CanaryConfig designates a target whose custom data carries a server-asserted
+// current timestamp.  Comparing that timestamp against real time lets a client
+// detect freeze attacks independently of metadata expiry.
+type CanaryConfig struct {
+	// TargetName is the name of the canary target in the repository
+	TargetName string
+	// MaxStaleness is how old the canary's embedded timestamp may be before
+	// the repository is considered possibly frozen
+	MaxStaleness time.Duration
+}
+
+// canaryCustom is the expected structure of a canary target's custom data
+type canaryCustom struct {
+	Timestamp *time.Time `json:"timestamp"`
+}
+
+// ErrInvalidCanary is returned when the canary target is missing or does not
+// carry a readable timestamp in its custom data
+type ErrInvalidCanary struct {
+	Name   string
+	Reason string
+}
+
+func (err ErrInvalidCanary) Error() string {
+	return fmt.Sprintf("invalid canary target %s: %s", err.Name, err.Reason)
+}
+
+// CanaryTimestamp returns the server-asserted timestamp embedded in the
+// custom data of a canary target, which must be of the form
+// {"timestamp": "<RFC 3339 time>"}
+func CanaryTimestamp(target *Target) (time.Time, error) {
+	if target.Custom == nil {
+		return time.Time{}, ErrInvalidCanary{Name: target.Name, Reason: "no custom data"}
+	}
+	var custom canaryCustom
+	if err := json.Unmarshal(*target.Custom, &custom); err != nil {
+		return time.Time{}, ErrInvalidCanary{Name: target.Name, Reason: err.Error()}
+	}
+	if custom.Timestamp == nil {
+		return time.Time{}, ErrInvalidCanary{Name: target.Name, Reason: "no timestamp in custom data"}
+	}
+	return *custom.Timestamp, nil
+}
+
+// checkCanary resolves the configured canary target and returns whether its
+// embedded timestamp is older than the configured staleness bound at the
+// given time
+func checkCanary(ro ReadOnly, canary CanaryConfig, now time.Time) (bool, error) {
+	target, err := ro.GetTargetByName(canary.TargetName)
+	if err != nil {
+		return false, ErrInvalidCanary{Name: canary.TargetName, Reason: err.Error()}
+	}
+	asserted, err := CanaryTimestamp(&target.Target)
+	if err != nil {
+		return false, err
+	}
+	return now.Sub(asserted) > canary.MaxStaleness, nil
+}
+
+// warnIfCanaryStale logs a warning if the canary target is unreadable, or if
+// its timestamp indicates the repository may be frozen
+func warnIfCanaryStale(repo *tuf.Repo, canary CanaryConfig, now time.Time) {
+	stale, err := checkCanary(NewReadOnly(repo), canary, now)
+	switch {
+	case err != nil:
+		logrus.Warnf("unable to check canary target: %s", err)
+	case stale:
+		logrus.Warnf("canary target %s is older than %s, the repository may be frozen",
+			canary.TargetName, canary.MaxStaleness)
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// warningRecorder is a logrus hook that records every warning logged
+type warningRecorder struct {
+	messages []string
+}
+
+func (w *warningRecorder) Levels() []logrus.Level {
+	return []logrus.Level{logrus.WarnLevel}
+}
+
+func (w *warningRecorder) Fire(entry *logrus.Entry) error {
+	w.messages = append(w.messages, entry.Message)
+	return nil
+}
+
+// recordWarnings installs a warningRecorder on the standard logger, returning
+// the recorder and a function that restores the previous hooks
+func recordWarnings() (*warningRecorder, func()) {
+	logger := logrus.StandardLogger()
+	oldHooks := logger.Hooks
+	logger.Hooks = make(logrus.LevelHooks)
+	for level, hooks := range oldHooks {
+		logger.Hooks[level] = append(logger.Hooks[level], hooks...)
+	}
+	recorder := &warningRecorder{}
+	logger.Hooks.Add(recorder)
+	return recorder, func() { logger.Hooks = oldHooks }
+}
+
+func (w *warningRecorder) containing(substr string) []string {
+	var matches []string
+	for _, msg := range w.messages {
+		if strings.Contains(msg, substr) {
+			matches = append(matches, msg)
+		}
+	}
+	return matches
+}
+
+// creates repo metadata with a canary target asserting the given time
+func canaryRepoMetadata(t *testing.T, asserted time.Time) map[data.RoleName][]byte {
+	tufRepo, _, err := testutils.EmptyRepo("docker.com/notary")
+	require.NoError(t, err)
+
+	custom := json.RawMessage(fmt.Sprintf(`{"timestamp": %q}`, asserted.Format(time.RFC3339)))
+	_, err = tufRepo.AddTargets(data.CanonicalTargetsRole, data.Files{
+		"canary": {Length: 1, Hashes: data.Hashes{"sha256": []byte("abc")}, Custom: &custom},
+	})
+	require.NoError(t, err)
+
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	return meta
+}
+
+func TestCanaryTimestamp(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	custom := json.RawMessage(fmt.Sprintf(`{"timestamp": %q}`, now.Format(time.RFC3339)))
+	asserted, err := CanaryTimestamp(&Target{Name: "canary", Custom: &custom})
+	require.NoError(t, err)
+	require.True(t, now.Equal(asserted))
+
+	// no custom data
+	_, err = CanaryTimestamp(&Target{Name: "canary"})
+	require.IsType(t, ErrInvalidCanary{}, err)
+
+	// custom data without a timestamp
+	custom = json.RawMessage(`{"other": "value"}`)
+	_, err = CanaryTimestamp(&Target{Name: "canary", Custom: &custom})
+	require.IsType(t, ErrInvalidCanary{}, err)
+}
+
+func TestCanaryStalenessWarning(t *testing.T) {
+	canary := CanaryConfig{TargetName: "canary", MaxStaleness: time.Hour}
+
+	for _, testCase := range []struct {
+		asserted time.Time
+		stale    bool
+	}{
+		{asserted: time.Now().Add(-2 * time.Hour), stale: true},
+		{asserted: time.Now().Add(-time.Minute), stale: false},
+	} {
+		meta := canaryRepoMetadata(t, testCase.asserted)
+
+		recorder, restore := recordWarnings()
+		_, _, err := LoadTUFRepo(TUFLoadOptions{
+			GUN:    "docker.com/notary",
+			Cache:  store.NewMemoryStore(meta),
+			Canary: &canary,
+		})
+		restore()
+		require.NoError(t, err)
+
+		if testCase.stale {
+			require.Len(t, recorder.containing("may be frozen"), 1)
+		} else {
+			require.Empty(t, recorder.containing("canary"))
+		}
+	}
+}
+
+func TestCanaryMissingTargetWarns(t *testing.T) {
+	meta, _, err := testutils.NewRepoMetadata("docker.com/notary")
+	require.NoError(t, err)
+
+	recorder, restore := recordWarnings()
+	defer restore()
+	_, _, err = LoadTUFRepo(TUFLoadOptions{
+		GUN:    "docker.com/notary",
+		Cache:  store.NewMemoryStore(meta),
+		Canary: &CanaryConfig{TargetName: "canary", MaxStaleness: time.Hour},
+	})
+	require.NoError(t, err)
+	require.Len(t, recorder.containing("unable to check canary"), 1)
+}
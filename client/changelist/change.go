@@ -22,6 +22,8 @@ const (
 	TypeTargetsTarget     = "target"
 	TypeTargetsDelegation = "delegation"
 	TypeWitness           = "witness"
+	TypeKeyPurposes       = "keypurposes"
+	TypeThreshold         = "threshold"
 )
 
 // TUFChange represents a change to a TUF repo
@@ -41,6 +43,12 @@ type TUFRootData struct {
 	RoleName data.RoleName `json:"role"`
 }
 
+// TUFThreshold represents a change of the signing threshold of a base role
+// or delegation
+type TUFThreshold struct {
+	Threshold int `json:"threshold"`
+}
+
 // NewTUFChange initializes a TUFChange object
 func NewTUFChange(action string, role data.RoleName, changeType, changePath string, content []byte) *TUFChange {
 	return &TUFChange{
@@ -0,0 +1,181 @@
+package changelist
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ExportType identifies serialized changelists produced by Export
+const ExportType = "notary-changelist"
+
+// ExportVersion is the version of the format produced by Export.  Import
+// rejects changelists with any other version.
+const ExportVersion = 1
+
+// exportedChangelist is the portable serialization of a changelist, which is
+// independent of how changelists are stored on disk
+type exportedChangelist struct {
+	Type    string           `json:"_type"`
+	Version int              `json:"version"`
+	GUN     data.GUN         `json:"gun"`
+	Changes []exportedChange `json:"changes"`
+}
+
+// exportedChange is a single serialized change.  The content, which for all
+// change types is JSON, is embedded as-is so the export is human readable.
+type exportedChange struct {
+	Action  string          `json:"action"`
+	Scope   data.RoleName   `json:"scope"`
+	Type    string          `json:"type"`
+	Path    string          `json:"path"`
+	Content json.RawMessage `json:"content,omitempty"`
+}
+
+// ErrInvalidChange is returned when a change, for instance one being imported,
+// is malformed
+type ErrInvalidChange struct {
+	Index  int
+	Reason string
+}
+
+func (e ErrInvalidChange) Error() string {
+	return fmt.Sprintf("invalid change %d: %s", e.Index, e.Reason)
+}
+
+// Export serializes the given changes for a GUN into a versioned,
+// self-describing format that can be loaded with Import
+func Export(gun data.GUN, changes []Change) ([]byte, error) {
+	exported := exportedChangelist{
+		Type:    ExportType,
+		Version: ExportVersion,
+		GUN:     gun,
+		Changes: make([]exportedChange, 0, len(changes)),
+	}
+	for i, c := range changes {
+		if err := ValidateChange(c); err != nil {
+			return nil, ErrInvalidChange{Index: i, Reason: err.Error()}
+		}
+		exported.Changes = append(exported.Changes, exportedChange{
+			Action:  c.Action(),
+			Scope:   c.Scope(),
+			Type:    c.Type(),
+			Path:    c.Path(),
+			Content: c.Content(),
+		})
+	}
+	return json.Marshal(exported)
+}
+
+// Import parses changes serialized by Export, validating every change.  The
+// changes must have been exported for the given GUN.
+func Import(gun data.GUN, raw []byte) ([]Change, error) {
+	var exported exportedChangelist
+	if err := json.Unmarshal(raw, &exported); err != nil {
+		return nil, fmt.Errorf("unable to parse changelist: %v", err)
+	}
+	if exported.Type != ExportType {
+		return nil, fmt.Errorf("not an exported changelist: unexpected type %q", exported.Type)
+	}
+	if exported.Version != ExportVersion {
+		return nil, fmt.Errorf("unsupported changelist version %d", exported.Version)
+	}
+	if exported.GUN != gun {
+		return nil, fmt.Errorf("changelist is for %s, not %s", exported.GUN, gun)
+	}
+
+	changes := make([]Change, 0, len(exported.Changes))
+	for i, ec := range exported.Changes {
+		var content []byte
+		if len(ec.Content) > 0 {
+			content = ec.Content
+		}
+		c := NewTUFChange(ec.Action, ec.Scope, ec.Type, ec.Path, content)
+		if err := ValidateChange(c); err != nil {
+			return nil, ErrInvalidChange{Index: i, Reason: err.Error()}
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// ValidateChange checks that a change has a known action and type, a scope
+// appropriate for its type, and content that parses as expected for its type
+func ValidateChange(c Change) error {
+	switch c.Action() {
+	case ActionCreate, ActionUpdate, ActionDelete:
+	default:
+		return fmt.Errorf("unknown action %q", c.Action())
+	}
+
+	scope := c.Scope()
+	isTargetsScope := scope == data.CanonicalTargetsRole || data.IsDelegation(scope)
+	var content interface{}
+	switch c.Type() {
+	case TypeBaseRole:
+		if scope != ScopeRoot {
+			return fmt.Errorf("%s changes must be scoped to %s, not %q", TypeBaseRole, ScopeRoot, scope)
+		}
+		content = &TUFRootData{}
+	case TypeTargetsTarget:
+		if !isTargetsScope {
+			return fmt.Errorf("%s changes must be scoped to a targets role, not %q", TypeTargetsTarget, scope)
+		}
+		if c.Path() == "" {
+			return fmt.Errorf("%s changes must have a path", TypeTargetsTarget)
+		}
+		if c.Action() != ActionDelete {
+			content = &data.FileMeta{}
+		}
+	case TypeTargetsDelegation:
+		if !data.IsDelegation(scope) && !data.IsWildDelegation(scope) {
+			return fmt.Errorf("%s changes must be scoped to a delegation, not %q", TypeTargetsDelegation, scope)
+		}
+		if c.Action() != ActionDelete {
+			content = &TUFDelegation{}
+		}
+	case TypeWitness:
+		if !data.ValidRole(scope) {
+			return fmt.Errorf("%s changes must be scoped to a role, not %q", TypeWitness, scope)
+		}
+	case TypeKeyPurposes:
+		if scope != ScopeRoot && !isTargetsScope {
+			return fmt.Errorf("%s changes must be scoped to %s or a targets role, not %q", TypeKeyPurposes, ScopeRoot, scope)
+		}
+		if c.Path() == "" {
+			return fmt.Errorf("%s changes must have a path", TypeKeyPurposes)
+		}
+		content = &[]string{}
+	case TypeThreshold:
+		if scope == ScopeRoot {
+			if !data.ValidRole(data.RoleName(c.Path())) || data.IsDelegation(data.RoleName(c.Path())) {
+				return fmt.Errorf("%s changes scoped to %s must have a base role as their path, not %q", TypeThreshold, ScopeRoot, c.Path())
+			}
+		} else if !data.IsDelegation(scope) {
+			return fmt.Errorf("%s changes must be scoped to %s or a delegation, not %q", TypeThreshold, ScopeRoot, scope)
+		}
+		content = &TUFThreshold{}
+	default:
+		return fmt.Errorf("unknown type %q", c.Type())
+	}
+
+	if content == nil {
+		if len(c.Content()) > 0 && !json.Valid(c.Content()) {
+			return fmt.Errorf("content is not valid JSON")
+		}
+		return nil
+	}
+	if len(c.Content()) == 0 {
+		return fmt.Errorf("%s %s changes must have content", c.Action(), c.Type())
+	}
+	if err := json.Unmarshal(c.Content(), content); err != nil {
+		return fmt.Errorf("unable to parse content: %v", err)
+	}
+	if meta, ok := content.(*data.FileMeta); ok {
+		if err := data.CheckValidHashStructures(meta.Hashes); err != nil {
+			return fmt.Errorf("invalid target hashes: %v", err)
+		}
+	}
+	return nil
+}
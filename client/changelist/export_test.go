@@ -0,0 +1,105 @@
+package changelist
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+func exportTestChanges(t *testing.T) []Change {
+	meta, err := json.Marshal(data.FileMeta{Length: 1, Hashes: data.Hashes{notary.SHA256: make([]byte, sha256.Size)}})
+	require.NoError(t, err)
+	delegation, err := json.Marshal(TUFDelegation{NewThreshold: 1, AddPaths: []string{"a/"}})
+	require.NoError(t, err)
+	return []Change{
+		NewTUFChange(ActionCreate, data.CanonicalTargetsRole, TypeTargetsTarget, "latest", meta),
+		NewTUFChange(ActionCreate, "targets/a", TypeTargetsDelegation, "", delegation),
+		NewTUFChange(ActionDelete, "targets/a", TypeTargetsTarget, "old", nil),
+		NewTUFChange(ActionUpdate, data.CanonicalSnapshotRole, TypeWitness, "", nil),
+		NewTUFChange(ActionUpdate, data.CanonicalRootRole, TypeKeyPurposes, "key1", []byte(`["primary"]`)),
+		NewTUFChange(ActionUpdate, data.CanonicalRootRole, TypeThreshold, "targets", []byte(`{"threshold":2}`)),
+		NewTUFChange(ActionUpdate, "targets/a", TypeThreshold, "", []byte(`{"threshold":2}`)),
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	changes := exportTestChanges(t)
+	exported, err := Export("docker.com/notary", changes)
+	require.NoError(t, err)
+
+	imported, err := Import("docker.com/notary", exported)
+	require.NoError(t, err)
+	require.Len(t, imported, len(changes))
+	for i, c := range changes {
+		require.Equal(t, c.Action(), imported[i].Action())
+		require.Equal(t, c.Scope(), imported[i].Scope())
+		require.Equal(t, c.Type(), imported[i].Type())
+		require.Equal(t, c.Path(), imported[i].Path())
+		require.Equal(t, c.Content(), imported[i].Content())
+	}
+}
+
+func TestImportRejectsWrongEnvelope(t *testing.T) {
+	exported, err := Export("docker.com/notary", exportTestChanges(t))
+	require.NoError(t, err)
+
+	_, err = Import("docker.com/other", exported)
+	require.Error(t, err)
+
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(exported, &envelope))
+	for field, value := range map[string]interface{}{"version": ExportVersion + 1, "_type": "something-else"} {
+		modified := make(map[string]interface{})
+		for k, v := range envelope {
+			modified[k] = v
+		}
+		modified[field] = value
+		raw, err := json.Marshal(modified)
+		require.NoError(t, err)
+		_, err = Import("docker.com/notary", raw)
+		require.Error(t, err, "expected an error when %s is %v", field, value)
+	}
+
+	_, err = Import("docker.com/notary", []byte("not json"))
+	require.Error(t, err)
+}
+
+func TestImportRejectsInvalidChanges(t *testing.T) {
+	invalidMeta, err := json.Marshal(data.FileMeta{Length: 1, Hashes: data.Hashes{notary.SHA256: []byte("short")}})
+	require.NoError(t, err)
+
+	for _, c := range []exportedChange{
+		{Action: "explode", Scope: data.CanonicalTargetsRole, Type: TypeTargetsTarget, Path: "latest"},
+		{Action: ActionCreate, Scope: data.CanonicalTargetsRole, Type: "unknown", Path: "latest"},
+		{Action: ActionCreate, Scope: data.CanonicalRootRole, Type: TypeTargetsTarget, Path: "latest",
+			Content: invalidMeta},
+		{Action: ActionCreate, Scope: data.CanonicalTargetsRole, Type: TypeTargetsTarget, Path: "latest",
+			Content: invalidMeta},
+		{Action: ActionCreate, Scope: data.CanonicalTargetsRole, Type: TypeTargetsTarget, Path: "latest"},
+		{Action: ActionCreate, Scope: data.CanonicalTargetsRole, Type: TypeTargetsDelegation,
+			Content: json.RawMessage(`{}`)},
+		{Action: ActionCreate, Scope: data.CanonicalTargetsRole, Type: TypeBaseRole, Content: json.RawMessage(`{}`)},
+		{Action: ActionUpdate, Scope: data.CanonicalSnapshotRole, Type: TypeKeyPurposes, Path: "key1",
+			Content: json.RawMessage(`["primary"]`)},
+		{Action: ActionUpdate, Scope: data.CanonicalRootRole, Type: TypeKeyPurposes, Content: json.RawMessage(`["primary"]`)},
+		{Action: ActionUpdate, Scope: data.CanonicalRootRole, Type: TypeThreshold, Path: "targets/a",
+			Content: json.RawMessage(`{"threshold":2}`)},
+		{Action: ActionUpdate, Scope: data.CanonicalTargetsRole, Type: TypeThreshold,
+			Content: json.RawMessage(`{"threshold":2}`)},
+	} {
+		raw, err := json.Marshal(exportedChangelist{
+			Type:    ExportType,
+			Version: ExportVersion,
+			GUN:     "docker.com/notary",
+			Changes: []exportedChange{c},
+		})
+		require.NoError(t, err)
+		_, err = Import("docker.com/notary", raw)
+		require.Error(t, err, "expected change to be rejected: %v", c)
+		require.IsType(t, ErrInvalidChange{}, err)
+	}
+}
@@ -46,8 +46,29 @@ type repository struct {
 	tufRepo        *tuf.Repo
 	invalid        *tuf.Repo // known data that was parsable but deemed invalid
 	roundTrip      http.RoundTripper
+	baseDir        string // directory the trust data is kept under, if file cached
 	trustPinning   trustpinning.TrustPinConfig
-	LegacyVersions int // number of versions back to fetch roots to sign with
+	LegacyVersions int                 // number of versions back to fetch roots to sign with
+	canary         *CanaryConfig       // target used to detect freeze attacks, if any
+	keyDowngrade   *KeyDowngradePolicy // policy for roots that downgrade key algorithms, if any
+	trustedTime    *TrustedTimeConfig  // time source to check expiry against, if not the system clock
+	expiryPolicy   ExpiryPolicy        // longest expiries allowed when signing roles on publish
+
+	unknownDelegations UnknownDelegationPolicy // how updates handle delegations that cannot be loaded
+	minimumThreshold   *MinimumThresholdPolicy // lowest threshold allowed for any role, if any
+	lenientRoleTypes   bool                    // whether metadata's declared type is not checked up front
+
+	consistentSnapshot ConsistentSnapshotRequirement // the root's required consistent snapshot setting
+	delegationPriority DelegationPriority            // order of sibling delegations when resolving targets
+	defaultTargetRole  data.RoleName                 // role AddTarget adds to when none is given, if not targets
+	snapshotCoverage   bool                          // whether delegation files missing from the snapshot fail updates
+	maxTimestampAge    time.Duration                 // oldest trusted timestamp updates accept, if not 0
+	timestampObserved  *TimestampObservation         // when the trusted timestamp's version was first trusted
+	rootRollback       RootRollbackPolicy            // how updates handle a remote root older than the trusted one
+	minimumKeyStrength int                           // strength in bits below which keys are reported as weak, if not the default
+
+	publishLog   publishLog // local record of the successful publishes
+	publishActor string     // actor recorded in the publish log, if any
 }
 
 // NewFileCachedRepository is a wrapper for NewRepository that initializes
@@ -88,7 +109,15 @@ func NewFileCachedRepository(baseDir string, gun data.GUN, baseURL string, rt ht
 		return nil, err
 	}
 
-	return NewRepository(gun, baseURL, remoteStore, cache, trustPinning, cryptoService, cl)
+	repo, err := NewRepository(gun, baseURL, remoteStore, cache, trustPinning, cryptoService, cl)
+	if err != nil {
+		return nil, err
+	}
+	repo.(*repository).publishLog.path = filepath.Join(
+		baseDir, tufDir, filepath.FromSlash(gun.String()), publishLogFile)
+	repo.(*repository).roundTrip = rt
+	repo.(*repository).baseDir = baseDir
+	return repo, nil
 }
 
 // NewRepository is the base method that returns a new notary repository.
@@ -133,12 +162,25 @@ func (r *repository) updateTUF(forWrite bool) error {
 		Cache:                  r.cache,
 		RemoteStore:            r.remoteStore,
 		AlwaysCheckInitialized: forWrite,
+		Canary:                 r.canary,
+		KeyDowngrade:           r.keyDowngrade,
+		TrustedTime:            r.trustedTime,
+		UnknownDelegations:     r.unknownDelegations,
+		MinimumThreshold:       r.minimumThreshold,
+		LenientRoleTypes:       r.lenientRoleTypes,
+		ConsistentSnapshot:     r.consistentSnapshot,
+		SnapshotCoverage:       r.snapshotCoverage,
+		MaxTimestampAge:        r.maxTimestampAge,
+		TimestampObserved:      r.timestampObserved,
+		RootRollback:           r.rootRollback,
 	})
 	if err != nil {
 		return err
 	}
+	repo.SetDelegationPriority(r.delegationPriority)
 	r.tufRepo = repo
 	r.invalid = invalid
+	r.observeTimestamp(time.Now())
 	return nil
 }
 
@@ -167,6 +209,14 @@ func (r *repository) GetAllTargetMetadataByName(name string) ([]TargetSignedStru
 
 }
 
+// EffectiveTargets calls update first before resolving every target
+func (r *repository) EffectiveTargets() ([]*TargetWithRole, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	return NewReadOnly(r.tufRepo).EffectiveTargets()
+}
+
 // ListRoles calls update first before getting roles
 func (r *repository) ListRoles() ([]RoleWithSignatures, error) {
 	if err := r.updateTUF(false); err != nil {
@@ -183,6 +233,14 @@ func (r *repository) GetDelegationRoles() ([]data.Role, error) {
 	return NewReadOnly(r.tufRepo).GetDelegationRoles()
 }
 
+// TrustedKeys calls update first before getting the keys trusted to sign a role
+func (r *repository) TrustedKeys(role data.RoleName) ([]data.PublicKey, int, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, 0, err
+	}
+	return NewReadOnly(r.tufRepo).TrustedKeys(role)
+}
+
 // NewTarget is a helper method that returns a Target
 func NewTarget(targetName, targetPath string, targetCustom *canonicaljson.RawMessage) (*Target, error) {
 	b, err := ioutil.ReadFile(targetPath)
@@ -223,7 +281,6 @@ func (r *repository) GetCryptoService() signed.CryptoService {
 
 // initialize initializes the notary repository with a set of rootkeys, root certificates and roles.
 func (r *repository) initialize(rootKeyIDs []string, rootCerts []data.PublicKey, serverManagedRoles ...data.RoleName) error {
-
 	// currently we only support server managing timestamps and snapshots, and
 	// nothing else - timestamps are always managed by the server, and implicit
 	// (do not have to be passed in as part of `serverManagedRoles`, so that
@@ -251,9 +308,14 @@ func (r *repository) initialize(rootKeyIDs []string, rootCerts []data.PublicKey,
 		}
 	}
 
+	// root key IDs may be given in any case, but must be well formed
+	rootKeyIDs, err := data.CanonicalKeyIDs(rootKeyIDs)
+	if err != nil {
+		return err
+	}
+
 	// gets valid public keys corresponding to the rootKeyIDs or generate if necessary
 	var publicKeys []data.PublicKey
-	var err error
 	if len(rootCerts) == 0 {
 		publicKeys, err = r.createNewPublicKeyFromKeyIDs(rootKeyIDs)
 	} else {
@@ -402,35 +464,47 @@ func (r *repository) InitializeWithCertificate(rootKeyIDs []string, rootCerts []
 	return r.initialize(rootKeyIDs, rootCerts, serverManagedRoles...)
 }
 
+// initializeRoles creates the base roles, each with its minimum threshold.
+// Locally managed roles are given as many new keys as their threshold needs,
+// while remotely managed roles get the single key the server holds for them.
 func (r *repository) initializeRoles(rootKeys []data.PublicKey, localRoles, remoteRoles []data.RoleName) (
 	root, targets, snapshot, timestamp data.BaseRole, err error) {
+	threshold, err := r.minimumThreshold.creationThreshold(data.CanonicalRootRole, len(rootKeys))
+	if err != nil {
+		return
+	}
 	root = data.NewBaseRole(
 		data.CanonicalRootRole,
-		notary.MinThreshold,
+		threshold,
 		rootKeys...,
 	)
 
 	// we want to create all the local keys first so we don't have to
 	// make unnecessary network calls
 	for _, role := range localRoles {
-		// This is currently hardcoding the keys to ECDSA.
-		var key data.PublicKey
-		key, err = r.GetCryptoService().Create(role, r.gun, data.ECDSAKey)
-		if err != nil {
-			return
+		threshold = r.minimumThreshold.minimumFor(role)
+		keys := make([]data.PublicKey, 0, threshold)
+		for len(keys) < threshold {
+			// This is currently hardcoding the keys to ECDSA.
+			var key data.PublicKey
+			key, err = r.GetCryptoService().Create(role, r.gun, data.ECDSAKey)
+			if err != nil {
+				return
+			}
+			keys = append(keys, key)
 		}
 		switch role {
 		case data.CanonicalSnapshotRole:
 			snapshot = data.NewBaseRole(
 				role,
-				notary.MinThreshold,
-				key,
+				threshold,
+				keys...,
 			)
 		case data.CanonicalTargetsRole:
 			targets = data.NewBaseRole(
 				role,
-				notary.MinThreshold,
-				key,
+				threshold,
+				keys...,
 			)
 		}
 	}
@@ -438,6 +512,11 @@ func (r *repository) initializeRoles(rootKeys []data.PublicKey, localRoles, remo
 	remote := r.getRemoteStore()
 
 	for _, role := range remoteRoles {
+		// the server holds a single key for each role it manages
+		threshold, err = r.minimumThreshold.creationThreshold(role, 1)
+		if err != nil {
+			return
+		}
 		// This key is generated by the remote server.
 		var key data.PublicKey
 		key, err = getRemoteKey(role, remote)
@@ -450,13 +529,13 @@ func (r *repository) initializeRoles(rootKeys []data.PublicKey, localRoles, remo
 		case data.CanonicalSnapshotRole:
 			snapshot = data.NewBaseRole(
 				role,
-				notary.MinThreshold,
+				threshold,
 				key,
 			)
 		case data.CanonicalTimestampRole:
 			timestamp = data.NewBaseRole(
 				role,
-				notary.MinThreshold,
+				threshold,
 				key,
 			)
 		}
@@ -500,11 +579,18 @@ func addChange(cl changelist.Changelist, c changelist.Change, roles ...data.Role
 
 // AddTarget creates new changelist entries to add a target to the given roles
 // in the repository when the changelist gets applied at publish time.
-// If roles are unspecified, the default role is "targets"
+// If roles are unspecified, the default role is "targets", unless another
+// has been set with SetDefaultTargetRole
 func (r *repository) AddTarget(target *Target, roles ...data.RoleName) error {
 	if len(target.Hashes) == 0 {
 		return fmt.Errorf("no hashes specified for target \"%s\"", target.Name)
 	}
+	if err := checkNoAnnotations(target.Name, target.Custom); err != nil {
+		return err
+	}
+	if len(roles) == 0 && r.defaultTargetRole != "" {
+		roles = []data.RoleName{r.defaultTargetRole}
+	}
 	logrus.Debugf("Adding target \"%s\" with sha256 \"%x\" and size %d bytes.\n", target.Name, target.Hashes["sha256"], target.Length)
 
 	meta := data.FileMeta{Length: target.Length, Hashes: target.Hashes, Custom: target.Custom}
@@ -519,6 +605,32 @@ func (r *repository) AddTarget(target *Target, roles ...data.RoleName) error {
 	return addChange(r.changelist, template, roles...)
 }
 
+// AddPrecomputedTarget creates a changelist entry to add a target to the given
+// role in the repository when the changelist gets applied at publish time.  The
+// hashes and length in meta are trusted as-is, which avoids re-hashing content
+// whose checksums are already known, e.g. from a build artifact index.  The meta
+// must include at least one valid hash of a supported algorithm and a
+// non-negative length.
+func (r *repository) AddPrecomputedTarget(role data.RoleName, name string, meta data.FileMeta) error {
+	if err := data.CheckValidHashStructures(meta.Hashes); err != nil {
+		return fmt.Errorf("invalid hashes specified for target \"%s\": %v", name, err)
+	}
+	if meta.Length < 0 {
+		return fmt.Errorf("invalid length %d specified for target \"%s\"", meta.Length, name)
+	}
+	logrus.Debugf("Adding precomputed target \"%s\" with size %d bytes.\n", name, meta.Length)
+
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	template := changelist.NewTUFChange(
+		changelist.ActionCreate, "", changelist.TypeTargetsTarget,
+		name, metaJSON)
+	return addChange(r.changelist, template, role)
+}
+
 // RemoveTarget creates new changelist entries to remove a target from the given
 // roles in the repository when the changelist gets applied at publish time.
 // If roles are unspecified, the default role is "target".
@@ -534,6 +646,46 @@ func (r *repository) GetChangelist() (changelist.Changelist, error) {
 	return r.changelist, nil
 }
 
+// ChangelistImportMode determines how ImportChangelist combines the imported
+// changes with those already staged
+type ChangelistImportMode int
+
+const (
+	// ChangelistImportMerge appends the imported changes after the staged ones
+	ChangelistImportMerge ChangelistImportMode = iota
+	// ChangelistImportReplace clears the staged changes before importing
+	ChangelistImportReplace
+)
+
+// ExportChangelist serializes the repository's unpublished changes in a
+// versioned format which can be loaded into another copy of the repository
+// using ImportChangelist
+func (r *repository) ExportChangelist() ([]byte, error) {
+	return changelist.Export(r.gun, r.changelist.List())
+}
+
+// ImportChangelist stages the changes serialized by ExportChangelist, either
+// after the already staged changes or in place of them.  Every change is
+// validated before any is staged, so a malformed export leaves the changelist
+// untouched.
+func (r *repository) ImportChangelist(exported []byte, mode ChangelistImportMode) error {
+	changes, err := changelist.Import(r.gun, exported)
+	if err != nil {
+		return err
+	}
+	if mode == ChangelistImportReplace {
+		if err := r.changelist.Clear(""); err != nil {
+			return err
+		}
+	}
+	for _, c := range changes {
+		if err := r.changelist.Add(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // getRemoteStore returns the remoteStore of a repository if valid or
 // or an OfflineStore otherwise
 func (r *repository) getRemoteStore() store.RemoteStore {
@@ -564,6 +716,26 @@ func (r *repository) Publish() error {
 // publish pushes the changes in the given changelist to the remote notary-server
 // Conceptually it performs an operation similar to a `git rebase`
 func (r *repository) publish(cl changelist.Changelist) error {
+	updatedFiles, err := r.signChanges(cl, true)
+	if err != nil {
+		return err
+	}
+
+	remote := r.getRemoteStore()
+
+	if err := remote.SetMulti(data.MetadataRoleMapToStringMap(updatedFiles)); err != nil {
+		return err
+	}
+	r.recordPublish(updatedFiles)
+	return nil
+}
+
+// signChanges updates the repository, applies the changes in the given
+// changelist to it, and signs every role they affect, returning the
+// serialized roles to upload.  If the repository has not been published,
+// and initialize is set, it is initialized if it cannot be loaded from the
+// cache.
+func (r *repository) signChanges(cl changelist.Changelist, initialize bool) (map[data.RoleName][]byte, error) {
 	var initialPublish bool
 	// update first before publishing
 	if err := r.updateTUF(true); err != nil {
@@ -571,14 +743,14 @@ func (r *repository) publish(cl changelist.Changelist) error {
 		// for the first time.  Try to initialize the repository before publishing.
 		if _, ok := err.(ErrRepositoryNotExist); ok {
 			err := r.bootstrapRepo()
-			if _, ok := err.(store.ErrMetaNotFound); ok {
+			if _, ok := err.(store.ErrMetaNotFound); ok && initialize {
 				logrus.Infof("No TUF data found locally or remotely - initializing repository %s for the first time", r.gun.String())
 				err = r.Initialize(nil)
 			}
 
 			if err != nil {
 				logrus.WithError(err).Debugf("Unable to load or initialize repository during first publish: %s", err.Error())
-				return err
+				return nil, err
 			}
 
 			// Ensure we will push the initial root and targets file.  Either or
@@ -589,13 +761,13 @@ func (r *repository) publish(cl changelist.Changelist) error {
 		} else {
 			// We could not update, so we cannot publish.
 			logrus.Error("Could not publish Repository since we could not update: ", err.Error())
-			return err
+			return nil, err
 		}
 	}
 	// apply the changelist to the repo
 	if err := applyChangelist(r.tufRepo, r.invalid, cl); err != nil {
 		logrus.Debug("Error applying changelist")
-		return err
+		return nil, err
 	}
 
 	// these are the TUF files we will need to update, serialized as JSON before
@@ -605,18 +777,18 @@ func (r *repository) publish(cl changelist.Changelist) error {
 	// Fetch old keys to support old clients
 	legacyKeys, err := r.oldKeysForLegacyClientSupport(r.LegacyVersions, initialPublish)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// check if our root file is nearing expiry or dirty. Resign if it is.  If
 	// root is not dirty but we are publishing for the first time, then just
 	// publish the existing root we have.
 	if err := signRootIfNecessary(updatedFiles, r.tufRepo, legacyKeys, initialPublish); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := signTargets(updatedFiles, r.tufRepo, initialPublish); err != nil {
-		return err
+		return nil, err
 	}
 
 	// if we initialized the repo while designating the server as the snapshot
@@ -624,7 +796,7 @@ func (r *repository) publish(cl changelist.Changelist) error {
 	// have a local key (if there was a rotation), so initialize one.
 	if r.tufRepo.Snapshot == nil {
 		if err := r.tufRepo.InitSnapshot(); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -640,12 +812,14 @@ func (r *repository) publish(cl changelist.Changelist) error {
 			"Assuming that server should sign the snapshot.")
 	} else {
 		logrus.Debugf("Client was unable to sign the snapshot: %s", err.Error())
-		return err
+		return nil, err
 	}
 
-	remote := r.getRemoteStore()
+	if err := r.expiryPolicy.check(r.tufRepo, updatedFiles, time.Now()); err != nil {
+		return nil, err
+	}
 
-	return remote.SetMulti(data.MetadataRoleMapToStringMap(updatedFiles))
+	return updatedFiles, nil
 }
 
 func signRootIfNecessary(updates map[data.RoleName][]byte, repo *tuf.Repo, extraSigningKeys data.KeyList, initialPublish bool) error {
@@ -700,7 +874,7 @@ func (r *repository) oldKeysForLegacyClientSupport(legacyVersions int, initialPu
 		Cache:                  r.cache,
 		RemoteStore:            r.remoteStore,
 		AlwaysCheckInitialized: true,
-	})
+	}, time.Now)
 	// require a server connection to fetch old roots
 	if err != nil {
 		return nil, err
@@ -718,7 +892,7 @@ func (r *repository) oldKeysForLegacyClientSupport(legacyVersions int, initialPu
 		}
 
 		signedOldRoot := &data.Signed{}
-		if err := json.Unmarshal(raw, signedOldRoot); err != nil {
+		if err := data.UnmarshalMetadata(raw, signedOldRoot); err != nil {
 			return nil, err
 		}
 		oldRootVersion, err := data.RootFromSigned(signedOldRoot)
@@ -862,6 +1036,67 @@ func (r *repository) RotateKey(role data.RoleName, serverManagesKey bool, keyLis
 	return r.publish(cl)
 }
 
+// RotateAllLocalKeys replaces the key of every base role which this client
+// can sign with a newly generated local key, and the keys of the server managed
+// roles with new keys generated by the server.  Since every base role's keys
+// are listed in the root, a local root key is required.  All the rotations are
+// published together as a single new root version, signed by both the old and
+// new root keys, so that the repository is valid before and after the
+// rotation, with no intermediate state.  It returns the ID of the new key of
+// each role, as listed in the root.
+func (r *repository) RotateAllLocalKeys() (map[data.RoleName]string, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+
+	type rotation struct {
+		role          data.RoleName
+		serverManaged bool
+	}
+	var rotations []rotation
+	for _, role := range data.BaseRoles {
+		localKeys, err := r.localSigningKeys(role)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(localKeys) > 0:
+			rotations = append(rotations, rotation{role: role})
+		case role == data.CanonicalTimestampRole || role == data.CanonicalSnapshotRole:
+			rotations = append(rotations, rotation{role: role, serverManaged: true})
+		case role == data.CanonicalRootRole:
+			return nil, fmt.Errorf("rotating keys requires a local %s key to sign the new root", role)
+		default:
+			// the role is signed by keys held elsewhere
+			logrus.Infof("not rotating the %s key, as no key for it is held locally", role)
+		}
+	}
+	// check every rotation before generating any keys, so that none are
+	// generated for a rotation which cannot be published
+	for _, rot := range rotations {
+		if err := checkRotationInput(rot.role, rot.serverManaged); err != nil {
+			return nil, err
+		}
+	}
+
+	cl := changelist.NewMemChangelist()
+	rotated := make(map[data.RoleName]string, len(rotations))
+	for _, rot := range rotations {
+		pubKeyList, err := r.pubKeyListForRotation(rot.role, rot.serverManaged, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.rootFileKeyChange(cl, rot.role, changelist.ActionCreate, pubKeyList); err != nil {
+			return nil, err
+		}
+		rotated[rot.role] = pubKeyList[0].ID()
+	}
+	if err := r.publish(cl); err != nil {
+		return nil, err
+	}
+	return rotated, nil
+}
+
 // Given a set of new keys to rotate to and a set of keys to drop, returns the list of current keys to use
 func (r *repository) pubKeyListForRotation(role data.RoleName, serverManaged bool, newKeys []string) (pubKeyList data.KeyList, err error) {
 	var pubKey data.PublicKey
@@ -996,3 +1231,52 @@ func DeleteTrustData(baseDir string, gun data.GUN, URL string, rt http.RoundTrip
 func (r *repository) SetLegacyVersions(n int) {
 	r.LegacyVersions = n
 }
+
+// SetCanary designates a canary target whose embedded timestamp is checked
+// against the given staleness bound on every update.  A warning is logged if
+// the canary is stale, as the repository may be frozen.
+func (r *repository) SetCanary(canary CanaryConfig) {
+	r.canary = &canary
+}
+
+// SetKeyDowngradePolicy enables checking, on every update, whether a new root
+// version replaces a base role's keys with keys of a weaker algorithm or size
+func (r *repository) SetKeyDowngradePolicy(policy KeyDowngradePolicy) {
+	r.keyDowngrade = &policy
+}
+
+// SetTrustedTimeSource makes every update check metadata expiry against the
+// configured trusted time source instead of the system clock
+func (r *repository) SetTrustedTimeSource(config TrustedTimeConfig) {
+	r.trustedTime = &config
+}
+
+// SetUnknownDelegationPolicy sets how updates handle a delegation role which is
+// referenced by its parent but cannot be loaded.  The policy applies to every
+// operation from the next update on, so it can be changed between operations.
+func (r *repository) SetUnknownDelegationPolicy(policy UnknownDelegationPolicy) {
+	r.unknownDelegations = policy
+}
+
+// SetMinimumThreshold creates roles with the policy's minimum thresholds,
+// forbids setting thresholds below them, and applies them to the metadata
+// verified by updates as the policy's Verify setting requires
+func (r *repository) SetMinimumThreshold(policy MinimumThresholdPolicy) {
+	r.minimumThreshold = &policy
+}
+
+// SetStrictRoleTypes sets whether updates reject, with ErrRoleTypeMismatch and
+// before verifying anything else, metadata whose declared type is not that of
+// the role it was fetched as.  It is strict by default; otherwise mismatched
+// metadata is only rejected as invalid once its checksum and signatures have
+// been verified.
+func (r *repository) SetStrictRoleTypes(strict bool) {
+	r.lenientRoleTypes = !strict
+}
+
+// SetExpiryPolicy limits the expiry of the roles signed on publish.  A publish
+// which would sign a role with an expiry further away than its policy allows
+// is refused with ErrExpiryPolicy, and nothing is uploaded.
+func (r *repository) SetExpiryPolicy(policy ExpiryPolicy) {
+	r.expiryPolicy = policy
+}
@@ -271,6 +271,28 @@ func TestInitRepositoryManagedRolesInvalidRole(t *testing.T) {
 	rec.requireCreated(t, nil)
 }
 
+// Root key IDs given to Initialize in uppercase are canonicalized, while
+// malformed root key IDs are rejected before any key is created
+func TestInitRepositoryCanonicalRootKeyIDs(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	tempBaseDir, err := ioutil.TempDir("", "notary-test-")
+	require.NoError(t, err, "failed to create a temporary directory")
+	defer os.RemoveAll(tempBaseDir)
+
+	repo, rec, rootPubKeyID := createRepoAndKey(
+		t, data.ECDSAKey, tempBaseDir, "docker.com/notary", ts.URL)
+	err = repo.Initialize([]string{rootPubKeyID[:10]})
+	require.IsType(t, data.ErrInvalidKeyID{}, err)
+	rec.requireCreated(t, nil)
+
+	require.NoError(t, repo.Initialize([]string{strings.ToUpper(rootPubKeyID)}))
+	rootRole, err := repo.tufRepo.GetBaseRole(data.CanonicalRootRole)
+	require.NoError(t, err)
+	require.Len(t, rootRole.Keys, 1)
+}
+
 // Initializing a new repo while specifying that the server should manage the
 // targets role will fail.
 func TestInitRepositoryManagedRolesIncludingTargets(t *testing.T) {
@@ -1021,6 +1043,136 @@ func TestAddTargetWithInvalidTarget(t *testing.T) {
 	require.Error(t, repo.AddTarget(target, data.CanonicalTargetsRole))
 }
 
+// TestAddPrecomputedTarget adds targets from already computed file meta, publishes
+// them, and confirms that content matching the provided hashes verifies.
+func TestAddPrecomputedTarget(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	content, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	meta, err := data.NewFileMeta(bytes.NewBuffer(content), data.NotaryDefaultHashes...)
+	require.NoError(t, err)
+
+	sha256Only := data.FileMeta{Length: meta.Length, Hashes: data.Hashes{notary.SHA256: meta.Hashes[notary.SHA256]}}
+	require.NoError(t, repo.AddPrecomputedTarget(data.CanonicalTargetsRole, "latest", meta))
+	require.NoError(t, repo.AddPrecomputedTarget(data.CanonicalTargetsRole, "current", sha256Only))
+	require.NoError(t, repo.Publish())
+
+	// use another repo to check metadata
+	repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(baseDir2)
+
+	for name, expected := range map[string]data.FileMeta{"latest": meta, "current": sha256Only} {
+		target, err := repo2.GetTargetByName(name)
+		require.NoError(t, err)
+		require.Equal(t, expected.Length, target.Length)
+		require.Equal(t, expected.Hashes, target.Hashes)
+		require.NoError(t, data.CheckHashes(content, name, target.Hashes))
+		require.Error(t, data.CheckHashes([]byte("other content"), name, target.Hashes))
+	}
+}
+
+// TestAddPrecomputedTargetInvalidMeta expects file meta without a recognized
+// hash, with a malformed hash, or with a negative length to be rejected.
+func TestAddPrecomputedTargetInvalidMeta(t *testing.T) {
+	ts, _, _ := simpleTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	validSHA256 := make([]byte, sha256.Size)
+	for _, meta := range []data.FileMeta{
+		{Length: 1},
+		{Length: 1, Hashes: data.Hashes{"md5": make([]byte, 16)}},
+		{Length: 1, Hashes: data.Hashes{notary.SHA256: []byte("short")}},
+		{Length: -1, Hashes: data.Hashes{notary.SHA256: validSHA256}},
+	} {
+		require.Error(t, repo.AddPrecomputedTarget(data.CanonicalTargetsRole, "latest", meta))
+	}
+
+	changes := getChanges(t, repo)
+	require.Empty(t, changes)
+}
+
+// TestExportImportChangelist stages changes in one repo, imports them into
+// another copy of the repository and expects the same changes to be staged,
+// and that importing in replace mode discards the previously staged changes.
+func TestExportImportChangelist(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	delgKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{data.PublicKeyFromPrivate(delgKey)}, []string{""}))
+	require.NoError(t, repo.RemoveTarget("old", data.CanonicalTargetsRole))
+	expected := getChanges(t, repo)
+
+	exported, err := repo.ExportChangelist()
+	require.NoError(t, err)
+
+	repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(baseDir2)
+	require.NoError(t, repo2.RemoveTarget("staged", data.CanonicalTargetsRole))
+
+	require.NoError(t, repo2.ImportChangelist(exported, ChangelistImportMerge))
+	merged := getChanges(t, repo2)
+	require.Len(t, merged, len(expected)+1)
+	require.Equal(t, "staged", merged[0].Path())
+	requireSameChanges(t, expected, merged[1:])
+
+	require.NoError(t, repo2.ImportChangelist(exported, ChangelistImportReplace))
+	requireSameChanges(t, expected, getChanges(t, repo2))
+
+	// the imported changes can be published by a repo with the signing keys
+	require.NoError(t, repo.ImportChangelist(exported, ChangelistImportReplace))
+	require.NoError(t, repo.Publish())
+	_, err = repo.GetTargetByName("latest")
+	require.NoError(t, err)
+}
+
+// TestImportChangelistInvalid expects an export for a different GUN or with
+// malformed changes to be rejected without staging anything.
+func TestImportChangelistInvalid(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	otherRepo, _, otherBaseDir := initializeRepo(t, data.ECDSAKey, "docker.com/other", ts.URL, false)
+	defer os.RemoveAll(otherBaseDir)
+	addTarget(t, otherRepo, "latest", "../fixtures/intermediate-ca.crt")
+	exported, err := otherRepo.ExportChangelist()
+	require.NoError(t, err)
+	require.Error(t, repo.ImportChangelist(exported, ChangelistImportMerge))
+
+	malformed := []byte(`{"_type": "notary-changelist", "version": 1, "gun": "docker.com/notary", "changes": [
+		{"action": "delete", "scope": "targets", "type": "target", "path": "old"},
+		{"action": "create", "scope": "targets", "type": "target", "path": "latest", "content": {"length": 1}}]}`)
+	require.Error(t, repo.ImportChangelist(malformed, ChangelistImportReplace))
+	require.Empty(t, getChanges(t, repo))
+}
+
+func requireSameChanges(t *testing.T, expected, actual []changelist.Change) {
+	require.Len(t, actual, len(expected))
+	for i, c := range expected {
+		require.Equal(t, c.Action(), actual[i].Action())
+		require.Equal(t, c.Scope(), actual[i].Scope())
+		require.Equal(t, c.Type(), actual[i].Type())
+		require.Equal(t, c.Path(), actual[i].Path())
+		require.Equal(t, c.Content(), actual[i].Content())
+	}
+}
+
 // TestAddTargetErrorWritingChanges expects errors writing a change to file
 // to be propagated.
 func TestAddTargetErrorWritingChanges(t *testing.T) {
@@ -2945,6 +3097,58 @@ func testRotateKeySuccess(t *testing.T, serverManagesSnapshotInit bool,
 	require.NoError(t, err)
 }
 
+// RotateAllLocalKeys rotates every base role key, whether held locally or by
+// the server, and the rotated repository remains verifiable by both the
+// rotating client and other clients trusting the original root
+func TestRotateAllLocalKeys(t *testing.T) {
+	for _, serverManagesSnapshot := range []bool{false, true} {
+		testRotateAllLocalKeys(t, serverManagesSnapshot)
+	}
+}
+
+func testRotateAllLocalKeys(t *testing.T, serverManagesSnapshot bool) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, serverManagesSnapshot)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	userRepo, _, userDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(userDir)
+	require.NoError(t, userRepo.updateTUF(false))
+
+	oldKeys := make(map[data.RoleName]data.BaseRole)
+	for _, role := range data.BaseRoles {
+		oldKeys[role], _ = repo.tufRepo.GetBaseRole(role)
+	}
+
+	rotated, err := repo.RotateAllLocalKeys()
+	require.NoError(t, err)
+	require.Equal(t, len(data.BaseRoles), len(rotated))
+
+	require.NoError(t, repo.updateTUF(false))
+	for _, role := range data.BaseRoles {
+		newRole, err := repo.tufRepo.GetBaseRole(role)
+		require.NoError(t, err)
+		require.Equal(t, []string{rotated[role]}, newRole.ListKeyIDs(), role.String())
+		_, wasKey := oldKeys[role].Keys[rotated[role]]
+		require.False(t, wasKey, role.String())
+	}
+	localSnapshotKeys, err := repo.localSigningKeys(data.CanonicalSnapshotRole)
+	require.NoError(t, err)
+	require.Equal(t, !serverManagesSnapshot, len(localSnapshotKeys) == 1)
+
+	_, err = repo.GetTargetByName("latest")
+	require.NoError(t, err)
+	_, err = userRepo.GetTargetByName("latest")
+	require.NoError(t, err)
+	newRoot, err := userRepo.tufRepo.GetBaseRole(data.CanonicalRootRole)
+	require.NoError(t, err)
+	require.Equal(t, []string{rotated[data.CanonicalRootRole]}, newRoot.ListKeyIDs())
+}
+
 func logRepoTrustRoot(t *testing.T, prefix string, repo *repository) {
 	logrus.Debugf("==== %s", prefix)
 	root := repo.tufRepo.Root
@@ -3431,6 +3635,28 @@ func TestRemoveDelegationChangefileValid(t *testing.T) {
 	require.Equal(t, "", changes[0].Path())
 }
 
+// Key IDs to remove from delegations are canonicalized, and malformed key IDs
+// are rejected without creating a change
+func TestRemoveDelegationKeysCanonicalKeyIDs(t *testing.T) {
+	gun := "docker.com/notary"
+	ts, _, _ := simpleTestServer(t)
+	defer ts.Close()
+
+	repo, rootKeyID, baseDir := initializeRepo(t, data.ECDSAKey, gun, ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	err := repo.RemoveDelegationKeys("targets/a", []string{rootKeyID, "not-a-key-id"})
+	require.IsType(t, data.ErrInvalidKeyID{}, err)
+	require.Empty(t, getChanges(t, repo))
+
+	require.NoError(t, repo.RemoveDelegationKeys("targets/a", []string{strings.ToUpper(rootKeyID)}))
+	changes := getChanges(t, repo)
+	require.Len(t, changes, 1)
+	var td changelist.TUFDelegation
+	require.NoError(t, json.Unmarshal(changes[0].Content(), &td))
+	require.Equal(t, []string{rootKeyID}, td.RemoveKeys)
+}
+
 // The changefile produced by RemoveDelegationKeys, when applied, actually removes
 // the delegation from the repo (assuming the repo exists - tests for
 // change application validation are in helpers_test.go)
@@ -3596,7 +3822,7 @@ func TestFullRemoveDelegationChangefileApplicable(t *testing.T) {
 // file to be propagated.
 func TestRemoveDelegationErrorWritingChanges(t *testing.T) {
 	testErrorWritingChangefiles(t, func(repo *repository) error {
-		return repo.RemoveDelegationKeysAndPaths("targets/a", []string{""}, []string{})
+		return repo.RemoveDelegationKeysAndPaths("targets/a", []string{strings.Repeat("a", notary.SHA256HexSize)}, []string{})
 	})
 }
 
@@ -3927,6 +4153,46 @@ func TestListRoles(t *testing.T) {
 	require.Len(t, rolesWithSigs, len(data.BaseRoles)+2)
 }
 
+// TrustedKeys reports the keys and threshold configured for base roles in the
+// root, and for delegations in the delegating role, from a fresh client
+func TestTrustedKeys(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	aKey1 := createKey(t, repo, "user", true)
+	aKey2 := createKey(t, repo, "user", true)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{aKey1, aKey2}, []string{""}))
+	require.NoError(t, repo.Publish())
+	require.NoError(t, repo.SetThreshold("targets/a", 2, false))
+	require.NoError(t, repo.Publish())
+
+	otherRepo, _, otherDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(otherDir)
+
+	for _, role := range data.BaseRoles {
+		keys, threshold, err := otherRepo.TrustedKeys(role)
+		require.NoError(t, err)
+		baseRole, err := repo.tufRepo.GetBaseRole(role)
+		require.NoError(t, err)
+		require.Equal(t, 1, threshold, role.String())
+		require.Len(t, keys, 1, role.String())
+		require.Equal(t, baseRole.ListKeyIDs(), []string{keys[0].ID()}, role.String())
+	}
+
+	keys, threshold, err := otherRepo.TrustedKeys("targets/a")
+	require.NoError(t, err)
+	require.Equal(t, 2, threshold)
+	expected := []string{aKey1.ID(), aKey2.ID()}
+	sort.Strings(expected)
+	require.Equal(t, expected, []string{keys[0].ID(), keys[1].ID()})
+
+	_, _, err = otherRepo.TrustedKeys("targets/b")
+	require.Error(t, err)
+}
+
 func TestGetAllTargetInfo(t *testing.T) {
 	ts, mux, keys := simpleTestServer(t)
 	defer ts.Close()
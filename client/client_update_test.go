@@ -856,7 +856,8 @@ var waysToMessUpServerBadMeta = []swizzleExpectations{
 	// for everything else, the errors come from tuf/signed
 
 	{desc: "invalid SignedMeta Type", expectErrs: []interface{}{
-		&trustpinning.ErrValidationFail{}, signed.ErrWrongType, data.ErrInvalidMetadata{}},
+		&trustpinning.ErrValidationFail{}, signed.ErrWrongType, data.ErrInvalidMetadata{},
+		data.ErrRoleTypeMismatch{}},
 		swizzle: (*testutils.MetadataSwizzler).SetInvalidMetadataType},
 
 	{desc: "lower metadata version", expectErrs: []interface{}{
@@ -1997,3 +1998,23 @@ func TestLoadTUFRepoBadURL(t *testing.T) {
 	// value
 	require.EqualError(t, err1, err2.Error())
 }
+
+// RotateAllLocalKeys refuses to rotate a role whose key can't be rotated as
+// held, such as a local timestamp key, before generating any keys
+func TestRotateAllLocalKeysChecksRolesFirst(t *testing.T) {
+	var gun data.GUN = "docker.com/notary"
+	serverMeta, cs, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	ts := readOnlyServer(t, store.NewMemoryStore(serverMeta), http.StatusNotFound, gun)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	// every base role's key, including the timestamp key, is held locally
+	repo.cryptoService = cs
+	heldKeys := len(cs.ListAllKeys())
+
+	_, err = repo.RotateAllLocalKeys()
+	require.Equal(t, ErrInvalidLocalRole{Role: data.CanonicalTimestampRole}, err)
+	require.Len(t, cs.ListAllKeys(), heldKeys)
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/theupdateframework/notary/cryptoservice"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// UpdateStep is an observable step of an update cycle
+type UpdateStep string
+
+// The steps of an update cycle, in the order they are taken.  If the
+// timestamp, snapshot or targets fail to verify, the root is updated and those
+// steps are attempted a second time.  The policy step enforces requirements
+// of the updated repo beyond its metadata verifying, such as the maximum
+// timestamp age or the key downgrade policy.
+const (
+	UpdateStepBootstrap UpdateStep = "bootstrap"
+	UpdateStepTimestamp UpdateStep = "timestamp"
+	UpdateStepSnapshot  UpdateStep = "snapshot"
+	UpdateStepTargets   UpdateStep = "targets"
+	UpdateStepRoot      UpdateStep = "root"
+	UpdateStepPolicy    UpdateStep = "policy"
+)
+
+// UpdateStepResult is the outcome of a step of an update cycle.  Attempt is 1
+// for the first pass over the metadata and 2 for the pass after the root is
+// updated.  Version is the version of the step's role that was trusted, or 0
+// if the step failed or trusts no single role.
+type UpdateStepResult struct {
+	Step    UpdateStep
+	Attempt int
+	Version int
+	Err     error
+}
+
+// UpdateReport is the outcome of an update cycle: each step taken, in order,
+// the error the update failed with, if any, and the versions of the base roles
+// trusted in the cache once the update finished
+type UpdateReport struct {
+	Steps    []UpdateStepResult
+	Err      error
+	Versions map[data.RoleName]int
+}
+
+// FailedStep returns the last step to fail, which is the step the update
+// failed at if it failed.  A step may fail and the update then succeed once
+// the root is updated.
+func (r UpdateReport) FailedStep() (UpdateStepResult, bool) {
+	for i := len(r.Steps) - 1; i >= 0; i-- {
+		if r.Steps[i].Err != nil {
+			return r.Steps[i], true
+		}
+	}
+	return UpdateStepResult{}, false
+}
+
+// ConformanceClient performs update cycles of a GUN against metadata served
+// from any metadata store, reporting the outcome of each step, so that a
+// conformance harness can check how the client handles rollbacks, expiry and
+// other attacks.  The trusted metadata is kept in the cache between refreshes;
+// seeding the cache with a root pins trust, and otherwise the first root
+// fetched is trusted.
+type ConformanceClient struct {
+	gun    data.GUN
+	remote store.RemoteStore
+	cache  store.MetadataStore
+	now    func() time.Time
+	repo   ReadOnly
+}
+
+// NewConformanceClient creates a conformance client for a GUN, fetching
+// metadata from the remote store, and checking expiry against the system clock
+func NewConformanceClient(gun data.GUN, remote, cache store.MetadataStore) *ConformanceClient {
+	return NewConformanceClientWithClock(gun, remote, cache, time.Now)
+}
+
+// NewConformanceClientWithClock creates a conformance client which checks
+// expiry against the time returned by now
+func NewConformanceClientWithClock(gun data.GUN, remote, cache store.MetadataStore, now func() time.Time) *ConformanceClient {
+	remoteStore, ok := remote.(store.RemoteStore)
+	if !ok {
+		remoteStore = metadataOnlyRemote{remote}
+	}
+	return &ConformanceClient{gun: gun, remote: remoteStore, cache: cache, now: now}
+}
+
+// NewConformanceClientFromDirs creates a conformance client which fetches the
+// metadata files, named <role>.json, from the remote directory, and keeps its
+// trusted metadata in the cache directory
+func NewConformanceClientFromDirs(gun data.GUN, remoteDir, cacheDir string) (*ConformanceClient, error) {
+	remote, err := store.NewFileStore(remoteDir, "json")
+	if err != nil {
+		return nil, err
+	}
+	cache, err := store.NewFileStore(cacheDir, "json")
+	if err != nil {
+		return nil, err
+	}
+	return NewConformanceClient(gun, remote, cache), nil
+}
+
+// Refresh performs a full update cycle, returning the outcome of each step.
+// The updated repo is available from Repo if the update succeeds.
+func (c *ConformanceClient) Refresh() UpdateReport {
+	report := UpdateReport{}
+	now := c.now
+	repo, _, err := LoadTUFRepo(TUFLoadOptions{
+		GUN:           c.gun,
+		TrustPinning:  trustpinning.TrustPinConfig{},
+		CryptoService: cryptoservice.EmptyService,
+		Cache:         c.cache,
+		RemoteStore:   c.remote,
+		TrustedTime: &TrustedTimeConfig{
+			Source:   func() (time.Time, error) { return now(), nil },
+			Required: true,
+		},
+		Observer: func(result UpdateStepResult) {
+			report.Steps = append(report.Steps, result)
+		},
+	})
+	report.Err = err
+	report.Versions = cachedVersions(c.cache)
+	if err == nil {
+		c.repo = NewReadOnly(repo)
+	}
+	return report
+}
+
+// Repo returns the repo trusted by the last successful refresh, or nil if no
+// refresh has succeeded
+func (c *ConformanceClient) Repo() ReadOnly {
+	return c.repo
+}
+
+// cachedVersions returns the versions of the base roles in the cache, omitting
+// any which are missing or cannot be parsed
+func cachedVersions(cache store.MetadataStore) map[data.RoleName]int {
+	versions := make(map[data.RoleName]int)
+	for _, role := range data.BaseRoles {
+		raw, err := cache.GetSized(role.String(), store.NoSizeLimit)
+		if err != nil {
+			continue
+		}
+		signed := &data.Signed{}
+		if err := data.UnmarshalMetadata(raw, signed); err != nil {
+			continue
+		}
+		common := data.SignedCommon{}
+		if err := data.UnmarshalMetadata(*signed.Signed, &common); err != nil {
+			continue
+		}
+		versions[role] = common.Version
+	}
+	return versions
+}
+
+// metadataOnlyRemote serves metadata from a store which has no keys, such as
+// a directory of metadata files
+type metadataOnlyRemote struct {
+	store.MetadataStore
+}
+
+func (m metadataOnlyRemote) GetKey(role data.RoleName) ([]byte, error) {
+	return nil, fmt.Errorf("%s does not serve keys", m.Location())
+}
+
+func (m metadataOnlyRemote) RotateKey(role data.RoleName) ([]byte, error) {
+	return nil, fmt.Errorf("%s does not serve keys", m.Location())
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+func newConformanceSwizzler(t *testing.T, gun data.GUN) *testutils.MetadataSwizzler {
+	meta, cs, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	return testutils.NewMetadataSwizzler(gun, meta, cs)
+}
+
+// A successful refresh reports every step, with the version each trusted
+func TestConformanceRefresh(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	swizzler := newConformanceSwizzler(t, gun)
+	client := NewConformanceClient(gun, swizzler.MetadataCache, store.NewMemoryStore(nil))
+	require.Nil(t, client.Repo())
+
+	report := client.Refresh()
+	require.NoError(t, report.Err)
+	require.Equal(t, []UpdateStepResult{
+		{Step: UpdateStepBootstrap, Attempt: 1, Version: 1},
+		{Step: UpdateStepTimestamp, Attempt: 1, Version: 1},
+		{Step: UpdateStepSnapshot, Attempt: 1, Version: 1},
+		{Step: UpdateStepTargets, Attempt: 1, Version: 1},
+		{Step: UpdateStepPolicy, Attempt: 1},
+	}, report.Steps)
+	_, failed := report.FailedStep()
+	require.False(t, failed)
+	require.Equal(t, map[data.RoleName]int{
+		data.CanonicalRootRole:      1,
+		data.CanonicalTargetsRole:   1,
+		data.CanonicalSnapshotRole:  1,
+		data.CanonicalTimestampRole: 1,
+	}, report.Versions)
+	require.NotNil(t, client.Repo())
+}
+
+// Serving a timestamp older than the trusted one fails the timestamp step,
+// both before and after the root is updated, and the newer timestamp remains
+// trusted
+func TestConformanceRollback(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, cs, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	swizzler := testutils.NewMetadataSwizzler(gun, testutils.CopyRepoMetadata(meta), cs)
+	client := NewConformanceClient(gun, swizzler.MetadataCache, store.NewMemoryStore(nil))
+
+	require.NoError(t, swizzler.OffsetMetadataVersion(data.CanonicalTimestampRole, 1))
+	report := client.Refresh()
+	require.NoError(t, report.Err)
+	require.Equal(t, 2, report.Versions[data.CanonicalTimestampRole])
+
+	require.NoError(t, swizzler.MetadataCache.Set(data.CanonicalTimestampRole.String(), meta[data.CanonicalTimestampRole]))
+	report = client.Refresh()
+	require.IsType(t, signed.ErrLowVersion{}, report.Err)
+	failedStep, failed := report.FailedStep()
+	require.True(t, failed)
+	require.Equal(t, UpdateStepTimestamp, failedStep.Step)
+	require.Equal(t, 2, failedStep.Attempt)
+	require.Equal(t, []UpdateStep{UpdateStepBootstrap, UpdateStepTimestamp, UpdateStepRoot, UpdateStepTimestamp},
+		steps(report))
+	require.Equal(t, 2, report.Versions[data.CanonicalTimestampRole])
+}
+
+// An expired timestamp fails the timestamp step, whether the server serves
+// one or the client's clock has passed its expiry
+func TestConformanceExpiredTimestamp(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	swizzler := newConformanceSwizzler(t, gun)
+	now := time.Now()
+	client := NewConformanceClientWithClock(gun, swizzler.MetadataCache, store.NewMemoryStore(nil),
+		func() time.Time { return now })
+	require.NoError(t, client.Refresh().Err)
+
+	now = now.AddDate(0, 1, 0)
+	report := client.Refresh()
+	require.IsType(t, signed.ErrExpired{}, report.Err)
+	failedStep, _ := report.FailedStep()
+	require.Equal(t, UpdateStepTimestamp, failedStep.Step)
+
+	now = time.Now()
+	require.NoError(t, client.Refresh().Err)
+	require.NoError(t, swizzler.ExpireMetadata(data.CanonicalTimestampRole))
+	report = client.Refresh()
+	require.IsType(t, signed.ErrExpired{}, report.Err)
+	failedStep, _ = report.FailedStep()
+	require.Equal(t, UpdateStepTimestamp, failedStep.Step)
+	require.Equal(t, UpdateStepResult{Step: UpdateStepRoot, Attempt: 2, Version: 1}, report.Steps[2])
+}
+
+// Metadata can be served from, and trusted in, directories of metadata files
+func TestConformanceClientFromDirs(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, _, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+
+	baseDir, err := ioutil.TempDir("", "conformance")
+	require.NoError(t, err)
+	defer os.RemoveAll(baseDir)
+	remoteDir, cacheDir := filepath.Join(baseDir, "remote"), filepath.Join(baseDir, "cache")
+	remote, err := store.NewFileStore(remoteDir, "json")
+	require.NoError(t, err)
+	// the root has consistent snapshots, so the files are also published by checksum
+	for role, raw := range meta {
+		checksum := sha256.Sum256(raw)
+		require.NoError(t, remote.Set(role.String(), raw))
+		require.NoError(t, remote.Set(role.String()+"."+hex.EncodeToString(checksum[:]), raw))
+	}
+
+	client, err := NewConformanceClientFromDirs(gun, remoteDir, cacheDir)
+	require.NoError(t, err)
+	report := client.Refresh()
+	require.NoError(t, report.Err)
+	require.Len(t, report.Versions, len(data.BaseRoles))
+
+	cached, err := ioutil.ReadFile(filepath.Join(cacheDir, "timestamp.json"))
+	require.NoError(t, err)
+	require.Equal(t, meta[data.CanonicalTimestampRole], cached)
+}
+
+func steps(report UpdateReport) []UpdateStep {
+	taken := make([]UpdateStep, 0, len(report.Steps))
+	for _, result := range report.Steps {
+		taken = append(taken, result.Step)
+	}
+	return taken
+}
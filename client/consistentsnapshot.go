@@ -0,0 +1,72 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ConsistentSnapshotRequirement is the consistent snapshot setting a client
+// expects the trusted root of a repository to have
+type ConsistentSnapshotRequirement int
+
+const (
+	// ConsistentSnapshotAny accepts either setting, and is the default
+	ConsistentSnapshotAny ConsistentSnapshotRequirement = iota
+	// ConsistentSnapshotRequired fails updates if the root does not enable
+	// consistent snapshots
+	ConsistentSnapshotRequired
+	// ConsistentSnapshotForbidden fails updates if the root enables
+	// consistent snapshots
+	ConsistentSnapshotForbidden
+)
+
+// ErrConsistentSnapshotMismatch is returned when the trusted root's consistent
+// snapshot setting is not the one the client requires
+type ErrConsistentSnapshotMismatch struct {
+	GUN     data.GUN
+	Enabled bool
+}
+
+func (err ErrConsistentSnapshotMismatch) Error() string {
+	if err.Enabled {
+		return fmt.Sprintf("the root of %s enables consistent snapshots, but the client requires them disabled", err.GUN)
+	}
+	return fmt.Sprintf("the root of %s does not enable consistent snapshots, but the client requires them", err.GUN)
+}
+
+// checkRoot returns ErrConsistentSnapshotMismatch if the serialized root does
+// not have the required setting.  It is checked as each root is loaded, so
+// that no other metadata is fetched or cached for a root the client won't
+// trust.
+func (req ConsistentSnapshotRequirement) checkRoot(gun data.GUN, rootJSON []byte) error {
+	if req == ConsistentSnapshotAny {
+		return nil
+	}
+	signedRoot := &data.SignedRoot{}
+	if err := data.UnmarshalMetadata(rootJSON, signedRoot); err != nil {
+		return err
+	}
+	enabled := signedRoot.Signed.ConsistentSnapshot
+	switch {
+	case req == ConsistentSnapshotRequired && !enabled, req == ConsistentSnapshotForbidden && enabled:
+		return ErrConsistentSnapshotMismatch{GUN: gun, Enabled: enabled}
+	}
+	return nil
+}
+
+// ConsistentSnapshotEnabled updates the repository and returns whether its
+// trusted root enables consistent snapshots
+func (r *repository) ConsistentSnapshotEnabled() (bool, error) {
+	if err := r.updateTUF(false); err != nil {
+		return false, err
+	}
+	return r.tufRepo.Root.Signed.ConsistentSnapshot, nil
+}
+
+// SetConsistentSnapshotRequirement fails every update, with
+// ErrConsistentSnapshotMismatch, whose trusted root does not have the required
+// consistent snapshot setting
+func (r *repository) SetConsistentSnapshotRequirement(req ConsistentSnapshotRequirement) {
+	r.consistentSnapshot = req
+}
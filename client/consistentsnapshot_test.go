@@ -0,0 +1,71 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// The consistent snapshot flag of the root is detected whether it is set or
+// not, and updates fail if it is not the required setting
+func TestConsistentSnapshotEnabled(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	for _, enabled := range []bool{false, true} {
+		meta, cs, err := testutils.NewRepoMetadata(gun)
+		require.NoError(t, err)
+		swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+		require.NoError(t, swizzler.MutateRoot(func(r *data.Root) { r.ConsistentSnapshot = enabled }))
+		require.NoError(t, swizzler.UpdateSnapshotHashes())
+		require.NoError(t, swizzler.UpdateTimestampHash())
+		ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+		defer ts.Close()
+
+		repo, baseDir := newBlankRepo(t, ts.URL)
+		defer os.RemoveAll(baseDir)
+		detected, err := repo.ConsistentSnapshotEnabled()
+		require.NoError(t, err)
+		require.Equal(t, enabled, detected)
+
+		matching, mismatching := ConsistentSnapshotForbidden, ConsistentSnapshotRequired
+		if enabled {
+			matching, mismatching = mismatching, matching
+		}
+		repo.SetConsistentSnapshotRequirement(matching)
+		_, err = repo.ListTargets()
+		require.NoError(t, err)
+
+		repo.SetConsistentSnapshotRequirement(mismatching)
+		_, err = repo.ListTargets()
+		require.Equal(t, ErrConsistentSnapshotMismatch{GUN: gun, Enabled: enabled}, err)
+
+		repo.SetConsistentSnapshotRequirement(ConsistentSnapshotAny)
+		_, err = repo.ListTargets()
+		require.NoError(t, err)
+	}
+}
+
+// A root without the required setting fails the update as soon as it is
+// loaded, before any other metadata is fetched, and nothing is cached
+func TestConsistentSnapshotCheckedBeforeCaching(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, _, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	ts := readOnlyServer(t, store.NewMemoryStore(meta), http.StatusNotFound, gun)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetConsistentSnapshotRequirement(ConsistentSnapshotRequired)
+	_, err = repo.ListTargets()
+	require.Equal(t, ErrConsistentSnapshotMismatch{GUN: gun, Enabled: false}, err)
+
+	for _, role := range data.BaseRoles {
+		_, err := repo.cache.GetSized(role.String(), store.NoSizeLimit)
+		require.IsType(t, store.ErrMetaNotFound{}, err, role.String())
+	}
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// SetDefaultTargetRole sets the role AddTarget adds targets to when no roles
+// are given, for repositories organized entirely around delegations.  The
+// role must be a delegation which is valid in the trusted metadata, which the
+// repository is updated for, and which this client holds at least one of the
+// signing keys of, so that the targets added can be signed on publish.  An
+// empty role or the targets role restores the default, without checking.
+func (r *repository) SetDefaultTargetRole(role data.RoleName) error {
+	if role == "" || role == data.CanonicalTargetsRole {
+		r.defaultTargetRole = ""
+		return nil
+	}
+	if !data.IsDelegation(role) {
+		return data.ErrInvalidRole{Role: role, Reason: "the default target role must be a delegation"}
+	}
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+	keyIDs, err := r.localSigningKeys(role)
+	if err != nil {
+		return err
+	}
+	if len(keyIDs) == 0 {
+		return data.ErrInvalidRole{Role: role, Reason: "no signing key for the role is available locally"}
+	}
+	r.defaultTargetRole = role
+	return nil
+}
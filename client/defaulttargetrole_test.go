@@ -0,0 +1,69 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Once a delegation this client holds a key for is the default target role,
+// targets added without a role land in it, while targets added to an
+// explicit role still land there
+func TestDefaultTargetRole(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	// the delegation's key is not yet available locally
+	err := repo.SetDefaultTargetRole("targets/a")
+	require.Error(t, err)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+
+	keyIDs := serverSwizzler.CryptoService.ListKeys("targets/a")
+	require.NotEmpty(t, keyIDs)
+	privKey, _, err := serverSwizzler.CryptoService.GetPrivateKey(keyIDs[0])
+	require.NoError(t, err)
+	require.NoError(t, repo.GetCryptoService().AddKey("targets/a", repo.gun, privKey))
+	require.NoError(t, repo.SetDefaultTargetRole("targets/a"))
+
+	addTarget(t, repo, "default", "../fixtures/root-ca.crt")
+	addTarget(t, repo, "explicit", "../fixtures/root-ca.crt", "targets/b")
+	require.NoError(t, repo.SetDefaultTargetRole(data.CanonicalTargetsRole))
+	addTarget(t, repo, "restored", "../fixtures/root-ca.crt")
+
+	changes := getChanges(t, repo)
+	require.Len(t, changes, 3)
+	scopes := make(map[string]data.RoleName)
+	for _, c := range changes {
+		scopes[c.Path()] = c.Scope()
+	}
+	require.Equal(t, data.RoleName("targets/a"), scopes["default"])
+	require.Equal(t, data.RoleName("targets/b"), scopes["explicit"])
+	require.Equal(t, data.CanonicalTargetsRole, scopes["restored"])
+}
+
+// Only delegations valid in the trusted metadata can be the default target
+// role, and a rejected role leaves the default unchanged
+func TestDefaultTargetRoleInvalid(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	for _, role := range []data.RoleName{data.CanonicalSnapshotRole, "targets/*", "targets/nonexistent"} {
+		require.Error(t, repo.SetDefaultTargetRole(role), role.String())
+	}
+
+	addTarget(t, repo, "app", "../fixtures/root-ca.crt")
+	changes := getChanges(t, repo)
+	require.Len(t, changes, 1)
+	require.Equal(t, data.CanonicalTargetsRole, changes[0].Scope())
+}
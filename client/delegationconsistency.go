@@ -0,0 +1,141 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// DelegationInconsistency describes a delegation whose keys or signatures do
+// not match those its parent authorizes for it
+type DelegationInconsistency struct {
+	Role data.RoleName
+	// Parent is the targets role which delegates to Role
+	Parent data.RoleName
+	// Reason describes the inconsistency
+	Reason string
+	// KeyIDs are the key IDs involved, such as the unauthorized signing keys
+	// or the authorized keys the parent does not list
+	KeyIDs []string
+}
+
+// VerifyDelegationConsistency walks the delegation tree of the trusted targets
+// and reports every delegation which lists a key its parent does not include,
+// which is signed by keys its parent does not authorize for it, or which is
+// not validly signed by a threshold of the keys its parent authorizes.
+// Delegations which failed verification on the update are checked as well,
+// as they are the ones most likely to be inconsistent, though targets which
+// list keys they do not include fail validation on the update and so are
+// never walked.  The inconsistencies are sorted by role; an error is only
+// returned if the repository could not be updated.
+func (r *repository) VerifyDelegationConsistency() ([]DelegationInconsistency, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+
+	var inconsistencies []DelegationInconsistency
+	toWalk := []data.RoleName{data.CanonicalTargetsRole}
+	walked := make(map[data.RoleName]bool)
+	for len(toWalk) > 0 {
+		parentName := toWalk[0]
+		toWalk = toWalk[1:]
+		parent := r.loadedTargets(parentName)
+		if parent == nil || walked[parentName] {
+			continue
+		}
+		walked[parentName] = true
+
+		for _, delegation := range parent.Signed.Delegations.Roles {
+			inconsistencies = append(inconsistencies,
+				checkDelegation(parent, parentName, delegation, r.loadedTargets(delegation.Name))...)
+			toWalk = append(toWalk, delegation.Name)
+		}
+	}
+
+	sort.SliceStable(inconsistencies, func(i, j int) bool {
+		return inconsistencies[i].Role < inconsistencies[j].Role
+	})
+	return inconsistencies, nil
+}
+
+// loadedTargets returns the targets metadata for the role loaded on the last
+// update, whether or not it was valid, or nil if none was loaded
+func (r *repository) loadedTargets(role data.RoleName) *data.SignedTargets {
+	if t, ok := r.tufRepo.Targets[role]; ok {
+		return t
+	}
+	if r.invalid != nil {
+		if t, ok := r.invalid.Targets[role]; ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// checkDelegation compares the delegation as declared by its parent with the
+// signatures on the delegation's metadata, if it was loaded
+func checkDelegation(parent *data.SignedTargets, parentName data.RoleName, delegation *data.Role,
+	child *data.SignedTargets) []DelegationInconsistency {
+
+	var (
+		inconsistencies []DelegationInconsistency
+		missing         []string
+	)
+	report := func(reason string, keyIDs []string) {
+		sort.Strings(keyIDs)
+		inconsistencies = append(inconsistencies, DelegationInconsistency{
+			Role:   delegation.Name,
+			Parent: parentName,
+			Reason: reason,
+			KeyIDs: keyIDs,
+		})
+	}
+
+	authorized := make(map[string]data.PublicKey)
+	for _, keyID := range delegation.KeyIDs {
+		key, ok := parent.Signed.Delegations.Keys[keyID]
+		if !ok {
+			missing = append(missing, keyID)
+			continue
+		}
+		authorized[keyID] = key
+	}
+	if len(missing) > 0 {
+		report(fmt.Sprintf("%s authorizes keys it does not include", parentName), missing)
+	}
+	if child == nil {
+		return inconsistencies
+	}
+
+	var unauthorized []string
+	seen := make(map[string]bool)
+	for _, sig := range child.Signatures {
+		if _, ok := authorized[sig.KeyID]; !ok && !seen[sig.KeyID] {
+			unauthorized = append(unauthorized, sig.KeyID)
+		}
+		seen[sig.KeyID] = true
+	}
+	if len(unauthorized) > 0 {
+		report(fmt.Sprintf("signed by keys %s does not authorize", parentName), unauthorized)
+	}
+
+	signedChild, err := child.ToSigned()
+	if err != nil {
+		report(fmt.Sprintf("could not be serialized for verification: %v", err), nil)
+		return inconsistencies
+	}
+	valid, err := signed.ValidSignatureKeyIDs(signedChild, data.BaseRole{
+		Name:      delegation.Name,
+		Keys:      authorized,
+		Threshold: delegation.Threshold,
+	})
+	if err != nil {
+		report(fmt.Sprintf("signatures could not be verified: %v", err), nil)
+	} else if len(valid) < delegation.Threshold {
+		report(fmt.Sprintf("validly signed by %d of the keys %s authorizes, fewer than the threshold of %d",
+			len(valid), parentName, delegation.Threshold), valid)
+	}
+	return inconsistencies
+}
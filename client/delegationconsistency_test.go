@@ -0,0 +1,78 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A delegation signed by a key its parent does not authorize is reported,
+// both as signed by an unauthorized key and as under its threshold, while
+// the consistent delegations are not
+func TestVerifyDelegationConsistencyUnauthorizedSigner(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	inconsistencies, err := repo.VerifyDelegationConsistency()
+	require.NoError(t, err)
+	require.Empty(t, inconsistencies)
+
+	require.NoError(t, serverSwizzler.SignMetadataWithInvalidKey("targets/b"))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	inconsistencies, err = repo.VerifyDelegationConsistency()
+	require.NoError(t, err)
+	require.Len(t, inconsistencies, 2)
+
+	meta, err := serverSwizzler.MetadataCache.GetSized("targets/b", store.NoSizeLimit)
+	require.NoError(t, err)
+	signedB := &data.Signed{}
+	require.NoError(t, json.Unmarshal(meta, signedB))
+	require.Len(t, signedB.Signatures, 1)
+
+	for _, inconsistency := range inconsistencies {
+		require.Equal(t, data.RoleName("targets/b"), inconsistency.Role)
+		require.Equal(t, data.CanonicalTargetsRole, inconsistency.Parent)
+	}
+	require.Contains(t, inconsistencies[0].Reason, "does not authorize")
+	require.Equal(t, []string{signedB.Signatures[0].KeyID}, inconsistencies[0].KeyIDs)
+	require.Contains(t, inconsistencies[1].Reason, "fewer than the threshold")
+	require.Empty(t, inconsistencies[1].KeyIDs)
+}
+
+// A delegation its parent authorizes keys for which the parent does not
+// include is reported.  Such targets metadata fails validation on the update,
+// so the delegation is checked directly.
+func TestVerifyDelegationConsistencyMissingKey(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	meta, err := serverSwizzler.MetadataCache.GetSized(data.CanonicalTargetsRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	parent := &data.SignedTargets{}
+	require.NoError(t, json.Unmarshal(meta, parent))
+
+	var delegation *data.Role
+	for _, role := range parent.Signed.Delegations.Roles {
+		if role.Name == "targets/a" {
+			delegation = role
+		}
+	}
+	require.NotNil(t, delegation)
+	require.Empty(t, checkDelegation(parent, data.CanonicalTargetsRole, delegation, nil))
+
+	delegation.KeyIDs = append(delegation.KeyIDs, "missing")
+	inconsistencies := checkDelegation(parent, data.CanonicalTargetsRole, delegation, nil)
+	require.Len(t, inconsistencies, 1)
+	require.Equal(t, data.RoleName("targets/a"), inconsistencies[0].Role)
+	require.Equal(t, data.CanonicalTargetsRole, inconsistencies[0].Parent)
+	require.Equal(t, []string{"missing"}, inconsistencies[0].KeyIDs)
+}
@@ -0,0 +1,24 @@
+package client
+
+import (
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// DelegationPriority compares two sibling delegations, returning a negative
+// number if a takes priority over b when resolving targets, a positive number
+// if b takes priority over a, and 0 if they keep their order of declaration.
+type DelegationPriority func(a, b data.Role) int
+
+// SetDelegationPriority reorders the sibling delegations of every role, when
+// targets are listed or resolved, by the given comparator rather than by
+// their order of declaration.  For instance, a "hotfix" delegation can be
+// made to win over the other delegations sharing its paths.  The metadata is
+// unchanged: this only affects which of the trusted delegations signing a
+// target takes priority, not which delegations are trusted or which paths
+// each may sign.  A nil comparator restores the order of declaration.
+func (r *repository) SetDelegationPriority(priority DelegationPriority) {
+	r.delegationPriority = priority
+	if r.tufRepo != nil {
+		r.tufRepo.SetDelegationPriority(priority)
+	}
+}
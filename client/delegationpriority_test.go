@@ -0,0 +1,78 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A custom comparator changes which of two sibling delegations signing the
+// same target wins, without changing the metadata
+func TestDelegationPriority(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/base", "targets/hotfix")
+	require.NoError(t, err)
+	for _, delgName := range []data.RoleName{"targets/base", "targets/hotfix"} {
+		tufRepo.InitTargets(delgName)
+		_, err := tufRepo.AddTargets(delgName, data.Files{"app": data.FileMeta{
+			Length: 1, Hashes: data.Hashes{"sha256": []byte(delgName.String())}}})
+		require.NoError(t, err)
+	}
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	ts := readOnlyServer(t, testutils.NewMetadataSwizzler(gun, meta, cs).MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	requireResolves := func(role data.RoleName) {
+		resolved, err := repo.GetTargetByName("app")
+		require.NoError(t, err)
+		require.Equal(t, role, resolved.Role)
+		require.Equal(t, data.Hashes{"sha256": []byte(role.String())}, resolved.Hashes)
+
+		listed, err := repo.ListTargets()
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		require.Equal(t, role, listed[0].Role)
+	}
+
+	// by default, the delegation declared first wins
+	requireResolves("targets/base")
+
+	hotfixFirst := func(a, b data.Role) int {
+		aHotfix, bHotfix := strings.HasSuffix(a.Name.String(), "/hotfix"), strings.HasSuffix(b.Name.String(), "/hotfix")
+		switch {
+		case aHotfix && !bHotfix:
+			return -1
+		case bHotfix && !aHotfix:
+			return 1
+		}
+		return 0
+	}
+	repo.SetDelegationPriority(hotfixFirst)
+	requireResolves("targets/hotfix")
+
+	// the order of the delegations in the metadata is unchanged
+	roles, err := repo.GetDelegationRoles()
+	require.NoError(t, err)
+	require.Len(t, roles, 2)
+	require.Equal(t, data.RoleName("targets/base"), roles[0].Name)
+
+	// the priority is applied to a repository set up before its first update
+	fresh, freshDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(freshDir)
+	fresh.SetDelegationPriority(hotfixFirst)
+	resolved, err := fresh.GetTargetByName("app")
+	require.NoError(t, err)
+	require.Equal(t, data.RoleName("targets/hotfix"), resolved.Role)
+
+	repo.SetDelegationPriority(nil)
+	requireResolves("targets/base")
+}
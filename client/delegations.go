@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/sirupsen/logrus"
-	"github.com/theupdateframework/notary"
 	"github.com/theupdateframework/notary/client/changelist"
 	"github.com/theupdateframework/notary/tuf/data"
 	"github.com/theupdateframework/notary/tuf/utils"
@@ -38,12 +37,17 @@ func (r *repository) AddDelegationRoleAndKeys(name data.RoleName, delegationKeys
 		return data.ErrInvalidRole{Role: name, Reason: "invalid delegation role name"}
 	}
 
+	// the delegation is created with its minimum threshold
+	threshold, err := r.minimumThreshold.creationThreshold(name, len(delegationKeys))
+	if err != nil {
+		return err
+	}
+
 	logrus.Debugf(`Adding delegation "%s" with threshold %d, and %d keys\n`,
-		name, notary.MinThreshold, len(delegationKeys))
+		name, threshold, len(delegationKeys))
 
-	// Defaulting to threshold of 1, since we don't allow for larger thresholds at the moment.
 	tdJSON, err := json.Marshal(&changelist.TUFDelegation{
-		NewThreshold: notary.MinThreshold,
+		NewThreshold: threshold,
 		AddKeys:      data.KeyList(delegationKeys),
 	})
 	if err != nil {
@@ -130,13 +134,19 @@ func (r *repository) RemoveDelegationPaths(name data.RoleName, paths []string) e
 // When this changelist is applied, if the specified keys are the only keys left in the role,
 // the role itself will be deleted in its entirety.
 // It can also delete a key from all delegations under a parent using a name
-// with a wildcard at the end.
+// with a wildcard at the end.  Key IDs are accepted in any case, but malformed
+// key IDs are rejected.
 func (r *repository) RemoveDelegationKeys(name data.RoleName, keyIDs []string) error {
 
 	if !data.IsDelegation(name) && !data.IsWildDelegation(name) {
 		return data.ErrInvalidRole{Role: name, Reason: "invalid delegation role name"}
 	}
 
+	keyIDs, err := data.CanonicalKeyIDs(keyIDs)
+	if err != nil {
+		return err
+	}
+
 	logrus.Debugf(`Removing %s keys from delegation "%s"\n`, keyIDs, name)
 
 	tdJSON, err := json.Marshal(&changelist.TUFDelegation{
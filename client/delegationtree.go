@@ -0,0 +1,205 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// TreeStatus is the result of checking a single role in the delegation tree
+type TreeStatus string
+
+// The possible statuses of a role in the delegation tree
+const (
+	// TreeStatusOK means the role is signed by its parent's delegated keys,
+	// unexpired and consistent with the snapshot
+	TreeStatusOK TreeStatus = "ok"
+	// TreeStatusExpired means the role is validly signed but has expired
+	TreeStatusExpired TreeStatus = "expired"
+	// TreeStatusBadSignature means the role does not meet the signing threshold
+	// set by its parent, or cannot be parsed
+	TreeStatusBadSignature TreeStatus = "bad-signature"
+	// TreeStatusSnapshotMismatch means the role's metadata does not match the
+	// hashes recorded for it in the snapshot
+	TreeStatusSnapshotMismatch TreeStatus = "snapshot-mismatch"
+	// TreeStatusMissingFromSnapshot means the role is delegated to and its
+	// metadata is published, but the snapshot does not list it, as happens
+	// when a publish updates the delegating role without the snapshot
+	TreeStatusMissingFromSnapshot TreeStatus = "missing-from-snapshot"
+	// TreeStatusUnreachable means the role is listed in the snapshot, but is not
+	// reachable through valid delegations from the targets role
+	TreeStatusUnreachable TreeStatus = "unreachable"
+	// TreeStatusDangling means the role is delegated to, but no metadata exists
+	// for it
+	TreeStatusDangling TreeStatus = "dangling"
+)
+
+// TreeRoleReport is the status of a single role in the delegation tree
+type TreeRoleReport struct {
+	Role data.RoleName
+	// Parent is the role delegating to this one, which is empty for the targets
+	// role and for unreachable roles
+	Parent data.RoleName
+	Status TreeStatus
+	// Err describes the problem found, if the status is not TreeStatusOK
+	Err error
+}
+
+// TreeReport is the result of checking every role in the delegation tree
+type TreeReport struct {
+	Roles map[data.RoleName]TreeRoleReport
+}
+
+// OK returns true if every role in the delegation tree is ok
+func (t TreeReport) OK() bool {
+	return len(t.Problems()) == 0
+}
+
+// Problems returns the reports for all roles that are not ok, sorted by role name
+func (t TreeReport) Problems() []TreeRoleReport {
+	var problems []TreeRoleReport
+	for _, roleReport := range t.Roles {
+		if roleReport.Status != TreeStatusOK {
+			problems = append(problems, roleReport)
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Role < problems[j].Role })
+	return problems
+}
+
+// delegationTreeNode is a targets role whose delegations still need to be checked
+type delegationTreeNode struct {
+	name    data.RoleName
+	targets *data.SignedTargets
+}
+
+// VerifyDelegationTree updates the repository and then walks every delegation
+// from the targets role down, checking that each delegated role is signed by
+// the keys its parent delegates to, unexpired and consistent with the snapshot.
+// Delegations whose published metadata the snapshot does not list are reported
+// as missing from the snapshot, roles listed in the snapshot which are not
+// reachable through valid delegations as unreachable, and delegations without
+// any metadata as dangling.  Problems with individual roles are aggregated in the report;
+// an error is only returned if the repository could not be updated.
+func (r *repository) VerifyDelegationTree() (TreeReport, error) {
+	if err := r.updateTUF(false); err != nil {
+		return TreeReport{}, err
+	}
+	now, err := trustedClock(r.trustedTime)
+	if err != nil {
+		return TreeReport{}, err
+	}
+	report := TreeReport{Roles: map[data.RoleName]TreeRoleReport{
+		// the targets role has already been verified by the update
+		data.CanonicalTargetsRole: {Role: data.CanonicalTargetsRole, Status: TreeStatusOK},
+	}}
+	snapshotMeta := r.tufRepo.Snapshot.Signed.Meta
+
+	toVisit := []delegationTreeNode{{
+		name:    data.CanonicalTargetsRole,
+		targets: r.tufRepo.Targets[data.CanonicalTargetsRole],
+	}}
+	for len(toVisit) > 0 {
+		parent := toVisit[0]
+		toVisit = toVisit[1:]
+
+		for _, child := range parent.targets.Signed.Delegations.Roles {
+			if _, ok := report.Roles[child.Name]; ok {
+				continue
+			}
+			childTargets, status, err := r.verifyDelegation(parent.targets, child.Name, snapshotMeta, now())
+			report.Roles[child.Name] = TreeRoleReport{
+				Role:   child.Name,
+				Parent: parent.name,
+				Status: status,
+				Err:    err,
+			}
+			// only descend into delegations whose signatures could be verified
+			if childTargets != nil {
+				toVisit = append(toVisit, delegationTreeNode{name: child.Name, targets: childTargets})
+			}
+		}
+	}
+
+	for name := range snapshotMeta {
+		roleName := data.RoleName(name)
+		if _, ok := report.Roles[roleName]; ok || !data.IsDelegation(roleName) {
+			continue
+		}
+		report.Roles[roleName] = TreeRoleReport{
+			Role:   roleName,
+			Status: TreeStatusUnreachable,
+			Err:    fmt.Errorf("%s is in the snapshot but not reachable from %s", roleName, data.CanonicalTargetsRole),
+		}
+	}
+	return report, nil
+}
+
+// verifyDelegation checks a single delegated role against the keys its parent
+// delegates to and the snapshot.  The parsed targets are returned if the role's
+// signatures are valid, even if it has expired, so its own delegations can be
+// checked.
+func (r *repository) verifyDelegation(parent *data.SignedTargets, name data.RoleName, snapshotMeta data.Files,
+	now time.Time) (
+	*data.SignedTargets, TreeStatus, error) {
+
+	role, err := parent.BuildDelegationRole(name)
+	if err != nil {
+		return nil, TreeStatusBadSignature, err
+	}
+	meta, ok := snapshotMeta[name.String()]
+	if !ok {
+		if err, ok := checkUnlistedDelegation(r.getRemoteStore(), name).(ErrSnapshotMissingDelegation); ok {
+			return nil, TreeStatusMissingFromSnapshot, err
+		}
+		return nil, TreeStatusDangling, fmt.Errorf("%s is delegated to but is not in the snapshot", name)
+	}
+	raw, err := r.delegationMetadata(name, meta)
+	if err != nil {
+		return nil, TreeStatusDangling, err
+	}
+	if err := data.CheckHashes(raw, name.String(), meta.Hashes); err != nil {
+		return nil, TreeStatusSnapshotMismatch, err
+	}
+
+	s := &data.Signed{}
+	if err := data.UnmarshalMetadata(raw, s); err != nil {
+		return nil, TreeStatusBadSignature, err
+	}
+	if err := signed.VerifySignatures(s, role.BaseRole); err != nil {
+		return nil, TreeStatusBadSignature, err
+	}
+	targets, err := data.TargetsFromSigned(s, name)
+	if err != nil {
+		return nil, TreeStatusBadSignature, err
+	}
+	if err := signed.VerifyExpiryAt(&targets.Signed.SignedCommon, name, now); err != nil {
+		return targets, TreeStatusExpired, err
+	}
+	return targets, TreeStatusOK, nil
+}
+
+// delegationMetadata returns the metadata for a delegated role from the cache
+// if it matches the snapshot, and otherwise from the remote
+func (r *repository) delegationMetadata(name data.RoleName, meta data.FileMeta) ([]byte, error) {
+	cached, err := r.cache.GetSized(name.String(), meta.Length)
+	if err == nil && data.CheckHashes(cached, name.String(), meta.Hashes) == nil {
+		return cached, nil
+	}
+	consistentName := utils.ConsistentName(name.String(), meta.Hashes[notary.SHA256])
+	raw, remoteErr := r.getRemoteStore().GetSized(consistentName, meta.Length)
+	if remoteErr != nil {
+		if err == nil {
+			// return the cached copy, which fails the snapshot check
+			return cached, nil
+		}
+		return nil, fmt.Errorf("no metadata found for %s (sha256 %s)", name, hex.EncodeToString(meta.Hashes[notary.SHA256]))
+	}
+	return raw, nil
+}
@@ -0,0 +1,158 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/client/changelist"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// Every problem in the tree is reported: an expired delegation, a delegation
+// without metadata, and metadata that is no longer delegated to
+func TestVerifyDelegationTree(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/a", "targets/a/b", "targets/c", "targets/d")
+	require.NoError(t, err)
+	// targets/c is delegated to, but never gets any metadata
+	for _, role := range []data.RoleName{"targets/a/b", "targets/d"} {
+		_, err = tufRepo.InitTargets(role)
+		require.NoError(t, err)
+	}
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	require.NoError(t, swizzler.ExpireMetadata("targets/a"))
+	// targets/d stays in the snapshot, but is no longer delegated to
+	require.NoError(t, swizzler.MutateTargets(func(tg *data.Targets) {
+		var roles []*data.Role
+		for _, role := range tg.Delegations.Roles {
+			if role.Name != "targets/d" {
+				roles = append(roles, role)
+			}
+		}
+		tg.Delegations.Roles = roles
+	}))
+	require.NoError(t, swizzler.UpdateSnapshotHashes())
+	require.NoError(t, swizzler.UpdateTimestampHash())
+
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+	r, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	report, err := r.VerifyDelegationTree()
+	require.NoError(t, err)
+	require.False(t, report.OK())
+
+	statuses := make(map[data.RoleName]TreeStatus)
+	for name, roleReport := range report.Roles {
+		statuses[name] = roleReport.Status
+		if roleReport.Status == TreeStatusOK {
+			require.NoError(t, roleReport.Err)
+		} else {
+			require.Error(t, roleReport.Err)
+		}
+	}
+	require.Equal(t, map[data.RoleName]TreeStatus{
+		data.CanonicalTargetsRole: TreeStatusOK,
+		"targets/a":               TreeStatusExpired,
+		"targets/a/b":             TreeStatusOK,
+		"targets/c":               TreeStatusDangling,
+		"targets/d":               TreeStatusUnreachable,
+	}, statuses)
+	require.IsType(t, signed.ErrExpired{}, report.Roles["targets/a"].Err)
+	require.Equal(t, data.RoleName("targets/a"), report.Roles["targets/a/b"].Parent)
+
+	problems := report.Problems()
+	require.Len(t, problems, 3)
+	require.Equal(t, data.RoleName("targets/a"), problems[0].Role)
+}
+
+// A delegation which is not signed by its parent's delegated keys is reported,
+// and is not descended into
+func TestVerifyDelegationTreeBadSignature(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/a", "targets/a/b")
+	require.NoError(t, err)
+	_, err = tufRepo.InitTargets("targets/a/b")
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	require.NoError(t, swizzler.InvalidateMetadataSignatures("targets/a"))
+	require.NoError(t, swizzler.UpdateSnapshotHashes())
+	require.NoError(t, swizzler.UpdateTimestampHash())
+
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+	r, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	report, err := r.VerifyDelegationTree()
+	require.NoError(t, err)
+	require.Equal(t, TreeStatusBadSignature, report.Roles["targets/a"].Status)
+	require.Equal(t, TreeStatusUnreachable, report.Roles["targets/a/b"].Status)
+
+}
+
+// A delegation whose metadata is published but which the snapshot does not
+// list is reported as missing from the snapshot, while one which has never been
+// published is dangling
+func TestVerifyDelegationTreeMissingFromSnapshot(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/a", "targets/b")
+	require.NoError(t, err)
+	for _, role := range []data.RoleName{"targets/a", "targets/b"} {
+		_, err = tufRepo.InitTargets(role)
+		require.NoError(t, err)
+	}
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	require.NoError(t, swizzler.RemoveMetadata("targets/b"))
+	require.NoError(t, swizzler.MutateSnapshot(func(snapshot *data.Snapshot) {
+		delete(snapshot.Meta, "targets/a")
+		delete(snapshot.Meta, "targets/b")
+	}))
+	require.NoError(t, swizzler.UpdateTimestampHash())
+
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+	r, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	report, err := r.VerifyDelegationTree()
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, TreeRoleReport{
+		Role:   "targets/a",
+		Parent: data.CanonicalTargetsRole,
+		Status: TreeStatusMissingFromSnapshot,
+		Err:    ErrSnapshotMissingDelegation{Role: "targets/a"},
+	}, report.Roles["targets/a"])
+	require.Equal(t, TreeStatusDangling, report.Roles["targets/b"].Status)
+}
+
+// A repository without any delegations is ok
+func TestVerifyDelegationTreeNoDelegations(t *testing.T) {
+	meta, cs, err := testutils.NewRepoMetadata("docker.com/notary")
+	require.NoError(t, err)
+	r, err := NewRepository("docker.com/notary", "", nil, store.NewMemoryStore(meta),
+		trustpinning.TrustPinConfig{}, cs, changelist.NewMemChangelist())
+	require.NoError(t, err)
+
+	report, err := r.VerifyDelegationTree()
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	require.Len(t, report.Roles, 1)
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// DriftStatus is how the cached version of a role compares to the remote one
+type DriftStatus string
+
+// The possible statuses of a cached role
+const (
+	// DriftInSync means the cache has the remote metadata
+	DriftInSync DriftStatus = "in-sync"
+	// DriftBehind means the cache has an older version than the remote, or
+	// none at all
+	DriftBehind DriftStatus = "behind"
+	// DriftAhead means the cache has a newer version than the remote, which
+	// may be due to a rollback of the server or a misconfiguration, such as
+	// the cache being pointed at the wrong server
+	DriftAhead DriftStatus = "ahead"
+	// DriftDiverged means the cache has different metadata than the remote
+	// with the same version, which should never happen
+	DriftDiverged DriftStatus = "diverged"
+)
+
+// RoleDrift is how the cached version of a role compares to the remote one
+type RoleDrift struct {
+	Role data.RoleName
+	// LocalVersion is 0 if the role is not cached
+	LocalVersion  int
+	RemoteVersion int
+	Status        DriftStatus
+}
+
+// driftRoles are the roles CacheDrift compares, each referenced by the one
+// before it
+var driftRoles = []data.RoleName{data.CanonicalTimestampRole, data.CanonicalSnapshotRole, data.CanonicalTargetsRole}
+
+// CacheDrift reports how the cached timestamp, snapshot and targets compare
+// to the remote ones, without updating or otherwise changing the cache.  The
+// remote timestamp is always fetched, and the remote snapshot and targets are
+// only fetched if they differ from the cached ones.  The remote metadata is
+// verified against the cached root, so it fails to verify if the remote root
+// has rotated the keys of a role.  A warning is logged for every role the
+// cache is ahead of the remote for.
+func (r *repository) CacheDrift() ([]RoleDrift, error) {
+	rootJSON, err := r.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	if err != nil {
+		if _, ok := err.(store.ErrMetaNotFound); ok {
+			return nil, ErrRepoNotInitialized{}
+		}
+		return nil, err
+	}
+	// the cached root is what pins trust, so no trust pinning is needed, and
+	// it is not checked against the remote snapshot, as the remote root may
+	// be a newer version
+	builder := tuf.NewRepoBuilder(r.gun, r.cryptoService, trustpinning.TrustPinConfig{})
+	if err := builder.LoadRootForUpdate(rootJSON, 1, false); err != nil {
+		return nil, err
+	}
+
+	drifts := make([]RoleDrift, 0, len(driftRoles))
+	for _, role := range driftRoles {
+		drift, err := r.roleDrift(builder, role)
+		if err != nil {
+			return nil, err
+		}
+		if drift.Status == DriftAhead {
+			logrus.Warnf("the cached %s is version %d, newer than the remote version %d: the server may have been rolled back or misconfigured",
+				role, drift.LocalVersion, drift.RemoteVersion)
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
+
+// roleDrift compares the cached role with the remote one, loading the remote
+// one into the builder, which must have loaded the role referencing it
+func (r *repository) roleDrift(builder tuf.RepoBuilder, role data.RoleName) (RoleDrift, error) {
+	drift := RoleDrift{Role: role}
+	ci := builder.GetConsistentInfo(role)
+
+	cached, cachedErr := r.cache.GetSized(role.String(), store.NoSizeLimit)
+	if cachedErr == nil {
+		version, err := metadataVersion(cached)
+		if err != nil {
+			return drift, err
+		}
+		drift.LocalVersion = version
+	}
+
+	remote := cached
+	if cachedErr != nil || !ci.ChecksumKnown() || consistentName(role, cached) != ci.ConsistentName() {
+		var err error
+		size := ci.Length()
+		if role == data.CanonicalTimestampRole {
+			size = notary.MaxTimestampSize
+		}
+		if remote, err = r.remoteStore.GetSized(ci.ConsistentName(), size); err != nil {
+			return drift, err
+		}
+	}
+	if err := builder.Load(role, remote, 1, true); err != nil {
+		return drift, err
+	}
+	drift.RemoteVersion = builder.GetLoadedVersion(role)
+
+	switch {
+	case drift.LocalVersion < drift.RemoteVersion:
+		drift.Status = DriftBehind
+	case drift.LocalVersion > drift.RemoteVersion:
+		drift.Status = DriftAhead
+	case consistentName(role, cached) != consistentName(role, remote):
+		drift.Status = DriftDiverged
+	default:
+		drift.Status = DriftInSync
+	}
+	return drift, nil
+}
+
+// consistentName returns the consistent name of the metadata for the role
+func consistentName(role data.RoleName, meta []byte) string {
+	checksum := sha256.Sum256(meta)
+	return utils.ConsistentName(role.String(), checksum[:])
+}
+
+// metadataVersion returns the version of the metadata, without verifying it
+func metadataVersion(meta []byte) (int, error) {
+	signedMeta := &data.Signed{}
+	if err := data.UnmarshalMetadata(meta, signedMeta); err != nil {
+		return 0, err
+	}
+	var common data.SignedCommon
+	if err := json.Unmarshal(*signedMeta.Signed, &common); err != nil {
+		return 0, err
+	}
+	return common.Version, nil
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// requireDrift asserts CacheDrift reports every compared role with the given
+// versions and status
+func requireDrift(t *testing.T, repo *repository, local, remote int, status DriftStatus) {
+	drifts, err := repo.CacheDrift()
+	require.NoError(t, err)
+	require.Len(t, drifts, len(driftRoles))
+	for i, role := range driftRoles {
+		require.Equal(t, RoleDrift{Role: role, LocalVersion: local, RemoteVersion: remote, Status: status}, drifts[i])
+	}
+}
+
+// The cache is in sync with the server once updated, behind it once the
+// server publishes newer versions, and ahead of it once the server is rolled
+// back, and checking never changes the cache
+func TestCacheDrift(t *testing.T) {
+	serverMeta, cs, err := testutils.NewRepoMetadata("docker.com/notary", metadataDelegations...)
+	require.NoError(t, err)
+	serverSwizzler := testutils.NewMetadataSwizzler("docker.com/notary", serverMeta, cs)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	_, err = repo.CacheDrift()
+	require.IsType(t, ErrRepoNotInitialized{}, err)
+
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	requireDrift(t, repo, 1, 1, DriftInSync)
+
+	bumpVersions(t, serverSwizzler, 1)
+	cachedTimestamp, err := repo.cache.GetSized(data.CanonicalTimestampRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	requireDrift(t, repo, 1, 2, DriftBehind)
+	stillCached, err := repo.cache.GetSized(data.CanonicalTimestampRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	require.Equal(t, cachedTimestamp, stillCached)
+
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	requireDrift(t, repo, 2, 2, DriftInSync)
+
+	for role, meta := range serverMeta {
+		require.NoError(t, serverSwizzler.MetadataCache.Set(role.String(), meta))
+	}
+	requireDrift(t, repo, 2, 1, DriftAhead)
+}
+
+// A role missing from the cache is behind
+func TestCacheDriftMissingRole(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	_, err := repo.ListTargets()
+	require.NoError(t, err)
+	require.NoError(t, repo.cache.Remove(data.CanonicalTargetsRole.String()))
+
+	drifts, err := repo.CacheDrift()
+	require.NoError(t, err)
+	require.Len(t, drifts, len(driftRoles))
+	require.Equal(t, DriftInSync, drifts[1].Status)
+	require.Equal(t, RoleDrift{Role: data.CanonicalTargetsRole, RemoteVersion: 1, Status: DriftBehind}, drifts[2])
+}
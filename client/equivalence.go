@@ -0,0 +1,249 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// DifferenceType is the kind of difference found between two repositories
+type DifferenceType string
+
+// The possible kinds of difference between two repositories
+const (
+	// DifferenceRoleVersion means a role's metadata has different versions
+	DifferenceRoleVersion DifferenceType = "role-version"
+	// DifferenceRoleKeys means a role is signed by different keys or with a
+	// different threshold, or a delegation covers different paths
+	DifferenceRoleKeys DifferenceType = "role-keys"
+	// DifferenceMissingDelegation means a delegated role exists in only one of
+	// the repositories
+	DifferenceMissingDelegation DifferenceType = "missing-delegation"
+	// DifferenceMissingTarget means a target is signed into a role in only one
+	// of the repositories
+	DifferenceMissingTarget DifferenceType = "missing-target"
+	// DifferenceTargetHash means a target has different hashes or length
+	DifferenceTargetHash DifferenceType = "target-hash"
+)
+
+// Difference describes a single difference in the trusted content of two
+// repositories
+type Difference struct {
+	Type DifferenceType
+	Role data.RoleName
+	// Target is the name of the target that differs, if any
+	Target string
+	// Detail describes the difference, for instance what each repository has
+	Detail string
+}
+
+func (d Difference) String() string {
+	if d.Target != "" {
+		return fmt.Sprintf("%s: %s target %s: %s", d.Type, d.Role, d.Target, d.Detail)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Type, d.Role, d.Detail)
+}
+
+// ReposEquivalent updates and verifies both repositories, and then compares
+// their trusted content: the versions and keys of the base roles, the full
+// delegation tree, and every target in every role.  It returns whether the
+// repositories are equivalent, and if not, every difference found, sorted by
+// role and target.  An error is returned if either repository could not be
+// updated.
+func ReposEquivalent(a, b Repository) (bool, []Difference, error) {
+	aRepo, err := trustedState(a)
+	if err != nil {
+		return false, nil, err
+	}
+	bRepo, err := trustedState(b)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var diffs []Difference
+	versions := map[data.RoleName][2]int{
+		data.CanonicalRootRole:      {aRepo.Root.Signed.Version, bRepo.Root.Signed.Version},
+		data.CanonicalSnapshotRole:  {aRepo.Snapshot.Signed.Version, bRepo.Snapshot.Signed.Version},
+		data.CanonicalTimestampRole: {aRepo.Timestamp.Signed.Version, bRepo.Timestamp.Signed.Version},
+	}
+	for roleName, v := range versions {
+		diffs = append(diffs, versionDifference(roleName, v[0], v[1])...)
+	}
+	for _, roleName := range data.BaseRoles {
+		aRole, aErr := aRepo.GetBaseRole(roleName)
+		bRole, bErr := bRepo.GetBaseRole(roleName)
+		if aErr != nil || bErr != nil {
+			return false, nil, fmt.Errorf("unable to compare %s keys: %v, %v", roleName, aErr, bErr)
+		}
+		if !sameKeys(aRole.ListKeyIDs(), bRole.ListKeyIDs()) || aRole.Threshold != bRole.Threshold {
+			diffs = append(diffs, Difference{
+				Type:   DifferenceRoleKeys,
+				Role:   roleName,
+				Detail: fmt.Sprintf("%s vs %s", describeRole(aRole, nil), describeRole(bRole, nil)),
+			})
+		}
+	}
+
+	for _, roleName := range targetsRoleNames(aRepo, bRepo) {
+		aTargets, inA := aRepo.Targets[roleName]
+		bTargets, inB := bRepo.Targets[roleName]
+		if !inA || !inB {
+			diffs = append(diffs, Difference{
+				Type:   DifferenceMissingDelegation,
+				Role:   roleName,
+				Detail: fmt.Sprintf("present in %s only", presentIn(inA)),
+			})
+			continue
+		}
+		if roleName != data.CanonicalTargetsRole {
+			diffs = append(diffs, delegationDifference(aRepo, bRepo, roleName)...)
+		}
+		diffs = append(diffs, versionDifference(roleName, aTargets.Signed.Version, bTargets.Signed.Version)...)
+		diffs = append(diffs, targetDifferences(roleName, aTargets.Signed.Targets, bTargets.Signed.Targets)...)
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Role != diffs[j].Role {
+			return diffs[i].Role < diffs[j].Role
+		}
+		if diffs[i].Target != diffs[j].Target {
+			return diffs[i].Target < diffs[j].Target
+		}
+		return diffs[i].Type < diffs[j].Type
+	})
+	return len(diffs) == 0, diffs, nil
+}
+
+// trustedState updates the repository and returns its verified TUF repo
+func trustedState(r Repository) (*tuf.Repo, error) {
+	var repo *repository
+	switch r := r.(type) {
+	case *repository:
+		repo = r
+	case *verifyOnlyRepository:
+		repo = r.repository
+	default:
+		return nil, fmt.Errorf("unable to read trusted state from repository of type %T", r)
+	}
+	if err := repo.updateTUF(false); err != nil {
+		return nil, err
+	}
+	return repo.tufRepo, nil
+}
+
+// targetsRoleNames returns the sorted names of the targets roles loaded in
+// either repository
+func targetsRoleNames(a, b *tuf.Repo) []data.RoleName {
+	seen := make(map[data.RoleName]struct{})
+	for roleName := range a.Targets {
+		seen[roleName] = struct{}{}
+	}
+	for roleName := range b.Targets {
+		seen[roleName] = struct{}{}
+	}
+	names := make([]data.RoleName, 0, len(seen))
+	for roleName := range seen {
+		names = append(names, roleName)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// delegationDifference compares how a delegated role is delegated to in each
+// repository
+func delegationDifference(a, b *tuf.Repo, roleName data.RoleName) []Difference {
+	aRole, aErr := a.GetDelegationRole(roleName)
+	bRole, bErr := b.GetDelegationRole(roleName)
+	if aErr != nil || bErr != nil {
+		// a role whose metadata is loaded must have been delegated to, but
+		// report rather than fail if it is somehow unreachable
+		return []Difference{{
+			Type:   DifferenceMissingDelegation,
+			Role:   roleName,
+			Detail: fmt.Sprintf("unable to resolve delegation: %v, %v", aErr, bErr),
+		}}
+	}
+	if sameKeys(aRole.ListKeyIDs(), bRole.ListKeyIDs()) && aRole.Threshold == bRole.Threshold &&
+		sameKeys(aRole.Paths, bRole.Paths) {
+		return nil
+	}
+	return []Difference{{
+		Type:   DifferenceRoleKeys,
+		Role:   roleName,
+		Detail: fmt.Sprintf("%s vs %s", describeRole(aRole.BaseRole, aRole.Paths), describeRole(bRole.BaseRole, bRole.Paths)),
+	}}
+}
+
+// targetDifferences compares the targets signed into a role in each repository
+func targetDifferences(roleName data.RoleName, a, b data.Files) []Difference {
+	var diffs []Difference
+	for name, aMeta := range a {
+		bMeta, ok := b[name]
+		if !ok {
+			diffs = append(diffs, Difference{
+				Type: DifferenceMissingTarget, Role: roleName, Target: name, Detail: "present in a only"})
+			continue
+		}
+		if !aMeta.Equals(bMeta) {
+			diffs = append(diffs, Difference{
+				Type:   DifferenceTargetHash,
+				Role:   roleName,
+				Target: name,
+				Detail: fmt.Sprintf("length %d vs %d, or differing hashes", aMeta.Length, bMeta.Length),
+			})
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			diffs = append(diffs, Difference{
+				Type: DifferenceMissingTarget, Role: roleName, Target: name, Detail: "present in b only"})
+		}
+	}
+	return diffs
+}
+
+func versionDifference(roleName data.RoleName, a, b int) []Difference {
+	if a == b {
+		return nil
+	}
+	return []Difference{{
+		Type:   DifferenceRoleVersion,
+		Role:   roleName,
+		Detail: fmt.Sprintf("version %d vs %d", a, b),
+	}}
+}
+
+func sameKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func describeRole(role data.BaseRole, paths []string) string {
+	keyIDs := role.ListKeyIDs()
+	sort.Strings(keyIDs)
+	description := fmt.Sprintf("%d of [%s]", role.Threshold, strings.Join(keyIDs, ", "))
+	if paths != nil {
+		description += fmt.Sprintf(" for paths %q", paths)
+	}
+	return description
+}
+
+func presentIn(inA bool) string {
+	if inA {
+		return "a"
+	}
+	return "b"
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// newEquivalenceTestRepo serves the metadata and returns a blank repository
+// using it, along with a cleanup function
+func newEquivalenceTestRepo(t *testing.T, swizzler *testutils.MetadataSwizzler) (*repository, func()) {
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, swizzler.Gun)
+	r, baseDir := newBlankRepo(t, ts.URL)
+	return r, func() {
+		ts.Close()
+		os.RemoveAll(baseDir)
+	}
+}
+
+func TestReposEquivalent(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/a")
+	require.NoError(t, err)
+	_, err = tufRepo.InitTargets("targets/a")
+	require.NoError(t, err)
+	_, err = tufRepo.AddTargets("targets/a", data.Files{"latest": data.FileMeta{
+		Length: 1, Hashes: data.Hashes{notary.SHA256: make([]byte, sha256.Size)}}})
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+
+	a, cleanupA := newEquivalenceTestRepo(t, testutils.NewMetadataSwizzler(gun, meta, cs))
+	defer cleanupA()
+	b, cleanupB := newEquivalenceTestRepo(t, testutils.NewMetadataSwizzler(gun, testutils.CopyRepoMetadata(meta), cs))
+	defer cleanupB()
+
+	equivalent, diffs, err := ReposEquivalent(a, b)
+	require.NoError(t, err)
+	require.True(t, equivalent)
+	require.Empty(t, diffs)
+}
+
+// A repository with one extra target, but otherwise the same versions and keys,
+// differs by exactly that target
+func TestReposEquivalentExtraTarget(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, cs, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+
+	a, cleanupA := newEquivalenceTestRepo(t, testutils.NewMetadataSwizzler(gun, meta, cs))
+	defer cleanupA()
+
+	swizzler := testutils.NewMetadataSwizzler(gun, testutils.CopyRepoMetadata(meta), cs)
+	require.NoError(t, swizzler.MutateTargets(func(tg *data.Targets) {
+		tg.Targets["extra"] = data.FileMeta{Length: 1, Hashes: data.Hashes{notary.SHA256: make([]byte, sha256.Size)}}
+	}))
+	require.NoError(t, swizzler.UpdateSnapshotHashes())
+	require.NoError(t, swizzler.UpdateTimestampHash())
+	b, cleanupB := newEquivalenceTestRepo(t, swizzler)
+	defer cleanupB()
+
+	equivalent, diffs, err := ReposEquivalent(a, b)
+	require.NoError(t, err)
+	require.False(t, equivalent)
+	require.Equal(t, []Difference{{
+		Type:   DifferenceMissingTarget,
+		Role:   data.CanonicalTargetsRole,
+		Target: "extra",
+		Detail: "present in b only",
+	}}, diffs)
+}
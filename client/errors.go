@@ -46,3 +46,24 @@ type ErrRepositoryNotExist struct {
 func (err ErrRepositoryNotExist) Error() string {
 	return fmt.Sprintf("%s does not have trust data for %s", err.remote, err.gun.String())
 }
+
+// ErrRepositoryRevoked is returned when loading a repository whose root key
+// holder has revoked it, after which none of its targets should be trusted
+type ErrRepositoryRevoked struct {
+	GUN    data.GUN
+	Reason string
+}
+
+func (err ErrRepositoryRevoked) Error() string {
+	return fmt.Sprintf("trust data for %s has been revoked: %s", err.GUN.String(), err.Reason)
+}
+
+// ErrNoSigningCapability is returned when a signing operation is attempted on
+// a verify-only repository, which has no access to private keys
+type ErrNoSigningCapability struct {
+	Operation string
+}
+
+func (err ErrNoSigningCapability) Error() string {
+	return fmt.Sprintf("cannot %s: repository is verify-only and has no signing capability", err.Operation)
+}
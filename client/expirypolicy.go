@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ExpiryPolicy maps role name prefixes to the longest expiry allowed for the
+// roles under them when they are signed on publish, for instance limiting
+// every delegation under "targets/prod" to 30 days.  A prefix covers the role
+// of that name and every role delegated below it, and each role is governed
+// by the longest prefix covering it.
+type ExpiryPolicy map[data.RoleName]time.Duration
+
+// ErrExpiryPolicy is returned by publish when a role would be signed with an
+// expiry further away than its expiry policy allows
+type ErrExpiryPolicy struct {
+	Role    data.RoleName
+	Prefix  data.RoleName
+	Expires time.Time
+	Max     time.Duration
+}
+
+func (err ErrExpiryPolicy) Error() string {
+	return fmt.Sprintf("%s would expire at %s, but the expiry policy for %s allows at most %s",
+		err.Role, err.Expires.Format(time.RFC3339), err.Prefix, err.Max)
+}
+
+// governing returns the longest prefix in the policy covering the role, and
+// its maximum expiry
+func (p ExpiryPolicy) governing(role data.RoleName) (data.RoleName, time.Duration, bool) {
+	var (
+		prefix data.RoleName
+		max    time.Duration
+		found  bool
+	)
+	for candidate, d := range p {
+		if role != candidate && !strings.HasPrefix(role.String(), candidate.String()+"/") {
+			continue
+		}
+		if !found || len(candidate) > len(prefix) {
+			prefix, max, found = candidate, d, true
+		}
+	}
+	return prefix, max, found
+}
+
+// check returns an error for the first of the updated roles, in sorted order,
+// whose expiry violates the policy
+func (p ExpiryPolicy) check(repo *tuf.Repo, updated map[data.RoleName][]byte, now time.Time) error {
+	if len(p) == 0 {
+		return nil
+	}
+	roles := make([]data.RoleName, 0, len(updated))
+	for role := range updated {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+
+	for _, role := range roles {
+		prefix, max, ok := p.governing(role)
+		if !ok {
+			continue
+		}
+		var expires time.Time
+		switch {
+		case role == data.CanonicalRootRole:
+			expires = repo.Root.Signed.Expires
+		case role == data.CanonicalSnapshotRole:
+			expires = repo.Snapshot.Signed.Expires
+		case repo.Targets[role] != nil:
+			expires = repo.Targets[role].Signed.Expires
+		default:
+			continue
+		}
+		if expires.After(now.Add(max)) {
+			return ErrExpiryPolicy{Role: role, Prefix: prefix, Expires: expires, Max: max}
+		}
+	}
+	return nil
+}
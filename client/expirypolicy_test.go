@@ -0,0 +1,73 @@
+package client
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+func TestExpiryPolicyGoverningPrefix(t *testing.T) {
+	policy := ExpiryPolicy{
+		"targets":      365 * 24 * time.Hour,
+		"targets/prod": 30 * 24 * time.Hour,
+	}
+	for role, expected := range map[data.RoleName]data.RoleName{
+		"targets":            "targets",
+		"targets/dev":        "targets",
+		"targets/prod":       "targets/prod",
+		"targets/prod/web":   "targets/prod",
+		"targets/production": "targets",
+	} {
+		prefix, max, ok := policy.governing(role)
+		require.True(t, ok, role.String())
+		require.Equal(t, expected, prefix, role.String())
+		require.Equal(t, policy[expected], max, role.String())
+	}
+	_, _, ok := policy.governing(data.CanonicalRootRole)
+	require.False(t, ok)
+}
+
+// A publish that would sign a prod delegation with an expiry longer than the
+// policy for targets/prod allows is refused, without uploading anything
+func TestPublishRefusedByExpiryPolicy(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	delgKey, err := repo.GetCryptoService().Create("targets/prod", repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, repo.AddDelegation("targets/prod", []data.PublicKey{delgKey}, []string{""}))
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt", "targets/prod")
+
+	// delegations are signed with the targets role's default expiry
+	require.True(t, notary.NotaryTargetsExpiry > 30*24*time.Hour)
+	repo.SetExpiryPolicy(ExpiryPolicy{"targets/prod": 30 * 24 * time.Hour})
+	err = repo.Publish()
+	require.Error(t, err)
+	policyErr, ok := err.(ErrExpiryPolicy)
+	require.True(t, ok, "expected ErrExpiryPolicy, got %v", err)
+	require.Equal(t, data.RoleName("targets/prod"), policyErr.Role)
+	require.Equal(t, data.RoleName("targets/prod"), policyErr.Prefix)
+	require.Len(t, getChanges(t, repo), 3)
+
+	// nothing was published
+	otherRepo, _, otherDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(otherDir)
+	delegations, err := otherRepo.GetDelegationRoles()
+	require.NoError(t, err)
+	require.Empty(t, delegations)
+
+	// the policy does not apply to the roles it does not cover
+	cl, err := repo.GetChangelist()
+	require.NoError(t, err)
+	require.NoError(t, cl.Clear(""))
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt", data.CanonicalTargetsRole)
+	require.NoError(t, repo.Publish())
+}
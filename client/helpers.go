@@ -68,6 +68,10 @@ func applyTargetsChange(repo *tuf.Repo, invalid *tuf.Repo, c changelist.Change)
 		return changeTargetsDelegation(repo, c)
 	case changelist.TypeWitness:
 		return witnessTargets(repo, invalid, c.Scope())
+	case changelist.TypeKeyPurposes:
+		return applyKeyPurposesChange(repo, c)
+	case changelist.TypeThreshold:
+		return applyThresholdChange(repo, c)
 	default:
 		return fmt.Errorf("only target meta and delegations changes supported")
 	}
@@ -168,6 +172,10 @@ func applyRootChange(repo *tuf.Repo, c changelist.Change) error {
 	switch c.Type() {
 	case changelist.TypeBaseRole:
 		err = applyRootRoleChange(repo, c)
+	case changelist.TypeKeyPurposes:
+		err = applyKeyPurposesChange(repo, c)
+	case changelist.TypeThreshold:
+		err = applyThresholdChange(repo, c)
 	default:
 		err = fmt.Errorf("type of root change not yet supported: %s", c.Type())
 	}
@@ -1,6 +1,12 @@
 package client
 
 import (
+	"context"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"time"
+
 	"github.com/theupdateframework/notary/client/changelist"
 	"github.com/theupdateframework/notary/tuf/data"
 	"github.com/theupdateframework/notary/tuf/signed"
@@ -35,6 +41,10 @@ type ReadOnly interface {
 	// signed into the repository in every role
 	GetAllTargetMetadataByName(name string) ([]TargetSignedStruct, error)
 
+	// EffectiveTargets returns every target as it resolves with TUF priority
+	// applied, so that each name appears once, annotated with the winning role
+	EffectiveTargets() ([]*TargetWithRole, error)
+
 	// ListRoles returns a list of RoleWithSignatures objects for this repo
 	// This represents the latest metadata for each role in this repo
 	ListRoles() ([]RoleWithSignatures, error)
@@ -42,6 +52,12 @@ type ReadOnly interface {
 	// GetDelegationRoles returns the keys and roles of the repository's delegations
 	// Also converts key IDs to canonical key IDs to keep consistent with signing prompts
 	GetDelegationRoles() ([]data.Role, error)
+
+	// TrustedKeys returns the public keys trusted to sign a role, and the
+	// number of their signatures the role requires, from the verified
+	// metadata: the root for base roles, and the delegating role for
+	// delegations
+	TrustedKeys(role data.RoleName) ([]data.PublicKey, int, error)
 }
 
 // Repository represents the set of options that must be supported over a TUF repo
@@ -57,6 +73,65 @@ type Repository interface {
 	// SetLegacyVersion sets the number of versions back to fetch roots to sign with
 	SetLegacyVersions(int)
 
+	// SetCanary designates a canary target whose embedded timestamp is checked
+	// against a staleness bound on every update, to detect freeze attacks
+	SetCanary(CanaryConfig)
+
+	// SetKeyDowngradePolicy enables checking, on every update, whether a new
+	// root version replaces a base role's keys with weaker ones
+	SetKeyDowngradePolicy(KeyDowngradePolicy)
+
+	// SetMinimumKeyStrength sets the security strength, in bits, below which
+	// KeyHealthReport reports keys as weak
+	SetMinimumKeyStrength(bits int)
+
+	// SetTrustedTimeSource makes every update check metadata expiry against a
+	// trusted time source rather than the system clock
+	SetTrustedTimeSource(TrustedTimeConfig)
+
+	// SetExpiryPolicy limits, by role name prefix, the expiry of the roles
+	// signed on publish
+	SetExpiryPolicy(ExpiryPolicy)
+
+	// SetUnknownDelegationPolicy sets whether updates fail, warn or skip when
+	// a referenced delegation role cannot be loaded
+	SetUnknownDelegationPolicy(UnknownDelegationPolicy)
+
+	// SetMinimumThreshold forbids roles with a threshold below a minimum
+	SetMinimumThreshold(MinimumThresholdPolicy)
+
+	// SetStrictRoleTypes sets whether metadata declaring a type other than
+	// that of the role it was fetched as is rejected up front
+	SetStrictRoleTypes(bool)
+
+	// SetConsistentSnapshotRequirement fails updates whose trusted root does
+	// not have the required consistent snapshot setting
+	SetConsistentSnapshotRequirement(ConsistentSnapshotRequirement)
+
+	// SetSnapshotCoverage sets whether updates fail if the server has the
+	// file of a delegation which the snapshot does not list
+	SetSnapshotCoverage(bool)
+
+	// SetMaxTimestampAge sets how long ago the trusted timestamp may have
+	// been issued before updates fail
+	SetMaxTimestampAge(time.Duration)
+
+	// SetRootRollbackPolicy sets whether updates reject a remote root older
+	// than the trusted root as a rollback attack, or trust it to recover
+	SetRootRollbackPolicy(RootRollbackPolicy)
+
+	// SetDelegationPriority reorders sibling delegations when resolving
+	// targets, without affecting which delegations are trusted
+	SetDelegationPriority(DelegationPriority)
+
+	// SetDefaultTargetRole sets the delegation AddTarget adds targets to when
+	// no roles are given, checking this client can sign for it
+	SetDefaultTargetRole(role data.RoleName) error
+
+	// SetPublishActor sets the actor recorded in the publish log for the
+	// publishes which follow
+	SetPublishActor(actor string)
+
 	// ----- General management operations -----
 
 	// Initialize creates a new repository by using rootKey as the root Key for the
@@ -74,23 +149,68 @@ type Repository interface {
 	// Conceptually it performs an operation similar to a `git rebase`
 	Publish() error
 
+	// UploadPlan returns the roles the next publish would upload, with their
+	// sizes in bytes, without uploading anything
+	UploadPlan() (map[data.RoleName]int64, error)
+
+	// PublishHistory returns, oldest first, the records kept locally of every
+	// successful publish of the repository by this client
+	PublishHistory() ([]PublishRecord, error)
+
 	// ----- Target Operations -----
 
 	// AddTarget creates new changelist entries to add a target to the given roles
 	// in the repository when the changelist gets applied at publish time.
-	// If roles are unspecified, the default role is "targets"
+	// If roles are unspecified, the default role is "targets", unless another
+	// has been set with SetDefaultTargetRole.  The target's custom data may not
+	// use the reserved annotations key.
 	AddTarget(target *Target, roles ...data.RoleName) error
 
+	// AddPrecomputedTarget creates a changelist entry to add a target with
+	// already computed hashes and length to the given role, without reading the
+	// target's content
+	AddPrecomputedTarget(role data.RoleName, name string, meta data.FileMeta) error
+
+	// AddTargetsFromLockfile creates a changelist entry for a target for
+	// every entry of a lockfile in the given format, with the entry's
+	// integrity hashes
+	AddTargetsFromLockfile(role data.RoleName, format string, lockfile io.Reader) error
+
+	// SetKeyPurposes creates a changelist entry to replace the purpose tags
+	// of a key, stored in the role's custom metadata
+	SetKeyPurposes(role data.RoleName, keyID string, purposes ...string) error
+
+	// SetThreshold creates a changelist entry to set the number of signatures
+	// required by a role, refusing to lower it unless allowDowngrade is true
+	SetThreshold(role data.RoleName, threshold int, allowDowngrade bool) error
+
+	// GetRoleInfo returns a role as defined by the trusted metadata, with the
+	// purpose tags of its keys
+	GetRoleInfo(role data.RoleName) (*RoleInfo, error)
+
 	// RemoveTarget creates new changelist entries to remove a target from the given
 	// roles in the repository when the changelist gets applied at publish time.
 	// If roles are unspecified, the default role is "target".
 	RemoveTarget(targetName string, roles ...data.RoleName) error
 
+	// SetTargetAnnotations creates a changelist entry to replace the signed
+	// annotations of a target, staged or already published in the given role,
+	// which are stored in a reserved namespace of the target's custom data.
+	SetTargetAnnotations(role data.RoleName, name string, annotations map[string]string) error
+
 	// ----- Changelist operations -----
 
 	// GetChangelist returns the list of the repository's unpublished changes
 	GetChangelist() (changelist.Changelist, error)
 
+	// ExportChangelist serializes the unpublished changes in a portable,
+	// versioned format
+	ExportChangelist() ([]byte, error)
+
+	// ImportChangelist validates and stages changes serialized by
+	// ExportChangelist, merging them with or replacing the staged changes
+	ImportChangelist(exported []byte, mode ChangelistImportMode) error
+
 	// ----- Role operations -----
 
 	// AddDelegation creates changelist entries to add provided delegation public keys and paths.
@@ -129,12 +249,98 @@ type Repository interface {
 	// ClearDelegationPaths creates a changelist entry to remove all paths from an existing delegation.
 	ClearDelegationPaths(name data.RoleName) error
 
+	// VerifyDelegationTree walks every delegation from the targets role down,
+	// reporting the status of each role in the tree rather than stopping at the
+	// first problem
+	VerifyDelegationTree() (TreeReport, error)
+
+	// VerifyAndAttest verifies the named target and returns an attestation of
+	// the verification, signed by attestKey
+	VerifyAndAttest(name string, attestKey data.PrivateKey) ([]byte, error)
+
+	// SignedTargetManifest returns a manifest of every effective target,
+	// signed by the held private key with the given ID
+	SignedTargetManifest(keyID string) ([]byte, error)
+
+	// VerifyWithInToto verifies the named target and confirms that the in-toto
+	// link records a material or product with the target's signed digest
+	VerifyWithInToto(targetName string, link io.Reader) error
+
+	// VerifyTargetFromURL verifies the named target and checks the artifact
+	// downloaded from the URL against its signed length and digests
+	VerifyTargetFromURL(targetName, url string, httpClient *http.Client) error
+	// VerifyTargetFromURLWithContext is VerifyTargetFromURL, abandoning the
+	// download when the context is cancelled
+	VerifyTargetFromURLWithContext(ctx context.Context, targetName, url string, httpClient *http.Client) error
+
+	// MinimalBundleFor returns the signed metadata of only the roles needed to
+	// verify the named target, by role name
+	MinimalBundleFor(name string) (map[data.RoleName][]byte, error)
+
+	// StateAsOf reconstructs the metadata a client would have trusted at a
+	// past time, from the versions retained by the server
+	StateAsOf(t time.Time) (TrustedStateSnapshot, error)
+
+	// TimestampAge returns how long ago the trusted timestamp was issued
+	TimestampAge() (time.Duration, error)
+
+	// CrossVerifyTarget reports, for each of the other GUNs mirroring this
+	// repository, whether it resolves the target as this repository does
+	CrossVerifyTarget(name string, otherGUNs []data.GUN) ([]MirrorResult, error)
+
+	// VerifyVersion verifies the signatures of a version of a role's
+	// metadata retained by the server, against the keys trusted for the role
+	// when it was signed
+	VerifyVersion(role data.RoleName, version int) error
+
+	// ConsistentSnapshotEnabled returns whether the trusted root enables
+	// consistent snapshots
+	ConsistentSnapshotEnabled() (bool, error)
+
+	// RootCertificates returns the certificates wrapped in the trusted root's
+	// x509 root keys
+	RootCertificates() ([]*x509.Certificate, error)
+
+	// RootCertificateReport checks, as an advisory report, the internal
+	// consistency of the certificate chain of every x509 root key
+	RootCertificateReport() ([]RootCertificateStatus, error)
+
+	// RootCertificateChain returns the verified certificate chain, leaf
+	// first, of a key which signed the trusted root
+	RootCertificateChain() ([]*x509.Certificate, error)
+
+	// CacheDrift reports whether the cached timestamp, snapshot and targets
+	// are behind, ahead of, or in sync with the remote ones, without updating
+	// the cache
+	CacheDrift() ([]RoleDrift, error)
+
+	// VerifyDelegationConsistency reports every delegation whose keys or
+	// signatures do not match those its parent authorizes for it
+	VerifyDelegationConsistency() ([]DelegationInconsistency, error)
+
+	// ValidateKeyReferences reports every key ID which signs, or is listed
+	// as a signing key of, a role but is not defined where it should be
+	ValidateKeyReferences() ([]KeyReferenceProblem, error)
+
+	// KeyHealthReport reports weak keys, key IDs trusted to sign more than one
+	// role, and public keys registered under more than one key ID
+	KeyHealthReport() (KeyHealthReport, error)
+
 	// ----- Witness and other re-signing operations -----
 
 	// Witness creates change objects to witness (i.e. re-sign) the given
 	// roles on the next publish. One change is created per role
 	Witness(roles ...data.RoleName) ([]data.RoleName, error)
 
+	// OutstandingSignatures reports, for every role whose locally stored
+	// metadata does not yet meet its signing threshold, how many valid
+	// signatures are attached and which authorized keys have not yet signed
+	OutstandingSignatures() (map[data.RoleName]SignatureRequirement, error)
+
+	// EligibleSigningKeys returns the IDs of the locally available keys that
+	// are authorized to sign the given role
+	EligibleSigningKeys(role data.RoleName) ([]string, error)
+
 	// ----- Key Operations -----
 
 	// RotateKey removes all existing keys associated with the role. If no keys are
@@ -144,6 +350,15 @@ type Repository interface {
 	// These changes are staged in a changelist until publish is called.
 	RotateKey(role data.RoleName, serverManagesKey bool, keyList []string) error
 
+	// RotateAllLocalKeys rotates, in a single publish, the key of every base
+	// role the client can sign and of every server managed role, returning the
+	// ID of each role's new key
+	RotateAllLocalKeys() (map[data.RoleName]string, error)
+
+	// RevokeRepository publishes a terminal, root-signed revocation of the
+	// repository, after which loading it fails with ErrRepositoryRevoked
+	RevokeRepository(reason string) error
+
 	// GetCryptoService is the getter for the repository's CryptoService, which is used
 	// to sign all updates.
 	GetCryptoService() signed.CryptoService
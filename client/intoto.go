@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// InTotoLinkType is the type of the signed portion of an in-toto link
+const InTotoLinkType = "link"
+
+// inTotoLink is the portion of an in-toto link metadata file needed to match
+// its artifacts against a target.  Artifacts map paths to digests, which map
+// hash algorithm names to hex encoded digests.
+type inTotoLink struct {
+	Signed struct {
+		Type      string                       `json:"_type"`
+		Name      string                       `json:"name"`
+		Materials map[string]map[string]string `json:"materials"`
+		Products  map[string]map[string]string `json:"products"`
+	} `json:"signed"`
+}
+
+// ErrInvalidInTotoLink is returned when an in-toto link cannot be parsed
+type ErrInvalidInTotoLink struct {
+	Reason string
+}
+
+func (err ErrInvalidInTotoLink) Error() string {
+	return fmt.Sprintf("invalid in-toto link: %s", err.Reason)
+}
+
+// ErrInTotoMismatch is returned when none of the materials or products of an
+// in-toto link have the signed digest of a target
+type ErrInTotoMismatch struct {
+	Target string
+	Role   data.RoleName
+	Link   string
+}
+
+func (err ErrInTotoMismatch) Error() string {
+	return fmt.Sprintf("in-toto link %q records no material or product matching the digest of target %s signed in %s",
+		err.Link, err.Target, err.Role)
+}
+
+// VerifyWithInToto resolves the named target, verifying it as GetTargetByName
+// does, and then confirms that a material or product recorded by the in-toto
+// link has the target's signed digest.  An artifact matches if it shares at
+// least one hash algorithm with the target, and every shared digest is equal.
+// Only the link's recorded artifacts are checked: verifying the link's
+// signatures against an in-toto layout is left to an in-toto implementation.
+func (r *repository) VerifyWithInToto(targetName string, link io.Reader) error {
+	target, err := r.GetTargetByName(targetName)
+	if err != nil {
+		return err
+	}
+
+	var parsed inTotoLink
+	if err := json.NewDecoder(link).Decode(&parsed); err != nil {
+		return ErrInvalidInTotoLink{Reason: err.Error()}
+	}
+	if parsed.Signed.Type != InTotoLinkType {
+		return ErrInvalidInTotoLink{Reason: fmt.Sprintf("expected type %q, got %q", InTotoLinkType, parsed.Signed.Type)}
+	}
+
+	for _, artifacts := range []map[string]map[string]string{parsed.Signed.Materials, parsed.Signed.Products} {
+		paths := make([]string, 0, len(artifacts))
+		for path := range artifacts {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			matches, err := digestsMatch(target.Hashes, artifacts[path])
+			if err != nil {
+				return ErrInvalidInTotoLink{Reason: fmt.Sprintf("artifact %s: %s", path, err)}
+			}
+			if matches {
+				return nil
+			}
+		}
+	}
+	return ErrInTotoMismatch{Target: target.Name, Role: target.Role, Link: parsed.Signed.Name}
+}
+
+// digestsMatch returns whether the in-toto digests share at least one hash
+// algorithm with the target's hashes, with every shared digest equal
+func digestsMatch(hashes data.Hashes, digests map[string]string) (bool, error) {
+	decoded := make(data.Hashes, len(digests))
+	for alg, hexDigest := range digests {
+		digest, err := hex.DecodeString(hexDigest)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s digest: %s", alg, err)
+		}
+		decoded[alg] = digest
+	}
+	return data.CompareMultiHashes(hashes, decoded) == nil, nil
+}
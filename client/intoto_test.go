@@ -0,0 +1,71 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+func inTotoLinkJSON(t *testing.T, linkType string, materials, products map[string]map[string]string) *bytes.Reader {
+	link := map[string]interface{}{
+		"signed": map[string]interface{}{
+			"_type":      linkType,
+			"name":       "build",
+			"command":    []string{"make"},
+			"materials":  materials,
+			"products":   products,
+			"byproducts": map[string]interface{}{},
+		},
+		"signatures": []interface{}{},
+	}
+	raw, err := json.Marshal(link)
+	require.NoError(t, err)
+	return bytes.NewReader(raw)
+}
+
+func TestVerifyWithInToto(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	content, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	digest := sha256.Sum256(content)
+	targetDigest := map[string]string{"sha256": hex.EncodeToString(digest[:])}
+	otherDigest := sha256.Sum256([]byte("something else"))
+	unrelated := map[string]map[string]string{"src/main.go": {"sha256": hex.EncodeToString(otherDigest[:])}}
+
+	// the target may be recorded as a product or as a material
+	require.NoError(t, repo.VerifyWithInToto("latest",
+		inTotoLinkJSON(t, InTotoLinkType, unrelated, map[string]map[string]string{"out/ca.crt": targetDigest})))
+	require.NoError(t, repo.VerifyWithInToto("latest",
+		inTotoLinkJSON(t, InTotoLinkType, map[string]map[string]string{"ca.crt": targetDigest}, nil)))
+
+	err = repo.VerifyWithInToto("latest", inTotoLinkJSON(t, InTotoLinkType, unrelated, unrelated))
+	require.Equal(t, ErrInTotoMismatch{Target: "latest", Role: data.CanonicalTargetsRole, Link: "build"}, err)
+
+	// an artifact with no hash algorithm in common with the target does not match
+	err = repo.VerifyWithInToto("latest", inTotoLinkJSON(t, InTotoLinkType, nil,
+		map[string]map[string]string{"out/ca.crt": {"md5": "d41d8cd98f00b204e9800998ecf8427e"}}))
+	require.IsType(t, ErrInTotoMismatch{}, err)
+
+	err = repo.VerifyWithInToto("latest", inTotoLinkJSON(t, "layout", nil, nil))
+	require.IsType(t, ErrInvalidInTotoLink{}, err)
+	err = repo.VerifyWithInToto("latest", bytes.NewReader([]byte("not json")))
+	require.IsType(t, ErrInvalidInTotoLink{}, err)
+
+	err = repo.VerifyWithInToto("nonexistent",
+		inTotoLinkJSON(t, InTotoLinkType, nil, map[string]map[string]string{"out/ca.crt": targetDigest}))
+	require.IsType(t, ErrNoSuchTarget(""), err)
+}
@@ -0,0 +1,212 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// KeyDowngradePolicy determines what happens when a new root version replaces
+// a base role's keys with keys of a weaker algorithm or size, for instance
+// RSA-4096 keys with RSA-2048 keys, or ECDSA keys with RSA-2048 keys
+type KeyDowngradePolicy struct {
+	// Reject fails the update instead of logging a warning, and keeps the
+	// previously trusted root in the cache
+	Reject bool
+	// Acknowledged lists the roles whose downgrade the operator has accepted
+	Acknowledged []data.RoleName
+}
+
+// KeyClass describes the algorithm and size of a key, along with its
+// approximate security strength in bits
+type KeyClass struct {
+	Algorithm string
+	Bits      int
+	Strength  int
+}
+
+func (k KeyClass) String() string {
+	return fmt.Sprintf("%s-%d", k.Algorithm, k.Bits)
+}
+
+// ErrKeyDowngrade is returned when a new root version replaces a role's keys
+// with weaker keys, and the policy is to reject such downgrades
+type ErrKeyDowngrade struct {
+	Role        data.RoleName
+	RootVersion int
+	Old         KeyClass
+	New         KeyClass
+}
+
+func (err ErrKeyDowngrade) Error() string {
+	return fmt.Sprintf("root version %d downgrades the %s keys from %s to %s",
+		err.RootVersion, err.Role, err.Old, err.New)
+}
+
+// GetKeyClass returns the algorithm class of a public key.  The security
+// strengths are those given by NIST SP 800-57 for RSA and elliptic curve keys.
+func GetKeyClass(key data.PublicKey) (KeyClass, error) {
+	var (
+		public interface{}
+		err    error
+	)
+	switch key.Algorithm() {
+	case data.ED25519Key:
+		return KeyClass{Algorithm: data.ED25519Key, Bits: 256, Strength: 128}, nil
+	case data.ECDSAKey, data.RSAKey:
+		public, err = x509.ParsePKIXPublicKey(key.Public())
+	case data.ECDSAx509Key, data.RSAx509Key:
+		var cert *x509.Certificate
+		if cert, err = utils.LoadCertFromPEM(key.Public()); err == nil {
+			public = cert.PublicKey
+		}
+	default:
+		return KeyClass{}, fmt.Errorf("unknown key algorithm: %s", key.Algorithm())
+	}
+	if err != nil {
+		return KeyClass{}, err
+	}
+
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		bits := public.N.BitLen()
+		class := KeyClass{Algorithm: data.RSAKey, Bits: bits}
+		switch {
+		case bits >= 15360:
+			class.Strength = 256
+		case bits >= 7680:
+			class.Strength = 192
+		case bits >= 4096:
+			// not rated by NIST, but commonly estimated at 140 bits
+			class.Strength = 140
+		case bits >= 3072:
+			class.Strength = 128
+		case bits >= 2048:
+			class.Strength = 112
+		default:
+			class.Strength = 80
+		}
+		return class, nil
+	case *ecdsa.PublicKey:
+		bits := public.Curve.Params().BitSize
+		return KeyClass{Algorithm: data.ECDSAKey, Bits: bits, Strength: bits / 2}, nil
+	}
+	return KeyClass{}, fmt.Errorf("unsupported public key type for %s key", key.Algorithm())
+}
+
+// weakestKeyClass returns the weakest class of the role's keys, since that
+// bounds the strength of the role's signatures
+func weakestKeyClass(role data.BaseRole) (KeyClass, error) {
+	var weakest *KeyClass
+	for _, key := range role.Keys {
+		class, err := GetKeyClass(key)
+		if err != nil {
+			return KeyClass{}, err
+		}
+		if weakest == nil || class.Strength < weakest.Strength {
+			weakest = &class
+		}
+	}
+	if weakest == nil {
+		return KeyClass{}, fmt.Errorf("%s has no keys", role.Name)
+	}
+	return *weakest, nil
+}
+
+// keyDowngrades compares the key classes of the base roles in the old and new
+// roots, and returns a downgrade for every role whose keys were weakened and
+// which has not been acknowledged
+func keyDowngrades(oldRoot, newRoot *data.SignedRoot, policy KeyDowngradePolicy) ([]ErrKeyDowngrade, error) {
+	if newRoot.Signed.Version <= oldRoot.Signed.Version {
+		return nil, nil
+	}
+	acknowledged := make(map[data.RoleName]bool)
+	for _, role := range policy.Acknowledged {
+		acknowledged[role] = true
+	}
+
+	var downgrades []ErrKeyDowngrade
+	for _, roleName := range data.BaseRoles {
+		if acknowledged[roleName] {
+			continue
+		}
+		oldRole, err := oldRoot.BuildBaseRole(roleName)
+		if err != nil {
+			continue
+		}
+		oldClass, err := weakestKeyClass(oldRole)
+		if err != nil {
+			// keys we can't classify can't be downgraded from
+			logrus.Debugf("unable to classify the previous %s keys: %s", roleName, err)
+			continue
+		}
+		newRole, err := newRoot.BuildBaseRole(roleName)
+		if err != nil {
+			return nil, err
+		}
+		newClass, err := weakestKeyClass(newRole)
+		if err != nil {
+			return nil, err
+		}
+		if newClass.Strength < oldClass.Strength {
+			downgrades = append(downgrades, ErrKeyDowngrade{
+				Role:        roleName,
+				RootVersion: newRoot.Signed.Version,
+				Old:         oldClass,
+				New:         newClass,
+			})
+		}
+	}
+	return downgrades, nil
+}
+
+// cachedRoot returns the raw and parsed root in the cache, if there is one.
+// The root is not verified, since bootstrapping the client does that.
+func cachedRoot(cache store.MetadataStore) ([]byte, *data.SignedRoot) {
+	rootJSON, err := cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	if err != nil {
+		return nil, nil
+	}
+	s := &data.Signed{}
+	if err := data.UnmarshalMetadata(rootJSON, s); err != nil {
+		return nil, nil
+	}
+	root, err := data.RootFromSigned(s)
+	if err != nil {
+		return nil, nil
+	}
+	return rootJSON, root
+}
+
+// enforceKeyDowngradePolicy warns about or rejects a downgrade of a base role's
+// keys between the previously cached root and the newly loaded one.  When
+// rejecting, the old root is written back to the cache, so that the downgrade
+// is detected again on the next update.
+func enforceKeyDowngradePolicy(cache store.MetadataStore, oldRootJSON []byte, oldRoot, newRoot *data.SignedRoot,
+	policy KeyDowngradePolicy) error {
+
+	downgrades, err := keyDowngrades(oldRoot, newRoot, policy)
+	if err != nil {
+		logrus.Warnf("unable to check the new root for key downgrades: %s", err)
+		return nil
+	}
+	if len(downgrades) == 0 {
+		return nil
+	}
+	if !policy.Reject {
+		for _, downgrade := range downgrades {
+			logrus.Warnf("possible key downgrade attack: %s", downgrade)
+		}
+		return nil
+	}
+	if err := cache.Set(data.CanonicalRootRole.String(), oldRootJSON); err != nil {
+		logrus.Errorf("could not restore previously trusted root to cache: %s", err)
+	}
+	return downgrades[0]
+}
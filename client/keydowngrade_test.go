@@ -0,0 +1,138 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/cryptoservice"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+func rsaTestKey(t *testing.T, bits int) data.PrivateKey {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	privKey, err := utils.RSAToPrivateKey(rsaKey)
+	require.NoError(t, err)
+	return privKey
+}
+
+func TestGetKeyClass(t *testing.T) {
+	ecdsaKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+	ed25519Key, err := utils.GenerateED25519Key(rand.Reader)
+	require.NoError(t, err)
+	startTime := time.Now()
+	x509Cert, err := cryptoservice.GenerateCertificate(ecdsaKey, "docker.com/notary", startTime, startTime.AddDate(10, 0, 0))
+	require.NoError(t, err)
+	cert := utils.CertToKey(x509Cert)
+
+	for _, testCase := range []struct {
+		key      data.PublicKey
+		expected KeyClass
+	}{
+		{key: data.PublicKeyFromPrivate(ecdsaKey), expected: KeyClass{Algorithm: data.ECDSAKey, Bits: 256, Strength: 128}},
+		{key: cert, expected: KeyClass{Algorithm: data.ECDSAKey, Bits: 256, Strength: 128}},
+		{key: data.PublicKeyFromPrivate(ed25519Key), expected: KeyClass{Algorithm: data.ED25519Key, Bits: 256, Strength: 128}},
+		{key: data.PublicKeyFromPrivate(rsaTestKey(t, 2048)), expected: KeyClass{Algorithm: data.RSAKey, Bits: 2048, Strength: 112}},
+		{key: data.PublicKeyFromPrivate(rsaTestKey(t, 4096)), expected: KeyClass{Algorithm: data.RSAKey, Bits: 4096, Strength: 140}},
+	} {
+		class, err := GetKeyClass(testCase.key)
+		require.NoError(t, err)
+		require.Equal(t, testCase.expected, class)
+	}
+}
+
+// creates metadata for a repo whose targets key is ECDSA, and metadata for the
+// next version of the repo, in which the targets key has been rotated to RSA-2048
+func downgradedRepoMetadata(t *testing.T) (map[data.RoleName][]byte, map[data.RoleName][]byte) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	oldMeta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+
+	rsaKey := rsaTestKey(t, 2048)
+	require.NoError(t, cs.AddKey(data.CanonicalTargetsRole, gun, rsaKey))
+	require.NoError(t, tufRepo.ReplaceBaseKeys(data.CanonicalTargetsRole, data.PublicKeyFromPrivate(rsaKey)))
+	newMeta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	return oldMeta, newMeta
+}
+
+func TestKeyDowngradeRootRotation(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	oldMeta, newMeta := downgradedRepoMetadata(t)
+	ts := readOnlyServer(t, store.NewMemoryStore(newMeta), http.StatusNotFound, gun)
+	defer ts.Close()
+	remote, err := getRemoteStore(ts.URL, gun, http.DefaultTransport)
+	require.NoError(t, err)
+
+	load := func(policy *KeyDowngradePolicy) (store.MetadataStore, *warningRecorder, error) {
+		cache := store.NewMemoryStore(testutils.CopyRepoMetadata(oldMeta))
+		recorder, restore := recordWarnings()
+		defer restore()
+		_, _, err := LoadTUFRepo(TUFLoadOptions{
+			GUN:          gun,
+			Cache:        cache,
+			RemoteStore:  remote,
+			KeyDowngrade: policy,
+		})
+		return cache, recorder, err
+	}
+
+	// rejected downgrades fail the update and keep the old root cached
+	cache, _, err := load(&KeyDowngradePolicy{Reject: true})
+	require.Error(t, err)
+	downgrade, ok := err.(ErrKeyDowngrade)
+	require.True(t, ok, "expected ErrKeyDowngrade, got %v", err)
+	require.Equal(t, data.CanonicalTargetsRole, downgrade.Role)
+	require.Equal(t, 2, downgrade.RootVersion)
+	require.Equal(t, KeyClass{Algorithm: data.ECDSAKey, Bits: 256, Strength: 128}, downgrade.Old)
+	require.Equal(t, KeyClass{Algorithm: data.RSAKey, Bits: 2048, Strength: 112}, downgrade.New)
+	cachedRootJSON, err := cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	require.Equal(t, oldMeta[data.CanonicalRootRole], cachedRootJSON)
+
+	// otherwise downgrades are warned about
+	_, recorder, err := load(&KeyDowngradePolicy{})
+	require.NoError(t, err)
+	require.Len(t, recorder.containing("key downgrade"), 1)
+
+	// unless they have been acknowledged
+	_, recorder, err = load(&KeyDowngradePolicy{Reject: true, Acknowledged: []data.RoleName{data.CanonicalTargetsRole}})
+	require.NoError(t, err)
+	require.Empty(t, recorder.containing("key downgrade"))
+
+	// and no check is made without a policy
+	_, recorder, err = load(nil)
+	require.NoError(t, err)
+	require.Empty(t, recorder.containing("key downgrade"))
+}
+
+// Rotating to keys of the same or a stronger class is not a downgrade
+func TestKeyDowngradeNotFlaggedForUpgrade(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	_, oldRoot := cachedRoot(store.NewMemoryStore(meta))
+	require.NotNil(t, oldRoot)
+
+	newKey, err := cs.Create(data.CanonicalTargetsRole, gun, data.ED25519Key)
+	require.NoError(t, err)
+	require.NoError(t, tufRepo.ReplaceBaseKeys(data.CanonicalTargetsRole, newKey))
+	_, err = tufRepo.SignRoot(data.DefaultExpires(data.CanonicalRootRole), nil)
+	require.NoError(t, err)
+
+	downgrades, err := keyDowngrades(oldRoot, tufRepo.Root, KeyDowngradePolicy{})
+	require.NoError(t, err)
+	require.Empty(t, downgrades)
+}
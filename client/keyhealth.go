@@ -0,0 +1,157 @@
+package client
+
+import (
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// DefaultMinimumKeyStrength is the security strength, in bits, below which
+// KeyHealthReport reports a key as weak unless another minimum is set.  It is
+// the strength of RSA-2048 keys, the weakest NIST SP 800-57 still accepts.
+const DefaultMinimumKeyStrength = 112
+
+// WeakKey is a key trusted to sign roles whose security strength is below the
+// minimum, or could not be determined
+type WeakKey struct {
+	KeyID string
+	Roles []data.RoleName
+	Class KeyClass
+	// Err is the reason the key's class could not be determined, if it
+	// could not
+	Err error
+}
+
+// ReusedKey is a key ID trusted to sign more than one role
+type ReusedKey struct {
+	KeyID string
+	Roles []data.RoleName
+}
+
+// DuplicatedKey is public key material registered under more than one key ID
+type DuplicatedKey struct {
+	KeyIDs []string
+	Roles  []data.RoleName
+}
+
+// KeyHealthReport is the result of auditing every key trusted by the root and
+// by the loaded delegations.  Each list is sorted by key ID.
+type KeyHealthReport struct {
+	Weak       []WeakKey
+	Reused     []ReusedKey
+	Duplicated []DuplicatedKey
+}
+
+// OK returns true if no key problems were found
+func (k KeyHealthReport) OK() bool {
+	return len(k.Weak) == 0 && len(k.Reused) == 0 && len(k.Duplicated) == 0
+}
+
+// trustedKey is a key as registered under one key ID, with the roles it is
+// trusted to sign
+type trustedKey struct {
+	key   data.PublicKey
+	roles map[data.RoleName]bool
+}
+
+// KeyHealthReport updates the repository and audits every key the root trusts
+// to sign the base roles, and every key the loaded targets metadata trusts to
+// sign delegations, reporting keys weaker than the minimum key strength, key
+// IDs trusted to sign more than one role, and identical public keys registered
+// under different key IDs.  Keys are identified by the IDs they are
+// registered under in the metadata.  An error is only returned if the
+// repository could not be updated.
+func (r *repository) KeyHealthReport() (KeyHealthReport, error) {
+	if err := r.updateTUF(false); err != nil {
+		return KeyHealthReport{}, err
+	}
+
+	keys := make(map[string]*trustedKey)
+	trust := func(role data.RoleName, keyID string, key data.PublicKey) {
+		if key == nil {
+			// dangling key references are reported by ValidateKeyReferences
+			return
+		}
+		if _, ok := keys[keyID]; !ok {
+			keys[keyID] = &trustedKey{key: key, roles: make(map[data.RoleName]bool)}
+		}
+		keys[keyID].roles[role] = true
+	}
+
+	root := r.tufRepo.Root.Signed
+	for roleName, role := range root.Roles {
+		for _, keyID := range role.KeyIDs {
+			trust(roleName, keyID, root.Keys[keyID])
+		}
+	}
+	for _, targets := range r.tufRepo.Targets {
+		for _, delegation := range targets.Signed.Delegations.Roles {
+			for _, keyID := range delegation.KeyIDs {
+				trust(delegation.Name, keyID, targets.Signed.Delegations.Keys[keyID])
+			}
+		}
+	}
+
+	minimum := r.minimumKeyStrength
+	if minimum == 0 {
+		minimum = DefaultMinimumKeyStrength
+	}
+	keyIDs := make([]string, 0, len(keys))
+	for keyID := range keys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+
+	var report KeyHealthReport
+	byMaterial := make(map[string][]string)
+	var materials []string
+	for _, keyID := range keyIDs {
+		trusted := keys[keyID]
+		roles := sortedRoles(trusted.roles)
+
+		class, err := GetKeyClass(trusted.key)
+		if err != nil || class.Strength < minimum {
+			report.Weak = append(report.Weak, WeakKey{KeyID: keyID, Roles: roles, Class: class, Err: err})
+		}
+		if len(roles) > 1 {
+			report.Reused = append(report.Reused, ReusedKey{KeyID: keyID, Roles: roles})
+		}
+		material := string(trusted.key.Public())
+		if _, ok := byMaterial[material]; !ok {
+			materials = append(materials, material)
+		}
+		byMaterial[material] = append(byMaterial[material], keyID)
+	}
+
+	for _, material := range materials {
+		duplicateIDs := byMaterial[material]
+		if len(duplicateIDs) < 2 {
+			continue
+		}
+		roles := make(map[data.RoleName]bool)
+		for _, keyID := range duplicateIDs {
+			for role := range keys[keyID].roles {
+				roles[role] = true
+			}
+		}
+		report.Duplicated = append(report.Duplicated, DuplicatedKey{KeyIDs: duplicateIDs, Roles: sortedRoles(roles)})
+	}
+	return report, nil
+}
+
+// sortedRoles returns the roles in the set in name order
+func sortedRoles(set map[data.RoleName]bool) []data.RoleName {
+	roles := make([]data.RoleName, 0, len(set))
+	for role := range set {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+	return roles
+}
+
+// SetMinimumKeyStrength sets the security strength, in bits, below which
+// KeyHealthReport reports keys as weak.  0 restores
+// DefaultMinimumKeyStrength.
+func (r *repository) SetMinimumKeyStrength(bits int) {
+	r.minimumKeyStrength = bits
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A key trusted by two delegations is reported as reused, and the same key
+// registered under a second key ID as duplicated material
+func TestKeyHealthReport(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/a", "targets/b")
+	require.NoError(t, err)
+	aRole, err := tufRepo.GetDelegationRole("targets/a")
+	require.NoError(t, err)
+	require.Len(t, aRole.Keys, 1)
+	aKey := aRole.Keys[aRole.ListKeyIDs()[0]]
+	require.NoError(t, tufRepo.UpdateDelegationKeys("targets/b", []data.PublicKey{aKey}, []string{}, 1))
+
+	// the key of targets/a is registered again under another ID for targets/b
+	duplicateID := strings.Repeat("d", notary.SHA256HexSize)
+	delegations := &tufRepo.Targets[data.CanonicalTargetsRole].Signed.Delegations
+	delegations.Keys[duplicateID] = data.NewPublicKey(aKey.Algorithm(), aKey.Public())
+	for _, role := range delegations.Roles {
+		if role.Name == "targets/b" {
+			role.KeyIDs = append(role.KeyIDs, duplicateID)
+		}
+	}
+
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	ts := readOnlyServer(t, testutils.NewMetadataSwizzler(gun, meta, cs).MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	report, err := repo.KeyHealthReport()
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Empty(t, report.Weak)
+	require.Equal(t, []ReusedKey{{KeyID: aKey.ID(), Roles: []data.RoleName{"targets/a", "targets/b"}}}, report.Reused)
+	expectedIDs := []string{aKey.ID(), duplicateID}
+	if duplicateID < aKey.ID() {
+		expectedIDs = []string{duplicateID, aKey.ID()}
+	}
+	require.Equal(t, []DuplicatedKey{{KeyIDs: expectedIDs, Roles: []data.RoleName{"targets/a", "targets/b"}}},
+		report.Duplicated)
+
+	// raising the minimum strength above that of the ECDSA P-256 keys reports
+	// every key as weak
+	repo.SetMinimumKeyStrength(192)
+	report, err = repo.KeyHealthReport()
+	require.NoError(t, err)
+	// the base role keys, the own key of targets/b, the shared key and its duplicate
+	require.Len(t, report.Weak, len(data.BaseRoles)+3)
+	for _, weak := range report.Weak {
+		require.NoError(t, weak.Err)
+		require.Equal(t, 128, weak.Class.Strength)
+		require.NotEmpty(t, weak.Roles)
+	}
+}
+
+// The keys of a freshly initialized repository are healthy
+func TestKeyHealthReportHealthy(t *testing.T) {
+	meta, cs, err := testutils.NewRepoMetadata("docker.com/notary", "targets/a")
+	require.NoError(t, err)
+	gun := data.GUN("docker.com/notary")
+	ts := readOnlyServer(t, testutils.NewMetadataSwizzler(gun, meta, cs).MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	report, err := repo.KeyHealthReport()
+	require.NoError(t, err)
+	require.True(t, report.OK())
+}
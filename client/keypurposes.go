@@ -0,0 +1,123 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// RoleInfo is a role as defined by the trusted metadata, with the purpose tags
+// of its keys stored in the role's own metadata
+type RoleInfo struct {
+	data.Role
+	// Version is the version of the role's metadata, or 0 if the role has no
+	// metadata yet
+	Version int
+	// KeyPurposes are the purpose tags of keys, by key ID
+	KeyPurposes map[string][]string
+}
+
+// GetRoleInfo returns the role as defined by the trusted metadata, with the
+// purpose tags of its keys
+func (r *repository) GetRoleInfo(role data.RoleName) (*RoleInfo, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	info := &RoleInfo{KeyPurposes: make(map[string][]string)}
+
+	var common *data.SignedCommon
+	if data.IsDelegation(role) {
+		delgRole, err := r.tufRepo.GetDelegationRole(role)
+		if err != nil {
+			return nil, err
+		}
+		info.Role = data.Role{
+			Name:     role,
+			RootRole: data.RootRole{KeyIDs: delgRole.ListKeyIDs(), Threshold: delgRole.Threshold},
+			Paths:    delgRole.Paths,
+		}
+	} else {
+		rootRole, ok := r.tufRepo.Root.Signed.Roles[role]
+		if !ok {
+			return nil, data.ErrInvalidRole{Role: role, Reason: "not a role of the repository"}
+		}
+		info.Role = data.Role{Name: role, RootRole: *rootRole}
+	}
+	switch role {
+	case data.CanonicalRootRole:
+		common = &r.tufRepo.Root.Signed.SignedCommon
+	case data.CanonicalSnapshotRole:
+		if r.tufRepo.Snapshot != nil {
+			common = &r.tufRepo.Snapshot.Signed.SignedCommon
+		}
+	case data.CanonicalTimestampRole:
+		if r.tufRepo.Timestamp != nil {
+			common = &r.tufRepo.Timestamp.Signed.SignedCommon
+		}
+	default:
+		if targets, ok := r.tufRepo.Targets[role]; ok {
+			common = &targets.Signed.SignedCommon
+		}
+	}
+
+	if common != nil {
+		purposes, err := common.KeyPurposes()
+		if err != nil {
+			return nil, err
+		}
+		info.Version, info.KeyPurposes = common.Version, purposes
+	}
+	return info, nil
+}
+
+// SetKeyPurposes creates a changelist entry to replace the purpose tags of
+// the key, such as "primary", "backup" or "ceremony", in the reserved
+// namespace of the role's custom metadata when the changelist gets applied
+// at publish time.  No purposes removes the key's tags.  The tags are signed
+// with, and kept when re-signing, the role's metadata, which must be the root,
+// targets or a delegation.  The key ID is accepted in any case.
+func (r *repository) SetKeyPurposes(role data.RoleName, keyID string, purposes ...string) error {
+	keyID, err := data.CanonicalKeyID(keyID)
+	if err != nil {
+		return err
+	}
+	purposesJSON, err := json.Marshal(purposes)
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("Setting %d purposes on key %s in %s", len(purposes), keyID, role)
+	template := changelist.NewTUFChange(
+		changelist.ActionUpdate, role, changelist.TypeKeyPurposes, keyID, purposesJSON)
+	if role == data.CanonicalRootRole {
+		return r.changelist.Add(template)
+	}
+	return addChange(r.changelist, template, role)
+}
+
+// applyKeyPurposesChange sets the purpose tags of the key in the change's
+// path in the metadata of the change's scope, marking it for re-signing
+func applyKeyPurposesChange(repo *tuf.Repo, c changelist.Change) error {
+	var purposes []string
+	if err := json.Unmarshal(c.Content(), &purposes); err != nil {
+		return err
+	}
+	if c.Scope() == data.CanonicalRootRole {
+		if err := repo.Root.Signed.SignedCommon.SetKeyPurposes(c.Path(), purposes); err != nil {
+			return err
+		}
+		repo.Root.Dirty = true
+		return nil
+	}
+	targets, ok := repo.Targets[c.Scope()]
+	if !ok {
+		return data.ErrInvalidRole{Role: c.Scope(), Reason: "no metadata to set key purposes in"}
+	}
+	if err := targets.Signed.SignedCommon.SetKeyPurposes(c.Path(), purposes); err != nil {
+		return err
+	}
+	targets.Dirty = true
+	return nil
+}
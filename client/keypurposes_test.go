@@ -0,0 +1,86 @@
+package client
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Purpose tags attached to root keys are published in the root, and read back
+// intact by other clients, including after the root is re-signed
+func TestKeyPurposes(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+
+	rootInfo, err := repo.GetRoleInfo(data.CanonicalRootRole)
+	require.NoError(t, err)
+	require.Len(t, rootInfo.KeyIDs, 1)
+	require.Empty(t, rootInfo.KeyPurposes)
+	rootKeyID := rootInfo.KeyIDs[0]
+	// purposes can be set on keys which are not yet in the role
+	offlineBackupKeyID := strings.Repeat("b", notary.SHA256HexSize)
+
+	targetsInfo, err := repo.GetRoleInfo(data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	require.Len(t, targetsInfo.KeyIDs, 1)
+	targetsKeyID := targetsInfo.KeyIDs[0]
+
+	require.NoError(t, repo.SetKeyPurposes(data.CanonicalRootRole, rootKeyID, "primary", "ceremony"))
+	require.NoError(t, repo.SetKeyPurposes(data.CanonicalRootRole, offlineBackupKeyID, "backup"))
+	require.NoError(t, repo.SetKeyPurposes(data.CanonicalTargetsRole, targetsKeyID, "ci"))
+	require.NoError(t, repo.Publish())
+
+	expectedRootPurposes := map[string][]string{
+		rootKeyID:          {"ceremony", "primary"},
+		offlineBackupKeyID: {"backup"},
+	}
+	otherRepo, _, otherDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(otherDir)
+	rootInfo, err = otherRepo.GetRoleInfo(data.CanonicalRootRole)
+	require.NoError(t, err)
+	require.Equal(t, expectedRootPurposes, rootInfo.KeyPurposes)
+	publishedVersion := rootInfo.Version
+	targetsInfo, err = otherRepo.GetRoleInfo(data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{targetsKeyID: {"ci"}}, targetsInfo.KeyPurposes)
+
+	// rotating the snapshot key re-signs the root, keeping the tags
+	require.NoError(t, repo.RotateKey(data.CanonicalSnapshotRole, false, nil))
+	require.NoError(t, repo.Publish())
+	rootInfo, err = otherRepo.GetRoleInfo(data.CanonicalRootRole)
+	require.NoError(t, err)
+	require.True(t, rootInfo.Version > publishedVersion)
+	require.Equal(t, expectedRootPurposes, rootInfo.KeyPurposes)
+
+	// removing every tag removes them from the root
+	require.NoError(t, repo.SetKeyPurposes(data.CanonicalRootRole, rootKeyID))
+	require.NoError(t, repo.SetKeyPurposes(data.CanonicalRootRole, offlineBackupKeyID))
+	require.NoError(t, repo.Publish())
+	rootInfo, err = otherRepo.GetRoleInfo(data.CanonicalRootRole)
+	require.NoError(t, err)
+	require.Empty(t, rootInfo.KeyPurposes)
+	require.Nil(t, otherRepo.tufRepo.Root.Signed.Custom)
+}
+
+// Key purposes can only be set on roles whose metadata clients sign
+func TestKeyPurposesInvalidRole(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	err := repo.SetKeyPurposes(data.CanonicalTimestampRole, strings.Repeat("a", notary.SHA256HexSize), "primary")
+	require.IsType(t, data.ErrInvalidRole{}, err)
+	err = repo.SetKeyPurposes(data.CanonicalRootRole, "key", "primary")
+	require.IsType(t, data.ErrInvalidKeyID{}, err)
+	require.Empty(t, getChanges(t, repo))
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// KeyReferenceProblem is a key ID referenced by the metadata of a role which
+// is not defined where it should be
+type KeyReferenceProblem struct {
+	// Role is the role signed by, or listing as a signing key, the key ID
+	Role   data.RoleName
+	KeyID  string
+	Reason string
+}
+
+// ValidateKeyReferences audits the trusted metadata for dangling key
+// references: for the base roles, key IDs the root lists for a role or which
+// sign the role but which are not defined in the root for that role, and for
+// delegations, key IDs a parent lists for a delegation or which sign the
+// delegation but which the parent does not define for it.  Delegations which
+// failed verification on the update are audited as well.  Right after a root
+// key rotation, the root's signatures by the previous root keys are reported,
+// as those keys are no longer defined in the root.
+//
+// The audit is read only, and complements the validation of each role's
+// structure.  The problems are sorted by role and key ID; an error is only
+// returned if the repository could not be updated.
+func (r *repository) ValidateKeyReferences() ([]KeyReferenceProblem, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	return r.keyReferenceProblems(), nil
+}
+
+// keyReferenceProblems audits the metadata loaded on the last update
+func (r *repository) keyReferenceProblems() []KeyReferenceProblem {
+	var problems []KeyReferenceProblem
+	report := func(role data.RoleName, keyID, reason string) {
+		problems = append(problems, KeyReferenceProblem{Role: role, KeyID: keyID, Reason: reason})
+	}
+
+	root := r.tufRepo.Root
+	for roleName, role := range root.Signed.Roles {
+		for _, keyID := range role.KeyIDs {
+			if _, ok := root.Signed.Keys[keyID]; !ok {
+				report(roleName, keyID, "listed as a signing key but not defined in the root")
+			}
+		}
+	}
+	baseSignatures := map[data.RoleName][]data.Signature{data.CanonicalRootRole: root.Signatures}
+	if r.tufRepo.Snapshot != nil {
+		baseSignatures[data.CanonicalSnapshotRole] = r.tufRepo.Snapshot.Signatures
+	}
+	if r.tufRepo.Timestamp != nil {
+		baseSignatures[data.CanonicalTimestampRole] = r.tufRepo.Timestamp.Signatures
+	}
+	if targets := r.loadedTargets(data.CanonicalTargetsRole); targets != nil {
+		baseSignatures[data.CanonicalTargetsRole] = targets.Signatures
+	}
+	for roleName, signatures := range baseSignatures {
+		var defined []string
+		if role, ok := root.Signed.Roles[roleName]; ok {
+			defined = role.KeyIDs
+		}
+		for _, keyID := range undefinedSigners(signatures, defined, root.Signed.Keys) {
+			report(roleName, keyID, "signed by a key not defined for the role in the root")
+		}
+	}
+
+	toWalk := []data.RoleName{data.CanonicalTargetsRole}
+	walked := make(map[data.RoleName]bool)
+	for len(toWalk) > 0 {
+		parentName := toWalk[0]
+		toWalk = toWalk[1:]
+		parent := r.loadedTargets(parentName)
+		if parent == nil || walked[parentName] {
+			continue
+		}
+		walked[parentName] = true
+
+		for _, delegation := range parent.Signed.Delegations.Roles {
+			for _, keyID := range delegation.KeyIDs {
+				if _, ok := parent.Signed.Delegations.Keys[keyID]; !ok {
+					report(delegation.Name, keyID,
+						fmt.Sprintf("listed as a signing key but not defined in %s", parentName))
+				}
+			}
+			if child := r.loadedTargets(delegation.Name); child != nil {
+				for _, keyID := range undefinedSigners(child.Signatures, delegation.KeyIDs, parent.Signed.Delegations.Keys) {
+					report(delegation.Name, keyID,
+						fmt.Sprintf("signed by a key not defined for the role in %s", parentName))
+				}
+			}
+			toWalk = append(toWalk, delegation.Name)
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Role != problems[j].Role {
+			return problems[i].Role < problems[j].Role
+		}
+		return problems[i].KeyID < problems[j].KeyID
+	})
+	return problems
+}
+
+// undefinedSigners returns, once each, the key IDs of the signatures which are
+// not among the role's key IDs or not defined in the keys
+func undefinedSigners(signatures []data.Signature, roleKeyIDs []string, keys data.Keys) []string {
+	listed := make(map[string]bool, len(roleKeyIDs))
+	for _, keyID := range roleKeyIDs {
+		listed[keyID] = true
+	}
+	var undefined []string
+	seen := make(map[string]bool)
+	for _, sig := range signatures {
+		if seen[sig.KeyID] {
+			continue
+		}
+		seen[sig.KeyID] = true
+		if _, ok := keys[sig.KeyID]; !ok || !listed[sig.KeyID] {
+			undefined = append(undefined, sig.KeyID)
+		}
+	}
+	return undefined
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A delegation signed by a key ID its parent does not define is reported
+func TestValidateKeyReferencesUndefinedSigner(t *testing.T) {
+	_, serverSwizzler := newServerSwizzler(t)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	problems, err := repo.ValidateKeyReferences()
+	require.NoError(t, err)
+	require.Empty(t, problems)
+
+	require.NoError(t, serverSwizzler.SignMetadataWithInvalidKey("targets/b"))
+	require.NoError(t, serverSwizzler.UpdateSnapshotHashes())
+	require.NoError(t, serverSwizzler.UpdateTimestampHash())
+
+	meta, err := serverSwizzler.MetadataCache.GetSized("targets/b", store.NoSizeLimit)
+	require.NoError(t, err)
+	signedB := &data.Signed{}
+	require.NoError(t, json.Unmarshal(meta, signedB))
+	require.Len(t, signedB.Signatures, 1)
+
+	problems, err = repo.ValidateKeyReferences()
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	require.Equal(t, data.RoleName("targets/b"), problems[0].Role)
+	require.Equal(t, signedB.Signatures[0].KeyID, problems[0].KeyID)
+	require.Contains(t, problems[0].Reason, "signed by a key not defined")
+}
+
+// A delegation whose parent lists a key ID it does not define is reported.
+// Such targets metadata fails validation on the update, so the loaded
+// metadata is audited directly.
+func TestValidateKeyReferencesMissingDelegationKey(t *testing.T) {
+	tufRepo, _, err := testutils.EmptyRepo("docker.com/notary", "targets/a", "targets/a/b")
+	require.NoError(t, err)
+	_, err = testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+
+	repo := &repository{tufRepo: tufRepo}
+	require.Empty(t, repo.keyReferenceProblems())
+
+	for _, role := range tufRepo.Targets["targets/a"].Signed.Delegations.Roles {
+		role.KeyIDs = append(role.KeyIDs, "missing")
+	}
+	problems := repo.keyReferenceProblems()
+	require.Len(t, problems, 1)
+	require.Equal(t, KeyReferenceProblem{
+		Role:   "targets/a/b",
+		KeyID:  "missing",
+		Reason: "listed as a signing key but not defined in targets/a",
+	}, problems[0])
+}
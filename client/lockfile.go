@@ -0,0 +1,180 @@
+package client
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// LockfileRequirements is the pip requirements format in hash-checking mode,
+// one pinned requirement per logical line, such as
+// "requests==2.31.0 --hash=sha256:<hex>", as produced by pip-compile
+const LockfileRequirements = "requirements"
+
+// ErrLockfileEntry is returned when an entry of a lockfile is malformed or
+// cannot be recorded as a target
+type ErrLockfileEntry struct {
+	// Line is the line, counting from 1, on which the entry starts
+	Line   int
+	Entry  string
+	Reason string
+}
+
+func (err ErrLockfileEntry) Error() string {
+	return fmt.Sprintf("lockfile line %d: %q: %s", err.Line, err.Entry, err.Reason)
+}
+
+// ErrUnsupportedLockfileFormat is returned when targets are imported from a
+// lockfile in a format which is not supported
+type ErrUnsupportedLockfileFormat struct {
+	Format string
+}
+
+func (err ErrUnsupportedLockfileFormat) Error() string {
+	return fmt.Sprintf("unsupported lockfile format %q", err.Format)
+}
+
+// lockfileTarget is a target parsed from a lockfile entry
+type lockfileTarget struct {
+	name   string
+	hashes data.Hashes
+}
+
+// AddTargetsFromLockfile creates a changelist entry, as AddPrecomputedTarget
+// does, for every entry of a lockfile in the given format.  Each entry becomes
+// a target named "<name>==<version>" whose hashes are the entry's integrity
+// hashes; lockfiles do not record sizes, so the targets' lengths are 0.  The
+// whole lockfile is parsed before any change is staged, so if any entry is
+// malformed or unsupported, an ErrLockfileEntry is returned and nothing is
+// staged.
+func (r *repository) AddTargetsFromLockfile(role data.RoleName, format string, lockfile io.Reader) error {
+	var (
+		targets []lockfileTarget
+		err     error
+	)
+	switch format {
+	case LockfileRequirements:
+		targets, err = parseRequirementsLockfile(lockfile)
+	default:
+		return ErrUnsupportedLockfileFormat{Format: format}
+	}
+	if err != nil {
+		return err
+	}
+	for _, target := range targets {
+		if err := r.AddPrecomputedTarget(role, target.name, data.FileMeta{Hashes: target.hashes}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseRequirementsLockfile parses pip requirements in hash-checking mode.
+// Lines ending in a backslash are continued on the next line, comments and
+// blank lines are ignored, and so are options other than those which include
+// requirements from elsewhere, which are reported as unsupported.
+func parseRequirementsLockfile(lockfile io.Reader) ([]lockfileTarget, error) {
+	var (
+		targets []lockfileTarget
+		entry   string
+		start   int
+	)
+	seen := make(map[string]int)
+	scanner := bufio.NewScanner(lockfile)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if entry == "" {
+			start = line
+		}
+		if i := strings.Index(text, "#"); i == 0 || (i > 0 && strings.ContainsAny(text[i-1:i], " \t")) {
+			text = text[:i]
+		}
+		if strings.HasSuffix(strings.TrimRight(text, " \t"), `\`) {
+			entry += strings.TrimSuffix(strings.TrimRight(text, " \t"), `\`) + " "
+			continue
+		}
+		entry = strings.TrimSpace(entry + text)
+		if entry == "" {
+			continue
+		}
+		target, err := parseRequirement(entry)
+		if err == nil && target != nil {
+			if first, ok := seen[target.name]; ok {
+				err = fmt.Errorf("%s is already pinned on line %d", target.name, first)
+			}
+		}
+		if err != nil {
+			return nil, ErrLockfileEntry{Line: start, Entry: entry, Reason: err.Error()}
+		}
+		if target != nil {
+			seen[target.name] = start
+			targets = append(targets, *target)
+		}
+		entry = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if entry != "" {
+		return nil, ErrLockfileEntry{Line: start, Entry: strings.TrimSpace(entry),
+			Reason: "the lockfile ends in a line continuation"}
+	}
+	return targets, nil
+}
+
+// parseRequirement parses a single logical requirements line, returning a nil
+// target for options which do not describe a requirement
+func parseRequirement(entry string) (*lockfileTarget, error) {
+	fields := strings.Fields(entry)
+	if strings.HasPrefix(fields[0], "-") {
+		option := strings.SplitN(fields[0], "=", 2)[0]
+		switch option {
+		case "-r", "--requirement", "-c", "--constraint", "-e", "--editable":
+			return nil, fmt.Errorf("the %s option is not supported", option)
+		}
+		return nil, nil
+	}
+
+	spec := strings.SplitN(fields[0], "==", 2)
+	if len(spec) != 2 || spec[0] == "" || strings.TrimLeft(spec[1], "=") == "" {
+		return nil, fmt.Errorf("the requirement is not pinned to a version with ==")
+	}
+	target := &lockfileTarget{name: fields[0], hashes: data.Hashes{}}
+	for _, field := range fields[1:] {
+		if !strings.HasPrefix(field, "--hash=") {
+			// environment markers and other per-requirement options do not
+			// affect the artifact's hashes
+			continue
+		}
+		hash := strings.SplitN(strings.TrimPrefix(field, "--hash="), ":", 2)
+		if len(hash) != 2 {
+			return nil, fmt.Errorf("malformed hash %q", field)
+		}
+		algorithm := hash[0]
+		switch algorithm {
+		case notary.SHA256, notary.SHA512:
+		default:
+			return nil, fmt.Errorf("unsupported hash algorithm %q", algorithm)
+		}
+		if _, ok := target.hashes[algorithm]; ok {
+			return nil, fmt.Errorf("more than one %s hash, but a target can only record one", algorithm)
+		}
+		digest, err := hex.DecodeString(hash[1])
+		if err != nil {
+			return nil, fmt.Errorf("malformed %s hash: %v", algorithm, err)
+		}
+		target.hashes[algorithm] = digest
+	}
+	if len(target.hashes) == 0 {
+		return nil, fmt.Errorf("the requirement has no hash")
+	}
+	if err := data.CheckValidHashStructures(target.hashes); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
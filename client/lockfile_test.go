@@ -0,0 +1,86 @@
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Every requirement of a lockfile, including ones continued over several
+// lines, is published as a target with the requirement's hashes
+func TestAddTargetsFromLockfile(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	certifi := sha256.Sum256([]byte("certifi"))
+	requests := sha256.Sum256([]byte("requests"))
+	requests512 := sha512.Sum512([]byte("requests"))
+	lockfile := strings.Join([]string{
+		"# generated by pip-compile",
+		"--index-url https://pypi.org/simple",
+		"",
+		"certifi==2023.7.22 --hash=sha256:" + hex.EncodeToString(certifi[:]) + "  # via requests",
+		"requests==2.31.0 \\",
+		"    --hash=sha256:" + hex.EncodeToString(requests[:]) + " \\",
+		"    --hash=sha512:" + hex.EncodeToString(requests512[:]),
+	}, "\n")
+	require.NoError(t, repo.AddTargetsFromLockfile(data.CanonicalTargetsRole, LockfileRequirements, strings.NewReader(lockfile)))
+	require.Len(t, getChanges(t, repo), 2)
+	require.NoError(t, repo.Publish())
+
+	repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(baseDir2)
+	for name, hashes := range map[string]data.Hashes{
+		"certifi==2023.7.22": {notary.SHA256: certifi[:]},
+		"requests==2.31.0":   {notary.SHA256: requests[:], notary.SHA512: requests512[:]},
+	} {
+		target, err := repo2.GetTargetByName(name)
+		require.NoError(t, err)
+		require.Equal(t, hashes, target.Hashes)
+		require.Equal(t, data.CanonicalTargetsRole, target.Role)
+	}
+}
+
+// Malformed and unsupported entries are reported with the line they start on,
+// and nothing is staged
+func TestAddTargetsFromLockfileInvalid(t *testing.T) {
+	ts, _, _ := simpleTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	digest := sha256.Sum256([]byte("content"))
+	valid := "--hash=sha256:" + hex.EncodeToString(digest[:])
+	for entry, expected := range map[string]ErrLockfileEntry{
+		"requests>=2.0 " + valid:                              {Line: 2, Reason: "not pinned"},
+		"requests==2.31.0":                                    {Line: 2, Reason: "no hash"},
+		"requests==2.31.0 --hash=md5:abcd":                    {Line: 2, Reason: "unsupported hash algorithm"},
+		"requests==2.31.0 --hash=sha256:zz":                   {Line: 2, Reason: "malformed sha256 hash"},
+		"requests==2.31.0 --hash=sha256:ab":                   {Line: 2, Reason: "sha256"},
+		"requests==2.31.0 \\\n  " + valid + " \\\n  " + valid: {Line: 2, Reason: "more than one sha256 hash"},
+		"-r other.txt":                                        {Line: 2, Reason: "-r option is not supported"},
+		"certifi==1.0 " + valid:                               {Line: 2, Reason: "already pinned on line 1"},
+		"requests==2.31.0 \\":                                 {Line: 2, Reason: "line continuation"},
+	} {
+		lockfile := "certifi==1.0 " + valid + "\n" + entry + "\n"
+		err := repo.AddTargetsFromLockfile(data.CanonicalTargetsRole, LockfileRequirements, strings.NewReader(lockfile))
+		require.IsType(t, ErrLockfileEntry{}, err, entry)
+		require.Equal(t, expected.Line, err.(ErrLockfileEntry).Line, entry)
+		require.Contains(t, err.Error(), expected.Reason, entry)
+	}
+	require.Empty(t, getChanges(t, repo))
+
+	err := repo.AddTargetsFromLockfile(data.CanonicalTargetsRole, "Gemfile.lock", strings.NewReader(""))
+	require.Equal(t, ErrUnsupportedLockfileFormat{Format: "Gemfile.lock"}, err)
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// MinimalBundleFor updates the repository and returns the signed metadata
+// needed to verify the named target and nothing more: the root, timestamp and
+// snapshot, and every role from the targets role down the delegation chain to
+// the role the target is found in.  The metadata is returned exactly as
+// verified by the update, so a store seeded with the bundle can be used as the
+// remote of a read-only repository.  Other delegations listed in the snapshot
+// are not included, so such a repository must skip delegations that cannot be
+// loaded, with SetUnknownDelegationPolicy.
+func (r *repository) MinimalBundleFor(name string) (map[data.RoleName][]byte, error) {
+	target, err := r.GetTargetByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := []data.RoleName{data.CanonicalRootRole, data.CanonicalTimestampRole, data.CanonicalSnapshotRole}
+	for role := target.Role; data.IsDelegation(role); role = role.Parent() {
+		roles = append(roles, role)
+	}
+	roles = append(roles, data.CanonicalTargetsRole)
+
+	bundle := make(map[data.RoleName][]byte, len(roles))
+	for _, role := range roles {
+		raw, err := r.cache.GetSized(role.String(), store.NoSizeLimit)
+		if err != nil {
+			return nil, err
+		}
+		bundle[role] = raw
+	}
+	return bundle, nil
+}
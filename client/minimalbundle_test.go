@@ -0,0 +1,70 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A bundle holds only the delegation chain to the target, and a repository
+// using the bundle alone as its remote verifies the target
+func TestMinimalBundleFor(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/a", "targets/b", "targets/b/c")
+	require.NoError(t, err)
+	for _, delg := range []struct {
+		name   data.RoleName
+		target string
+	}{{"targets/a", "other"}, {"targets/b", "parent"}, {"targets/b/c", "current"}} {
+		// targets/b already has metadata, holding its delegation to targets/b/c
+		if _, ok := tufRepo.Targets[delg.name]; !ok {
+			_, err = tufRepo.InitTargets(delg.name)
+			require.NoError(t, err)
+		}
+		_, err := tufRepo.AddTargets(delg.name, data.Files{delg.target: data.FileMeta{
+			Length: 1, Hashes: data.Hashes{"sha256": []byte(delg.name.String())}}})
+		require.NoError(t, err)
+	}
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	ts := readOnlyServer(t, testutils.NewMetadataSwizzler(gun, meta, cs).MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	bundle, err := repo.MinimalBundleFor("current")
+	require.NoError(t, err)
+	require.Len(t, bundle, 6)
+	for _, role := range []data.RoleName{data.CanonicalRootRole, data.CanonicalTimestampRole,
+		data.CanonicalSnapshotRole, data.CanonicalTargetsRole, "targets/b", "targets/b/c"} {
+		require.Equal(t, meta[role], bundle[role], role.String())
+	}
+
+	expected, err := repo.GetTargetByName("current")
+	require.NoError(t, err)
+
+	bundleServer := readOnlyServer(t, store.NewMemoryStore(bundle), http.StatusNotFound, gun)
+	defer bundleServer.Close()
+
+	// targets/a, which is left out of the bundle, must be skipped
+	reader, readerDir := newBlankRepo(t, bundleServer.URL)
+	defer os.RemoveAll(readerDir)
+	_, err = reader.GetTargetByName("current")
+	require.Error(t, err)
+
+	reader.SetUnknownDelegationPolicy(UnknownDelegationSkip)
+	verified, err := reader.GetTargetByName("current")
+	require.NoError(t, err)
+	require.Equal(t, expected, verified)
+	_, err = reader.GetTargetByName("other")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+
+	_, err = repo.MinimalBundleFor("nonexistent")
+	require.IsType(t, ErrNoSuchTarget(""), err)
+}
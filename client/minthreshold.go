@@ -0,0 +1,130 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ThresholdVerification determines how updates apply a MinimumThresholdPolicy
+// to the metadata they verify
+type ThresholdVerification int
+
+// The possible ways of applying minimum thresholds on update
+const (
+	// ThresholdsAsDeclared verifies each role against the threshold in its
+	// trusted metadata.  The minimums only apply to roles the client creates.
+	ThresholdsAsDeclared ThresholdVerification = iota
+	// RaiseThresholds requires, on every update, at least each role's minimum
+	// number of valid signatures, even if the role's threshold in the metadata
+	// is lower.  A role meeting its metadata threshold but not the minimum is
+	// rejected with signed.ErrRoleThreshold.
+	RaiseThresholds
+	// RejectLowThresholds rejects, on every update, trusted metadata which
+	// gives any base role or loaded delegation a threshold below its minimum,
+	// even though its signatures meet that threshold
+	RejectLowThresholds
+)
+
+// MinimumThresholdPolicy forbids roles with a signing threshold below a
+// minimum.  Roles created by the client are given their minimum threshold, and
+// metadata loaded on update is optionally checked against it.
+type MinimumThresholdPolicy struct {
+	// Minimum is the lowest threshold allowed for any role not listed in
+	// Roles.  Values of 1 or less allow any threshold.
+	Minimum int
+	// Roles overrides Minimum for individual roles
+	Roles map[data.RoleName]int
+	// Verify is how updates apply the minimums
+	Verify ThresholdVerification
+}
+
+// ErrThresholdBelowMinimum is returned when a role is created with, or
+// trusted metadata gives a role, a threshold below the minimum threshold
+type ErrThresholdBelowMinimum struct {
+	Role      data.RoleName
+	Threshold int
+	Minimum   int
+}
+
+func (err ErrThresholdBelowMinimum) Error() string {
+	return fmt.Sprintf("%s has a threshold of %d, below the minimum threshold of %d",
+		err.Role, err.Threshold, err.Minimum)
+}
+
+// minimumFor returns the lowest threshold allowed for the role.  A nil policy
+// allows the lowest supported threshold.
+func (p *MinimumThresholdPolicy) minimumFor(role data.RoleName) int {
+	minimum := notary.MinThreshold
+	if p != nil {
+		if roleMinimum, ok := p.Roles[role]; ok {
+			minimum = roleMinimum
+		} else {
+			minimum = p.Minimum
+		}
+	}
+	if minimum < notary.MinThreshold {
+		return notary.MinThreshold
+	}
+	return minimum
+}
+
+// check returns an error if the threshold is below the role's minimum.  A nil
+// policy allows any threshold.
+func (p *MinimumThresholdPolicy) check(role data.RoleName, threshold int) error {
+	if p == nil {
+		return nil
+	}
+	if minimum := p.minimumFor(role); threshold < minimum {
+		return ErrThresholdBelowMinimum{Role: role, Threshold: threshold, Minimum: minimum}
+	}
+	return nil
+}
+
+// creationThreshold returns the threshold to create the role with, which is
+// its minimum.  ErrThresholdBelowMinimum, with the highest threshold the keys
+// allow, is returned if the role has too few keys to meet it.
+func (p *MinimumThresholdPolicy) creationThreshold(role data.RoleName, keys int) (int, error) {
+	threshold := p.minimumFor(role)
+	if keys < threshold {
+		return 0, ErrThresholdBelowMinimum{Role: role, Threshold: keys, Minimum: threshold}
+	}
+	return threshold, nil
+}
+
+// verifying configures a builder to require each role's minimum number of
+// signatures, if the policy raises thresholds on update
+func (p *MinimumThresholdPolicy) verifying(rb tuf.RepoBuilder) tuf.RepoBuilder {
+	if p == nil || p.Verify != RaiseThresholds {
+		return rb
+	}
+	return tuf.WithMinThreshold(tuf.WithMinThresholds(rb, p.Roles), p.Minimum)
+}
+
+// checkRepo, if the policy rejects low thresholds on update, checks the
+// threshold of every base role and of every delegation in the loaded targets
+// metadata
+func (p *MinimumThresholdPolicy) checkRepo(repo *tuf.Repo) error {
+	if p == nil || p.Verify != RejectLowThresholds {
+		return nil
+	}
+	for _, roleName := range data.BaseRoles {
+		role, err := repo.GetBaseRole(roleName)
+		if err != nil {
+			return err
+		}
+		if err := p.check(roleName, role.Threshold); err != nil {
+			return err
+		}
+	}
+	for _, targets := range repo.Targets {
+		for _, role := range targets.Signed.Delegations.Roles {
+			if err := p.check(role.Name, role.Threshold); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
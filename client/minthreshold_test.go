@@ -0,0 +1,131 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// Under a minimum threshold of 2, a delegation with a single key, or a
+// repository with a single root key, cannot be created
+func TestMinimumThresholdOnCreation(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	delegationKey, err := repo.GetCryptoService().Create("targets/a", repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+
+	repo.SetMinimumThreshold(MinimumThresholdPolicy{Minimum: 2})
+	err = repo.AddDelegation("targets/a", []data.PublicKey{delegationKey}, []string{""})
+	require.Equal(t, ErrThresholdBelowMinimum{Role: "targets/a", Threshold: 1, Minimum: 2}, err)
+	require.Len(t, getChanges(t, repo), 0)
+
+	uninitialized, _, rootKeyID := createRepoAndKey(t, data.ECDSAKey, baseDir, "docker.com/other", ts.URL)
+	uninitialized.SetMinimumThreshold(MinimumThresholdPolicy{Minimum: 2})
+	err = uninitialized.Initialize([]string{rootKeyID})
+	require.IsType(t, ErrThresholdBelowMinimum{}, err)
+
+	// the default minimum allows creating the delegation
+	repo.SetMinimumThreshold(MinimumThresholdPolicy{Minimum: 1})
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{delegationKey}, []string{""}))
+}
+
+// Under a minimum threshold of 2, roles are created with a threshold of 2, so a
+// repository and delegation meeting the minimum can be created and published,
+// and then pass updates rejecting thresholds below it
+func TestMinimumThresholdCreatesCompliantRoles(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+	baseDir, err := ioutil.TempDir("", "notary-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(baseDir)
+
+	// the server only holds a single timestamp key
+	policy := MinimumThresholdPolicy{Minimum: 2, Roles: map[data.RoleName]int{data.CanonicalTimestampRole: 1}}
+	repo, _, rootKeyID := createRepoAndKey(t, data.ECDSAKey, baseDir, "docker.com/notary", ts.URL)
+	secondRootKey, err := repo.GetCryptoService().Create(data.CanonicalRootRole, repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+	repo.SetMinimumThreshold(policy)
+	require.NoError(t, repo.Initialize([]string{rootKeyID, secondRootKey.ID()}))
+
+	delegationKeys := make([]data.PublicKey, 0, 2)
+	for i := 0; i < 2; i++ {
+		key, err := repo.GetCryptoService().Create("targets/a", repo.gun, data.ECDSAKey)
+		require.NoError(t, err)
+		delegationKeys = append(delegationKeys, key)
+	}
+	require.NoError(t, repo.AddDelegation("targets/a", delegationKeys, []string{""}))
+	require.NoError(t, repo.Publish())
+
+	for _, roleName := range []data.RoleName{data.CanonicalRootRole, data.CanonicalTargetsRole, data.CanonicalSnapshotRole} {
+		role, err := repo.tufRepo.GetBaseRole(roleName)
+		require.NoError(t, err)
+		require.Equal(t, 2, role.Threshold, roleName.String())
+		require.Len(t, role.Keys, 2, roleName.String())
+	}
+	delegation, err := repo.tufRepo.GetDelegationRole("targets/a")
+	require.NoError(t, err)
+	require.Equal(t, 2, delegation.Threshold)
+
+	policy.Verify = RejectLowThresholds
+	repo.SetMinimumThreshold(policy)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+}
+
+// Existing metadata with thresholds of 1 is only rejected on update if the
+// policy rejects low thresholds on verification
+func TestMinimumThresholdOnVerify(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, cs, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	ts := readOnlyServer(t, testutils.NewMetadataSwizzler(gun, meta, cs).MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetMinimumThreshold(MinimumThresholdPolicy{Minimum: 2})
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+
+	repo.SetMinimumThreshold(MinimumThresholdPolicy{Minimum: 2, Verify: RejectLowThresholds})
+	_, err = repo.ListTargets()
+	require.IsType(t, ErrThresholdBelowMinimum{}, err)
+	require.Equal(t, 1, err.(ErrThresholdBelowMinimum).Threshold)
+}
+
+// Raising thresholds on update rejects a targets role which meets its
+// threshold of 1 in the metadata with a single signature
+func TestMinimumThresholdRaisedOnUpdate(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, cs, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	ts := readOnlyServer(t, testutils.NewMetadataSwizzler(gun, meta, cs).MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	targetsMinimum := map[data.RoleName]int{data.CanonicalTargetsRole: 2}
+	repo.SetMinimumThreshold(MinimumThresholdPolicy{Roles: targetsMinimum})
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+
+	repo.SetMinimumThreshold(MinimumThresholdPolicy{Roles: targetsMinimum, Verify: RaiseThresholds})
+	_, err = repo.ListTargets()
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+
+	// nor is a root trusted the first time it is downloaded
+	repo, baseDir = newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetMinimumThreshold(MinimumThresholdPolicy{Minimum: 2, Verify: RaiseThresholds})
+	_, err = repo.ListTargets()
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+}
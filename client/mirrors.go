@@ -0,0 +1,96 @@
+package client
+
+import (
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// MirrorStatus is how a mirror's copy of a target compares to the primary's
+type MirrorStatus string
+
+// The possible statuses of a mirror's copy of a target
+const (
+	// MirrorMatches means the mirror's target has the primary's length and
+	// hashes
+	MirrorMatches MirrorStatus = "matches"
+	// MirrorDiffers means the mirror's target has a different length or
+	// different hashes from the primary's
+	MirrorDiffers MirrorStatus = "differs"
+	// MirrorMissing means the mirror has no valid trust data for the target,
+	// or does not exist
+	MirrorMissing MirrorStatus = "missing"
+	// MirrorVerificationFailed means the mirror's trust data could not be
+	// updated or verified, so whether it has the target is unknown
+	MirrorVerificationFailed MirrorStatus = "verification-failed"
+)
+
+// MirrorResult is how a mirror's copy of a target compares to the primary's
+type MirrorResult struct {
+	GUN    data.GUN
+	Status MirrorStatus
+	// Role, Length and Hashes are those of the target resolved in the
+	// mirror, if any
+	Role   data.RoleName
+	Length int64
+	Hashes data.Hashes
+	// Err is why the target could not be resolved in the mirror, if it was
+	// missing or failed verification
+	Err error
+}
+
+// CrossVerifyTarget resolves the target in this repository, and then in each
+// of the other GUNs, mirrors of this repository on the same server, reporting
+// for each mirror the target it resolved and whether it matches this
+// repository's.  An error is returned only if the target cannot be resolved
+// in this repository.
+//
+// Each mirror is opened as a verify-only repository with this repository's
+// trust pinning configuration, sharing this repository's base directory for
+// its cached trust data if this repository is file cached.
+func (r *repository) CrossVerifyTarget(name string, otherGUNs []data.GUN) ([]MirrorResult, error) {
+	primary, err := r.GetTargetByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]MirrorResult, 0, len(otherGUNs))
+	for _, gun := range otherGUNs {
+		result := MirrorResult{GUN: gun}
+		target, err := r.resolveInMirror(gun, name)
+		switch err.(type) {
+		case nil:
+			result.Role, result.Length, result.Hashes = target.Role, target.Length, target.Hashes
+			result.Status = MirrorMatches
+			if target.Length != primary.Length || data.CompareMultiHashes(target.Hashes, primary.Hashes) != nil {
+				result.Status = MirrorDiffers
+			}
+		case ErrNoSuchTarget, ErrRepositoryNotExist:
+			result.Status, result.Err = MirrorMissing, err
+		default:
+			result.Status, result.Err = MirrorVerificationFailed, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// resolveInMirror opens the mirror for the GUN, and resolves the target in it
+func (r *repository) resolveInMirror(gun data.GUN, name string) (*TargetWithRole, error) {
+	var (
+		mirror Repository
+		err    error
+	)
+	if r.baseDir != "" {
+		mirror, err = NewFileCachedVerifyOnlyRepository(r.baseDir, gun, r.baseURL, r.roundTrip, r.trustPinning)
+	} else {
+		var remoteStore store.RemoteStore
+		if remoteStore, err = getRemoteStore(r.baseURL, gun, r.roundTrip); err != nil {
+			return nil, err
+		}
+		mirror, err = NewVerifyOnlyRepository(gun, r.baseURL, remoteStore, store.NewMemoryStore(nil), r.trustPinning)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mirror.GetTargetByName(name)
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Of three mirrors, the one with the same target matches, the one with a
+// different target differs, and the one without it is missing, as is a GUN
+// which does not exist
+func TestCrossVerifyTarget(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	publishWith := func(gun, file string) {
+		repo, _, baseDir := initializeRepo(t, data.ECDSAKey, gun, ts.URL, false)
+		defer os.RemoveAll(baseDir)
+		if file != "" {
+			addTarget(t, repo, "app", file)
+		}
+		require.NoError(t, repo.Publish())
+	}
+	publishWith("docker.com/notary/mirror-same", "../fixtures/intermediate-ca.crt")
+	publishWith("docker.com/notary/mirror-different", "../fixtures/root-ca.crt")
+	publishWith("docker.com/notary/mirror-empty", "")
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "app", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	primary, err := repo.GetTargetByName("app")
+	require.NoError(t, err)
+
+	results, err := repo.CrossVerifyTarget("app", []data.GUN{
+		"docker.com/notary/mirror-same",
+		"docker.com/notary/mirror-different",
+		"docker.com/notary/mirror-empty",
+		"docker.com/notary/mirror-absent",
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	require.Equal(t, data.GUN("docker.com/notary/mirror-same"), results[0].GUN)
+	require.Equal(t, MirrorMatches, results[0].Status)
+	require.Equal(t, data.CanonicalTargetsRole, results[0].Role)
+	require.Equal(t, primary.Hashes, results[0].Hashes)
+	require.Equal(t, primary.Length, results[0].Length)
+	require.NoError(t, results[0].Err)
+
+	require.Equal(t, MirrorDiffers, results[1].Status)
+	require.NotEqual(t, primary.Hashes, results[1].Hashes)
+	require.NoError(t, results[1].Err)
+
+	require.Equal(t, MirrorMissing, results[2].Status)
+	require.IsType(t, ErrNoSuchTarget(""), results[2].Err)
+	require.Equal(t, MirrorMissing, results[3].Status)
+	require.IsType(t, ErrRepositoryNotExist{}, results[3].Err)
+
+	// a mirror whose trust data does not verify against the cached root
+	otherRoot, err := repo.getRemoteStore().GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	cache, err := store.NewFileStore(
+		filepath.Join(baseDir, tufDir, filepath.FromSlash("docker.com/notary/mirror-same"), "metadata"), "json")
+	require.NoError(t, err)
+	require.NoError(t, cache.Set(data.CanonicalRootRole.String(), otherRoot))
+	results, err = repo.CrossVerifyTarget("app", []data.GUN{"docker.com/notary/mirror-same"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, MirrorVerificationFailed, results[0].Status)
+	require.Error(t, results[0].Err)
+
+	// the target must resolve in the primary repository
+	_, err = repo.CrossVerifyTarget("missing", []data.GUN{"docker.com/notary/mirror-same"})
+	require.IsType(t, ErrNoSuchTarget(""), err)
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+const publishLogFile = "publish_log.json"
+
+// PublishRecord records a successful publish of a repository by this client
+type PublishRecord struct {
+	Time time.Time `json:"time"`
+	// Actor is the actor set with SetPublishActor at the time of the publish,
+	// if any
+	Actor string `json:"actor,omitempty"`
+	// Roles maps each role signed and uploaded by the client to its new
+	// version.  Roles signed by the server are not included.
+	Roles map[data.RoleName]int `json:"roles"`
+}
+
+// publishLog is the local, append only, log of the publishes of a
+// repository.  It is kept in a file of one JSON record per line if the
+// repository has a local directory, or otherwise in memory.
+type publishLog struct {
+	path    string
+	lock    sync.Mutex
+	records []PublishRecord
+}
+
+func (l *publishLog) append(record PublishRecord) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.path == "" {
+		l.records = append(l.records, record)
+		return nil
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), notary.PrivExecPerms); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, notary.PrivNoExecPerms)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+func (l *publishLog) read() ([]PublishRecord, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.path == "" {
+		return append([]PublishRecord{}, l.records...), nil
+	}
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return []PublishRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records := []PublishRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record PublishRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// recordPublish appends a record of the roles uploaded by a successful publish
+// to the publish log.  The publish has already succeeded, so a failure to
+// record it is only logged.
+func (r *repository) recordPublish(updated map[data.RoleName][]byte) {
+	record := PublishRecord{
+		Time:  time.Now().UTC(),
+		Actor: r.publishActor,
+		Roles: make(map[data.RoleName]int, len(updated)),
+	}
+	for role, meta := range updated {
+		signedMeta := &data.SignedMeta{}
+		if err := json.Unmarshal(meta, signedMeta); err != nil {
+			logrus.Warnf("unable to record the version of %s published: %s", role, err)
+			continue
+		}
+		record.Roles[role] = signedMeta.Signed.Version
+	}
+	if err := r.publishLog.append(record); err != nil {
+		logrus.Warnf("unable to record publish of %s in the publish log: %s", r.gun, err)
+	}
+}
+
+// PublishHistory returns, oldest first, the records of every successful
+// publish of the repository by this client
+func (r *repository) PublishHistory() ([]PublishRecord, error) {
+	records, err := r.publishLog.read()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
+
+// SetPublishActor sets the actor recorded in the publish log for the
+// publishes which follow
+func (r *repository) SetPublishActor(actor string) {
+	r.publishActor = actor
+}
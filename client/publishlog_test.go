@@ -0,0 +1,68 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/passphrase"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Each successful publish is recorded, in order, with the versions of the
+// roles published and the actor, in the repository's local directory
+func TestPublishHistory(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	history, err := repo.PublishHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 0)
+
+	repo.SetPublishActor("alice")
+	require.NoError(t, repo.Publish())
+	repo.SetPublishActor("")
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	other, err := NewFileCachedRepository(baseDir, repo.gun, ts.URL, http.DefaultTransport,
+		passphrase.ConstantRetriever("pass"), trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+	for _, r := range []Repository{repo, other} {
+		history, err = r.PublishHistory()
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+
+		require.Equal(t, "alice", history[0].Actor)
+		require.Equal(t, map[data.RoleName]int{
+			data.CanonicalRootRole:     1,
+			data.CanonicalTargetsRole:  2,
+			data.CanonicalSnapshotRole: 2,
+		}, history[0].Roles)
+
+		require.Equal(t, "", history[1].Actor)
+		require.Equal(t, map[data.RoleName]int{
+			data.CanonicalTargetsRole:  3,
+			data.CanonicalSnapshotRole: 3,
+		}, history[1].Roles)
+		require.False(t, history[1].Time.Before(history[0].Time))
+	}
+}
+
+// A failed publish is not recorded
+func TestPublishHistoryFailedPublish(t *testing.T) {
+	ts := fullTestServer(t)
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	ts.Close()
+	require.Error(t, repo.Publish())
+
+	history, err := repo.PublishHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 0)
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"fmt"
+	"sort"
 
 	canonicaljson "github.com/docker/go/canonical/json"
 	store "github.com/theupdateframework/notary/storage"
@@ -192,6 +193,45 @@ func (r *reader) GetAllTargetMetadataByName(name string) ([]TargetSignedStruct,
 	return targetInfoList, nil
 }
 
+// EffectiveTargets returns, sorted by name, every target a consumer would get
+// by resolving its name with GetTargetByName and no roles, each annotated with
+// the role it resolves to.  Unlike ListTargets, whose result depends on the
+// priority of the roles it is passed, names are resolved by the delegation tree
+// alone.  Targets signed into a role outside its delegated paths, which never
+// resolve, are omitted.
+func (r *reader) EffectiveTargets() ([]*TargetWithRole, error) {
+	signedTargets, err := r.GetAllTargetMetadataByName("")
+	if _, ok := err.(ErrNoSuchTarget); ok {
+		return []*TargetWithRole{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, signedTarget := range signedTargets {
+		if !seen[signedTarget.Target.Name] {
+			seen[signedTarget.Target.Name] = true
+			names = append(names, signedTarget.Target.Name)
+		}
+	}
+	sort.Strings(names)
+
+	effective := []*TargetWithRole{}
+	for _, name := range names {
+		target, err := r.GetTargetByName(name)
+		if _, ok := err.(ErrNoSuchTarget); ok {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		effective = append(effective, target)
+	}
+	return effective, nil
+}
+
 // ListRoles returns a list of RoleWithSignatures objects for this repo
 // This represents the latest metadata for each role in this repo
 func (r *reader) ListRoles() ([]RoleWithSignatures, error) {
@@ -255,3 +295,37 @@ func (r *reader) GetDelegationRoles() ([]data.Role, error) {
 	}
 	return allDelegations, nil
 }
+
+// TrustedKeys returns the public keys trusted to sign the role, sorted by key
+// ID, and the number of their signatures the role requires, as defined by the
+// root for base roles, and by the delegating targets metadata for delegations
+func (r *reader) TrustedKeys(role data.RoleName) ([]data.PublicKey, int, error) {
+	var (
+		keys      map[string]data.PublicKey
+		threshold int
+	)
+	if data.IsDelegation(role) {
+		delgRole, err := r.tufRepo.GetDelegationRole(role)
+		if err != nil {
+			return nil, 0, err
+		}
+		keys, threshold = delgRole.Keys, delgRole.Threshold
+	} else {
+		baseRole, err := r.tufRepo.GetBaseRole(role)
+		if err != nil {
+			return nil, 0, err
+		}
+		keys, threshold = baseRole.Keys, baseRole.Threshold
+	}
+
+	keyIDs := make([]string, 0, len(keys))
+	for keyID := range keys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+	trusted := make([]data.PublicKey, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		trusted = append(trusted, keys[keyID])
+	}
+	return trusted, threshold, nil
+}
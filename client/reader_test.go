@@ -0,0 +1,72 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// Over overlapping delegations, each target name resolves once, to the role
+// GetTargetByName resolves it to: the targets role before its delegations, an
+// earlier delegation before a later one, and a parent before its children
+func TestEffectiveTargets(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	repo, _, err := testutils.EmptyRepo(gun, "targets/a", "targets/a/c", "targets/b")
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateDelegationPaths("targets/b", []string{"shared", "only-b"}, nil, true))
+
+	rolesTargets := map[data.RoleName][]string{
+		data.CanonicalTargetsRole: {"top"},
+		"targets/a":               {"top", "shared", "only-a"},
+		"targets/a/c":             {"only-a", "deep"},
+		"targets/b":               {"shared", "only-b"},
+	}
+	for role, names := range rolesTargets {
+		files := data.Files{}
+		for _, name := range names {
+			files[name] = data.FileMeta{Length: 1, Hashes: data.Hashes{"sha256": []byte(role.String())}}
+		}
+		_, err := repo.AddTargets(role, files)
+		require.NoError(t, err)
+	}
+	// signed into targets/b, but outside the paths it is delegated
+	repo.Targets["targets/b"].Signed.Targets["elsewhere"] = data.FileMeta{Length: 1}
+
+	// listing with a caller chosen priority resolves "shared" to targets/b
+	reader := NewReadOnly(repo)
+	listed, err := reader.ListTargets("targets/b", data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	for _, target := range listed {
+		if target.Name == "shared" {
+			require.Equal(t, data.RoleName("targets/b"), target.Role)
+		}
+	}
+
+	effective, err := reader.EffectiveTargets()
+	require.NoError(t, err)
+	resolved := make(map[string]data.RoleName)
+	var names []string
+	for _, target := range effective {
+		names = append(names, target.Name)
+		resolved[target.Name] = target.Role
+		byName, err := reader.GetTargetByName(target.Name)
+		require.NoError(t, err)
+		require.Equal(t, byName, target)
+	}
+	require.Equal(t, []string{"deep", "only-a", "only-b", "shared", "top"}, names)
+	require.Equal(t, map[string]data.RoleName{
+		"top":    data.CanonicalTargetsRole,
+		"shared": "targets/a",
+		"only-a": "targets/a",
+		"only-b": "targets/b",
+		"deep":   "targets/a/c",
+	}, resolved)
+
+	empty, _, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	effective, err = NewReadOnly(empty).EffectiveTargets()
+	require.NoError(t, err)
+	require.Len(t, effective, 0)
+}
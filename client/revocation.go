@@ -0,0 +1,162 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// RevocationTargetName is the reserved name of the target which marks a
+// repository as revoked.  Its custom data holds the revocation, signed by the
+// root keys.
+const RevocationTargetName = "_notary_revocation"
+
+// revocation is the signed record embedded in the revocation target
+type revocation struct {
+	GUN       data.GUN  `json:"gun"`
+	Reason    string    `json:"reason"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+// RevokeRepository publishes a terminal state for the repository: every target
+// and delegation is removed from the targets role, and replaced with a single
+// marker target recording the reason, signed by the root keys.  Clients that
+// load the repository afterwards fail with ErrRepositoryRevoked, so it can no
+// longer be read from or published to.  The root keys must be available locally,
+// since only the holder of the root keys may revoke a repository.
+func (r *repository) RevokeRepository(reason string) error {
+	if err := r.updateTUF(true); err != nil {
+		return err
+	}
+	rootRole, err := r.tufRepo.GetBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return err
+	}
+
+	record, err := canonicaljson.MarshalCanonical(revocation{
+		GUN:       r.gun,
+		Reason:    reason,
+		RevokedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	raw := canonicaljson.RawMessage(record)
+	s := &data.Signed{Signed: &raw}
+	if err := signed.Sign(r.cryptoService, s, rootRole.ListKeys(), rootRole.Threshold, nil); err != nil {
+		return err
+	}
+	signedRecord, err := canonicalize(s)
+	if err != nil {
+		return err
+	}
+	custom := canonicaljson.RawMessage(signedRecord)
+
+	meta, err := data.NewFileMeta(bytes.NewReader(record), data.NotaryDefaultHashes...)
+	if err != nil {
+		return err
+	}
+	meta.Custom = &custom
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	cl := changelist.NewMemChangelist()
+	targets := r.tufRepo.Targets[data.CanonicalTargetsRole]
+	for name := range targets.Signed.Targets {
+		if err := cl.Add(changelist.NewTUFChange(changelist.ActionDelete, data.CanonicalTargetsRole,
+			changelist.TypeTargetsTarget, name, nil)); err != nil {
+			return err
+		}
+	}
+	// removing the top level delegations removes the whole delegation tree
+	for _, role := range targets.Signed.Delegations.Roles {
+		if err := cl.Add(newDeleteDelegationChange(role.Name, nil)); err != nil {
+			return err
+		}
+	}
+	if err := cl.Add(changelist.NewTUFChange(changelist.ActionCreate, data.CanonicalTargetsRole,
+		changelist.TypeTargetsTarget, RevocationTargetName, metaJSON)); err != nil {
+		return err
+	}
+	logrus.Infof("Revoking repository %s: %s", r.gun, reason)
+	return r.publish(cl)
+}
+
+// canonicalize returns the canonical JSON encoding of v.  It is decoded
+// generically first, since the encoder caches a struct's fields in the order of
+// whichever encoding of that type happened first, which need not be canonical,
+// and the custom data must round-trip exactly for the targets signatures to
+// verify.
+func canonicalize(v interface{}) ([]byte, error) {
+	encoded, err := canonicaljson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := canonicaljson.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return canonicaljson.MarshalCanonical(decoded)
+}
+
+// checkRevoked returns ErrRepositoryRevoked if the repository's targets contain
+// a revocation marker signed by the root keys.  A marker that is not validly
+// signed by the root is ignored with a warning, since anyone holding only the
+// targets key must not be able to revoke the repository.
+func checkRevoked(gun data.GUN, repo *tuf.Repo) error {
+	targets, ok := repo.Targets[data.CanonicalTargetsRole]
+	if !ok {
+		return nil
+	}
+	meta, ok := targets.Signed.Targets[RevocationTargetName]
+	if !ok {
+		return nil
+	}
+	record, err := verifyRevocation(gun, repo, meta)
+	if err != nil {
+		logrus.Warnf("ignoring invalid revocation marker: %s", err)
+		return nil
+	}
+	return ErrRepositoryRevoked{GUN: gun, Reason: record.Reason}
+}
+
+// verifyRevocation checks that the revocation in the marker's custom data is
+// signed by the root keys and is for this repository
+func verifyRevocation(gun data.GUN, repo *tuf.Repo, meta data.FileMeta) (*revocation, error) {
+	if meta.Custom == nil {
+		return nil, errors.New("no custom data")
+	}
+	s := &data.Signed{}
+	if err := canonicaljson.Unmarshal(*meta.Custom, s); err != nil {
+		return nil, err
+	}
+	if s.Signed == nil {
+		return nil, errors.New("no signed content")
+	}
+	rootRole, err := repo.GetBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return nil, err
+	}
+	if err := signed.VerifySignatures(s, rootRole); err != nil {
+		return nil, err
+	}
+	record := &revocation{}
+	if err := canonicaljson.Unmarshal(*s.Signed, record); err != nil {
+		return nil, err
+	}
+	if record.GUN != gun {
+		return nil, fmt.Errorf("revocation is for %s, not %s", record.GUN, gun)
+	}
+	return record, nil
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// After a repository is revoked, resolving targets from it fails with the
+// revocation error for any client, and it can no longer be published to
+func TestRevokeRepository(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	delgKey, err := repo.GetCryptoService().Create("targets/a", repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{delgKey}, []string{""}))
+	require.NoError(t, repo.Publish())
+
+	require.NoError(t, repo.RevokeRepository("keys compromised"))
+
+	// the published targets hold nothing but the revocation marker
+	targetsJSON, err := repo.getRemoteStore().GetSized(data.CanonicalTargetsRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(targetsJSON, s))
+	targets, err := data.TargetsFromSigned(s, data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(targets.Signed.Targets))
+	_, ok := targets.Signed.Targets[RevocationTargetName]
+	require.True(t, ok)
+	require.Empty(t, targets.Signed.Delegations.Roles)
+
+	repo2, _, baseDir2 := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(baseDir2)
+	for _, r := range []*repository{repo, repo2} {
+		_, err = r.GetTargetByName("latest")
+		require.Error(t, err)
+		revoked, ok := err.(ErrRepositoryRevoked)
+		require.True(t, ok, "expected ErrRepositoryRevoked, got %v", err)
+		require.Equal(t, "keys compromised", revoked.Reason)
+		require.Equal(t, repo.gun, revoked.GUN)
+
+		_, err = r.ListTargets()
+		require.IsType(t, ErrRepositoryRevoked{}, err)
+		_, err = r.ListTargets("targets/a")
+		require.IsType(t, ErrRepositoryRevoked{}, err)
+		_, err = r.GetAllTargetMetadataByName("")
+		require.IsType(t, ErrRepositoryRevoked{}, err)
+	}
+
+	addTarget(t, repo, "current", "../fixtures/intermediate-ca.crt")
+	require.IsType(t, ErrRepositoryRevoked{}, repo.Publish())
+}
+
+// A revocation marker which is not signed by the root keys, for instance one
+// added by someone holding only the targets key, is ignored
+func TestRevocationMarkerNotSignedByRoot(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	cs := repo.GetCryptoService()
+	targetsKeys := cs.ListKeys(data.CanonicalTargetsRole)
+	require.Len(t, targetsKeys, 1)
+	record, err := json.MarshalCanonical(revocation{GUN: repo.gun, Reason: "forged"})
+	require.NoError(t, err)
+	raw := json.RawMessage(record)
+	s := &data.Signed{Signed: &raw}
+	require.NoError(t, signed.Sign(cs, s, []data.PublicKey{cs.GetKey(targetsKeys[0])}, 1, nil))
+	signedRecord, err := canonicalize(s)
+	require.NoError(t, err)
+	custom := json.RawMessage(signedRecord)
+	target, err := NewTarget(RevocationTargetName, "../fixtures/intermediate-ca.crt", &custom)
+	require.NoError(t, err)
+	require.NoError(t, repo.AddTarget(target, data.CanonicalTargetsRole))
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	_, err = repo.GetTargetByName("latest")
+	require.NoError(t, err)
+}
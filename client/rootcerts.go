@@ -0,0 +1,203 @@
+package client
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// ErrNoRootCertificateChain is returned when none of the keys which signed the
+// trusted root wraps a certificate chain, because bare keys are used.  Trust
+// pinning only accepts roots signed by certificate-backed keys, so this is
+// only returned for roots trusted by other means.
+type ErrNoRootCertificateChain struct {
+	GUN data.GUN
+}
+
+func (err ErrNoRootCertificateChain) Error() string {
+	return fmt.Sprintf("the root of %s is not signed by any key with a certificate chain", err.GUN)
+}
+
+// ErrInvalidRootCertificateChain is returned when the certificate chain of
+// every certificate-backed key which signed the trusted root is inconsistent.
+// The KeyID and Problems are those of the first such key, by key ID.
+type ErrInvalidRootCertificateChain struct {
+	KeyID    string
+	Problems []string
+}
+
+func (err ErrInvalidRootCertificateChain) Error() string {
+	return fmt.Sprintf("the certificate chain of root key %s is invalid: %s",
+		err.KeyID, strings.Join(err.Problems, "; "))
+}
+
+// RootCertificateStatus is the advisory report on the certificate chain of a
+// single x509-wrapped root key
+type RootCertificateStatus struct {
+	KeyID string
+	// Chain holds the certificates wrapped in the key, leaf first
+	Chain []*x509.Certificate
+	// Problems describes every inconsistency found in the chain, such as a
+	// certificate outside its validity period, or one which was not issued by
+	// the next certificate in the chain
+	Problems []string
+}
+
+// Valid returns whether no problems were found with the chain
+func (s RootCertificateStatus) Valid() bool {
+	return len(s.Problems) == 0
+}
+
+// RootCertificates returns the certificates wrapped in the trusted root's
+// x509 root keys, ordered by key ID, each key's leaf certificate followed by
+// its intermediates.  Root keys which are not x509-wrapped are skipped.
+func (r *repository) RootCertificates() ([]*x509.Certificate, error) {
+	statuses, err := r.RootCertificateReport()
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for _, status := range statuses {
+		certs = append(certs, status.Chain...)
+	}
+	return certs, nil
+}
+
+// RootCertificateReport checks the internal consistency of the certificate
+// chain of every x509-wrapped root key: that each certificate is within its
+// validity period, the chain starts with its only leaf certificate, and each
+// certificate was issued by the next one.  The report is advisory, as the
+// root has already been verified by the update; an error is only returned if
+// the repository could not be updated.
+func (r *repository) RootCertificateReport() ([]RootCertificateStatus, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	now, err := trustedClock(r.trustedTime)
+	if err != nil {
+		return nil, err
+	}
+	return rootCertificateStatuses(r.tufRepo, now())
+}
+
+// RootCertificateChain returns the certificate chain of a key which signed
+// the trusted root, ordered from its leaf certificate towards the root
+// certificate.  The repository is updated first, so the chain is only
+// returned once the root has been verified.  Of the root keys, ordered by key
+// ID, whose signatures on the root are valid, the first whose certificate
+// chain is consistent, as checked by RootCertificateReport, is used.
+// ErrNoRootCertificateChain is returned if no such key wraps certificates, and
+// ErrInvalidRootCertificateChain if none of their chains is consistent.
+func (r *repository) RootCertificateChain() ([]*x509.Certificate, error) {
+	if err := r.updateTUF(false); err != nil {
+		return nil, err
+	}
+	now, err := trustedClock(r.trustedTime)
+	if err != nil {
+		return nil, err
+	}
+	return rootCertificateChain(r.gun, r.tufRepo, now())
+}
+
+func rootCertificateChain(gun data.GUN, repo *tuf.Repo, now time.Time) ([]*x509.Certificate, error) {
+	statuses, err := rootCertificateStatuses(repo, now)
+	if err != nil {
+		return nil, err
+	}
+	rootRole, err := repo.GetBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return nil, err
+	}
+	rootSigned, err := repo.Root.ToSigned()
+	if err != nil {
+		return nil, err
+	}
+	signers, err := signed.ValidSignatureKeyIDs(rootSigned, rootRole)
+	if err != nil {
+		return nil, err
+	}
+	signedBy := make(map[string]bool, len(signers))
+	for _, keyID := range signers {
+		signedBy[keyID] = true
+	}
+
+	var invalid *RootCertificateStatus
+	for i, status := range statuses {
+		if !signedBy[status.KeyID] {
+			continue
+		}
+		if status.Valid() && len(status.Chain) > 0 {
+			return status.Chain, nil
+		}
+		if invalid == nil {
+			invalid = &statuses[i]
+		}
+	}
+	if invalid != nil {
+		return nil, ErrInvalidRootCertificateChain{KeyID: invalid.KeyID, Problems: invalid.Problems}
+	}
+	return nil, ErrNoRootCertificateChain{GUN: gun}
+}
+
+func rootCertificateStatuses(repo *tuf.Repo, now time.Time) ([]RootCertificateStatus, error) {
+	rootRole, err := repo.GetBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return nil, err
+	}
+	keyIDs := rootRole.ListKeyIDs()
+	sort.Strings(keyIDs)
+
+	var statuses []RootCertificateStatus
+	for _, keyID := range keyIDs {
+		key := rootRole.Keys[keyID]
+		switch key.Algorithm() {
+		case data.ECDSAx509Key, data.RSAx509Key:
+		default:
+			continue
+		}
+		status := RootCertificateStatus{KeyID: keyID}
+		status.Chain, err = utils.LoadCertBundleFromPEM(key.Public())
+		if err != nil {
+			status.Problems = append(status.Problems, fmt.Sprintf("unable to parse certificates: %v", err))
+		}
+		status.Problems = append(status.Problems, chainProblems(status.Chain, now)...)
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// chainProblems describes the inconsistencies in a certificate chain, which
+// should be ordered leaf first
+func chainProblems(chain []*x509.Certificate, now time.Time) []string {
+	var problems []string
+	if len(chain) > 0 && chain[0].IsCA {
+		problems = append(problems, "the chain does not start with a leaf certificate")
+	}
+	if leaves := utils.GetLeafCerts(chain); len(chain) > 0 && len(leaves) != 1 {
+		problems = append(problems, fmt.Sprintf("the chain has %d leaf certificates, rather than 1", len(leaves)))
+	}
+	for i, cert := range chain {
+		switch {
+		case now.Before(cert.NotBefore):
+			problems = append(problems, fmt.Sprintf("certificate %q is not valid until %s",
+				cert.Subject.CommonName, cert.NotBefore.Format(time.RFC3339)))
+		case now.After(cert.NotAfter):
+			problems = append(problems, fmt.Sprintf("certificate %q expired at %s",
+				cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339)))
+		}
+		if i+1 < len(chain) {
+			if err := cert.CheckSignatureFrom(chain[i+1]); err != nil {
+				problems = append(problems, fmt.Sprintf("certificate %q was not issued by %q: %v",
+					cert.Subject.CommonName, chain[i+1].Subject.CommonName, err))
+			}
+		}
+	}
+	return problems
+}
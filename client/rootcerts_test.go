@@ -0,0 +1,179 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/cryptoservice"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// createRootKey creates a root key in the crypto service and returns its
+// private key
+func createRootKey(t *testing.T, cs signed.CryptoService, gun data.GUN) data.PrivateKey {
+	pubKey, err := cs.Create(data.CanonicalRootRole, gun, data.ECDSAKey)
+	require.NoError(t, err)
+	privKey, _, err := cs.GetPrivateKey(pubKey.ID())
+	require.NoError(t, err)
+	return privKey
+}
+
+// createCASignedRootKey creates a root key wrapping a leaf certificate issued
+// by a CA, returning the key and its chain
+func createCASignedRootKey(t *testing.T, cs signed.CryptoService, gun data.GUN) (data.PublicKey, []*x509.Certificate) {
+	start := time.Now().AddDate(0, 0, -1)
+	caKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+	caTemplate, err := utils.NewCertificate("Notary Testing CA", start, start.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	caTemplate.IsCA = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.CryptoSigner().Public(), caKey.CryptoSigner())
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	leafKey := createRootKey(t, cs, gun)
+	leafTemplate, err := utils.NewCertificate(gun.String(), start, start.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caCert, leafKey.CryptoSigner().Public(), caKey.CryptoSigner())
+	require.NoError(t, err)
+	leafCert, err := x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+	return data.NewECDSAx509PublicKey(append(utils.CertToPEM(leafCert), utils.CertToPEM(caCert)...)),
+		[]*x509.Certificate{leafCert, caCert}
+}
+
+// createExpiredRootKey creates a root key wrapping an expired certificate
+func createExpiredRootKey(t *testing.T, cs signed.CryptoService, gun data.GUN) data.PublicKey {
+	start := time.Now().AddDate(0, 0, -1)
+	expiredCert, err := cryptoservice.GenerateCertificate(
+		createRootKey(t, cs, gun), gun, start.AddDate(-1, 0, 0), start.AddDate(0, 0, -1))
+	require.NoError(t, err)
+	return data.NewECDSAx509PublicKey(utils.CertToPEM(expiredCert))
+}
+
+// rootKeysRepo serves the repo with the given root keys, all of which sign the
+// root, and returns a client for it and a cleanup function
+func rootKeysRepo(t *testing.T, gun data.GUN, tufRepo *tuf.Repo, cs signed.CryptoService,
+	rootKeys ...data.PublicKey) (*repository, func()) {
+
+	require.NoError(t, tufRepo.ReplaceBaseKeys(data.CanonicalRootRole, rootKeys...))
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	ts := readOnlyServer(t, testutils.NewMetadataSwizzler(gun, meta, cs).MetadataCache, http.StatusNotFound, gun)
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	return repo, func() {
+		ts.Close()
+		os.RemoveAll(baseDir)
+	}
+}
+
+// The report on a root with one key wrapping a leaf certificate issued by a CA,
+// and one key wrapping an expired certificate, flags only the expired one
+func TestRootCertificateReport(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	chainKey, _ := createCASignedRootKey(t, cs, gun)
+	expiredKey := createExpiredRootKey(t, cs, gun)
+	repo, cleanup := rootKeysRepo(t, gun, tufRepo, cs, chainKey, expiredKey)
+	defer cleanup()
+
+	statuses, err := repo.RootCertificateReport()
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	byKeyID := make(map[string]RootCertificateStatus)
+	for _, status := range statuses {
+		byKeyID[status.KeyID] = status
+	}
+
+	chainStatus := byKeyID[chainKey.ID()]
+	require.True(t, chainStatus.Valid(), "unexpected problems: %v", chainStatus.Problems)
+	require.Len(t, chainStatus.Chain, 2)
+	require.Equal(t, gun.String(), chainStatus.Chain[0].Subject.CommonName)
+	require.Equal(t, "Notary Testing CA", chainStatus.Chain[0].Issuer.CommonName)
+
+	expiredStatus := byKeyID[expiredKey.ID()]
+	require.False(t, expiredStatus.Valid())
+	require.Len(t, expiredStatus.Problems, 1)
+	require.Contains(t, expiredStatus.Problems[0], "expired")
+
+	certs, err := repo.RootCertificates()
+	require.NoError(t, err)
+	require.Len(t, certs, 3)
+}
+
+// A chain whose leaf was not issued by the certificate which follows it is
+// reported as inconsistent
+func TestRootCertificateChainProblems(t *testing.T) {
+	start := time.Now().AddDate(0, 0, -1)
+	key, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+	leaf, err := cryptoservice.GenerateCertificate(key, "docker.com/notary", start, start.AddDate(1, 0, 0))
+	require.NoError(t, err)
+	other, err := cryptoservice.GenerateCertificate(key, "docker.com/other", start, start.AddDate(1, 0, 0))
+	require.NoError(t, err)
+
+	require.Empty(t, chainProblems([]*x509.Certificate{leaf}, time.Now()))
+	problems := chainProblems([]*x509.Certificate{leaf, other}, time.Now())
+	require.Len(t, problems, 2)
+	require.Contains(t, problems[0], "2 leaf certificates")
+	require.Contains(t, problems[1], "was not issued by")
+}
+
+// The chain of the certificate-backed key which signed the root is returned,
+// leaf first, and skips keys whose chains are inconsistent
+func TestRootCertificateChain(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	chainKey, chain := createCASignedRootKey(t, cs, gun)
+	repo, cleanup := rootKeysRepo(t, gun, tufRepo, cs, chainKey, createExpiredRootKey(t, cs, gun))
+	defer cleanup()
+
+	certs, err := repo.RootCertificateChain()
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	require.True(t, chain[0].Equal(certs[0]))
+	require.True(t, chain[1].Equal(certs[1]))
+	require.Equal(t, gun.String(), certs[0].Subject.CommonName)
+	require.Equal(t, "Notary Testing CA", certs[1].Subject.CommonName)
+}
+
+// A root signed only by a key whose leaf certificate was not issued by the CA
+// certificate following it has no valid chain, and one signed by bare keys,
+// which trust pinning would not accept, has none at all
+func TestRootCertificateChainUnavailable(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	_, chain := createCASignedRootKey(t, cs, gun)
+	_, otherChain := createCASignedRootKey(t, cs, gun)
+	mismatchedKey := data.NewECDSAx509PublicKey(append(utils.CertToPEM(chain[0]), utils.CertToPEM(otherChain[1])...))
+	repo, cleanup := rootKeysRepo(t, gun, tufRepo, cs, mismatchedKey)
+	defer cleanup()
+	_, err = repo.RootCertificateChain()
+	require.IsType(t, ErrInvalidRootCertificateChain{}, err)
+	require.Equal(t, mismatchedKey.ID(), err.(ErrInvalidRootCertificateChain).KeyID)
+
+	tufRepo, cs, err = testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	bareKey, err := cs.Create(data.CanonicalRootRole, gun, data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, tufRepo.ReplaceBaseKeys(data.CanonicalRootRole, bareKey))
+	_, err = tufRepo.SignRoot(data.DefaultExpires(data.CanonicalRootRole), nil)
+	require.NoError(t, err)
+	certs, err := rootCertificateChain(gun, tufRepo, time.Now())
+	require.Equal(t, ErrNoRootCertificateChain{GUN: gun}, err)
+	require.Empty(t, certs)
+}
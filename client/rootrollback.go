@@ -0,0 +1,84 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// RootRollbackPolicy determines what an update does when the remote root has
+// a lower version than the trusted root in the cache, for instance after the
+// server was rolled back or restored from a backup
+type RootRollbackPolicy int
+
+// The possible ways of handling a remote root older than the trusted one
+const (
+	// RootRollbackReject treats the remote root as a rollback attack, failing
+	// the update with ErrRollbackAttack
+	RootRollbackReject RootRollbackPolicy = iota
+	// RootRollbackTrustRemoteLower ("trust-remote-lower") trusts the remote
+	// root, and the remote metadata it signs, in place of the newer cached
+	// metadata, logging a warning.  It is meant for deliberately recovering
+	// from a server restore.  The remote root must still be signed by a
+	// threshold of the trusted root's keys.
+	RootRollbackTrustRemoteLower
+)
+
+// ErrRollbackAttack is returned, under RootRollbackReject, when the remote
+// root has a lower version than the trusted root
+type ErrRollbackAttack struct {
+	Role           data.RoleName
+	TrustedVersion int
+	RemoteVersion  int
+}
+
+func (err ErrRollbackAttack) Error() string {
+	return fmt.Sprintf("possible rollback attack: the remote %s is version %d, lower than the trusted version %d",
+		err.Role, err.RemoteVersion, err.TrustedVersion)
+}
+
+// SetRootRollbackPolicy sets how updates handle a remote root with a lower
+// version than the trusted root.  By default such a root is rejected with
+// ErrRollbackAttack.  RootRollbackTrustRemoteLower should only be set for as
+// long as it takes to recover from a deliberate rollback of the server.
+func (r *repository) SetRootRollbackPolicy(policy RootRollbackPolicy) {
+	r.rootRollback = policy
+}
+
+// handleRootRollback applies the policy to the downloaded root, which has a
+// lower version than the trusted version, returning the error the update
+// should fail with, if any
+func (c *tufClient) handleRootRollback(raw []byte, trustedVersion int) error {
+	signedRoot := &data.Signed{}
+	if err := data.UnmarshalMetadata(raw, signedRoot); err != nil {
+		return err
+	}
+	remoteRoot, err := data.RootFromSigned(signedRoot)
+	if err != nil {
+		return err
+	}
+	remoteVersion := remoteRoot.Signed.SignedCommon.Version
+
+	if c.rootRollback != RootRollbackTrustRemoteLower {
+		return ErrRollbackAttack{
+			Role:           data.CanonicalRootRole,
+			TrustedVersion: trustedVersion,
+			RemoteVersion:  remoteVersion,
+		}
+	}
+
+	// still verified against the trusted root, just not its version
+	if err := c.newBuilder.LoadRootForUpdate(raw, 1, true); err != nil {
+		logrus.Debugf("downloaded %d.%s is invalid: %s", remoteVersion, data.CanonicalRootRole, err)
+		return err
+	}
+	logrus.Warnf("trusting version %d of the remote %s, lower than the trusted version %d, as configured to recover from a rollback",
+		remoteVersion, data.CanonicalRootRole, trustedVersion)
+	c.rolledBack = true
+
+	if err := c.cache.Set(data.CanonicalRootRole.String(), raw); err != nil {
+		logrus.Debugf("unable to write %d.%s to cache: %s", remoteVersion, data.CanonicalRootRole, err)
+	}
+	return nil
+}
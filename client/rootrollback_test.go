@@ -0,0 +1,92 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// cachedRootVersion returns the version of the root in the repository's cache
+func cachedRootVersion(t *testing.T, repo *repository) int {
+	raw, err := repo.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	signedRoot := &data.Signed{}
+	require.NoError(t, data.UnmarshalMetadata(raw, signedRoot))
+	root, err := data.RootFromSigned(signedRoot)
+	require.NoError(t, err)
+	return root.Signed.Version
+}
+
+// Once the client trusts version 2 of the root, the server serving version 1
+// again is rejected as a rollback attack by default, and trusted, along with
+// the older metadata it signs, under the override
+func TestRootRollback(t *testing.T) {
+	serverMeta, cs, err := testutils.NewRepoMetadata("docker.com/notary", metadataDelegations...)
+	require.NoError(t, err)
+	serverSwizzler := testutils.NewMetadataSwizzler("docker.com/notary", serverMeta, cs)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	bumpVersions(t, serverSwizzler, 1)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	require.Equal(t, 2, cachedRootVersion(t, repo))
+
+	// restore the server from its "backup"
+	for role, meta := range serverMeta {
+		require.NoError(t, serverSwizzler.MetadataCache.Set(role.String(), meta))
+	}
+
+	_, err = repo.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, ErrRollbackAttack{}, err)
+	require.Equal(t, ErrRollbackAttack{Role: data.CanonicalRootRole, TrustedVersion: 2, RemoteVersion: 1}, err)
+	require.Equal(t, 2, cachedRootVersion(t, repo))
+
+	repo.SetRootRollbackPolicy(RootRollbackTrustRemoteLower)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	require.Equal(t, 1, cachedRootVersion(t, repo))
+	require.Equal(t, 1, repo.tufRepo.Root.Signed.Version)
+	require.Equal(t, 1, repo.tufRepo.Timestamp.Signed.Version)
+
+	// now that version 1 is trusted, the override is no longer needed
+	repo.SetRootRollbackPolicy(RootRollbackReject)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+}
+
+// A lower remote root is only trusted under the override if it is signed by
+// the trusted root's keys
+func TestRootRollbackRequiresTrustedSignatures(t *testing.T) {
+	serverMeta, cs, err := testutils.NewRepoMetadata("docker.com/notary", metadataDelegations...)
+	require.NoError(t, err)
+	serverSwizzler := testutils.NewMetadataSwizzler("docker.com/notary", serverMeta, cs)
+	ts := readOnlyServer(t, serverSwizzler.MetadataCache, http.StatusNotFound, "docker.com/notary")
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetRootRollbackPolicy(RootRollbackTrustRemoteLower)
+
+	bumpVersions(t, serverSwizzler, 1)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+
+	for role, meta := range serverMeta {
+		require.NoError(t, serverSwizzler.MetadataCache.Set(role.String(), meta))
+	}
+	require.NoError(t, serverSwizzler.SignMetadataWithInvalidKey(data.CanonicalRootRole))
+
+	_, err = repo.ListTargets()
+	require.Error(t, err)
+	require.Equal(t, 2, cachedRootVersion(t, repo))
+}
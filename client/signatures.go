@@ -0,0 +1,203 @@
+package client
+
+import (
+	"sort"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// SignatureRequirement describes how far a role's metadata is from meeting its
+// signing threshold
+type SignatureRequirement struct {
+	// Threshold is the number of valid signatures the role requires
+	Threshold int
+	// ValidSignatures is the number of valid signatures currently attached
+	ValidSignatures int
+	// UnsignedKeyIDs are the authorized key IDs that have not yet signed
+	UnsignedKeyIDs []string
+}
+
+// Needed returns how many more valid signatures are required to meet the threshold
+func (s SignatureRequirement) Needed() int {
+	if s.ValidSignatures >= s.Threshold {
+		return 0
+	}
+	return s.Threshold - s.ValidSignatures
+}
+
+// OutstandingSignatures reports, for every role whose locally stored metadata
+// does not yet meet its signing threshold, the threshold, the number of valid
+// signatures currently attached, and which authorized keys have not yet signed.
+// Base roles are checked against the keys in the local root, and delegations
+// against the keys their parent authorizes.  Since partially signed metadata
+// cannot be verified, this reads the local cache without updating from the
+// remote, and does not verify the root's own threshold.
+func (r *repository) OutstandingSignatures() (map[data.RoleName]SignatureRequirement, error) {
+	rootSigned, err := r.cachedSigned(data.CanonicalRootRole)
+	if err != nil {
+		if _, ok := err.(store.ErrMetaNotFound); ok {
+			return nil, ErrRepoNotInitialized{}
+		}
+		return nil, err
+	}
+	root, err := data.RootFromSigned(rootSigned)
+	if err != nil {
+		return nil, err
+	}
+
+	outstanding := make(map[data.RoleName]SignatureRequirement)
+	for _, roleName := range data.BaseRoles {
+		s := rootSigned
+		if roleName != data.CanonicalRootRole {
+			if s, err = r.cachedSigned(roleName); err != nil {
+				if _, ok := err.(store.ErrMetaNotFound); ok {
+					continue
+				}
+				return nil, err
+			}
+		}
+		role, err := root.BuildBaseRole(roleName)
+		if err != nil {
+			return nil, err
+		}
+		if err := addOutstandingSignatures(outstanding, s, role); err != nil {
+			return nil, err
+		}
+	}
+
+	// walk the delegations that have local metadata, parents first, since the
+	// parent defines the keys and threshold for its children
+	toVisit := []data.RoleName{data.CanonicalTargetsRole}
+	for len(toVisit) > 0 {
+		parentName := toVisit[0]
+		toVisit = toVisit[1:]
+
+		s, err := r.cachedSigned(parentName)
+		if err != nil {
+			if _, ok := err.(store.ErrMetaNotFound); ok {
+				continue
+			}
+			return nil, err
+		}
+		parent, err := data.TargetsFromSigned(s, parentName)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range parent.Signed.Delegations.Roles {
+			childRole, err := parent.BuildDelegationRole(child.Name)
+			if err != nil {
+				return nil, err
+			}
+			childSigned, err := r.cachedSigned(child.Name)
+			if err != nil {
+				if _, ok := err.(store.ErrMetaNotFound); ok {
+					continue
+				}
+				return nil, err
+			}
+			if err := addOutstandingSignatures(outstanding, childSigned, childRole.BaseRole); err != nil {
+				return nil, err
+			}
+			toVisit = append(toVisit, child.Name)
+		}
+	}
+	return outstanding, nil
+}
+
+// addOutstandingSignatures records the signature requirement for the role if
+// the signed metadata does not meet the role's threshold
+func addOutstandingSignatures(outstanding map[data.RoleName]SignatureRequirement, s *data.Signed, role data.BaseRole) error {
+	validIDs, err := signed.ValidSignatureKeyIDs(s, role)
+	if err != nil {
+		return err
+	}
+	if len(validIDs) >= role.Threshold {
+		return nil
+	}
+
+	signedBy := make(map[string]struct{})
+	for _, keyID := range validIDs {
+		signedBy[keyID] = struct{}{}
+	}
+	unsigned := []string{}
+	for _, keyID := range role.ListKeyIDs() {
+		if _, ok := signedBy[keyID]; !ok {
+			unsigned = append(unsigned, keyID)
+		}
+	}
+	sort.Strings(unsigned)
+	outstanding[role.Name] = SignatureRequirement{
+		Threshold:       role.Threshold,
+		ValidSignatures: len(validIDs),
+		UnsignedKeyIDs:  unsigned,
+	}
+	return nil
+}
+
+// cachedSigned reads and parses the locally cached metadata for a role
+func (r *repository) cachedSigned(role data.RoleName) (*data.Signed, error) {
+	raw, err := r.cache.GetSized(role.String(), store.NoSizeLimit)
+	if err != nil {
+		return nil, err
+	}
+	s := &data.Signed{}
+	if err := data.UnmarshalMetadata(raw, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// EligibleSigningKeys returns the sorted canonical IDs of the keys which are
+// both authorized to sign the role by the current metadata, and available in
+// the local key stores.  If the repository has not yet been published, the role
+// is looked up in the locally stored metadata.
+func (r *repository) EligibleSigningKeys(role data.RoleName) ([]string, error) {
+	if err := r.updateTUF(false); err != nil {
+		if _, ok := err.(ErrRepositoryNotExist); !ok {
+			return nil, err
+		}
+		if err := r.bootstrapRepo(); err != nil {
+			return nil, err
+		}
+	}
+	return r.localSigningKeys(role)
+}
+
+// localSigningKeys returns the sorted canonical IDs of the locally available
+// keys authorized to sign the role by the already loaded metadata
+func (r *repository) localSigningKeys(role data.RoleName) ([]string, error) {
+	var authorized data.BaseRole
+	if data.IsDelegation(role) {
+		delgRole, err := r.tufRepo.GetDelegationRole(role)
+		if err != nil {
+			return nil, err
+		}
+		authorized = delgRole.BaseRole
+	} else {
+		baseRole, err := r.tufRepo.GetBaseRole(role)
+		if err != nil {
+			return nil, err
+		}
+		authorized = baseRole
+	}
+
+	// list the held keys rather than loading each one, which could prompt
+	// for a passphrase or a hardware token
+	held := r.cryptoService.ListAllKeys()
+	eligible := []string{}
+	for _, key := range authorized.Keys {
+		canonicalID, err := utils.CanonicalKeyID(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := held[canonicalID]; !ok {
+			continue
+		}
+		eligible = append(eligible, canonicalID)
+	}
+	sort.Strings(eligible)
+	return eligible, nil
+}
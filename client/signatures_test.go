@@ -0,0 +1,176 @@
+package client
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/cryptoservice"
+	"github.com/theupdateframework/notary/passphrase"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustmanager"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A 2-of-3 role signed by one key reports that it needs one more signature from
+// either of the two remaining keys, and fully signed roles are not reported
+func TestOutstandingSignatures(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+
+	// the other two targets keys are held by someone else
+	otherCS := cryptoservice.NewCryptoService(trustmanager.NewKeyMemoryStore(passphrase.ConstantRetriever("pass")))
+	var otherKeyIDs []string
+	for i := 0; i < 2; i++ {
+		key, err := testutils.CreateKey(otherCS, gun, data.CanonicalTargetsRole, data.ECDSAKey)
+		require.NoError(t, err)
+		require.NoError(t, tufRepo.AddBaseKeys(data.CanonicalTargetsRole, key))
+		otherKeyIDs = append(otherKeyIDs, key.ID())
+	}
+	sort.Strings(otherKeyIDs)
+	tufRepo.Root.Signed.Roles[data.CanonicalTargetsRole].Threshold = 2
+	rootSigned, err := tufRepo.SignRoot(data.DefaultExpires(data.CanonicalRootRole), nil)
+	require.NoError(t, err)
+	meta[data.CanonicalRootRole], err = json.Marshal(rootSigned)
+	require.NoError(t, err)
+
+	// sign the targets with only the key this client holds
+	targetsKeyIDs := tufRepo.Root.Signed.Roles[data.CanonicalTargetsRole].KeyIDs
+	var ownKey data.PublicKey
+	for _, keyID := range targetsKeyIDs {
+		if cs.GetKey(keyID) != nil {
+			ownKey = tufRepo.Root.Signed.Keys[keyID]
+		}
+	}
+	require.NotNil(t, ownKey)
+	targetsSigned, err := tufRepo.Targets[data.CanonicalTargetsRole].ToSigned()
+	require.NoError(t, err)
+	require.NoError(t, signed.Sign(cs, targetsSigned, []data.PublicKey{ownKey}, 1, nil))
+	meta[data.CanonicalTargetsRole], err = json.Marshal(targetsSigned)
+	require.NoError(t, err)
+
+	r, err := NewRepository(gun, "", nil, store.NewMemoryStore(meta), trustpinning.TrustPinConfig{},
+		cs, changelist.NewMemChangelist())
+	require.NoError(t, err)
+
+	outstanding, err := r.OutstandingSignatures()
+	require.NoError(t, err)
+	require.Equal(t, map[data.RoleName]SignatureRequirement{
+		data.CanonicalTargetsRole: {
+			Threshold:       2,
+			ValidSignatures: 1,
+			UnsignedKeyIDs:  otherKeyIDs,
+		},
+	}, outstanding)
+	require.Equal(t, 1, outstanding[data.CanonicalTargetsRole].Needed())
+}
+
+// Delegations are checked against the keys their parent authorizes
+func TestOutstandingSignaturesDelegation(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/a")
+	require.NoError(t, err)
+	_, err = tufRepo.InitTargets("targets/a")
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+
+	// strip the signatures from the delegation
+	delgSigned := &data.Signed{}
+	require.NoError(t, json.Unmarshal(meta["targets/a"], delgSigned))
+	delgSigned.Signatures = nil
+	meta["targets/a"], err = json.Marshal(delgSigned)
+	require.NoError(t, err)
+
+	r, err := NewRepository(gun, "", nil, store.NewMemoryStore(meta), trustpinning.TrustPinConfig{},
+		cs, changelist.NewMemChangelist())
+	require.NoError(t, err)
+
+	outstanding, err := r.OutstandingSignatures()
+	require.NoError(t, err)
+	require.Len(t, outstanding, 1)
+	delgRole, err := tufRepo.GetDelegationRole("targets/a")
+	require.NoError(t, err)
+	require.Equal(t, SignatureRequirement{
+		Threshold:       1,
+		ValidSignatures: 0,
+		UnsignedKeyIDs:  delgRole.ListKeyIDs(),
+	}, outstanding["targets/a"])
+}
+
+func TestOutstandingSignaturesUninitialized(t *testing.T) {
+	r, err := NewRepository("docker.com/notary", "", nil, store.NewMemoryStore(nil), trustpinning.TrustPinConfig{},
+		nil, changelist.NewMemChangelist())
+	require.NoError(t, err)
+	_, err = r.OutstandingSignatures()
+	require.IsType(t, ErrRepoNotInitialized{}, err)
+}
+
+// Only the keys that are both authorized for a role and held locally are
+// eligible to sign it: keys held by someone else and local keys which are not
+// authorized are excluded.
+func TestEligibleSigningKeys(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	cs := repo.GetCryptoService()
+	localKey, err := cs.Create("targets/a", repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+	otherCS := cryptoservice.NewCryptoService(trustmanager.NewKeyMemoryStore(passphrase.ConstantRetriever("pass")))
+	otherKey, err := otherCS.Create("targets/a", repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+	// a key held locally for the role which the role does not authorize
+	_, err = cs.Create("targets/a", repo.gun, data.ECDSAKey)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.AddDelegation("targets/a", []data.PublicKey{localKey, otherKey}, []string{""}))
+	require.NoError(t, repo.Publish())
+
+	eligible, err := repo.EligibleSigningKeys("targets/a")
+	require.NoError(t, err)
+	require.Equal(t, []string{localKey.ID()}, eligible)
+
+	// the root is authorized by certificate, but the canonical key ID is returned
+	rootKeys := cs.ListKeys(data.CanonicalRootRole)
+	require.Len(t, rootKeys, 1)
+	eligible, err = repo.EligibleSigningKeys(data.CanonicalRootRole)
+	require.NoError(t, err)
+	require.Equal(t, rootKeys, eligible)
+
+	// the timestamp key is held by the server
+	eligible, err = repo.EligibleSigningKeys(data.CanonicalTimestampRole)
+	require.NoError(t, err)
+	require.Empty(t, eligible)
+
+	_, err = repo.EligibleSigningKeys("targets/missing")
+	require.Error(t, err)
+
+	// listing eligible keys never loads the private keys
+	repo.cryptoService = listOnlyCryptoService{cs}
+	eligible, err = repo.EligibleSigningKeys("targets/a")
+	require.NoError(t, err)
+	require.Equal(t, []string{localKey.ID()}, eligible)
+}
+
+// listOnlyCryptoService fails to load any private key, as if each one needed a
+// passphrase or hardware token which is unavailable
+type listOnlyCryptoService struct {
+	signed.CryptoService
+}
+
+func (listOnlyCryptoService) GetPrivateKey(keyID string) (data.PrivateKey, data.RoleName, error) {
+	return nil, "", errors.New("private key " + keyID + " was loaded")
+}
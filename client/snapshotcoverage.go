@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrSnapshotMissingDelegation is returned, when checking snapshot coverage or
+// the delegation tree, if the file of a delegation referenced by its parent
+// exists on the server but is not listed in the snapshot, so its integrity
+// cannot be verified
+type ErrSnapshotMissingDelegation struct {
+	Role data.RoleName
+}
+
+func (err ErrSnapshotMissingDelegation) Error() string {
+	return fmt.Sprintf("delegation %s is published, but the snapshot does not list it", err.Role)
+}
+
+// checkSnapshotCoverage, if checking snapshot coverage, returns
+// ErrSnapshotMissingDelegation if the server has the file of a delegation which
+// is not listed in the snapshot
+func (c *tufClient) checkSnapshotCoverage(role data.RoleName) error {
+	if !c.snapshotCoverage {
+		return nil
+	}
+	return checkUnlistedDelegation(c.remote, role)
+}
+
+// checkUnlistedDelegation checks a delegation the snapshot does not list,
+// returning ErrSnapshotMissingDelegation if the remote has a file for it.  A
+// delegation whose file has never been published is not expected to be listed.
+// A remote which is offline or unreachable is treated as not having the file,
+// so the check is skipped rather than failing verification.
+func checkUnlistedDelegation(remote store.RemoteStore, role data.RoleName) error {
+	_, err := remote.GetSized(role.String(), notary.MaxDownloadSize)
+	switch err.(type) {
+	case nil:
+		return ErrSnapshotMissingDelegation{Role: role}
+	case store.ErrMetaNotFound:
+		return nil
+	case store.ErrOffline, store.ErrServerUnavailable, store.NetworkError:
+		logrus.Debugf("unable to check whether %s is published, skipping the check: %v", role, err)
+		return nil
+	default:
+		return err
+	}
+}
+
+// SetSnapshotCoverage sets whether every update checks that the snapshot
+// lists each delegation, reachable from the targets role, whose file has been
+// published, failing with ErrSnapshotMissingDelegation otherwise.  This
+// catches publishes which updated a delegation's parent without updating the
+// snapshot.
+func (r *repository) SetSnapshotCoverage(enabled bool) {
+	r.snapshotCoverage = enabled
+}
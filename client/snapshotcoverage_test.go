@@ -0,0 +1,74 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A delegation whose file is published, and which is referenced by targets,
+// but which the snapshot does not list, fails updates checking snapshot
+// coverage
+func TestSnapshotMissingDelegation(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/a", "targets/b")
+	require.NoError(t, err)
+	tufRepo.InitTargets("targets/a")
+	tufRepo.InitTargets("targets/b")
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	// targets/b has never been published
+	require.NoError(t, swizzler.RemoveMetadata("targets/b"))
+	require.NoError(t, swizzler.MutateSnapshot(func(snapshot *data.Snapshot) {
+		delete(snapshot.Meta, "targets/b")
+	}))
+	require.NoError(t, swizzler.UpdateTimestampHash())
+
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	// an unpublished delegation need not be listed
+	repo.SetSnapshotCoverage(true)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+
+	// the publish of targets/a did not update the snapshot
+	require.NoError(t, swizzler.MutateSnapshot(func(snapshot *data.Snapshot) {
+		delete(snapshot.Meta, "targets/a")
+	}))
+	require.NoError(t, swizzler.UpdateTimestampHash())
+
+	repo.SetSnapshotCoverage(false)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+
+	repo.SetSnapshotCoverage(true)
+	_, err = repo.ListTargets()
+	require.Equal(t, ErrSnapshotMissingDelegation{Role: "targets/a"}, err)
+}
+
+// Checking coverage is skipped, rather than failing the update, when the
+// server cannot be reached
+func TestSnapshotCoverageOffline(t *testing.T) {
+	c := &tufClient{remote: store.OfflineStore{}, snapshotCoverage: true}
+	require.NoError(t, c.checkSnapshotCoverage("targets/a"))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	remote, err := store.NewHTTPStore(ts.URL+"/v2/docker.com/notary/_trust/tuf/", "", "json", "key",
+		http.DefaultTransport)
+	require.NoError(t, err)
+	c.remote = remote
+	require.NoError(t, c.checkSnapshotCoverage("targets/a"))
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/theupdateframework/notary"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// TrustedStateSnapshot is the trusted state of a repository as it would have
+// been seen by a client at a past time
+type TrustedStateSnapshot struct {
+	AsOf time.Time
+	// Versions is the version of each role's metadata trusted at that time
+	Versions map[data.RoleName]int
+	// Repo is the metadata trusted at that time, verified as of that time
+	Repo *tuf.Repo
+}
+
+// ErrStateNotRetained is returned when the trusted state at a past time cannot
+// be reconstructed from the versions of the metadata retained by the server
+type ErrStateNotRetained struct {
+	Role   data.RoleName
+	AsOf   time.Time
+	Reason string
+}
+
+func (err ErrStateNotRetained) Error() string {
+	return fmt.Sprintf("unable to reconstruct the %s metadata trusted at %s: %s",
+		err.Role, err.AsOf.Format(time.RFC3339), err.Reason)
+}
+
+// StateAsOf reconstructs the metadata a client would have trusted at the given
+// time, from the versions retained by the server.  For the root and timestamp,
+// this is the latest version issued by then and not yet expired; the snapshot,
+// targets and delegations are then those referenced by their checksums, as a
+// client would have fetched a consistent set.  The result is verified against
+// the selected root as of the given time.  ErrStateNotRetained is returned if
+// the retained versions, or their recorded issued times, do not cover the time.
+func (r *repository) StateAsOf(t time.Time) (TrustedStateSnapshot, error) {
+	if err := r.updateTUF(false); err != nil {
+		return TrustedStateSnapshot{}, err
+	}
+	remote := r.getRemoteStore()
+
+	rootJSON, err := versionAsOf(remote, data.CanonicalRootRole, r.tufRepo.Root.Signed.Version, t)
+	if err != nil {
+		return TrustedStateSnapshot{}, err
+	}
+	timestampJSON, err := versionAsOf(remote, data.CanonicalTimestampRole, r.tufRepo.Timestamp.Signed.Version, t)
+	if err != nil {
+		return TrustedStateSnapshot{}, err
+	}
+	metas := map[data.RoleName][]byte{data.CanonicalTimestampRole: timestampJSON}
+
+	timestamp := &data.Signed{}
+	if err := data.UnmarshalMetadata(timestampJSON, timestamp); err != nil {
+		return TrustedStateSnapshot{}, err
+	}
+	signedTimestamp, err := data.TimestampFromSigned(timestamp)
+	if err != nil {
+		return TrustedStateSnapshot{}, err
+	}
+	snapshotJSON, err := metaByChecksum(remote, data.CanonicalSnapshotRole, signedTimestamp.Signed.Meta, t)
+	if err != nil {
+		return TrustedStateSnapshot{}, err
+	}
+	metas[data.CanonicalSnapshotRole] = snapshotJSON
+
+	snapshot := &data.Signed{}
+	if err := data.UnmarshalMetadata(snapshotJSON, snapshot); err != nil {
+		return TrustedStateSnapshot{}, err
+	}
+	signedSnapshot, err := data.SnapshotFromSigned(snapshot)
+	if err != nil {
+		return TrustedStateSnapshot{}, err
+	}
+	for name := range signedSnapshot.Signed.Meta {
+		roleName := data.RoleName(name)
+		if roleName == data.CanonicalRootRole {
+			continue
+		}
+		if metas[roleName], err = metaByChecksum(remote, roleName, signedSnapshot.Signed.Meta, t); err != nil {
+			return TrustedStateSnapshot{}, err
+		}
+	}
+
+	repo, err := verifyWithRootAt(r.gun, rootJSON, metas, func() time.Time { return t })
+	if err != nil {
+		return TrustedStateSnapshot{}, err
+	}
+	versions := map[data.RoleName]int{
+		data.CanonicalRootRole:      repo.Root.Signed.Version,
+		data.CanonicalTimestampRole: repo.Timestamp.Signed.Version,
+		data.CanonicalSnapshotRole:  repo.Snapshot.Signed.Version,
+	}
+	for roleName, targets := range repo.Targets {
+		versions[roleName] = targets.Signed.Version
+	}
+	return TrustedStateSnapshot{AsOf: t, Versions: versions, Repo: repo}, nil
+}
+
+// versionAsOf returns the latest version of the role, starting from the given
+// version, which had been issued by the given time and had not expired by then
+func versionAsOf(remote store.RemoteStore, role data.RoleName, latest int, t time.Time) ([]byte, error) {
+	for version := latest; version >= 1; version-- {
+		raw, err := remote.GetSized(fmt.Sprintf("%d.%s", version, role), store.NoSizeLimit)
+		if err != nil {
+			if _, ok := err.(store.ErrMetaNotFound); ok {
+				return nil, ErrStateNotRetained{Role: role, AsOf: t, Reason: fmt.Sprintf("version %d is not retained", version)}
+			}
+			return nil, err
+		}
+		meta := &data.SignedMeta{}
+		if err := data.UnmarshalMetadata(raw, meta); err != nil {
+			return nil, err
+		}
+		if meta.Signed.Issued == nil {
+			return nil, ErrStateNotRetained{Role: role, AsOf: t, Reason: fmt.Sprintf("version %d records no issued time", version)}
+		}
+		if meta.Signed.Issued.After(t) || !meta.Signed.Expires.After(t) {
+			continue
+		}
+		return raw, nil
+	}
+	return nil, ErrStateNotRetained{Role: role, AsOf: t, Reason: "no retained version had been issued and was unexpired by then"}
+}
+
+// metaByChecksum fetches the version of the role with the checksum listed in
+// the given metadata
+func metaByChecksum(remote store.RemoteStore, role data.RoleName, meta data.Files, t time.Time) ([]byte, error) {
+	fileMeta, ok := meta[role.String()]
+	if !ok {
+		return nil, ErrStateNotRetained{Role: role, AsOf: t, Reason: "no checksum for it was listed"}
+	}
+	checksum, ok := fileMeta.Hashes[notary.SHA256]
+	if !ok {
+		return nil, ErrStateNotRetained{Role: role, AsOf: t, Reason: "no sha256 checksum for it was listed"}
+	}
+	raw, err := remote.GetSized(utils.ConsistentName(role.String(), checksum), fileMeta.Length)
+	if err != nil {
+		if _, ok := err.(store.ErrMetaNotFound); ok {
+			return nil, ErrStateNotRetained{Role: role, AsOf: t,
+				Reason: fmt.Sprintf("the version with checksum %s is not retained", hex.EncodeToString(checksum))}
+		}
+		return nil, err
+	}
+	return raw, nil
+}
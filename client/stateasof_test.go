@@ -0,0 +1,68 @@
+package client
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// The state reconstructed as of a time between two publishes is the state
+// published by the earlier one
+func TestStateAsOf(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	beforeAll := time.Now()
+
+	addTarget(t, repo, "a", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	first := time.Now()
+	firstRoot, firstTargets := publishedVersions(t, repo)
+
+	addTarget(t, repo, "b", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	second := time.Now()
+	secondRoot, secondTargets := publishedVersions(t, repo)
+
+	require.NoError(t, repo.RemoveTarget("a"))
+	require.NoError(t, repo.RotateKey(data.CanonicalTargetsRole, false, nil))
+	require.NoError(t, repo.Publish())
+	lastRoot, lastTargets := publishedVersions(t, repo)
+	require.True(t, lastRoot > secondRoot)
+
+	requireTargetsAsOf := func(asOf time.Time, rootVersion, targetsVersion int, names ...string) {
+		state, err := repo.StateAsOf(asOf)
+		require.NoError(t, err)
+		require.Equal(t, asOf, state.AsOf)
+		require.Equal(t, rootVersion, state.Versions[data.CanonicalRootRole])
+		require.Equal(t, targetsVersion, state.Versions[data.CanonicalTargetsRole])
+		require.Equal(t, state.Repo.Timestamp.Signed.Version, state.Versions[data.CanonicalTimestampRole])
+		found := make([]string, 0, len(names))
+		for name := range state.Repo.Targets[data.CanonicalTargetsRole].Signed.Targets {
+			found = append(found, name)
+		}
+		require.Equal(t, len(names), len(found))
+		for _, name := range names {
+			require.NotNil(t, state.Repo.Targets[data.CanonicalTargetsRole].Signed.Targets[name], name)
+		}
+	}
+	requireTargetsAsOf(first, firstRoot, firstTargets, "a")
+	requireTargetsAsOf(second, secondRoot, secondTargets, "a", "b")
+	requireTargetsAsOf(time.Now(), lastRoot, lastTargets, "b")
+
+	// the root had been signed when the repository was initialized, but the
+	// server had not yet issued any timestamp
+	_, err := repo.StateAsOf(beforeAll)
+	require.IsType(t, ErrStateNotRetained{}, err)
+	require.Equal(t, data.CanonicalTimestampRole, err.(ErrStateNotRetained).Role)
+}
+
+func publishedVersions(t *testing.T, repo *repository) (root, targets int) {
+	require.NoError(t, repo.updateTUF(false))
+	return repo.tufRepo.Root.Signed.Version, repo.tufRepo.Targets[data.CanonicalTargetsRole].Signed.Version
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	canonicaljson "github.com/docker/go/canonical/json"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// TargetManifestType is the type of the signed portion of a target manifest
+const TargetManifestType = "TargetManifest"
+
+// TargetManifest lists every target of a repository as it resolved at a
+// particular root version, for consumers which trust the manifest's signing
+// key but do not parse TUF metadata
+type TargetManifest struct {
+	Type        string           `json:"_type"`
+	GUN         data.GUN         `json:"gun"`
+	RootVersion int              `json:"root_version"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Targets     []ManifestTarget `json:"targets"`
+}
+
+// ManifestTarget is a target listed in a TargetManifest
+type ManifestTarget struct {
+	Name   string                    `json:"name"`
+	Length int64                     `json:"length"`
+	Hashes data.Hashes               `json:"hashes"`
+	Custom *canonicaljson.RawMessage `json:"custom,omitempty"`
+}
+
+// ErrInvalidTargetManifest is returned when a target manifest cannot be parsed
+// or its signature is not valid for the expected key
+type ErrInvalidTargetManifest struct {
+	Reason string
+}
+
+func (err ErrInvalidTargetManifest) Error() string {
+	return fmt.Sprintf("invalid target manifest: %s", err.Reason)
+}
+
+// SignedTargetManifest updates the repository and returns a manifest of every
+// effective target, sorted by name, as EffectiveTargets resolves them.  The
+// manifest is a data.Signed envelope signed by the private key with the given
+// ID, which must be held by the repository's crypto service, and can be checked
+// with VerifyTargetManifest.  The manifest is not TUF metadata, and is never
+// published.
+func (r *repository) SignedTargetManifest(keyID string) ([]byte, error) {
+	targets, err := r.EffectiveTargets()
+	if err != nil {
+		return nil, err
+	}
+	privKey, _, err := r.GetCryptoService().GetPrivateKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := TargetManifest{
+		Type:        TargetManifestType,
+		GUN:         r.gun,
+		RootVersion: r.tufRepo.Root.Signed.Version,
+		GeneratedAt: time.Now().UTC(),
+		Targets:     make([]ManifestTarget, 0, len(targets)),
+	}
+	for _, target := range targets {
+		manifest.Targets = append(manifest.Targets, ManifestTarget{
+			Name:   target.Name,
+			Length: target.Length,
+			Hashes: target.Hashes,
+			Custom: target.Custom,
+		})
+	}
+	return signEnvelope(manifest, privKey)
+}
+
+// VerifyTargetManifest checks that a manifest produced by SignedTargetManifest
+// is signed by the given key, and returns its contents
+func VerifyTargetManifest(manifestJSON []byte, manifestKey data.PublicKey) (*TargetManifest, error) {
+	content, err := openEnvelope(manifestJSON, manifestKey)
+	if err != nil {
+		return nil, ErrInvalidTargetManifest{Reason: err.Error()}
+	}
+
+	manifest := &TargetManifest{}
+	if err := canonicaljson.Unmarshal(content, manifest); err != nil {
+		return nil, ErrInvalidTargetManifest{Reason: err.Error()}
+	}
+	if manifest.Type != TargetManifestType {
+		return nil, ErrInvalidTargetManifest{Reason: fmt.Sprintf("unexpected type %q", manifest.Type)}
+	}
+	return manifest, nil
+}
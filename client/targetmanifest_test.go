@@ -0,0 +1,90 @@
+package client
+
+import (
+	"crypto/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/trustmanager"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+// The manifest lists every target with its hashes, length and custom data, and
+// verifies under the held key which signed it
+func TestSignedTargetManifest(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	custom := json.RawMessage(`{"arch":"amd64"}`)
+	latest := addTargetWithCustom(t, repo, "latest", "../fixtures/intermediate-ca.crt", &custom)
+	stable := addTarget(t, repo, "stable", "../fixtures/root-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	targetsInfo, err := repo.GetRoleInfo(data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	keyID := targetsInfo.KeyIDs[0]
+
+	before := time.Now().Add(-time.Second)
+	manifestJSON, err := repo.SignedTargetManifest(keyID)
+	require.NoError(t, err)
+
+	manifest, err := VerifyTargetManifest(manifestJSON, repo.GetCryptoService().GetKey(keyID))
+	require.NoError(t, err)
+	require.Equal(t, data.GUN("docker.com/notary"), manifest.GUN)
+	require.Equal(t, repo.tufRepo.Root.Signed.Version, manifest.RootVersion)
+	require.True(t, manifest.GeneratedAt.After(before))
+	require.Equal(t, []ManifestTarget{
+		{Name: "latest", Length: latest.Length, Hashes: latest.Hashes, Custom: &custom},
+		{Name: "stable", Length: stable.Length, Hashes: stable.Hashes},
+	}, manifest.Targets)
+
+	// the manifest does not verify with a different key
+	otherKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = VerifyTargetManifest(manifestJSON, data.PublicKeyFromPrivate(otherKey))
+	require.IsType(t, ErrInvalidTargetManifest{}, err)
+
+	// nor once it has been tampered with
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(manifestJSON, s))
+	tampered := json.RawMessage([]byte(`{"_type":"TargetManifest","targets":[]}`))
+	s.Signed = &tampered
+	tamperedJSON, err := json.Marshal(s)
+	require.NoError(t, err)
+	_, err = VerifyTargetManifest(tamperedJSON, repo.GetCryptoService().GetKey(keyID))
+	require.IsType(t, ErrInvalidTargetManifest{}, err)
+
+	// an attestation signed by the same key is not a manifest
+	attestationJSON, err := repo.VerifyAndAttest("latest", mustPrivateKey(t, repo, keyID))
+	require.NoError(t, err)
+	_, err = VerifyTargetManifest(attestationJSON, repo.GetCryptoService().GetKey(keyID))
+	require.IsType(t, ErrInvalidTargetManifest{}, err)
+}
+
+// A manifest can only be signed with a key the repository holds
+func TestSignedTargetManifestKeyNotHeld(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	otherKey, err := utils.GenerateECDSAKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = repo.SignedTargetManifest(otherKey.ID())
+	require.IsType(t, trustmanager.ErrKeyNotFound{}, err)
+}
+
+func mustPrivateKey(t *testing.T, repo *repository, keyID string) data.PrivateKey {
+	privKey, _, err := repo.GetCryptoService().GetPrivateKey(keyID)
+	require.NoError(t, err)
+	return privKey
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrThresholdDowngrade is returned when the threshold of a role would be
+// lowered without the downgrade being explicitly allowed
+type ErrThresholdDowngrade struct {
+	Role      data.RoleName
+	Current   int
+	Requested int
+}
+
+func (err ErrThresholdDowngrade) Error() string {
+	return fmt.Sprintf("refusing to lower the threshold of %s from %d to %d: lowering a threshold weakens the role, and must be explicitly allowed",
+		err.Role, err.Current, err.Requested)
+}
+
+// SetThreshold creates a changelist entry to set the number of signatures
+// required by a base role or delegation when the changelist gets applied at
+// publish time.  The repository is updated, and the threshold checked against
+// the role as defined by the trusted metadata: it may be no more than the
+// number of the role's keys, and lower than the role's current threshold only
+// if allowDowngrade is true.
+func (r *repository) SetThreshold(role data.RoleName, threshold int, allowDowngrade bool) error {
+	if err := r.minimumThreshold.check(role, threshold); err != nil {
+		return err
+	}
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+
+	var (
+		current int
+		keyIDs  []string
+	)
+	if data.IsDelegation(role) {
+		delgRole, err := r.tufRepo.GetDelegationRole(role)
+		if err != nil {
+			return err
+		}
+		current, keyIDs = delgRole.Threshold, delgRole.ListKeyIDs()
+	} else {
+		baseRole, err := r.tufRepo.GetBaseRole(role)
+		if err != nil {
+			return err
+		}
+		current, keyIDs = baseRole.Threshold, baseRole.ListKeyIDs()
+	}
+	if threshold < 1 || threshold > len(keyIDs) {
+		return data.ErrInvalidRole{Role: role,
+			Reason: fmt.Sprintf("threshold must be between 1 and its %d keys, not %d", len(keyIDs), threshold)}
+	}
+	if threshold < current && !allowDowngrade {
+		return ErrThresholdDowngrade{Role: role, Current: current, Requested: threshold}
+	}
+
+	tdJSON, err := json.Marshal(&changelist.TUFThreshold{Threshold: threshold})
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("Setting the threshold of %s from %d to %d", role, current, threshold)
+	if !data.IsDelegation(role) {
+		return r.changelist.Add(changelist.NewTUFChange(
+			changelist.ActionUpdate, changelist.ScopeRoot, changelist.TypeThreshold, role.String(), tdJSON))
+	}
+	template := changelist.NewTUFChange(changelist.ActionUpdate, role, changelist.TypeThreshold, "", tdJSON)
+	return addChange(r.changelist, template, role)
+}
+
+// applyThresholdChange sets the threshold of the role named by the change's
+// path, for changes scoped to the root, or otherwise by its scope
+func applyThresholdChange(repo *tuf.Repo, c changelist.Change) error {
+	var td changelist.TUFThreshold
+	if err := json.Unmarshal(c.Content(), &td); err != nil {
+		return err
+	}
+	role := c.Scope()
+	if role == changelist.ScopeRoot {
+		role = data.RoleName(c.Path())
+	}
+	return repo.SetThreshold(role, td.Threshold)
+}
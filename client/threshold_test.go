@@ -0,0 +1,71 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Thresholds can be raised up to the number of keys of a role, but lowered
+// only when the downgrade is explicitly allowed
+func TestSetThreshold(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	delgKeys := []data.PublicKey{createKey(t, repo, "targets/a", false), createKey(t, repo, "targets/a", false)}
+	require.NoError(t, repo.AddDelegation("targets/a", delgKeys, []string{""}))
+	require.NoError(t, repo.Publish())
+
+	otherRepo, _, otherDir := newRepoToTestRepo(t, repo, "")
+	defer os.RemoveAll(otherDir)
+	requireThreshold := func(expected int) {
+		require.NoError(t, otherRepo.updateTUF(true))
+		role, err := otherRepo.tufRepo.GetDelegationRole("targets/a")
+		require.NoError(t, err)
+		require.Equal(t, expected, role.Threshold)
+	}
+
+	// raising
+	require.NoError(t, repo.SetThreshold("targets/a", 2, false))
+	require.NoError(t, repo.Publish())
+	requireThreshold(2)
+
+	// beyond the role's keys
+	err := repo.SetThreshold("targets/a", 3, false)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+	err = repo.SetThreshold(data.CanonicalTargetsRole, 2, false)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+
+	// lowering without being allowed
+	err = repo.SetThreshold("targets/a", 1, false)
+	require.Equal(t, ErrThresholdDowngrade{Role: "targets/a", Current: 2, Requested: 1}, err)
+	require.Empty(t, getChanges(t, repo))
+
+	// lowering when allowed
+	require.NoError(t, repo.SetThreshold("targets/a", 1, true))
+	require.NoError(t, repo.Publish())
+	requireThreshold(1)
+}
+
+// Base role thresholds are changed in the root, which is re-signed with them
+func TestSetThresholdBaseRole(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	require.NoError(t, repo.Publish())
+	rootVersion := repo.tufRepo.Root.Signed.Version
+
+	// the threshold is already 1, so this is neither a raise nor a downgrade
+	require.NoError(t, repo.SetThreshold(data.CanonicalTargetsRole, 1, false))
+	require.NoError(t, repo.Publish())
+	require.Equal(t, rootVersion+1, repo.tufRepo.Root.Signed.Version)
+
+	err := repo.SetThreshold(data.CanonicalTargetsRole, 0, true)
+	require.IsType(t, data.ErrInvalidRole{}, err)
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrTimestampTooOld is returned when the trusted timestamp was issued longer
+// ago than the maximum timestamp age allows, meaning the server has kept
+// serving the same timestamp rather than re-signing it
+type ErrTimestampTooOld struct {
+	Age    time.Duration
+	MaxAge time.Duration
+}
+
+func (err ErrTimestampTooOld) Error() string {
+	return fmt.Sprintf("the trusted timestamp was issued %s ago, longer than the maximum age of %s",
+		err.Age, err.MaxAge)
+}
+
+// TimestampObservation records when a version of the timestamp was first
+// trusted, to age timestamps which record no issued time
+type TimestampObservation struct {
+	Version int
+	At      time.Time
+}
+
+// timestampAge returns how long before now the timestamp was issued.  If it
+// records no issued time, its age is counted from when its version was first
+// observed, and a version not observed before has just been.
+func timestampAge(timestamp *data.SignedTimestamp, observed *TimestampObservation, now time.Time) time.Duration {
+	if timestamp.Signed.Issued != nil {
+		return now.Sub(*timestamp.Signed.Issued)
+	}
+	if observed != nil && observed.Version == timestamp.Signed.Version {
+		return now.Sub(observed.At)
+	}
+	return 0
+}
+
+// checkTimestampAge returns ErrTimestampTooOld if the repository's timestamp
+// is older than the maximum age.  A maximum age of 0 allows any age.
+func checkTimestampAge(repo *tuf.Repo, maxAge time.Duration, observed *TimestampObservation, now time.Time) error {
+	if maxAge <= 0 || repo.Timestamp == nil {
+		return nil
+	}
+	if age := timestampAge(repo.Timestamp, observed, now); age > maxAge {
+		return ErrTimestampTooOld{Age: age, MaxAge: maxAge}
+	}
+	return nil
+}
+
+// observeTimestamp records when the version of the repository's timestamp
+// was first trusted
+func (r *repository) observeTimestamp(now time.Time) {
+	if r.tufRepo == nil || r.tufRepo.Timestamp == nil {
+		return
+	}
+	version := r.tufRepo.Timestamp.Signed.Version
+	if r.timestampObserved == nil || r.timestampObserved.Version != version {
+		r.timestampObserved = &TimestampObservation{Version: version, At: now}
+	}
+}
+
+// TimestampAge returns how long ago the currently trusted timestamp was
+// issued, updating the repository first if nothing has been loaded yet.  For a
+// timestamp which records no issued time, this is how long ago its version was
+// first trusted by this repository.  Unlike the timestamp's expiry, this
+// bounds how stale the trusted view of the repository may be, since the
+// server re-signs the timestamp whenever it is published.
+func (r *repository) TimestampAge() (time.Duration, error) {
+	if r.tufRepo == nil {
+		if err := r.updateTUF(false); err != nil {
+			return 0, err
+		}
+	}
+	if r.tufRepo.Timestamp == nil {
+		return 0, ErrRepoNotInitialized{}
+	}
+	now, err := trustedClock(r.trustedTime)
+	if err != nil {
+		return 0, err
+	}
+	return timestampAge(r.tufRepo.Timestamp, r.timestampObserved, now()), nil
+}
+
+// SetMaxTimestampAge fails every update with ErrTimestampTooOld if the
+// timestamp it trusts was issued longer ago than the maximum age, for instance
+// because the server, or an attacker in its place, keeps serving an old
+// timestamp which has not yet expired.  A maximum age of 0 allows any age.
+func (r *repository) SetMaxTimestampAge(maxAge time.Duration) {
+	r.maxTimestampAge = maxAge
+}
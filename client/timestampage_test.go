@@ -0,0 +1,92 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A timestamp issued longer ago than the maximum age fails updates, even
+// though it has not expired, and one issued more recently is accepted
+func TestMaxTimestampAge(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	issued := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, swizzler.MutateTimestamp(func(timestamp *data.Timestamp) {
+		timestamp.Issued = &issued
+	}))
+
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	age, err := repo.TimestampAge()
+	require.NoError(t, err)
+	require.True(t, age >= 2*time.Hour, "age %s", age)
+	require.True(t, age < 3*time.Hour, "age %s", age)
+
+	repo.SetMaxTimestampAge(time.Hour)
+	_, err = repo.ListTargets()
+	require.IsType(t, ErrTimestampTooOld{}, err)
+	require.Equal(t, time.Hour, err.(ErrTimestampTooOld).MaxAge)
+	require.True(t, err.(ErrTimestampTooOld).Age >= 2*time.Hour)
+
+	repo.SetMaxTimestampAge(3 * time.Hour)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+
+	repo.SetMaxTimestampAge(0)
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+}
+
+// A timestamp recording no issued time is aged from when its version was
+// first trusted
+func TestTimestampAgeObserved(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	tufRepo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	require.NoError(t, swizzler.MutateTimestamp(func(timestamp *data.Timestamp) {
+		timestamp.Issued = nil
+	}))
+
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	version := repo.tufRepo.Timestamp.Signed.Version
+
+	// the same version is observed again, two hours later
+	repo.timestampObserved = &TimestampObservation{Version: version, At: time.Now().Add(-2 * time.Hour)}
+	age, err := repo.TimestampAge()
+	require.NoError(t, err)
+	require.True(t, age >= 2*time.Hour, "age %s", age)
+
+	repo.SetMaxTimestampAge(time.Hour)
+	_, err = repo.ListTargets()
+	require.IsType(t, ErrTimestampTooOld{}, err)
+
+	// a version not observed before has just been
+	repo.timestampObserved = &TimestampObservation{Version: version - 1, At: time.Now().Add(-2 * time.Hour)}
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	age, err = repo.TimestampAge()
+	require.NoError(t, err)
+	require.True(t, age < time.Hour, "age %s", age)
+}
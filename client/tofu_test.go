@@ -0,0 +1,51 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A root approved on first use is cached and trusted from then on without
+// asking again, while a rejected root fails the update and is not cached
+func TestTOFUApprover(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, cs, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	ts := readOnlyServer(t, testutils.NewMetadataSwizzler(gun, meta, cs).MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	asked := 0
+	approver := func(approved bool) trustpinning.TOFUApprover {
+		return func(string, []string) (bool, error) {
+			asked++
+			return approved, nil
+		}
+	}
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.trustPinning = trustpinning.TrustPinConfig{TOFUApprover: approver(false)}
+	_, err = repo.ListTargets()
+	require.IsType(t, &trustpinning.ErrTOFURejected{}, err)
+	require.Equal(t, 1, asked)
+	_, err = repo.cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	require.Error(t, err)
+
+	repo.trustPinning = trustpinning.TrustPinConfig{TOFUApprover: approver(true)}
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	require.Equal(t, 2, asked)
+
+	// the approved root is now pinned, so a rejecting approver is not asked
+	repo.trustPinning = trustpinning.TrustPinConfig{TOFUApprover: approver(false)}
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	require.Equal(t, 2, asked)
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TrustedTimeSource returns the current time from a source which is trusted
+// more than the local clock, for instance an NTP or Roughtime client
+type TrustedTimeSource func() (time.Time, error)
+
+// TrustedTimeConfig configures a trusted time source to check metadata expiry
+// against instead of the system clock
+type TrustedTimeConfig struct {
+	Source TrustedTimeSource
+	// Required fails the update if the source is unavailable, instead of
+	// logging a warning and falling back to the system clock
+	Required bool
+}
+
+// ErrTrustedTimeUnavailable is returned when the trusted time source could not
+// be queried and the configuration requires trusted time
+type ErrTrustedTimeUnavailable struct {
+	Err error
+}
+
+func (err ErrTrustedTimeUnavailable) Error() string {
+	return fmt.Sprintf("trusted time source unavailable: %s", err.Err)
+}
+
+// trustedClock queries the trusted time source once, and returns a clock which
+// advances from the trusted time by the time elapsed on the system clock since.
+// With no configuration, or if the source is unavailable and not required, the
+// system clock is returned.
+func trustedClock(config *TrustedTimeConfig) (func() time.Time, error) {
+	if config == nil || config.Source == nil {
+		return time.Now, nil
+	}
+	trusted, err := config.Source()
+	if err != nil {
+		if config.Required {
+			return nil, ErrTrustedTimeUnavailable{Err: err}
+		}
+		logrus.Warnf("trusted time source unavailable, falling back to the system clock: %s", err)
+		return time.Now, nil
+	}
+	offset := trusted.Sub(time.Now())
+	return func() time.Time { return time.Now().Add(offset) }, nil
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+func fixedTime(t time.Time) TrustedTimeSource {
+	return func() (time.Time, error) { return t, nil }
+}
+
+// Expiry is checked against the trusted time rather than the system clock, in
+// both directions: metadata which is unexpired by the system clock is rejected
+// if the trusted time is past its expiry, and metadata which has expired by the
+// system clock is accepted if the trusted time is before its expiry
+func TestTrustedTimeSource(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, cs, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	// expires about 13 months ago by the system clock
+	require.NoError(t, swizzler.ExpireMetadata(data.CanonicalTargetsRole))
+	require.NoError(t, swizzler.UpdateSnapshotHashes())
+	require.NoError(t, swizzler.UpdateTimestampHash())
+
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, err = repo.ListTargets()
+	require.IsType(t, signed.ErrExpired{}, err)
+
+	repo, baseDir = newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetTrustedTimeSource(TrustedTimeConfig{Source: fixedTime(time.Now().AddDate(-2, 0, 0))})
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+
+	// the timestamp expires within weeks, so has expired a year from now
+	repo.SetTrustedTimeSource(TrustedTimeConfig{Source: fixedTime(time.Now().AddDate(1, 0, 0))})
+	_, err = repo.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, signed.ErrExpired{}, err)
+}
+
+// If the trusted time source is unavailable, the update either falls back to
+// the system clock with a warning, or fails if trusted time is required
+func TestTrustedTimeSourceUnavailable(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, _, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, nil)
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	unavailable := func() (time.Time, error) { return time.Time{}, errors.New("no route to time server") }
+
+	recorder, restore := recordWarnings()
+	defer restore()
+	repo.SetTrustedTimeSource(TrustedTimeConfig{Source: unavailable})
+	_, err = repo.ListTargets()
+	require.NoError(t, err)
+	require.Len(t, recorder.containing("falling back to the system clock"), 1)
+
+	repo.SetTrustedTimeSource(TrustedTimeConfig{Source: unavailable, Required: true})
+	_, err = repo.ListTargets()
+	require.IsType(t, ErrTrustedTimeUnavailable{}, err)
+}
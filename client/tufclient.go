@@ -1,9 +1,11 @@
 package client
 
 import (
-	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/theupdateframework/notary"
@@ -21,6 +23,26 @@ type tufClient struct {
 	cache      store.MetadataStore
 	oldBuilder tuf.RepoBuilder
 	newBuilder tuf.RepoBuilder
+	// unknownDelegations handles delegations that cannot be loaded
+	unknownDelegations UnknownDelegationPolicy
+	// snapshotCoverage fails updates if a delegation file on the server is
+	// not listed in the snapshot
+	snapshotCoverage bool
+	// rootRollback handles a remote root older than the trusted root
+	rootRollback RootRollbackPolicy
+	// rolledBack is set once an older remote root has been trusted, after
+	// which the remote metadata need not be newer than the cached metadata
+	rolledBack bool
+	// observe, if set, is told the outcome of each step of the update
+	observe func(UpdateStepResult)
+	// attempt counts the passes the update has made over the metadata
+	attempt int
+	// gun is the repository being updated
+	gun data.GUN
+	// consistentSnapshot is the consistent snapshot setting the root must have
+	consistentSnapshot ConsistentSnapshotRequirement
+	// rootJSON is the root loaded into newBuilder
+	rootJSON []byte
 }
 
 // Update performs an update to the TUF repo as defined by the TUF spec
@@ -34,14 +56,18 @@ func (c *tufClient) Update() (*tuf.Repo, *tuf.Repo, error) {
 	//   a. If incorrect, download new root and return to 1.
 	// 4. Iteratively download and search targets and delegations to find target meta
 	logrus.Debug("updating TUF client")
+	c.attempt = 1
 	err := c.update()
 	if err != nil {
 		logrus.Debug("Error occurred. Root will be downloaded and another update attempted")
 		logrus.Debug("Resetting the TUF builder...")
 
+		c.attempt++
 		c.newBuilder = c.newBuilder.BootstrapNewBuilder()
 
-		if err := c.updateRoot(); err != nil {
+		err := c.updateRoot()
+		c.report(UpdateStepRoot, data.CanonicalRootRole, err)
+		if err != nil {
 			logrus.Debug("Client Update (Root): ", err)
 			return nil, nil, err
 		}
@@ -56,22 +82,47 @@ func (c *tufClient) Update() (*tuf.Repo, *tuf.Repo, error) {
 }
 
 func (c *tufClient) update() error {
-	if err := c.downloadTimestamp(); err != nil {
+	// a root without the required consistent snapshot setting may have been
+	// fixed by a newer root, which the retry after this error will download
+	if err := c.consistentSnapshot.checkRoot(c.gun, c.rootJSON); err != nil {
+		logrus.Debugf("Client Update (Root): %s", err.Error())
+		return err
+	}
+	err := c.downloadTimestamp()
+	c.report(UpdateStepTimestamp, data.CanonicalTimestampRole, err)
+	if err != nil {
 		logrus.Debugf("Client Update (Timestamp): %s", err.Error())
 		return err
 	}
-	if err := c.downloadSnapshot(); err != nil {
+	err = c.downloadSnapshot()
+	c.report(UpdateStepSnapshot, data.CanonicalSnapshotRole, err)
+	if err != nil {
 		logrus.Debugf("Client Update (Snapshot): %s", err.Error())
 		return err
 	}
 	// will always need top level targets at a minimum
-	if err := c.downloadTargets(); err != nil {
+	err = c.downloadTargets()
+	c.report(UpdateStepTargets, data.CanonicalTargetsRole, err)
+	if err != nil {
 		logrus.Debugf("Client Update (Targets): %s", err.Error())
 		return err
 	}
 	return nil
 }
 
+// report tells the observer, if there is one, the outcome of a step of the
+// current attempt, with the version of the role the step trusted
+func (c *tufClient) report(step UpdateStep, role data.RoleName, err error) {
+	if c.observe == nil {
+		return
+	}
+	result := UpdateStepResult{Step: step, Attempt: c.attempt, Err: err}
+	if err == nil {
+		result.Version = c.newBuilder.GetLoadedVersion(role)
+	}
+	c.observe(result)
+}
+
 // updateRoot checks if there is a newer version of the root available, and if so
 // downloads all intermediate root files to allow proper key rotation.
 func (c *tufClient) updateRoot() error {
@@ -87,9 +138,13 @@ func (c *tufClient) updateRoot() error {
 		// Rotation errors are okay since we haven't yet downloaded
 		// all intermediate root files
 		break
+	case signed.ErrLowVersion:
+		// The remote root is older than the trusted one
+		return c.handleRootRollback(raw, currentVersion)
 	case nil:
 		// No error updating root - we were at most 1 version behind
-		return nil
+		c.rootJSON = raw
+		return c.consistentSnapshot.checkRoot(c.gun, raw)
 	default:
 		// Return any non-rotation error.
 		return err
@@ -108,7 +163,7 @@ func (c *tufClient) updateRoot() error {
 
 	// Extract newest version number
 	signedRoot := &data.Signed{}
-	if err := json.Unmarshal(raw, signedRoot); err != nil {
+	if err := data.UnmarshalMetadata(raw, signedRoot); err != nil {
 		return err
 	}
 	newestRoot, err := data.RootFromSigned(signedRoot)
@@ -128,6 +183,10 @@ func (c *tufClient) updateRoot() error {
 		return err
 	}
 	logrus.Debugf("successfully verified downloaded %d.%s", newestVersion, data.CanonicalRootRole)
+	c.rootJSON = raw
+	if err := c.consistentSnapshot.checkRoot(c.gun, raw); err != nil {
+		return err
+	}
 
 	// Write newest to cache
 	if err := c.cache.Set(data.CanonicalRootRole.String(), raw); err != nil {
@@ -209,7 +268,10 @@ func (c *tufClient) downloadSnapshot() error {
 
 // downloadTargets downloads all targets and delegated targets for the repository.
 // It uses a pre-order tree traversal as it's necessary to download parents first
-// to obtain the keys to validate children.
+// to obtain the keys to validate children.  There is no need to check for
+// delegation cycles: GetValidDelegations only returns the direct children of a
+// role by name, so no metadata, verified or not, can make a role its own
+// descendant here.
 func (c *tufClient) downloadTargets() error {
 	toDownload := []data.DelegationRole{{
 		BaseRole: data.BaseRole{Name: data.CanonicalTargetsRole},
@@ -222,7 +284,16 @@ func (c *tufClient) downloadTargets() error {
 
 		consistentInfo := c.newBuilder.GetConsistentInfo(role.Name)
 		if !consistentInfo.ChecksumKnown() {
-			logrus.Debugf("skipping %s because there is no checksum for it", role.Name)
+			if role.Name == data.CanonicalTargetsRole {
+				logrus.Debugf("skipping %s because there is no checksum for it", role.Name)
+				continue
+			}
+			if err := c.checkSnapshotCoverage(role.Name); err != nil {
+				return err
+			}
+			if err := c.unknownDelegations.handle(role.Name, "not listed in the snapshot", nil); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -234,6 +305,13 @@ func (c *tufClient) downloadTargets() error {
 			}
 			logrus.Warnf("Error getting %s: %s", role.Name, err)
 			break
+		case store.ErrMetaNotFound:
+			if role.Name == data.CanonicalTargetsRole {
+				return err
+			}
+			if err := c.unknownDelegations.handle(role.Name, "not found on the server", err); err != nil {
+				return err
+			}
 		case nil:
 			toDownload = append(children, toDownload...)
 		default:
@@ -254,7 +332,7 @@ func (c tufClient) getTargetsFile(role data.DelegationRole, ci tuf.ConsistentInf
 
 	// we know it unmarshals because if `tryLoadCacheThenRemote` didn't fail, then
 	// the raw has already been loaded into the builder
-	json.Unmarshal(raw, tgs)
+	data.UnmarshalMetadata(raw, tgs)
 	return tgs.GetValidDelegations(role), nil
 }
 
@@ -305,11 +383,20 @@ func (c *tufClient) tryLoadRemote(consistentInfo tuf.ConsistentInfo, old []byte)
 	// will be 1
 	c.oldBuilder.Load(consistentInfo.RoleName, old, 1, true)
 	minVersion := c.oldBuilder.GetLoadedVersion(consistentInfo.RoleName)
+	if c.rolledBack {
+		minVersion = 1
+	}
 	if err := c.newBuilder.Load(consistentInfo.RoleName, raw, minVersion, false); err != nil {
 		logrus.Debugf("downloaded %s is invalid: %s", consistentName, err)
 		return raw, err
 	}
 	logrus.Debugf("successfully verified downloaded %s", consistentName)
+	if consistentInfo.RoleName == data.CanonicalRootRole {
+		// don't cache a root the client won't trust
+		if err := c.consistentSnapshot.checkRoot(c.gun, raw); err != nil {
+			return raw, err
+		}
+	}
 	if err := c.cache.Set(consistentInfo.RoleName.String(), raw); err != nil {
 		logrus.Debugf("Unable to write %s to cache: %s", consistentInfo.RoleName, err)
 	}
@@ -325,6 +412,42 @@ type TUFLoadOptions struct {
 	Cache                  store.MetadataStore
 	RemoteStore            store.RemoteStore
 	AlwaysCheckInitialized bool
+	Canary                 *CanaryConfig
+	KeyDowngrade           *KeyDowngradePolicy
+	TrustedTime            *TrustedTimeConfig
+	UnknownDelegations     UnknownDelegationPolicy
+	MinimumThreshold       *MinimumThresholdPolicy
+	// LenientRoleTypes skips checking, before verifying it, that metadata
+	// declares the type of the role it was fetched as
+	LenientRoleTypes bool
+	// ConsistentSnapshot is the consistent snapshot setting the trusted root
+	// must have
+	ConsistentSnapshot ConsistentSnapshotRequirement
+	// SnapshotCoverage fails the update with ErrSnapshotMissingDelegation if
+	// the server has the file of a delegation the snapshot does not list
+	SnapshotCoverage bool
+	// MaxTimestampAge fails the update with ErrTimestampTooOld if the trusted
+	// timestamp was issued longer ago than this.  0 allows any age.
+	MaxTimestampAge time.Duration
+	// TimestampObserved is when a version of the timestamp was first
+	// trusted, from which a timestamp recording no issued time is aged
+	TimestampObserved *TimestampObservation
+	// RootRollback is how the update handles a remote root with a lower
+	// version than the trusted root
+	RootRollback RootRollbackPolicy
+	// Observer, if set, is called with the outcome of each step of the
+	// update as it completes
+	Observer func(UpdateStepResult)
+}
+
+// verifying configures a builder for the new metadata with the options'
+// verification requirements
+func (l TUFLoadOptions) verifying(rb tuf.RepoBuilder) tuf.RepoBuilder {
+	rb = l.MinimumThreshold.verifying(rb)
+	if l.LenientRoleTypes {
+		rb = tuf.WithLenientRoleTypes(rb)
+	}
+	return rb
 }
 
 // bootstrapClient attempts to bootstrap a root.json to be used as the trust
@@ -345,20 +468,25 @@ type TUFLoadOptions struct {
 // is not trusted.
 //
 // Returns a TUFClient for the remote server, which may not be actually
-// operational (if the URL is invalid but a root.json is cached).
-func bootstrapClient(l TUFLoadOptions) (*tufClient, error) {
+// operational (if the URL is invalid but a root.json is cached).  Expiry is
+// checked against the time returned by now.
+func bootstrapClient(l TUFLoadOptions, now func() time.Time) (*tufClient, error) {
 	minVersion := 1
 	// the old root on disk should not be validated against any trust pinning configuration
 	// because if we have an old root, it itself is the thing that pins trust
-	oldBuilder := tuf.NewRepoBuilder(l.GUN, l.CryptoService, trustpinning.TrustPinConfig{})
+	oldBuilder := tuf.NewRepoBuilderWithClock(l.GUN, l.CryptoService, trustpinning.TrustPinConfig{}, now)
+	if l.LenientRoleTypes {
+		oldBuilder = tuf.WithLenientRoleTypes(oldBuilder)
+	}
 
 	// by default, we want to use the trust pinning configuration on any new root that we download
-	newBuilder := tuf.NewRepoBuilder(l.GUN, l.CryptoService, l.TrustPinning)
+	newBuilder := l.verifying(tuf.NewRepoBuilderWithClock(l.GUN, l.CryptoService, l.TrustPinning, now))
 
 	// Try to read root from cache first. We will trust this root until we detect a problem
 	// during update which will cause us to download a new root and perform a rotation.
 	// If we have an old root, and it's valid, then we overwrite the newBuilder to be one
 	// preloaded with the old root or one which uses the old root for trust bootstrapping.
+	var loadedRoot []byte
 	if rootJSON, err := l.Cache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit); err == nil {
 		// if we can't load the cached root, fail hard because that is how we pin trust
 		if err := oldBuilder.Load(data.CanonicalRootRole, rootJSON, minVersion, true); err != nil {
@@ -367,14 +495,16 @@ func bootstrapClient(l TUFLoadOptions) (*tufClient, error) {
 
 		// again, the root on disk is the source of trust pinning, so use an empty trust
 		// pinning configuration
-		newBuilder = tuf.NewRepoBuilder(l.GUN, l.CryptoService, trustpinning.TrustPinConfig{})
+		newBuilder = l.verifying(tuf.NewRepoBuilderWithClock(l.GUN, l.CryptoService, trustpinning.TrustPinConfig{}, now))
 
 		if err := newBuilder.Load(data.CanonicalRootRole, rootJSON, minVersion, false); err != nil {
 			// Ok, the old root is expired - we want to download a new one.  But we want to use the
 			// old root to verify the new root, so bootstrap a new builder with the old builder
 			// but use the trustpinning to validate the new root
 			minVersion = oldBuilder.GetLoadedVersion(data.CanonicalRootRole)
-			newBuilder = oldBuilder.BootstrapNewBuilderWithNewTrustpin(l.TrustPinning)
+			newBuilder = l.verifying(oldBuilder.BootstrapNewBuilderWithNewTrustpin(l.TrustPinning))
+		} else {
+			loadedRoot = rootJSON
 		}
 	}
 
@@ -396,6 +526,11 @@ func bootstrapClient(l TUFLoadOptions) (*tufClient, error) {
 			if err := newBuilder.Load(data.CanonicalRootRole, tmpJSON, minVersion, false); err != nil {
 				return nil, err
 			}
+			// don't cache a root the client won't trust
+			if err := l.ConsistentSnapshot.checkRoot(l.GUN, tmpJSON); err != nil {
+				return nil, err
+			}
+			loadedRoot = tmpJSON
 
 			err = l.Cache.Set(data.CanonicalRootRole.String(), tmpJSON)
 			if err != nil {
@@ -416,6 +551,14 @@ func bootstrapClient(l TUFLoadOptions) (*tufClient, error) {
 		newBuilder: newBuilder,
 		remote:     l.RemoteStore,
 		cache:      l.Cache,
+
+		unknownDelegations: l.UnknownDelegations,
+		snapshotCoverage:   l.SnapshotCoverage,
+		rootRollback:       l.RootRollback,
+		observe:            l.Observer,
+		gun:                l.GUN,
+		consistentSnapshot: l.ConsistentSnapshot,
+		rootJSON:           loadedRoot,
 	}, nil
 }
 
@@ -434,7 +577,27 @@ func LoadTUFRepo(options TUFLoadOptions) (*tuf.Repo, *tuf.Repo, error) {
 		options.CryptoService = cryptoservice.EmptyService
 	}
 
-	c, err := bootstrapClient(options)
+	var (
+		oldRootJSON []byte
+		oldRoot     *data.SignedRoot
+	)
+	if options.KeyDowngrade != nil {
+		oldRootJSON, oldRoot = cachedRoot(options.Cache)
+	}
+
+	now, err := trustedClock(options.TrustedTime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := bootstrapClient(options, now)
+	if options.Observer != nil {
+		bootstrapped := UpdateStepResult{Step: UpdateStepBootstrap, Attempt: 1, Err: err}
+		if err == nil {
+			bootstrapped.Version = c.newBuilder.GetLoadedVersion(data.CanonicalRootRole)
+		}
+		options.Observer(bootstrapped)
+	}
 	if err != nil {
 		if _, ok := err.(store.ErrMetaNotFound); ok {
 			return nil, nil, ErrRepositoryNotExist{
@@ -458,6 +621,97 @@ func LoadTUFRepo(options TUFLoadOptions) (*tuf.Repo, *tuf.Repo, error) {
 		}
 		return nil, nil, err
 	}
+	err = checkLoadedRepo(options, repo, now, oldRootJSON, oldRoot)
+	if options.Observer != nil {
+		options.Observer(UpdateStepResult{Step: UpdateStepPolicy, Attempt: c.attempt, Err: err})
+	}
+	if err != nil {
+		return nil, nil, err
+	}
 	warnRolesNearExpiry(repo)
+	if options.Canary != nil {
+		warnIfCanaryStale(repo, *options.Canary, now())
+	}
 	return repo, invalid, nil
 }
+
+// checkLoadedRepo enforces the options' requirements of the updated repo,
+// beyond the metadata verifying.  A non-nil oldRoot is the root trusted before
+// the update.
+func checkLoadedRepo(options TUFLoadOptions, repo *tuf.Repo, now func() time.Time, oldRootJSON []byte, oldRoot *data.SignedRoot) error {
+	if err := checkTimestampAge(repo, options.MaxTimestampAge, options.TimestampObserved, now()); err != nil {
+		return err
+	}
+	if oldRoot != nil {
+		err := enforceKeyDowngradePolicy(options.Cache, oldRootJSON, oldRoot, repo.Root, *options.KeyDowngrade)
+		if err != nil {
+			return err
+		}
+	}
+	if err := checkRevoked(options.GUN, repo); err != nil {
+		return err
+	}
+	if err := options.MinimumThreshold.checkRepo(repo); err != nil {
+		return err
+	}
+	return nil
+}
+
+// VerifyWithRoot verifies a set of metadata for a GUN statelessly, using the
+// given root as the trust anchor instead of a cached root or a trust pinning
+// configuration.  This is for verifiers which receive the root through a
+// separate, already trusted channel.  The root must be signed by a threshold of
+// its own root keys, and the timestamp, snapshot and targets, which must all be
+// provided, and any delegations are then verified against it in order.  Nothing
+// is read from or written to a cache.
+func VerifyWithRoot(gun data.GUN, rootJSON []byte, metas map[data.RoleName][]byte) (*tuf.Repo, error) {
+	return verifyWithRootAt(gun, rootJSON, metas, time.Now)
+}
+
+// verifyWithRootAt is VerifyWithRoot, checking expiry against the given clock
+func verifyWithRootAt(gun data.GUN, rootJSON []byte, metas map[data.RoleName][]byte, now func() time.Time) (*tuf.Repo, error) {
+	// the root is trusted because the caller says so, so it is not checked
+	// against any trust pinning, nor against a previously trusted root
+	builder := tuf.NewRepoBuilderWithClock(gun, cryptoservice.EmptyService, trustpinning.TrustPinConfig{}, now)
+	if err := builder.Load(data.CanonicalRootRole, rootJSON, 1, false); err != nil {
+		return nil, err
+	}
+
+	var delegations []data.RoleName
+	for roleName := range metas {
+		switch {
+		case roleName == data.CanonicalRootRole:
+			return nil, fmt.Errorf("the root must be provided as the trust anchor, not with the other metadata")
+		case data.IsDelegation(roleName):
+			delegations = append(delegations, roleName)
+		case !data.ValidRole(roleName):
+			return nil, data.ErrInvalidRole{Role: roleName, Reason: "not a valid role name"}
+		}
+	}
+	for _, roleName := range []data.RoleName{data.CanonicalTimestampRole, data.CanonicalSnapshotRole, data.CanonicalTargetsRole} {
+		meta, ok := metas[roleName]
+		if !ok {
+			return nil, fmt.Errorf("no %s metadata provided", roleName)
+		}
+		if err := builder.Load(roleName, meta, 1, false); err != nil {
+			return nil, err
+		}
+	}
+
+	// parents must be loaded before their children
+	sort.Slice(delegations, func(i, j int) bool {
+		iDepth, jDepth := strings.Count(delegations[i].String(), "/"), strings.Count(delegations[j].String(), "/")
+		if iDepth != jDepth {
+			return iDepth < jDepth
+		}
+		return delegations[i] < delegations[j]
+	})
+	for _, roleName := range delegations {
+		if err := builder.Load(roleName, metas[roleName], 1, false); err != nil {
+			return nil, err
+		}
+	}
+
+	repo, _, err := builder.Finish()
+	return repo, err
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// Metadata, including nested delegations, verifies against the root it was
+// signed for when that root is passed in explicitly
+func TestVerifyWithRoot(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, _, err := testutils.NewRepoMetadata(gun, "targets/a", "targets/a/b")
+	require.NoError(t, err)
+
+	metas := testutils.CopyRepoMetadata(meta)
+	delete(metas, data.CanonicalRootRole)
+	require.NotNil(t, metas["targets/a"])
+	repo, err := VerifyWithRoot(gun, meta[data.CanonicalRootRole], metas)
+	require.NoError(t, err)
+	require.NotNil(t, repo.Targets["targets/a"])
+
+	// the root may not be smuggled in with the rest of the metadata
+	_, err = VerifyWithRoot(gun, meta[data.CanonicalRootRole], meta)
+	require.Error(t, err)
+
+	for _, roleName := range []data.RoleName{data.CanonicalTimestampRole, data.CanonicalSnapshotRole, data.CanonicalTargetsRole} {
+		incomplete := testutils.CopyRepoMetadata(metas)
+		delete(incomplete, roleName)
+		_, err = VerifyWithRoot(gun, meta[data.CanonicalRootRole], incomplete)
+		require.Error(t, err, "expected an error without %s", roleName)
+	}
+}
+
+// Metadata is rejected if the root passed in is a different, even if valid,
+// root, or is not signed by its own root keys
+func TestVerifyWithRootMismatch(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, _, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	otherMeta, _, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+
+	metas := testutils.CopyRepoMetadata(meta)
+	delete(metas, data.CanonicalRootRole)
+	_, err = VerifyWithRoot(gun, otherMeta[data.CanonicalRootRole], metas)
+	require.Error(t, err)
+
+	// a root which is not signed by its own keys is not a valid trust anchor
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, nil)
+	require.NoError(t, swizzler.InvalidateMetadataSignatures(data.CanonicalRootRole))
+	invalidRoot, err := swizzler.MetadataCache.GetSized(data.CanonicalRootRole.String(), store.NoSizeLimit)
+	require.NoError(t, err)
+	_, err = VerifyWithRoot(gun, invalidRoot, metas)
+	require.Error(t, err)
+
+	// nor is the root for a different GUN
+	_, err = VerifyWithRoot("docker.com/other", meta[data.CanonicalRootRole], metas)
+	require.Error(t, err)
+}
+
+// A server answering a request for the snapshot with the timestamp is caught
+// as a role type mismatch, unless role types are not strictly checked
+func TestUpdateRoleTypeMismatch(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	meta, cs, err := testutils.NewRepoMetadata(gun)
+	require.NoError(t, err)
+	swizzler := testutils.NewMetadataSwizzler(gun, meta, cs)
+	require.NoError(t, swizzler.MetadataCache.Set(data.CanonicalSnapshotRole.String(), meta[data.CanonicalTimestampRole]))
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, err = repo.ListTargets()
+	require.Equal(t, data.ErrRoleTypeMismatch{Role: data.CanonicalSnapshotRole, Type: "Timestamp"}, err)
+
+	repo.SetStrictRoleTypes(false)
+	_, err = repo.ListTargets()
+	require.Error(t, err)
+	require.IsType(t, data.ErrMismatchedChecksum{}, err)
+}
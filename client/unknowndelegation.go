@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// UnknownDelegationPolicy determines what an update does when a delegation
+// role referenced by its parent targets metadata cannot be loaded, for
+// instance because the server has only partially synced the repository
+type UnknownDelegationPolicy int
+
+// The possible ways of handling a delegation that cannot be loaded
+const (
+	// UnknownDelegationDefault skips delegations that are not listed in the
+	// snapshot, and fails the update if a delegation listed in the snapshot
+	// cannot be downloaded
+	UnknownDelegationDefault UnknownDelegationPolicy = iota
+	// UnknownDelegationFail fails the update with ErrUnknownDelegation
+	UnknownDelegationFail
+	// UnknownDelegationWarn logs a warning naming the delegation and
+	// continues the update without it
+	UnknownDelegationWarn
+	// UnknownDelegationSkip logs, at info level, the name of the delegation
+	// and continues the update without it
+	UnknownDelegationSkip
+)
+
+// ErrUnknownDelegation is returned, under UnknownDelegationFail, when a
+// delegation role referenced by its parent cannot be loaded
+type ErrUnknownDelegation struct {
+	Role   data.RoleName
+	Reason string
+}
+
+func (err ErrUnknownDelegation) Error() string {
+	return fmt.Sprintf("delegation %s is referenced but could not be loaded: %s", err.Role, err.Reason)
+}
+
+// handle applies the policy to a delegation that could not be loaded,
+// returning the error the update should fail with, if any.  err is the error
+// the default policy fails with, which is nil if it skips the delegation.
+func (p UnknownDelegationPolicy) handle(role data.RoleName, reason string, err error) error {
+	switch p {
+	case UnknownDelegationFail:
+		return ErrUnknownDelegation{Role: role, Reason: reason}
+	case UnknownDelegationWarn:
+		logrus.Warnf("delegation %s is referenced but could not be loaded, continuing without it: %s", role, reason)
+		return nil
+	case UnknownDelegationSkip:
+		logrus.Infof("skipping delegation %s, which is referenced but could not be loaded: %s", role, reason)
+		return nil
+	default:
+		if err == nil {
+			logrus.Debugf("skipping %s: %s", role, reason)
+		}
+		return err
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+// A delegation which is listed in the snapshot and referenced by the targets
+// role, but whose metadata the server does not have, fails the update by
+// default and under UnknownDelegationFail, and is reported and ignored under
+// UnknownDelegationWarn and UnknownDelegationSkip
+func TestUnknownDelegationPolicy(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	swizzler := delegatedRepoSwizzler(t, gun)
+	require.NoError(t, swizzler.RemoveMetadata("targets/a"))
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	load := func(policy UnknownDelegationPolicy) (*recordedUpdate, error) {
+		repo, baseDir := newBlankRepo(t, ts.URL)
+		defer os.RemoveAll(baseDir)
+		repo.SetUnknownDelegationPolicy(policy)
+		recorder, restore := recordWarnings()
+		defer restore()
+		_, err := repo.ListTargets()
+		return &recordedUpdate{repo: repo, warnings: recorder}, err
+	}
+
+	_, err := load(UnknownDelegationDefault)
+	require.IsType(t, storage.ErrMetaNotFound{}, err)
+
+	_, err = load(UnknownDelegationFail)
+	require.Equal(t, ErrUnknownDelegation{Role: "targets/a", Reason: "not found on the server"}, err)
+
+	update, err := load(UnknownDelegationWarn)
+	require.NoError(t, err)
+	require.Len(t, update.warnings.containing("delegation targets/a is referenced but could not be loaded"), 1)
+	_, loaded := update.repo.tufRepo.Targets["targets/a"]
+	require.False(t, loaded)
+
+	update, err = load(UnknownDelegationSkip)
+	require.NoError(t, err)
+	require.Empty(t, update.warnings.messages)
+	_, loaded = update.repo.tufRepo.Targets["targets/a"]
+	require.False(t, loaded)
+}
+
+// A delegation which is referenced by the targets role but not listed in the
+// snapshot is skipped by default, and handled by the policy otherwise
+func TestUnknownDelegationPolicyNotInSnapshot(t *testing.T) {
+	gun := data.GUN("docker.com/notary")
+	swizzler := delegatedRepoSwizzler(t, gun)
+	require.NoError(t, swizzler.MutateSnapshot(func(sn *data.Snapshot) {
+		delete(sn.Meta, "targets/a")
+	}))
+	require.NoError(t, swizzler.UpdateTimestampHash())
+	ts := readOnlyServer(t, swizzler.MetadataCache, http.StatusNotFound, gun)
+	defer ts.Close()
+
+	for _, policy := range []UnknownDelegationPolicy{UnknownDelegationDefault, UnknownDelegationWarn, UnknownDelegationSkip} {
+		repo, baseDir := newBlankRepo(t, ts.URL)
+		defer os.RemoveAll(baseDir)
+		repo.SetUnknownDelegationPolicy(policy)
+		_, err := repo.ListTargets()
+		require.NoError(t, err)
+	}
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	repo.SetUnknownDelegationPolicy(UnknownDelegationFail)
+	_, err := repo.ListTargets()
+	require.Equal(t, ErrUnknownDelegation{Role: "targets/a", Reason: "not listed in the snapshot"}, err)
+}
+
+// delegatedRepoSwizzler returns a swizzler of the metadata of a repository
+// with the targets/a delegation, whose metadata is listed in the snapshot
+func delegatedRepoSwizzler(t *testing.T, gun data.GUN) *testutils.MetadataSwizzler {
+	tufRepo, cs, err := testutils.EmptyRepo(gun, "targets/a")
+	require.NoError(t, err)
+	_, err = tufRepo.InitTargets("targets/a")
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(tufRepo)
+	require.NoError(t, err)
+	return testutils.NewMetadataSwizzler(gun, meta, cs)
+}
+
+type recordedUpdate struct {
+	repo     *repository
+	warnings *warningRecorder
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// UploadPlan returns the roles the next publish would upload, and the size in
+// bytes of each, without uploading anything.  The changelist is applied and
+// the affected roles are signed exactly as on publish, but to a copy of the
+// repository which is then discarded, and the changelist is kept.  Roles the
+// server signs, such as the timestamp, are not included.  As the roles record
+// the times they were signed and expire, and those times are serialized
+// without trailing zeros, the roles signed on publish may differ in size from
+// those planned by a few bytes.  A repository which has never been published
+// must have been initialized locally.
+func (r *repository) UploadPlan() (map[data.RoleName]int64, error) {
+	// signChanges replaces the loaded repository with the one it signs, so
+	// the previously loaded one is restored
+	tufRepo, invalid := r.tufRepo, r.invalid
+	defer func() {
+		r.tufRepo, r.invalid = tufRepo, invalid
+	}()
+
+	updatedFiles, err := r.signChanges(r.changelist, false)
+	if err != nil {
+		if _, ok := err.(store.ErrMetaNotFound); ok {
+			return nil, ErrRepoNotInitialized{}
+		}
+		return nil, err
+	}
+	plan := make(map[data.RoleName]int64, len(updatedFiles))
+	for role, meta := range updatedFiles {
+		plan[role] = int64(len(meta))
+	}
+	return plan, nil
+}
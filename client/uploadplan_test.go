@@ -0,0 +1,73 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// The plan for a changelist adding a target lists exactly the targets and
+// snapshot, at about the sizes then published, and uploads and changes nothing
+func TestUploadPlan(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	// the first publish also uploads the root
+	plan, err := repo.UploadPlan()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(plan))
+	_, ok := plan[data.CanonicalRootRole]
+	require.True(t, ok)
+	require.NoError(t, repo.Publish())
+	_, targetsVersion := publishedVersions(t, repo)
+
+	addTarget(t, repo, "a", "../fixtures/intermediate-ca.crt")
+	plan, err = repo.UploadPlan()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(plan))
+	for _, role := range []data.RoleName{data.CanonicalTargetsRole, data.CanonicalSnapshotRole} {
+		_, ok := plan[role]
+		require.True(t, ok, "%s is not planned", role)
+	}
+
+	// nothing was uploaded, and the changes are still staged
+	_, published := publishedVersions(t, repo)
+	require.Equal(t, targetsVersion, published)
+	require.Len(t, getChanges(t, repo), 1)
+	again, err := repo.UploadPlan()
+	require.NoError(t, err)
+	require.Equal(t, len(plan), len(again))
+
+	require.NoError(t, repo.Publish())
+	remote := repo.getRemoteStore()
+	for role, size := range plan {
+		meta, err := remote.GetSized(role.String(), store.NoSizeLimit)
+		require.NoError(t, err)
+		// the signing and expiry times recorded vary in length by a few bytes
+		require.InDelta(t, size, len(meta), 20, "%s", role)
+	}
+
+	// with no changes left, publishing only re-signs the snapshot
+	plan, err = repo.UploadPlan()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(plan))
+	_, ok = plan[data.CanonicalSnapshotRole]
+	require.True(t, ok)
+}
+
+// A repository neither published nor initialized locally has no plan
+func TestUploadPlanNotInitialized(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, baseDir := newBlankRepo(t, ts.URL)
+	defer os.RemoveAll(baseDir)
+	_, err := repo.UploadPlan()
+	require.IsType(t, ErrRepoNotInitialized{}, err)
+}
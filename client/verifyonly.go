@@ -0,0 +1,194 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/cryptoservice"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// verifyOnlyRepository is a repository without any key store.  Read and verify
+// operations behave as for any other repository, but every operation that
+// signs, or stages changes that would need to be signed on publish, fails
+// immediately with ErrNoSigningCapability.
+type verifyOnlyRepository struct {
+	*repository
+}
+
+// NewFileCachedVerifyOnlyRepository is a wrapper for NewVerifyOnlyRepository
+// that initializes a file cache for the GUN under the provided base directory.
+// No private key store is opened.
+//
+// In case of a nil RoundTripper, a default offline store is used instead.
+func NewFileCachedVerifyOnlyRepository(baseDir string, gun data.GUN, baseURL string, rt http.RoundTripper,
+	trustPinning trustpinning.TrustPinConfig) (Repository, error) {
+
+	cache, err := store.NewFileStore(
+		filepath.Join(baseDir, tufDir, filepath.FromSlash(gun.String()), "metadata"),
+		"json",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteStore, err := getRemoteStore(baseURL, gun, rt)
+	if err != nil {
+		// baseURL is syntactically invalid
+		return nil, err
+	}
+
+	repo, err := NewVerifyOnlyRepository(gun, baseURL, remoteStore, cache, trustPinning)
+	if err != nil {
+		return nil, err
+	}
+	repo.(*verifyOnlyRepository).roundTrip = rt
+	repo.(*verifyOnlyRepository).baseDir = baseDir
+	return repo, nil
+}
+
+// NewVerifyOnlyRepository returns a notary repository that can only read and
+// verify trust data, for deployments where the verifier must never have access
+// to private keys.  It expects an initialized cache.  In case of a nil remote
+// store, a default offline store is used.
+func NewVerifyOnlyRepository(gun data.GUN, baseURL string, remoteStore store.RemoteStore, cache store.MetadataStore,
+	trustPinning trustpinning.TrustPinConfig) (Repository, error) {
+
+	r, err := NewRepository(gun, baseURL, remoteStore, cache, trustPinning,
+		cryptoservice.EmptyService, changelist.NewMemChangelist())
+	if err != nil {
+		return nil, err
+	}
+	return &verifyOnlyRepository{repository: r.(*repository)}, nil
+}
+
+// Initialize always fails, since initializing a repository requires signing
+func (r *verifyOnlyRepository) Initialize(rootKeyIDs []string, serverManagedRoles ...data.RoleName) error {
+	return ErrNoSigningCapability{Operation: "initialize repository"}
+}
+
+// InitializeWithCertificate always fails, since initializing a repository requires signing
+func (r *verifyOnlyRepository) InitializeWithCertificate(rootKeyIDs []string, rootCerts []data.PublicKey,
+	serverManagedRoles ...data.RoleName) error {
+	return ErrNoSigningCapability{Operation: "initialize repository"}
+}
+
+// Publish always fails, since publishing requires signing
+func (r *verifyOnlyRepository) Publish() error {
+	return ErrNoSigningCapability{Operation: "publish"}
+}
+
+// UploadPlan always fails, since planning a publish requires signing
+func (r *verifyOnlyRepository) UploadPlan() (map[data.RoleName]int64, error) {
+	return nil, ErrNoSigningCapability{Operation: "plan publish"}
+}
+
+// AddTarget always fails, since the change could never be signed
+func (r *verifyOnlyRepository) AddTarget(target *Target, roles ...data.RoleName) error {
+	return ErrNoSigningCapability{Operation: "add target"}
+}
+
+// AddPrecomputedTarget always fails, since the change could never be signed
+func (r *verifyOnlyRepository) AddPrecomputedTarget(role data.RoleName, name string, meta data.FileMeta) error {
+	return ErrNoSigningCapability{Operation: "add target"}
+}
+
+// AddTargetsFromLockfile always fails, since the changes could never be signed
+func (r *verifyOnlyRepository) AddTargetsFromLockfile(role data.RoleName, format string, lockfile io.Reader) error {
+	return ErrNoSigningCapability{Operation: "add targets from lockfile"}
+}
+
+// RemoveTarget always fails, since the change could never be signed
+func (r *verifyOnlyRepository) RemoveTarget(targetName string, roles ...data.RoleName) error {
+	return ErrNoSigningCapability{Operation: "remove target"}
+}
+
+// SetTargetAnnotations always fails, since the change could never be signed
+func (r *verifyOnlyRepository) SetTargetAnnotations(role data.RoleName, name string, annotations map[string]string) error {
+	return ErrNoSigningCapability{Operation: "set target annotations"}
+}
+
+// SetKeyPurposes always fails, since the change could never be signed
+func (r *verifyOnlyRepository) SetKeyPurposes(role data.RoleName, keyID string, purposes ...string) error {
+	return ErrNoSigningCapability{Operation: "set key purposes"}
+}
+
+// SetThreshold always fails, since the change could never be signed
+func (r *verifyOnlyRepository) SetThreshold(role data.RoleName, threshold int, allowDowngrade bool) error {
+	return ErrNoSigningCapability{Operation: "set threshold"}
+}
+
+// RevokeRepository always fails, since revoking a repository requires signing
+func (r *verifyOnlyRepository) RevokeRepository(reason string) error {
+	return ErrNoSigningCapability{Operation: "revoke repository"}
+}
+
+// ImportChangelist always fails, since the changes could never be signed
+func (r *verifyOnlyRepository) ImportChangelist(exported []byte, mode ChangelistImportMode) error {
+	return ErrNoSigningCapability{Operation: "import changelist"}
+}
+
+// AddDelegation always fails, since the change could never be signed
+func (r *verifyOnlyRepository) AddDelegation(name data.RoleName, delegationKeys []data.PublicKey, paths []string) error {
+	return ErrNoSigningCapability{Operation: "add delegation"}
+}
+
+// AddDelegationRoleAndKeys always fails, since the change could never be signed
+func (r *verifyOnlyRepository) AddDelegationRoleAndKeys(name data.RoleName, delegationKeys []data.PublicKey) error {
+	return ErrNoSigningCapability{Operation: "add delegation"}
+}
+
+// AddDelegationPaths always fails, since the change could never be signed
+func (r *verifyOnlyRepository) AddDelegationPaths(name data.RoleName, paths []string) error {
+	return ErrNoSigningCapability{Operation: "add delegation paths"}
+}
+
+// RemoveDelegationKeysAndPaths always fails, since the change could never be signed
+func (r *verifyOnlyRepository) RemoveDelegationKeysAndPaths(name data.RoleName, keyIDs, paths []string) error {
+	return ErrNoSigningCapability{Operation: "remove delegation keys and paths"}
+}
+
+// RemoveDelegationRole always fails, since the change could never be signed
+func (r *verifyOnlyRepository) RemoveDelegationRole(name data.RoleName) error {
+	return ErrNoSigningCapability{Operation: "remove delegation"}
+}
+
+// RemoveDelegationPaths always fails, since the change could never be signed
+func (r *verifyOnlyRepository) RemoveDelegationPaths(name data.RoleName, paths []string) error {
+	return ErrNoSigningCapability{Operation: "remove delegation paths"}
+}
+
+// RemoveDelegationKeys always fails, since the change could never be signed
+func (r *verifyOnlyRepository) RemoveDelegationKeys(name data.RoleName, keyIDs []string) error {
+	return ErrNoSigningCapability{Operation: "remove delegation keys"}
+}
+
+// ClearDelegationPaths always fails, since the change could never be signed
+func (r *verifyOnlyRepository) ClearDelegationPaths(name data.RoleName) error {
+	return ErrNoSigningCapability{Operation: "clear delegation paths"}
+}
+
+// Witness always fails, since witnessing requires re-signing
+func (r *verifyOnlyRepository) Witness(roles ...data.RoleName) ([]data.RoleName, error) {
+	return nil, ErrNoSigningCapability{Operation: "witness"}
+}
+
+// RotateKey always fails, since key rotation requires signing
+func (r *verifyOnlyRepository) RotateKey(role data.RoleName, serverManagesKey bool, keyList []string) error {
+	return ErrNoSigningCapability{Operation: "rotate key"}
+}
+
+// RotateAllLocalKeys always fails, since key rotation requires signing
+func (r *verifyOnlyRepository) RotateAllLocalKeys() (map[data.RoleName]string, error) {
+	return nil, ErrNoSigningCapability{Operation: "rotate key"}
+}
+
+// SignedTargetManifest always fails, since the manifest is signed with a held
+// private key
+func (r *verifyOnlyRepository) SignedTargetManifest(keyID string) ([]byte, error) {
+	return nil, ErrNoSigningCapability{Operation: "sign target manifest"}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// A verify-only repository can read and verify published trust data
+func TestVerifyOnlyRepositoryReads(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	target := addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	remote, err := getRemoteStore(ts.URL, repo.gun, http.DefaultTransport)
+	require.NoError(t, err)
+	verifier, err := NewVerifyOnlyRepository(repo.gun, ts.URL, remote, store.NewMemoryStore(nil),
+		trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+
+	found, err := verifier.GetTargetByName("latest")
+	require.NoError(t, err)
+	require.Equal(t, target.Hashes, found.Hashes)
+
+	roles, err := verifier.ListRoles()
+	require.NoError(t, err)
+	require.Len(t, roles, len(data.BaseRoles))
+	require.Empty(t, verifier.GetCryptoService().ListAllKeys())
+}
+
+// Every signing operation on a verify-only repository fails up front, without
+// staging any changes
+func TestVerifyOnlyRepositoryRejectsSigning(t *testing.T) {
+	verifier, err := NewVerifyOnlyRepository("docker.com/notary", "", nil, store.NewMemoryStore(nil),
+		trustpinning.TrustPinConfig{})
+	require.NoError(t, err)
+
+	target := &Target{Name: "latest", Hashes: data.Hashes{"sha256": make([]byte, 32)}, Length: 1}
+	for _, op := range []func() error{
+		func() error { return verifier.Initialize(nil) },
+		func() error { return verifier.InitializeWithCertificate(nil, nil) },
+		verifier.Publish,
+		func() error {
+			_, err := verifier.UploadPlan()
+			return err
+		},
+		func() error { return verifier.AddTarget(target) },
+		func() error {
+			return verifier.AddPrecomputedTarget(data.CanonicalTargetsRole, target.Name,
+				data.FileMeta{Length: target.Length, Hashes: target.Hashes})
+		},
+		func() error {
+			return verifier.AddTargetsFromLockfile(data.CanonicalTargetsRole, LockfileRequirements,
+				strings.NewReader("requests==2.25.1 --hash=sha256:"+strings.Repeat("a", 64)+"\n"))
+		},
+		func() error { return verifier.RemoveTarget("latest") },
+		func() error { return verifier.SetTargetAnnotations(data.CanonicalTargetsRole, "latest", nil) },
+		func() error {
+			return verifier.SetKeyPurposes(data.CanonicalRootRole, strings.Repeat("a", 64), "primary")
+		},
+		func() error { return verifier.SetThreshold(data.CanonicalTargetsRole, 2, false) },
+		func() error { return verifier.AddDelegation("targets/a", nil, []string{""}) },
+		func() error { return verifier.AddDelegationRoleAndKeys("targets/a", nil) },
+		func() error { return verifier.AddDelegationPaths("targets/a", []string{""}) },
+		func() error { return verifier.RemoveDelegationKeysAndPaths("targets/a", nil, nil) },
+		func() error { return verifier.RemoveDelegationRole("targets/a") },
+		func() error { return verifier.RemoveDelegationPaths("targets/a", nil) },
+		func() error { return verifier.RemoveDelegationKeys("targets/a", nil) },
+		func() error { return verifier.ClearDelegationPaths("targets/a") },
+		func() error {
+			_, err := verifier.Witness(data.CanonicalTargetsRole)
+			return err
+		},
+		func() error { return verifier.RotateKey(data.CanonicalSnapshotRole, true, nil) },
+		func() error {
+			_, err := verifier.SignedTargetManifest(strings.Repeat("a", 64))
+			return err
+		},
+	} {
+		require.IsType(t, ErrNoSigningCapability{}, op())
+	}
+
+	cl, err := verifier.GetChangelist()
+	require.NoError(t, err)
+	require.Empty(t, cl.List())
+}
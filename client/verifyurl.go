@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// ErrTargetDownload is returned when an artifact cannot be fetched from a URL
+type ErrTargetDownload struct {
+	URL        string
+	StatusCode int
+}
+
+func (err ErrTargetDownload) Error() string {
+	return fmt.Sprintf("could not download %s: server returned status %d", err.URL, err.StatusCode)
+}
+
+// ErrTargetLengthMismatch is returned when an artifact is not the signed
+// length of a target.  Actual is the number of bytes read, which stops one
+// byte past the expected length, so a longer artifact is reported as
+// Expected+1 bytes.
+type ErrTargetLengthMismatch struct {
+	Target   string
+	Role     data.RoleName
+	Expected int64
+	Actual   int64
+}
+
+func (err ErrTargetLengthMismatch) Error() string {
+	if err.Actual > err.Expected {
+		return fmt.Sprintf("artifact for target %s is longer than the %d bytes signed in %s",
+			err.Target, err.Expected, err.Role)
+	}
+	return fmt.Sprintf("artifact for target %s is %d bytes, but %d bytes are signed in %s",
+		err.Target, err.Actual, err.Expected, err.Role)
+}
+
+// ErrTargetHashMismatch is returned when an artifact does not have the
+// signed digest of a target
+type ErrTargetHashMismatch struct {
+	Target    string
+	Role      data.RoleName
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (err ErrTargetHashMismatch) Error() string {
+	return fmt.Sprintf("artifact for target %s has %s digest %s, but %s is signed in %s",
+		err.Target, err.Algorithm, err.Actual, err.Expected, err.Role)
+}
+
+// VerifyTargetFromURL resolves the named target, verifying it as
+// GetTargetByName does, and then downloads the artifact from the URL,
+// checking it against the target's signed length and digests as it is read.
+// A nil httpClient uses http.DefaultClient.
+func (r *repository) VerifyTargetFromURL(targetName, url string, httpClient *http.Client) error {
+	return r.VerifyTargetFromURLWithContext(context.Background(), targetName, url, httpClient)
+}
+
+// VerifyTargetFromURLWithContext is VerifyTargetFromURL, abandoning the
+// download when the context is cancelled.  The artifact is never held in
+// memory, and no more than one byte past the signed length is read.
+func (r *repository) VerifyTargetFromURLWithContext(ctx context.Context, targetName, url string, httpClient *http.Client) error {
+	target, err := r.GetTargetByName(targetName)
+	if err != nil {
+		return err
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return ErrTargetDownload{URL: url, StatusCode: resp.StatusCode}
+	}
+
+	return verifyTargetStream(target, io.LimitReader(resp.Body, target.Length+1))
+}
+
+// verifyTargetStream checks the artifact read from the reader against the
+// target's signed length and every signed digest of a supported algorithm
+func verifyTargetStream(target *TargetWithRole, artifact io.Reader) error {
+	algorithms := make([]string, 0, len(target.Hashes))
+	hashers := make(map[string]hash.Hash, len(target.Hashes))
+	for alg := range target.Hashes {
+		var h hash.Hash
+		switch alg {
+		case notary.SHA256:
+			h = sha256.New()
+		case notary.SHA512:
+			h = sha512.New()
+		default:
+			continue
+		}
+		algorithms = append(algorithms, alg)
+		hashers[alg] = h
+		artifact = io.TeeReader(artifact, h)
+	}
+	if len(algorithms) == 0 {
+		return data.ErrMissingMeta{Role: target.Name}
+	}
+	sort.Strings(algorithms)
+
+	n, err := io.Copy(ioutil.Discard, artifact)
+	if err != nil {
+		return err
+	}
+	if n != target.Length {
+		return ErrTargetLengthMismatch{Target: target.Name, Role: target.Role, Expected: target.Length, Actual: n}
+	}
+	for _, alg := range algorithms {
+		actual := hashers[alg].Sum(nil)
+		if subtle.ConstantTimeCompare(actual, target.Hashes[alg]) == 0 {
+			return ErrTargetHashMismatch{
+				Target:    target.Name,
+				Role:      target.Role,
+				Algorithm: alg,
+				Expected:  hex.EncodeToString(target.Hashes[alg]),
+				Actual:    hex.EncodeToString(actual),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Artifacts downloaded over HTTP are checked against the target's signed
+// length and digests, reporting exactly how a download fails to match
+func TestVerifyTargetFromURL(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	artifact, err := ioutil.ReadFile("../fixtures/intermediate-ca.crt")
+	require.NoError(t, err)
+	corrupted := append([]byte(nil), artifact...)
+	corrupted[len(corrupted)/2] ^= 0xff
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/correct", func(w http.ResponseWriter, r *http.Request) { w.Write(artifact) })
+	mux.HandleFunc("/corrupted", func(w http.ResponseWriter, r *http.Request) { w.Write(corrupted) })
+	mux.HandleFunc("/truncated", func(w http.ResponseWriter, r *http.Request) { w.Write(artifact[:10]) })
+	mux.HandleFunc("/extended", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(append(append([]byte(nil), artifact...), artifact...))
+	})
+	artifacts := httptest.NewServer(mux)
+	defer artifacts.Close()
+
+	require.NoError(t, repo.VerifyTargetFromURL("latest", artifacts.URL+"/correct", nil))
+	require.NoError(t, repo.VerifyTargetFromURL("latest", artifacts.URL+"/correct", artifacts.Client()))
+
+	err = repo.VerifyTargetFromURL("latest", artifacts.URL+"/corrupted", nil)
+	require.IsType(t, ErrTargetHashMismatch{}, err)
+	mismatch := err.(ErrTargetHashMismatch)
+	require.Equal(t, "latest", mismatch.Target)
+	require.Equal(t, data.CanonicalTargetsRole, mismatch.Role)
+	require.NotEqual(t, mismatch.Expected, mismatch.Actual)
+
+	err = repo.VerifyTargetFromURL("latest", artifacts.URL+"/truncated", nil)
+	require.Equal(t, ErrTargetLengthMismatch{
+		Target: "latest", Role: data.CanonicalTargetsRole, Expected: int64(len(artifact)), Actual: 10}, err)
+
+	err = repo.VerifyTargetFromURL("latest", artifacts.URL+"/extended", nil)
+	require.Equal(t, ErrTargetLengthMismatch{
+		Target: "latest", Role: data.CanonicalTargetsRole, Expected: int64(len(artifact)), Actual: int64(len(artifact)) + 1}, err)
+
+	err = repo.VerifyTargetFromURL("latest", artifacts.URL+"/missing", nil)
+	require.Equal(t, ErrTargetDownload{URL: artifacts.URL + "/missing", StatusCode: http.StatusNotFound}, err)
+
+	err = repo.VerifyTargetFromURL("nonexistent", artifacts.URL+"/correct", nil)
+	require.IsType(t, ErrNoSuchTarget(""), err)
+}
+
+// A cancelled context abandons the download before the artifact is read
+func TestVerifyTargetFromURLCancelled(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+	addTarget(t, repo, "latest", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+
+	requested := false
+	artifacts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer artifacts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := repo.VerifyTargetFromURLWithContext(ctx, "latest", artifacts.URL, nil)
+	require.Error(t, err)
+	require.Equal(t, context.Canceled, ctx.Err())
+	require.False(t, requested)
+}
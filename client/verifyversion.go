@@ -0,0 +1,201 @@
+package client
+
+import (
+	"fmt"
+	"time"
+
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// ErrVersionNotRetained is returned when a version of a role's metadata,
+// needed to verify a historical version, is not retained by the server
+type ErrVersionNotRetained struct {
+	Role    data.RoleName
+	Version int
+}
+
+func (err ErrVersionNotRetained) Error() string {
+	return fmt.Sprintf("version %d of the %s metadata is not retained", err.Version, err.Role)
+}
+
+// ErrInvalidVersion is returned when a retained version of a role's metadata
+// is not the version it was retained as, or the metadata it should be
+// verified against cannot be determined
+type ErrInvalidVersion struct {
+	Role    data.RoleName
+	Version int
+	Reason  string
+}
+
+func (err ErrInvalidVersion) Error() string {
+	return fmt.Sprintf("unable to verify version %d of the %s metadata: %s", err.Version, err.Role, err.Reason)
+}
+
+// VerifyVersion verifies the signatures of a version of a role's metadata, as
+// retained by the server, against the keys which were trusted for the role
+// when it was signed, regardless of whether it has since expired.
+//
+// A version of the root is verified against its own root keys and those of
+// the previous version, as a client updating to it would have.  Any other
+// role is verified against the latest root, and for a delegation the latest
+// version of its parent, issued no later than the version's issued time; the
+// parent is itself verified in the same way.  ErrVersionNotRetained is
+// returned if any of these versions is not retained.
+func (r *repository) VerifyVersion(role data.RoleName, version int) error {
+	if err := r.updateTUF(false); err != nil {
+		return err
+	}
+	latest := map[data.RoleName]int{data.CanonicalRootRole: r.tufRepo.Root.Signed.Version}
+	for roleName, targets := range r.tufRepo.Targets {
+		latest[roleName] = targets.Signed.Version
+	}
+	verifier := versionVerifier{remote: r.getRemoteStore(), latest: latest}
+	_, err := verifier.verify(role, version)
+	return err
+}
+
+// versionVerifier verifies versions of the metadata retained by the server
+type versionVerifier struct {
+	remote store.RemoteStore
+	// latest is the latest trusted version of each role which can sign
+	// another, from which the versions current at a time are searched for
+	latest map[data.RoleName]int
+}
+
+// fetch returns a retained version of the role's metadata, checking that it
+// declares the role's type and the version it was retained as
+func (v versionVerifier) fetch(role data.RoleName, version int) (*data.Signed, *data.SignedCommon, error) {
+	raw, err := v.remote.GetSized(fmt.Sprintf("%d.%s", version, role), store.NoSizeLimit)
+	if err != nil {
+		if _, ok := err.(store.ErrMetaNotFound); ok {
+			return nil, nil, ErrVersionNotRetained{Role: role, Version: version}
+		}
+		return nil, nil, err
+	}
+	s := &data.Signed{}
+	if err := data.UnmarshalMetadata(raw, s); err != nil {
+		return nil, nil, err
+	}
+	meta := &data.SignedMeta{}
+	if err := data.UnmarshalMetadata(raw, meta); err != nil {
+		return nil, nil, err
+	}
+	if !data.ValidTUFType(meta.Signed.Type, role) {
+		return nil, nil, data.ErrRoleTypeMismatch{Role: role, Type: meta.Signed.Type}
+	}
+	if meta.Signed.Version != version {
+		return nil, nil, ErrInvalidVersion{Role: role, Version: version,
+			Reason: fmt.Sprintf("it is signed as version %d", meta.Signed.Version)}
+	}
+	return s, &meta.Signed, nil
+}
+
+// verify fetches a version of the role's metadata and verifies its signatures
+// against the keys trusted for the role when it was signed
+func (v versionVerifier) verify(role data.RoleName, version int) (*data.Signed, error) {
+	s, common, err := v.fetch(role, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if role == data.CanonicalRootRole {
+		if err := verifyRootSignatures(s, s); err != nil {
+			return nil, err
+		}
+		if version > 1 {
+			previous, _, err := v.fetch(role, version-1)
+			if err != nil {
+				return nil, err
+			}
+			if err := verifyRootSignatures(s, previous); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	}
+
+	if common.Issued == nil {
+		return nil, ErrInvalidVersion{Role: role, Version: version,
+			Reason: "it records no issued time, so the keys trusted when it was signed cannot be determined"}
+	}
+	signer := data.CanonicalRootRole
+	if data.IsDelegation(role) {
+		signer = role.Parent()
+	}
+	signerVersion, err := v.versionAsOf(signer, *common.Issued)
+	if err != nil {
+		return nil, err
+	}
+	if signerVersion == 0 {
+		return nil, ErrInvalidVersion{Role: role, Version: version,
+			Reason: fmt.Sprintf("no retained version of %s had been issued when it was signed", signer)}
+	}
+	signerSigned, err := v.verify(signer, signerVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys data.BaseRole
+	if signer == data.CanonicalRootRole {
+		signedRoot, err := data.RootFromSigned(signerSigned)
+		if err != nil {
+			return nil, err
+		}
+		if keys, err = signedRoot.BuildBaseRole(role); err != nil {
+			return nil, err
+		}
+	} else {
+		signedParent, err := data.TargetsFromSigned(signerSigned, signer)
+		if err != nil {
+			return nil, err
+		}
+		delegation, err := signedParent.BuildDelegationRole(role)
+		if err != nil {
+			return nil, err
+		}
+		keys = delegation.BaseRole
+	}
+	if err := signed.VerifySignatures(s, keys); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// versionAsOf returns the latest version of the role issued no later than the
+// given time, or 0 if none was
+func (v versionVerifier) versionAsOf(role data.RoleName, t time.Time) (int, error) {
+	latest, ok := v.latest[role]
+	if !ok {
+		return 0, nil
+	}
+	for version := latest; version >= 1; version-- {
+		_, common, err := v.fetch(role, version)
+		if err != nil {
+			return 0, err
+		}
+		if common.Issued == nil {
+			return 0, ErrInvalidVersion{Role: role, Version: version,
+				Reason: "it records no issued time, so whether it was current at a time cannot be determined"}
+		}
+		if !common.Issued.After(t) {
+			return version, nil
+		}
+	}
+	return 0, nil
+}
+
+// verifyRootSignatures verifies the root's signatures against the root keys
+// of the trusted root
+func verifyRootSignatures(root, trusted *data.Signed) error {
+	signedRoot, err := data.RootFromSigned(trusted)
+	if err != nil {
+		return err
+	}
+	keys, err := signedRoot.BuildBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return err
+	}
+	return signed.VerifySignatures(root, keys)
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+	store "github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// tamperingRemote serves altered or missing metadata for some names
+type tamperingRemote struct {
+	store.RemoteStore
+	tampered map[string][]byte
+	missing  map[string]bool
+}
+
+func (s tamperingRemote) GetSized(name string, size int64) ([]byte, error) {
+	if s.missing[name] {
+		return nil, store.ErrMetaNotFound{Resource: name}
+	}
+	if meta, ok := s.tampered[name]; ok {
+		return meta, nil
+	}
+	return s.RemoteStore.GetSized(name, size)
+}
+
+// Every historical version, including those signed with keys since rotated
+// out, verifies against the keys trusted when it was signed
+func TestVerifyVersion(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	addTarget(t, repo, "a", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	firstRoot, firstTargets := publishedVersions(t, repo)
+
+	require.NoError(t, repo.RotateKey(data.CanonicalTargetsRole, false, nil))
+	addTarget(t, repo, "b", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	lastRoot, lastTargets := publishedVersions(t, repo)
+	require.True(t, lastRoot > firstRoot)
+	require.True(t, lastTargets > firstTargets)
+
+	for version := 1; version <= lastRoot; version++ {
+		require.NoError(t, repo.VerifyVersion(data.CanonicalRootRole, version))
+	}
+	for version := firstTargets; version <= lastTargets; version++ {
+		require.NoError(t, repo.VerifyVersion(data.CanonicalTargetsRole, version))
+	}
+	require.NoError(t, repo.VerifyVersion(data.CanonicalSnapshotRole, repo.tufRepo.Snapshot.Signed.Version))
+	require.NoError(t, repo.VerifyVersion(data.CanonicalTimestampRole, repo.tufRepo.Timestamp.Signed.Version))
+
+	err := repo.VerifyVersion(data.CanonicalTargetsRole, lastTargets+1)
+	require.Equal(t, ErrVersionNotRetained{Role: data.CanonicalTargetsRole, Version: lastTargets + 1}, err)
+}
+
+// A historical version altered after it was signed, or retained as another
+// version, fails verification, as does one whose root is not retained
+func TestVerifyVersionTampered(t *testing.T) {
+	ts := fullTestServer(t)
+	defer ts.Close()
+
+	repo, _, baseDir := initializeRepo(t, data.ECDSAKey, "docker.com/notary", ts.URL, false)
+	defer os.RemoveAll(baseDir)
+
+	addTarget(t, repo, "a", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	_, firstTargets := publishedVersions(t, repo)
+	addTarget(t, repo, "b", "../fixtures/intermediate-ca.crt")
+	require.NoError(t, repo.Publish())
+	_, lastTargets := publishedVersions(t, repo)
+
+	remote := repo.getRemoteStore()
+	firstName := fmt.Sprintf("%d.%s", firstTargets, data.CanonicalTargetsRole)
+	raw, err := remote.GetSized(firstName, store.NoSizeLimit)
+	require.NoError(t, err)
+
+	// a target is added, keeping the original signatures
+	s := &data.Signed{}
+	require.NoError(t, json.Unmarshal(raw, s))
+	signedTargets, err := data.TargetsFromSigned(s, data.CanonicalTargetsRole)
+	require.NoError(t, err)
+	signedTargets.Signed.Targets["evil"] = data.FileMeta{Length: 1, Hashes: data.Hashes{"sha256": make([]byte, 32)}}
+	tamperedSigned, err := signedTargets.ToSigned()
+	require.NoError(t, err)
+	tampered, err := json.Marshal(tamperedSigned)
+	require.NoError(t, err)
+
+	lastName := fmt.Sprintf("%d.%s", lastTargets, data.CanonicalTargetsRole)
+	repo.remoteStore = tamperingRemote{
+		RemoteStore: remote,
+		tampered:    map[string][]byte{firstName: tampered, lastName: raw},
+	}
+	err = repo.VerifyVersion(data.CanonicalTargetsRole, firstTargets)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+
+	// a validly signed version retained as a later one
+	err = repo.VerifyVersion(data.CanonicalTargetsRole, lastTargets)
+	require.IsType(t, ErrInvalidVersion{}, err)
+
+	repo.remoteStore = tamperingRemote{
+		RemoteStore: remote,
+		missing:     map[string]bool{"1.root": true},
+	}
+	err = repo.VerifyVersion(data.CanonicalTargetsRole, firstTargets)
+	require.Equal(t, ErrVersionNotRetained{Role: data.CanonicalRootRole, Version: 1}, err)
+}
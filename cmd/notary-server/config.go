@@ -18,6 +18,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/theupdateframework/notary"
 	"github.com/theupdateframework/notary/server"
+	"github.com/theupdateframework/notary/server/handlers"
 	"github.com/theupdateframework/notary/server/storage"
 	"github.com/theupdateframework/notary/signer/client"
 	"github.com/theupdateframework/notary/storage/rethinkdb"
@@ -40,6 +41,55 @@ func getRequiredGunPrefixes(configuration *viper.Viper) ([]string, error) {
 	return prefixes, nil
 }
 
+// gets the lowest threshold this server will accept for any role in published
+// metadata.  If none is configured, any threshold is accepted.
+func getMinimumThreshold(configuration *viper.Viper) (int, error) {
+	if !configuration.IsSet("repositories.minimum_threshold") {
+		return notary.MinThreshold, nil
+	}
+	minimum := configuration.GetInt("repositories.minimum_threshold")
+	if minimum < notary.MinThreshold {
+		return 0, fmt.Errorf("invalid minimum threshold %d", minimum)
+	}
+	return minimum, nil
+}
+
+// gets the batcher coalescing the publishes of a GUN into a single snapshot
+// and timestamp signing, if batching is configured with a positive window
+func getUpdateBatcher(configuration *viper.Viper) (*handlers.UpdateBatcher, error) {
+	if !configuration.IsSet("repositories.update_batching.window") {
+		return nil, nil
+	}
+	window, err := time.ParseDuration(configuration.GetString("repositories.update_batching.window"))
+	if err != nil || window < 0 {
+		return nil, fmt.Errorf("invalid update batching window %s",
+			configuration.GetString("repositories.update_batching.window"))
+	}
+	workers := configuration.GetInt("repositories.update_batching.workers")
+	if workers < 0 {
+		return nil, fmt.Errorf("invalid number of update batching workers %d", workers)
+	}
+	if window == 0 {
+		return nil, nil
+	}
+	return handlers.NewUpdateBatcher(window, workers), nil
+}
+
+// gets the signature methods this server will accept on published metadata.
+// If none are configured, any method is accepted.
+func getAllowedSignatureMethods(configuration *viper.Viper) ([]data.SigAlgorithm, error) {
+	methods := configuration.GetStringSlice("repositories.signature_methods")
+	allowed := make([]data.SigAlgorithm, 0, len(methods))
+	for _, method := range methods {
+		sigAlgorithm := data.SigAlgorithm(strings.TrimSpace(method))
+		if _, ok := signed.Verifiers[sigAlgorithm]; !ok {
+			return nil, fmt.Errorf("invalid signature method %s", method)
+		}
+		allowed = append(allowed, sigAlgorithm)
+	}
+	return allowed, nil
+}
+
 // get the address for the HTTP server, and parses the optional TLS
 // configuration for the server - if no TLS configuration is specified,
 // TLS is not enabled.
@@ -126,7 +176,41 @@ func getStore(configuration *viper.Viper, hRegister healthRegister, doBootstrap
 	default:
 		return nil, fmt.Errorf("%s is not a supported storage backend", backend)
 	}
-	return store, nil
+
+	journal, policy, err := getJournal(configuration)
+	if err != nil || journal == nil {
+		return store, err
+	}
+	logrus.Infof("Journaling publishes to %s", configuration.GetString("storage.journal.path"))
+	journaled, err := storage.NewJournaledStore(store, journal, policy)
+	if err != nil {
+		return nil, err
+	}
+	return journaled, nil
+}
+
+// gets the write-ahead journal publishes are recorded in before being applied
+// to the storage backend, and how publishes left uncommitted by a crash are
+// recovered, if a journal is configured
+func getJournal(configuration *viper.Viper) (storage.Journal, storage.JournalRecoveryPolicy, error) {
+	path := configuration.GetString("storage.journal.path")
+	if path == "" {
+		return nil, storage.JournalReplay, nil
+	}
+	var policy storage.JournalRecoveryPolicy
+	switch recovery := configuration.GetString("storage.journal.recovery"); recovery {
+	case "", "replay":
+		policy = storage.JournalReplay
+	case "discard":
+		policy = storage.JournalDiscard
+	default:
+		return nil, policy, fmt.Errorf("invalid journal recovery policy %s", recovery)
+	}
+	journal, err := storage.NewFileJournal(path)
+	if err != nil {
+		return nil, policy, err
+	}
+	return journal, policy, nil
 }
 
 type signerFactory func(hostname, port string, tlsConfig *tls.Config) (*client.NotarySigner, error)
@@ -250,6 +334,30 @@ func parseServerConfig(configFilePath string, hRegister healthRegister, doBootst
 		return nil, server.Config{}, err
 	}
 
+	signatureMethods, err := getAllowedSignatureMethods(config)
+	if err != nil {
+		return nil, server.Config{}, err
+	}
+	if len(signatureMethods) > 0 {
+		ctx = context.WithValue(ctx, notary.CtxKeySignatureMethods, signatureMethods)
+	}
+
+	minimumThreshold, err := getMinimumThreshold(config)
+	if err != nil {
+		return nil, server.Config{}, err
+	}
+	if minimumThreshold > notary.MinThreshold {
+		ctx = context.WithValue(ctx, notary.CtxKeyMinimumThreshold, minimumThreshold)
+	}
+
+	batcher, err := getUpdateBatcher(config)
+	if err != nil {
+		return nil, server.Config{}, err
+	}
+	if batcher != nil {
+		ctx = context.WithValue(ctx, notary.CtxKeyUpdateBatcher, batcher)
+	}
+
 	// parse bugsnag config
 	bugsnagConf, err := utils.ParseBugsnag(config)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -318,6 +319,29 @@ func TestGetStoreDBStore(t *testing.T) {
 	require.Equal(t, 1, registerCalled)
 }
 
+func TestGetStoreJournaled(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	dbFile := filepath.Join(tmpDir, "sqlite3")
+	journalFile := filepath.Join(tmpDir, "journal")
+
+	config := fmt.Sprintf(`{"storage": {"backend": "%s", "db_url": "%s", "journal": {"path": "%s"}}}`,
+		notary.SQLiteBackend, dbFile, journalFile)
+	var registerCalled = 0
+	store, err := getStore(configure(config), fakeRegisterer(&registerCalled), false)
+	require.NoError(t, err)
+	_, ok := store.(*storage.JournaledStore)
+	require.True(t, ok)
+	_, err = os.Stat(journalFile)
+	require.NoError(t, err)
+
+	config = fmt.Sprintf(`{"storage": {"backend": "%s", "db_url": "%s", "journal": {"path": "%s", "recovery": "undo"}}}`,
+		notary.SQLiteBackend, dbFile, journalFile)
+	_, err = getStore(configure(config), fakeRegisterer(&registerCalled), false)
+	require.Error(t, err)
+}
+
 func TestGetStoreRethinkDBStoreConnectionFails(t *testing.T) {
 	config := fmt.Sprintf(
 		`{"storage": {
@@ -404,6 +428,67 @@ func TestGetGUNPRefixes(t *testing.T) {
 	}
 }
 
+func TestGetAllowedSignatureMethods(t *testing.T) {
+	valids := map[string][]data.SigAlgorithm{
+		`{}`: {},
+		`{"repositories": {"signature_methods": ["ecdsa"]}}`:             {data.ECDSASignature},
+		`{"repositories": {"signature_methods": ["eddsa", " rsapss "]}}`: {data.EDDSASignature, data.RSAPSSSignature},
+	}
+	invalids := []string{
+		`{"repositories": {"signature_methods": ["md5"]}}`,
+		`{"repositories": {"signature_methods": ["ecdsa", "ECDSA"]}}`,
+	}
+
+	for valid, expected := range valids {
+		methods, err := getAllowedSignatureMethods(configure(valid))
+		require.NoError(t, err)
+		require.Equal(t, expected, methods)
+	}
+	for _, invalid := range invalids {
+		_, err := getAllowedSignatureMethods(configure(invalid))
+		require.Error(t, err, "expected error with %s", invalid)
+	}
+}
+
+func TestGetMinimumThreshold(t *testing.T) {
+	valids := map[string]int{
+		`{}`: 1,
+		`{"repositories": {"minimum_threshold": 1}}`: 1,
+		`{"repositories": {"minimum_threshold": 2}}`: 2,
+	}
+	for valid, expected := range valids {
+		minimum, err := getMinimumThreshold(configure(valid))
+		require.NoError(t, err)
+		require.Equal(t, expected, minimum)
+	}
+	_, err := getMinimumThreshold(configure(`{"repositories": {"minimum_threshold": 0}}`))
+	require.Error(t, err)
+}
+
+func TestGetUpdateBatcher(t *testing.T) {
+	for _, disabled := range []string{
+		`{}`,
+		`{"repositories": {"update_batching": {"window": "0s"}}}`,
+	} {
+		batcher, err := getUpdateBatcher(configure(disabled))
+		require.NoError(t, err)
+		require.Nil(t, batcher)
+	}
+	batcher, err := getUpdateBatcher(configure(`{"repositories": {"update_batching": {"window": "50ms", "workers": 4}}}`))
+	require.NoError(t, err)
+	require.NotNil(t, batcher)
+
+	invalids := []string{
+		`{"repositories": {"update_batching": {"window": "soon"}}}`,
+		`{"repositories": {"update_batching": {"window": "-1s"}}}`,
+		`{"repositories": {"update_batching": {"window": "50ms", "workers": -1}}}`,
+	}
+	for _, invalid := range invalids {
+		_, err := getUpdateBatcher(configure(invalid))
+		require.Error(t, err, "expected error with %s", invalid)
+	}
+}
+
 // For sanity, make sure we can always parse the sample config
 func TestSampleConfig(t *testing.T) {
 	var registerCalled = 0
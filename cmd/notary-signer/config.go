@@ -75,13 +75,46 @@ func parseSignerConfig(configFilePath string, doBootstrap bool) (signer.Config,
 		return signer.Config{}, err
 	}
 
+	rateLimits, err := getRateLimits(config)
+	if err != nil {
+		return signer.Config{}, err
+	}
+
 	return signer.Config{
 		GRPCAddr:       grpcAddr,
 		TLSConfig:      tlsConfig,
 		CryptoServices: cryptoServices,
+		RateLimits:     rateLimits,
 	}, nil
 }
 
+// gets the rate limits of key creation and signing by role, which override
+// the default limits of the roles configured
+func getRateLimits(configuration *viper.Viper) (signer.RateLimits, error) {
+	limits := signer.DefaultRateLimits()
+	for endpoint, byRole := range map[string]map[data.RoleName]signer.RateLimit{
+		"create_key": limits.CreateKey,
+		"sign":       limits.Sign,
+	} {
+		section := "rate_limits." + endpoint
+		for role := range configuration.GetStringMap(section) {
+			if !data.IsBaseRole(data.RoleName(role)) {
+				return signer.RateLimits{}, fmt.Errorf("invalid role %s for %s rate limits", role, endpoint)
+			}
+			limit := signer.RateLimit{
+				Rate:  configuration.GetFloat64(section + "." + role + ".rate"),
+				Burst: configuration.GetInt(section + "." + role + ".burst"),
+			}
+			if limit.Rate < 0 || limit.Burst < 0 {
+				return signer.RateLimits{}, fmt.Errorf("invalid %s rate limit for %s: rate %v, burst %d",
+					endpoint, role, limit.Rate, limit.Burst)
+			}
+			byRole[data.RoleName(role)] = limit
+		}
+	}
+	return limits, nil
+}
+
 func getEnv(env string) string {
 	v := viper.New()
 	utils.SetupViper(v, envPrefix)
@@ -198,10 +231,12 @@ func setupGRPCServer(signerConfig signer.Config) (*grpc.Server, net.Listener, er
 
 	//RPC server setup
 	kms := &api.KeyManagementServer{
-		CryptoServices: signerConfig.CryptoServices,
+		CryptoServices:  signerConfig.CryptoServices,
+		CreateKeyLimits: signer.NewRoleRateLimiter(signerConfig.RateLimits.CreateKey),
 	}
 	ss := &api.SignerServer{
 		CryptoServices: signerConfig.CryptoServices,
+		SignLimits:     signer.NewRoleRateLimiter(signerConfig.RateLimits.Sign),
 	}
 	hs := ghealth.NewServer()
 
@@ -244,6 +244,31 @@ func TestSetupGRPCServerSuccess(t *testing.T) {
 	require.NotNil(t, grpcServer)
 }
 
+func TestGetRateLimits(t *testing.T) {
+	limits, err := getRateLimits(configure(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, signer.DefaultRateLimits(), limits)
+
+	limits, err = getRateLimits(configure(`{"rate_limits": {
+		"create_key": {"root": {"rate": 0.1, "burst": 1}},
+		"sign": {"timestamp": {"burst": 0}}}}`))
+	require.NoError(t, err)
+	require.Equal(t, signer.RateLimit{Rate: 0.1, Burst: 1}, limits.CreateKey[data.CanonicalRootRole])
+	require.Equal(t, signer.RateLimit{}, limits.Sign[data.CanonicalTimestampRole])
+	// the roles not configured keep their defaults
+	require.Equal(t, signer.DefaultRateLimits().CreateKey[data.CanonicalTargetsRole], limits.CreateKey[data.CanonicalTargetsRole])
+
+	invalids := []string{
+		`{"rate_limits": {"create_key": {"targets/a": {"rate": 1, "burst": 1}}}}`,
+		`{"rate_limits": {"sign": {"root": {"rate": -1, "burst": 1}}}}`,
+		`{"rate_limits": {"sign": {"root": {"rate": 1, "burst": -1}}}}`,
+	}
+	for _, invalid := range invalids {
+		_, err := getRateLimits(configure(invalid))
+		require.Error(t, err, "expected error with %s", invalid)
+	}
+}
+
 func TestBootstrap(t *testing.T) {
 	var ks trustmanager.KeyStore
 	err := bootstrap(ks)
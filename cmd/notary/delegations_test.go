@@ -5,11 +5,13 @@ import (
 	"crypto/x509"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary"
 	"github.com/theupdateframework/notary/cryptoservice"
 	testutils "github.com/theupdateframework/notary/tuf/testutils/keys"
 	"github.com/theupdateframework/notary/tuf/utils"
@@ -39,8 +41,14 @@ func TestPurgeDelegationKeys(t *testing.T) {
 	err = cmdr.delegationPurgeKeys(cmd, []string{"gun"})
 	require.Error(t, err)
 
+	// malformed key IDs are rejected
 	cmdr.keyIDs = []string{"abc"}
 	err = cmdr.delegationPurgeKeys(cmd, []string{"gun"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid key ID")
+
+	cmdr.keyIDs = []string{strings.Repeat("AB", notary.SHA256HexSize/2)}
+	err = cmdr.delegationPurgeKeys(cmd, []string{"gun"})
 	require.NoError(t, err)
 }
 
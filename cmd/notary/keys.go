@@ -426,11 +426,10 @@ func (k *keyCommander) keyRemove(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	keyID := args[0]
-
-	// This is an invalid ID
-	if len(keyID) != notary.SHA256HexSize {
-		return fmt.Errorf("invalid key ID provided: %s", keyID)
+	// key IDs are accepted in any case, but must be well formed
+	keyID, err := data.CanonicalKeyID(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid key ID provided: %s", args[0])
 	}
 	cmd.Println("")
 	err = removeKeyInteractively(ks, keyID, k.input, cmd.OutOrStdout())
@@ -454,11 +453,10 @@ func (k *keyCommander) keyPassphraseChange(cmd *cobra.Command, args []string) er
 		return err
 	}
 
-	keyID := args[0]
-
-	// This is an invalid ID
-	if len(keyID) != notary.SHA256HexSize {
-		return fmt.Errorf("invalid key ID provided: %s", keyID)
+	// key IDs are accepted in any case, but must be well formed
+	keyID, err := data.CanonicalKeyID(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid key ID provided: %s", args[0])
 	}
 
 	// Find which keyStore we should replace the key password in, and replace if we find it
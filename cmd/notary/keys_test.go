@@ -531,8 +531,8 @@ func TestChangeKeyPassphraseNonexistentID(t *testing.T) {
 		configGetter: func() (*viper.Viper, error) { return viper.New(), nil },
 		getRetriever: func() notary.PassRetriever { return passphrase.ConstantRetriever("pass") },
 	}
-	// Valid ID size, but does not exist as a key ID
-	err := k.keyPassphraseChange(&cobra.Command{}, []string{strings.Repeat("x", notary.SHA256HexSize)})
+	// Valid ID, but does not exist as a key ID
+	err := k.keyPassphraseChange(&cobra.Command{}, []string{strings.Repeat("a", notary.SHA256HexSize)})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "could not retrieve local key for key ID provided")
 }
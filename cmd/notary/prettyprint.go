@@ -175,6 +175,37 @@ func prettyPrintTargets(ts []*client.TargetWithRole, writer io.Writer) {
 	tw.Flush()
 }
 
+// Pretty-prints how each cached role compares to the remote one, in the order
+// given, and warns about every role the cache is ahead of the remote for.
+func prettyPrintCacheDrift(drifts []client.RoleDrift, writer io.Writer) {
+	tw := initTabWriter([]string{"ROLE", "LOCAL VERSION", "REMOTE VERSION", "STATUS"}, writer)
+
+	var ahead []string
+	for _, d := range drifts {
+		local := "-"
+		if d.LocalVersion > 0 {
+			local = fmt.Sprintf("%d", d.LocalVersion)
+		}
+		fmt.Fprintf(
+			tw,
+			fourItemRow,
+			d.Role,
+			local,
+			fmt.Sprintf("%d", d.RemoteVersion),
+			d.Status,
+		)
+		if d.Status == client.DriftAhead {
+			ahead = append(ahead, d.Role.String())
+		}
+	}
+	tw.Flush()
+
+	if len(ahead) > 0 {
+		fmt.Fprintf(writer, "\nWARNING: the cache is ahead of the remote for %s: the server may have been rolled back or misconfigured\n",
+			strings.Join(ahead, ", "))
+	}
+}
+
 // Pretty-prints the list of provided Roles
 func prettyPrintRoles(rs []data.Role, writer io.Writer, roleType string) {
 	if len(rs) == 0 {
@@ -142,6 +142,30 @@ func TestPrettyPrintRootAndSigningKeys(t *testing.T) {
 	}
 }
 
+// --- tests for pretty printing cache drift ---
+
+// Each role is printed in order with its versions and status, an uncached role
+// without a local version, and the roles the cache is ahead for are warned of.
+func TestPrettyPrintCacheDrift(t *testing.T) {
+	var b bytes.Buffer
+	prettyPrintCacheDrift([]client.RoleDrift{
+		{Role: data.CanonicalTimestampRole, LocalVersion: 3, RemoteVersion: 2, Status: client.DriftAhead},
+		{Role: data.CanonicalSnapshotRole, LocalVersion: 2, RemoteVersion: 2, Status: client.DriftInSync},
+		{Role: data.CanonicalTargetsRole, RemoteVersion: 1, Status: client.DriftBehind},
+	}, &b)
+	text, err := ioutil.ReadAll(&b)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(text)), "\n")
+	require.Len(t, lines, 7)
+	require.Equal(t, strings.Fields("ROLE LOCAL VERSION REMOTE VERSION STATUS"), strings.Fields(lines[0]))
+	require.Equal(t, []string{"timestamp", "3", "2", "ahead"}, strings.Fields(lines[2]))
+	require.Equal(t, []string{"snapshot", "2", "2", "in-sync"}, strings.Fields(lines[3]))
+	require.Equal(t, []string{"targets", "-", "1", "behind"}, strings.Fields(lines[4]))
+	require.Empty(t, lines[5])
+	require.Contains(t, lines[6], "ahead of the remote for timestamp:")
+}
+
 // --- tests for pretty printing targets ---
 
 // If there are no targets, no table is printed, only a line saying that there
@@ -29,6 +29,7 @@ import (
 	notaryclient "github.com/theupdateframework/notary/client"
 	"github.com/theupdateframework/notary/cryptoservice"
 	"github.com/theupdateframework/notary/passphrase"
+	store "github.com/theupdateframework/notary/storage"
 	"github.com/theupdateframework/notary/trustmanager"
 	"github.com/theupdateframework/notary/trustpinning"
 	"github.com/theupdateframework/notary/tuf/data"
@@ -96,6 +97,12 @@ var cmdTUFVerifyTemplate = usageTemplate{
 	Long:  "Verifies if the data passed in STDIN is included in the remote trusted collection identified by the Globally Unique Name.",
 }
 
+var cmdTUFDriftTemplate = usageTemplate{
+	Use:   "drift [ GUN ]",
+	Short: "Compares the local cache of a trusted collection with the remote.",
+	Long:  "Reports whether the cached timestamp, snapshot and targets of the trusted collection identified by the Globally Unique Name are behind, ahead of, or in sync with the remote trusted collection, without updating the cache. This is an online operation.",
+}
+
 var cmdWitnessTemplate = usageTemplate{
 	Use:   "witness [ GUN ] <role> ...",
 	Short: "Marks roles to be re-signed the next time they're published",
@@ -183,6 +190,8 @@ func (t *tufCommander) AddToCommand(cmd *cobra.Command) {
 	cmdTUFVerify.Flags().BoolVarP(&t.quiet, "quiet", "q", false, "No output except for errors")
 	cmd.AddCommand(cmdTUFVerify)
 
+	cmd.AddCommand(cmdTUFDriftTemplate.ToCommand(t.tufDrift))
+
 	cmdWitness := cmdWitnessTemplate.ToCommand(t.tufWitness)
 	cmdWitness.Flags().BoolVarP(&t.autoPublish, "publish", "p", false, htAutoPublish)
 	cmd.AddCommand(cmdWitness)
@@ -591,6 +600,31 @@ func (t *tufCommander) tufLookup(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func (t *tufCommander) tufDrift(cmd *cobra.Command, args []string) error {
+	if len(args) < 1 {
+		cmd.Usage()
+		return fmt.Errorf("Must specify a GUN")
+	}
+	config, err := t.configGetter()
+	if err != nil {
+		return err
+	}
+	gun := data.GUN(args[0])
+
+	fact := ConfigureRepo(config, t.retriever, true, readOnly)
+	nRepo, err := fact(gun)
+	if err != nil {
+		return err
+	}
+
+	drifts, err := nRepo.CacheDrift()
+	if err != nil {
+		return err
+	}
+	prettyPrintCacheDrift(drifts, cmd.OutOrStdout())
+	return nil
+}
+
 func (t *tufCommander) tufStatus(cmd *cobra.Command, args []string) error {
 	if len(args) < 1 {
 		cmd.Usage()
@@ -886,6 +920,10 @@ func getTransport(config *viper.Viper, gun data.GUN, permission httpAccess) (htt
 		return nil, fmt.Errorf("unable to configure TLS: %s", err.Error())
 	}
 
+	pool := store.ConnectionPoolConfig{
+		MaxConnsPerHost:     config.GetInt("remote_server.max_conns_per_host"),
+		MaxIdleConnsPerHost: config.GetInt("remote_server.max_idle_conns_per_host"),
+	}
 	base := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		Dial: (&net.Dialer{
@@ -895,8 +933,10 @@ func getTransport(config *viper.Viper, gun data.GUN, permission httpAccess) (htt
 		}).Dial,
 		TLSHandshakeTimeout: 10 * time.Second,
 		TLSClientConfig:     tlsConfig,
-		DisableKeepAlives:   true,
+		// connections are only kept open for reuse if an idle limit is configured
+		DisableKeepAlives: pool.MaxIdleConnsPerHost <= 0,
 	}
+	base = store.NewPooledTransport(base, pool)
 	trustServerURL := getRemoteTrustServer(config)
 	return tokenAuth(trustServerURL, base, gun, permission)
 }
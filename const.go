@@ -83,6 +83,9 @@ const (
 	CtxKeyKeyAlgo
 	CtxKeyCryptoSvc
 	CtxKeyRepo
+	CtxKeySignatureMethods
+	CtxKeyMinimumThreshold
+	CtxKeyUpdateBatcher
 )
 
 // NotarySupportedBackends contains the backends we would like to support at present
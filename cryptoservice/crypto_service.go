@@ -1,10 +1,12 @@
 package cryptoservice
 
 import (
+	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/sirupsen/logrus"
 	"github.com/theupdateframework/notary"
@@ -30,11 +32,24 @@ var (
 // operate on
 type CryptoService struct {
 	keyStores []trustmanager.KeyStore
+	random    io.Reader
 }
 
 // NewCryptoService returns an instance of CryptoService
 func NewCryptoService(keyStores ...trustmanager.KeyStore) *CryptoService {
-	return &CryptoService{keyStores: keyStores}
+	return NewCryptoServiceWithRand(rand.Reader, keyStores...)
+}
+
+// NewCryptoServiceWithRand returns an instance of CryptoService that uses the
+// provided source of randomness to generate keys and sign, for instance a
+// validated DRBG, or a deterministic reader in tests
+func NewCryptoServiceWithRand(random io.Reader, keyStores ...trustmanager.KeyStore) *CryptoService {
+	return &CryptoService{keyStores: keyStores, random: random}
+}
+
+// RandSource returns the source of randomness used to generate keys and sign
+func (cs *CryptoService) RandSource() io.Reader {
+	return cs.random
 }
 
 // Create is used to generate keys for targets, snapshots and timestamps
@@ -43,7 +58,7 @@ func (cs *CryptoService) Create(role data.RoleName, gun data.GUN, algorithm stri
 		return nil, fmt.Errorf("%s keys can only be imported", data.RSAKey)
 	}
 
-	privKey, err := utils.GenerateKey(algorithm)
+	privKey, err := utils.GenerateKeyWithRand(algorithm, cs.random)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate %s key: %v", algorithm, err)
 	}
@@ -1,6 +1,7 @@
 package cryptoservice
 
 import (
+	"bytes"
 	"crypto/rand"
 	"fmt"
 	"io/ioutil"
@@ -431,3 +432,25 @@ func TestCryptoSignerInterfaceBehavior(t *testing.T) {
 	cs = NewCryptoService(trustmanager.NewKeyMemoryStore(passphraseRetriever))
 	interfaces.AddListKeyCryptoServiceInterfaceBehaviorTests(t, cs, data.ECDSAKey)
 }
+
+// Keys generated from the same fixed seed are identical.  Only ED25519 is
+// checked, since the standard library's ECDSA key generation intentionally
+// mixes in extra randomness even when given a deterministic reader.
+func TestCryptoServiceWithRandIsReproducible(t *testing.T) {
+	seed := bytes.Repeat([]byte("fixed seed"), 100)
+	var keyIDs []string
+	for i := 0; i < 2; i++ {
+		cs := NewCryptoServiceWithRand(bytes.NewReader(seed), trustmanager.NewKeyMemoryStore(passphraseRetriever))
+		key, err := cs.Create(data.CanonicalTargetsRole, "", data.ED25519Key)
+		require.NoError(t, err)
+		keyIDs = append(keyIDs, key.ID())
+	}
+	require.Equal(t, keyIDs[0], keyIDs[1])
+
+	// an exhausted source of randomness can't produce a key
+	for _, algorithm := range []string{data.ECDSAKey, data.ED25519Key} {
+		cs := NewCryptoServiceWithRand(bytes.NewReader(nil), trustmanager.NewKeyMemoryStore(passphraseRetriever))
+		_, err := cs.Create(data.CanonicalTargetsRole, "", algorithm)
+		require.Error(t, err, "%s key generated without any randomness", algorithm)
+	}
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/theupdateframework/notary/server/storage"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// UpdateBatcher coalesces the updates published to the same GUN within a
+// window into a single batch, for which the server signs a single snapshot
+// and timestamp, and processes the batches of up to a number of GUNs in
+// parallel.  The batches of a GUN are processed one at a time.
+//
+// Each update in a batch is validated in order against the metadata as
+// updated by the ones before it, and an update which fails validation fails
+// alone.  If signing or storing the batch as a whole fails, its updates are
+// retried one at a time, exactly as if they had not been batched, so that
+// each gets its own result.  An update is only reported as applied once it
+// has been stored along with a snapshot and timestamp reflecting it.
+type UpdateBatcher struct {
+	window  time.Duration
+	workers chan struct{}
+
+	lock    sync.Mutex
+	pending map[data.GUN][]*batchedUpdate
+	running map[data.GUN]bool
+}
+
+// batchedUpdate is an update waiting for its batch to be processed
+type batchedUpdate struct {
+	cs      signed.CryptoService
+	store   storage.MetaStore
+	updates []storage.MetaUpdate
+	done    chan batchResult
+}
+
+// batchResult is the result of applying an update: the updates stored for it,
+// or why it was invalid or could not be stored
+type batchResult struct {
+	applied []storage.MetaUpdate
+	invalid error
+	err     error
+}
+
+// NewUpdateBatcher returns an UpdateBatcher coalescing the updates of a GUN
+// published within window of the first, and processing the batches of up to
+// workers GUNs at once.  If workers is less than 1, the number of CPUs is used.
+func NewUpdateBatcher(window time.Duration, workers int) *UpdateBatcher {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	return &UpdateBatcher{
+		window:  window,
+		workers: make(chan struct{}, workers),
+		pending: make(map[data.GUN][]*batchedUpdate),
+		running: make(map[data.GUN]bool),
+	}
+}
+
+// apply validates the update and stores it, with the server signed roles,
+// as part of the next batch for the GUN.  It returns once the batch has been
+// processed, as applyUpdate does.
+func (b *UpdateBatcher) apply(cs signed.CryptoService, gun data.GUN, updates []storage.MetaUpdate,
+	store storage.MetaStore) batchResult {
+
+	pending := &batchedUpdate{cs: cs, store: store, updates: updates, done: make(chan batchResult, 1)}
+	b.lock.Lock()
+	b.pending[gun] = append(b.pending[gun], pending)
+	if !b.running[gun] {
+		b.running[gun] = true
+		go b.run(gun)
+	}
+	b.lock.Unlock()
+
+	return <-pending.done
+}
+
+// run processes the batches of a GUN until none are pending
+func (b *UpdateBatcher) run(gun data.GUN) {
+	for {
+		time.Sleep(b.window)
+
+		b.workers <- struct{}{}
+		b.lock.Lock()
+		batch := b.pending[gun]
+		delete(b.pending, gun)
+		b.lock.Unlock()
+
+		processBatch(gun, batch)
+		<-b.workers
+
+		b.lock.Lock()
+		if len(b.pending[gun]) == 0 {
+			delete(b.running, gun)
+			b.lock.Unlock()
+			return
+		}
+		b.lock.Unlock()
+	}
+}
+
+// processBatch validates the batch's updates in order, then signs a single
+// snapshot and timestamp for, and stores, all of those which are valid
+func processBatch(gun data.GUN, batch []*batchedUpdate) {
+	if len(batch) == 1 {
+		batch[0].applyAlone(gun)
+		return
+	}
+	cs, store := batch[0].cs, batch[0].store
+	overlay := newOverlayStore(store)
+
+	var (
+		accepted         []*batchedUpdate
+		toApply          []storage.MetaUpdate
+		snapshotSupplied bool
+	)
+	for _, pending := range batch {
+		if overlay.supersedes(pending.updates) {
+			// an earlier update of the batch has already published a version
+			// this update is publishing, so it would fail to be stored
+			pending.done <- batchResult{err: storage.ErrOldVersion{}}
+			continue
+		}
+		updates, _, err := validateClientUpdate(cs, gun, pending.updates, overlay)
+		if err != nil {
+			pending.done <- batchResult{invalid: err}
+			continue
+		}
+		overlay.apply(updates)
+		accepted = append(accepted, pending)
+		toApply = append(toApply, updates...)
+		snapshotSupplied = hasRole(updates, data.CanonicalSnapshotRole)
+	}
+	if len(accepted) == 0 {
+		return
+	}
+
+	serverUpdates, err := signBatch(cs, gun, overlay, snapshotSupplied)
+	if err == nil {
+		toApply = append(toApply, serverUpdates...)
+		err = store.UpdateMany(gun, toApply)
+	}
+	if err != nil {
+		logrus.Debugf("unable to apply a batch of %d updates to %s, applying them one at a time: %v",
+			len(accepted), gun, err)
+		for _, pending := range accepted {
+			pending.applyAlone(gun)
+		}
+		return
+	}
+	for _, pending := range accepted {
+		pending.done <- batchResult{applied: toApply}
+	}
+}
+
+// signBatch generates the timestamp, and the snapshot unless the last update
+// of the batch supplied one, for the metadata as updated by the batch
+func signBatch(cs signed.CryptoService, gun data.GUN, overlay *overlayStore, snapshotSupplied bool) ([]storage.MetaUpdate, error) {
+	builder := tuf.NewRepoBuilder(gun, cs, trustpinning.TrustPinConfig{})
+	if err := loadFromStore(gun, data.CanonicalRootRole, builder, overlay); err != nil {
+		return nil, err
+	}
+	if _, err := loadAndValidateTargets(gun, builder, overlay.latest, overlay); err != nil {
+		return nil, err
+	}
+	if snapshotSupplied {
+		if err := loadFromStore(gun, data.CanonicalSnapshotRole, builder, overlay); err != nil {
+			return nil, err
+		}
+	}
+	return signServerRoles(gun, builder, overlay, !snapshotSupplied)
+}
+
+// applyAlone applies the update as if it had not been batched
+func (pending *batchedUpdate) applyAlone(gun data.GUN) {
+	pending.done <- applyUpdate(pending.cs, gun, pending.updates, pending.store)
+}
+
+// applyUpdate validates an update and stores it with the server signed roles
+func applyUpdate(cs signed.CryptoService, gun data.GUN, updates []storage.MetaUpdate,
+	store storage.MetaStore) batchResult {
+
+	updates, err := validateUpdate(cs, gun, updates, store)
+	if err != nil {
+		return batchResult{invalid: err}
+	}
+	if err := store.UpdateMany(gun, updates); err != nil {
+		return batchResult{err: err}
+	}
+	return batchResult{applied: updates}
+}
+
+// overlayStore is a MetaStore whose current metadata for each role is the
+// latest update applied to the overlay, if any, and otherwise the backing
+// store's.  Only its current metadata may be read.
+type overlayStore struct {
+	storage.MetaStore
+	latest map[data.RoleName]storage.MetaUpdate
+}
+
+func newOverlayStore(backing storage.MetaStore) *overlayStore {
+	return &overlayStore{MetaStore: backing, latest: make(map[data.RoleName]storage.MetaUpdate)}
+}
+
+func (o *overlayStore) apply(updates []storage.MetaUpdate) {
+	for _, update := range updates {
+		o.latest[update.Role] = update
+	}
+}
+
+// supersedes returns whether an update applied to the overlay has a version
+// at least as high as one of the updates
+func (o *overlayStore) supersedes(updates []storage.MetaUpdate) bool {
+	for _, update := range updates {
+		if latest, ok := o.latest[update.Role]; ok && latest.Version >= update.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCurrent returns the latest update applied to the overlay for the role,
+// or the backing store's current metadata
+func (o *overlayStore) GetCurrent(gun data.GUN, tufRole data.RoleName) (*time.Time, []byte, error) {
+	if update, ok := o.latest[tufRole]; ok {
+		now := time.Now()
+		return &now, update.Data, nil
+	}
+	return o.MetaStore.GetCurrent(gun, tufRole)
+}
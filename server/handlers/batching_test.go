@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/go/canonical/json"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/server/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+var batchDelegations = []data.RoleName{"targets/a", "targets/b", "targets/c", "targets/d", "targets/e"}
+
+// setupBatchRepo stores a repo with several delegations, returning the repo
+// and the server's crypto service
+func setupBatchRepo(t *testing.T, gun data.GUN, store storage.MetaStore) (*tuf.Repo, signed.CryptoService) {
+	repo, cs, err := testutils.EmptyRepo(gun, batchDelegations...)
+	require.NoError(t, err)
+	for _, delgName := range batchDelegations {
+		repo.InitTargets(delgName)
+	}
+	meta, err := testutils.SignAndSerialize(repo)
+	require.NoError(t, err)
+
+	var updates []storage.MetaUpdate
+	for role, metaBytes := range meta {
+		updates = append(updates, storage.MetaUpdate{Role: role, Version: 1, Data: metaBytes})
+	}
+	require.NoError(t, store.UpdateMany(gun, updates))
+	return repo, mustCopyKeys(t, cs, data.CanonicalTimestampRole, data.CanonicalSnapshotRole)
+}
+
+// delegationUpdate adds a target to the delegation and returns the update
+// publishing it, without a snapshot
+func delegationUpdate(t *testing.T, repo *tuf.Repo, delgName data.RoleName) []storage.MetaUpdate {
+	_, err := repo.AddTargets(delgName, data.Files{delgName.String() + "/target": data.FileMeta{
+		Length: 1, Hashes: data.Hashes{"sha256": make([]byte, 32)}}})
+	require.NoError(t, err)
+	signedDelg, err := repo.SignTargets(delgName, data.DefaultExpires(data.CanonicalTargetsRole))
+	require.NoError(t, err)
+	metaBytes, err := json.Marshal(signedDelg)
+	require.NoError(t, err)
+	return []storage.MetaUpdate{{Role: delgName, Version: 2, Data: metaBytes}}
+}
+
+func currentSnapshot(t *testing.T, gun data.GUN, store storage.MetaStore) *data.SignedSnapshot {
+	_, snapshotBytes, err := store.GetCurrent(gun, data.CanonicalSnapshotRole)
+	require.NoError(t, err)
+	signedSnapshot := &data.SignedSnapshot{}
+	require.NoError(t, json.Unmarshal(snapshotBytes, signedSnapshot))
+	return signedSnapshot
+}
+
+// requireAllPublished asserts that every delegation's update is stored, and
+// listed at its new version in the current snapshot
+func requireAllPublished(t *testing.T, gun data.GUN, store storage.MetaStore) *data.SignedSnapshot {
+	snapshot := currentSnapshot(t, gun, store)
+	for _, delgName := range batchDelegations {
+		_, delgBytes, err := store.GetCurrent(gun, delgName)
+		require.NoError(t, err)
+		signedDelg := &data.SignedTargets{}
+		require.NoError(t, json.Unmarshal(delgBytes, signedDelg))
+		require.Equal(t, 2, signedDelg.Signed.Version, "%s was not updated", delgName)
+
+		meta, ok := snapshot.Signed.Meta[delgName.String()]
+		require.True(t, ok, "%s is missing from the snapshot", delgName)
+		require.Equal(t, int64(len(delgBytes)), meta.Length, "the snapshot lists an old version of %s", delgName)
+	}
+	return snapshot
+}
+
+// Concurrent publishes to the same GUN, each of a different delegation, all
+// succeed and none of them is lost from the signed snapshot
+func TestUpdateBatcherConcurrentPublishes(t *testing.T) {
+	var gun data.GUN = "docker.com/notary"
+	store := storage.NewMemStorage()
+	repo, serverCrypto := setupBatchRepo(t, gun, store)
+
+	var allUpdates [][]storage.MetaUpdate
+	for _, delgName := range batchDelegations {
+		allUpdates = append(allUpdates, delegationUpdate(t, repo, delgName))
+	}
+
+	batcher := NewUpdateBatcher(50*time.Millisecond, 2)
+	results := make([]batchResult, len(allUpdates))
+	var wg sync.WaitGroup
+	for i, updates := range allUpdates {
+		wg.Add(1)
+		go func(i int, updates []storage.MetaUpdate) {
+			defer wg.Done()
+			results[i] = batcher.apply(serverCrypto, gun, updates, store)
+		}(i, updates)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		require.NoError(t, result.invalid)
+		require.NoError(t, result.err)
+		require.True(t, hasRole(result.applied, batchDelegations[i]))
+		require.True(t, hasRole(result.applied, data.CanonicalSnapshotRole))
+		require.True(t, hasRole(result.applied, data.CanonicalTimestampRole))
+	}
+	requireAllPublished(t, gun, store)
+}
+
+// A batch is signed with a single new snapshot and timestamp, and an update
+// failing within the batch does not fail the others
+func TestProcessBatchSignsOnce(t *testing.T) {
+	var gun data.GUN = "docker.com/notary"
+	store := storage.NewMemStorage()
+	repo, serverCrypto := setupBatchRepo(t, gun, store)
+	oldVersion := currentSnapshot(t, gun, store).Signed.Version
+
+	var (
+		batch      []*batchedUpdate
+		duplicated []storage.MetaUpdate
+	)
+	for _, delgName := range batchDelegations {
+		updates := delegationUpdate(t, repo, delgName)
+		if duplicated == nil {
+			duplicated = updates
+		}
+		batch = append(batch, &batchedUpdate{cs: serverCrypto, store: store, updates: updates,
+			done: make(chan batchResult, 1)})
+	}
+	// publishes a version of a delegation already published earlier in the batch
+	batch = append(batch, &batchedUpdate{cs: serverCrypto, store: store, updates: duplicated,
+		done: make(chan batchResult, 1)})
+	// publishes a role which has not been delegated
+	invalid := []storage.MetaUpdate{{Role: "targets/a/b", Version: 1, Data: duplicated[0].Data}}
+	batch = append(batch, &batchedUpdate{cs: serverCrypto, store: store, updates: invalid,
+		done: make(chan batchResult, 1)})
+
+	processBatch(gun, batch)
+
+	for _, pending := range batch[:len(batchDelegations)] {
+		result := <-pending.done
+		require.NoError(t, result.invalid)
+		require.NoError(t, result.err)
+	}
+	duplicateResult := <-batch[len(batchDelegations)].done
+	require.IsType(t, storage.ErrOldVersion{}, duplicateResult.err)
+	invalidResult := <-batch[len(batchDelegations)+1].done
+	require.Error(t, invalidResult.invalid)
+
+	snapshot := requireAllPublished(t, gun, store)
+	require.Equal(t, oldVersion+1, snapshot.Signed.Version)
+}
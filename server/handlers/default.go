@@ -96,7 +96,21 @@ func atomicUpdateHandler(ctx context.Context, w http.ResponseWriter, r *http.Req
 			Data:    inBuf.Bytes(),
 		})
 	}
-	updates, err = validateUpdate(cryptoService, gun, updates, store)
+	allowedMethods, _ := ctx.Value(notary.CtxKeySignatureMethods).([]data.SigAlgorithm)
+	err = validateSignatureMethods(allowedMethods, updates)
+	if err == nil {
+		minimumThreshold, _ := ctx.Value(notary.CtxKeyMinimumThreshold).(int)
+		err = validateMinimumThreshold(minimumThreshold, updates)
+	}
+	var result batchResult
+	if err == nil {
+		if batcher, ok := ctx.Value(notary.CtxKeyUpdateBatcher).(*UpdateBatcher); ok {
+			result = batcher.apply(cryptoService, gun, updates, store)
+		} else {
+			result = applyUpdate(cryptoService, gun, updates, store)
+		}
+		err = result.invalid
+	}
 	if err != nil {
 		serializable, serializableError := validation.NewSerializableError(err)
 		if serializableError != nil {
@@ -105,8 +119,7 @@ func atomicUpdateHandler(ctx context.Context, w http.ResponseWriter, r *http.Req
 		}
 		return errors.ErrInvalidUpdate.WithDetail(serializable)
 	}
-	err = store.UpdateMany(gun, updates)
-	if err != nil {
+	if err = result.err; err != nil {
 		// If we have an old version error, surface to user with error code
 		if _, ok := err.(storage.ErrOldVersion); ok {
 			logger.Info("400 POST old version error")
@@ -117,7 +130,7 @@ func atomicUpdateHandler(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return errors.ErrUpdating.WithDetail(nil)
 	}
 
-	logTS(logger, gun.String(), updates)
+	logTS(logger, gun.String(), result.applied)
 
 	return nil
 }
@@ -28,9 +28,11 @@ import (
 
 type handlerState struct {
 	// interface{} so we can test invalid values
-	store   interface{}
-	crypto  interface{}
-	keyAlgo interface{}
+	store            interface{}
+	crypto           interface{}
+	keyAlgo          interface{}
+	signatureMethods []data.SigAlgorithm
+	minimumThreshold int
 }
 
 func defaultState() handlerState {
@@ -46,6 +48,12 @@ func getContext(h handlerState) context.Context {
 	ctx = context.WithValue(ctx, notary.CtxKeyMetaStore, h.store)
 	ctx = context.WithValue(ctx, notary.CtxKeyKeyAlgo, h.keyAlgo)
 	ctx = context.WithValue(ctx, notary.CtxKeyCryptoSvc, h.crypto)
+	if h.signatureMethods != nil {
+		ctx = context.WithValue(ctx, notary.CtxKeySignatureMethods, h.signatureMethods)
+	}
+	if h.minimumThreshold != 0 {
+		ctx = context.WithValue(ctx, notary.CtxKeyMinimumThreshold, h.minimumThreshold)
+	}
 	return ctxu.WithLogger(ctx, ctxu.GetRequestLogger(ctx))
 }
 
@@ -496,3 +504,102 @@ func TestAtomicUpdateVersionErrorPropagated(t *testing.T) {
 	require.Equal(t, errors.ErrOldVersion, errorObj.Code)
 	require.Equal(t, storage.ErrOldVersion{}, errorObj.Detail)
 }
+
+// A publish containing a signature made with a method the server does not
+// allow is rejected before anything is stored
+func TestAtomicUpdateDisallowedSignatureMethod(t *testing.T) {
+	var gun data.GUN = "testGUN"
+	vars := map[string]string{"gun": gun.String()}
+
+	repo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	rsaKey, err := testutils.CreateKey(cs, gun, data.CanonicalTargetsRole, data.RSAKey)
+	require.NoError(t, err)
+	require.NoError(t, repo.ReplaceBaseKeys(data.CanonicalTargetsRole, rsaKey))
+
+	r, tg, sn, ts, err := testutils.Sign(repo)
+	require.NoError(t, err)
+	rs, tgs, sns, _, err := testutils.Serialize(r, tg, sn, ts)
+	require.NoError(t, err)
+	metas := map[string][]byte{
+		data.CanonicalRootRole.String():     rs,
+		data.CanonicalTargetsRole.String():  tgs,
+		data.CanonicalSnapshotRole.String(): sns,
+	}
+
+	metaStore := storage.NewMemStorage()
+	state := handlerState{
+		store:            metaStore,
+		crypto:           mustCopyKeys(t, cs, data.CanonicalTimestampRole),
+		signatureMethods: []data.SigAlgorithm{data.ECDSASignature},
+	}
+	req, err := store.NewMultiPartMetaRequest("", metas)
+	require.NoError(t, err)
+	err = atomicUpdateHandler(getContext(state), httptest.NewRecorder(), req, vars)
+	require.Error(t, err)
+	errorObj, ok := err.(errcode.Error)
+	require.True(t, ok, "Expected an errcode.Error, got %v", err)
+	require.Equal(t, errors.ErrInvalidUpdate, errorObj.Code)
+	serializable, ok := errorObj.Detail.(*validation.SerializableError)
+	require.True(t, ok, "Expected a SerializableObject, got %v", errorObj.Detail)
+	require.IsType(t, validation.ErrValidation{}, serializable.Error)
+	require.Contains(t, serializable.Error.Error(), string(data.RSAPSSSignature))
+
+	for _, role := range data.BaseRoles {
+		_, _, err := metaStore.GetCurrent(gun, role)
+		require.IsType(t, storage.ErrNotFound{}, err, role.String())
+	}
+
+	// the same publish is accepted if RSA-PSS signatures are allowed
+	state.signatureMethods = append(state.signatureMethods, data.RSAPSSSignature)
+	req, err = store.NewMultiPartMetaRequest("", metas)
+	require.NoError(t, err)
+	require.NoError(t, atomicUpdateHandler(getContext(state), httptest.NewRecorder(), req, vars))
+}
+
+// A publish whose root gives roles a threshold below the server's minimum is
+// rejected before anything is stored
+func TestAtomicUpdateBelowMinimumThreshold(t *testing.T) {
+	var gun data.GUN = "testGUN"
+	vars := map[string]string{"gun": gun.String()}
+
+	repo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	r, tg, sn, ts, err := testutils.Sign(repo)
+	require.NoError(t, err)
+	rs, tgs, sns, _, err := testutils.Serialize(r, tg, sn, ts)
+	require.NoError(t, err)
+	metas := map[string][]byte{
+		data.CanonicalRootRole.String():     rs,
+		data.CanonicalTargetsRole.String():  tgs,
+		data.CanonicalSnapshotRole.String(): sns,
+	}
+
+	metaStore := storage.NewMemStorage()
+	state := handlerState{
+		store:            metaStore,
+		crypto:           mustCopyKeys(t, cs, data.CanonicalTimestampRole),
+		minimumThreshold: 2,
+	}
+	req, err := store.NewMultiPartMetaRequest("", metas)
+	require.NoError(t, err)
+	err = atomicUpdateHandler(getContext(state), httptest.NewRecorder(), req, vars)
+	require.Error(t, err)
+	errorObj, ok := err.(errcode.Error)
+	require.True(t, ok, "Expected an errcode.Error, got %v", err)
+	require.Equal(t, errors.ErrInvalidUpdate, errorObj.Code)
+	serializable, ok := errorObj.Detail.(*validation.SerializableError)
+	require.True(t, ok, "Expected a SerializableObject, got %v", errorObj.Detail)
+	require.IsType(t, validation.ErrValidation{}, serializable.Error)
+	require.Contains(t, serializable.Error.Error(), "minimum threshold of 2")
+
+	for _, role := range data.BaseRoles {
+		_, _, err := metaStore.GetCurrent(gun, role)
+		require.IsType(t, storage.ErrNotFound{}, err, role.String())
+	}
+
+	state.minimumThreshold = 1
+	req, err = store.NewMultiPartMetaRequest("", metas)
+	require.NoError(t, err)
+	require.NoError(t, atomicUpdateHandler(getContext(state), httptest.NewRecorder(), req, vars))
+}
@@ -16,6 +16,76 @@ import (
 	"github.com/theupdateframework/notary/tuf/validation"
 )
 
+// validateSignatureMethods checks that every signature on the updates being
+// pushed uses one of the allowed signature methods.  If no methods are
+// configured, all methods are allowed.
+func validateSignatureMethods(allowed []data.SigAlgorithm, updates []storage.MetaUpdate) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, update := range updates {
+		meta := &data.SignedMeta{}
+		if err := data.UnmarshalMetadata(update.Data, meta); err != nil {
+			return validation.ErrValidation{Msg: fmt.Sprintf("unable to parse %s: %s", update.Role, err)}
+		}
+		for _, sig := range meta.Signatures {
+			if !sigAlgorithmAllowed(allowed, sig.Method) {
+				return validation.ErrValidation{Msg: fmt.Sprintf(
+					"%s is signed by key %s using signature method %s, which the server does not allow",
+					update.Role, sig.KeyID, sig.Method)}
+			}
+		}
+	}
+	return nil
+}
+
+func sigAlgorithmAllowed(allowed []data.SigAlgorithm, method data.SigAlgorithm) bool {
+	for _, a := range allowed {
+		if a == method {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMinimumThreshold checks that no root or targets update being pushed
+// gives a role a threshold below the minimum.  A minimum of 1 or less allows
+// any threshold.
+func validateMinimumThreshold(minimum int, updates []storage.MetaUpdate) error {
+	if minimum <= 1 {
+		return nil
+	}
+	for _, update := range updates {
+		thresholds := make(map[data.RoleName]int)
+		switch {
+		case update.Role == data.CanonicalRootRole:
+			root := &data.SignedRoot{}
+			if err := data.UnmarshalMetadata(update.Data, root); err != nil {
+				return validation.ErrBadRoot{Msg: fmt.Sprintf("unable to parse root: %s", err)}
+			}
+			for roleName, role := range root.Signed.Roles {
+				thresholds[roleName] = role.Threshold
+			}
+		case update.Role == data.CanonicalTargetsRole || data.IsDelegation(update.Role):
+			targets := &data.SignedTargets{}
+			if err := data.UnmarshalMetadata(update.Data, targets); err != nil {
+				return validation.ErrBadTargets{Msg: fmt.Sprintf("unable to parse %s: %s", update.Role, err)}
+			}
+			for _, role := range targets.Signed.Delegations.Roles {
+				thresholds[role.Name] = role.Threshold
+			}
+		}
+		for roleName, threshold := range thresholds {
+			if threshold < minimum {
+				return validation.ErrValidation{Msg: fmt.Sprintf(
+					"%s gives %s a threshold of %d, below the server's minimum threshold of %d",
+					update.Role, roleName, threshold, minimum)}
+			}
+		}
+	}
+	return nil
+}
+
 // validateUpload checks that the updates being pushed
 // are semantically correct and the signatures are correct
 // A list of possibly modified updates are returned if all
@@ -23,6 +93,21 @@ import (
 // created and added if snapshotting has been delegated to the
 // server
 func validateUpdate(cs signed.CryptoService, gun data.GUN, updates []storage.MetaUpdate, store storage.MetaStore) ([]storage.MetaUpdate, error) {
+	updatesToApply, builder, err := validateClientUpdate(cs, gun, updates, store)
+	if err != nil {
+		return nil, err
+	}
+	serverUpdates, err := signServerRoles(gun, builder, store, !hasRole(updates, data.CanonicalSnapshotRole))
+	if err != nil {
+		return nil, err
+	}
+	return append(updatesToApply, serverUpdates...), nil
+}
+
+// validateClientUpdate checks the root, targets and snapshot updates signed
+// by the client, returning the updates to apply and a builder loaded with
+// them, from which the server signed roles can be generated
+func validateClientUpdate(cs signed.CryptoService, gun data.GUN, updates []storage.MetaUpdate, store storage.MetaStore) ([]storage.MetaUpdate, tuf.RepoBuilder, error) {
 
 	// some delegated targets role may be invalid based on other updates
 	// that have been made by other clients. We'll rebuild the slice of
@@ -37,7 +122,7 @@ func validateUpdate(cs signed.CryptoService, gun data.GUN, updates []storage.Met
 	builder := tuf.NewRepoBuilder(gun, cs, trustpinning.TrustPinConfig{})
 	if err := loadFromStore(gun, data.CanonicalRootRole, builder, store); err != nil {
 		if _, ok := err.(storage.ErrNotFound); !ok {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
@@ -45,22 +130,22 @@ func validateUpdate(cs signed.CryptoService, gun data.GUN, updates []storage.Met
 		currentRootVersion := builder.GetLoadedVersion(data.CanonicalRootRole)
 		if rootUpdate.Version != currentRootVersion && rootUpdate.Version != currentRootVersion+1 {
 			msg := fmt.Sprintf("Root modifications must increment the version. Current %d, new %d", currentRootVersion, rootUpdate.Version)
-			return nil, validation.ErrBadRoot{Msg: msg}
+			return nil, nil, validation.ErrBadRoot{Msg: msg}
 		}
 		builder = builder.BootstrapNewBuilder()
 		if err := builder.Load(data.CanonicalRootRole, rootUpdate.Data, currentRootVersion, false); err != nil {
-			return nil, validation.ErrBadRoot{Msg: err.Error()}
+			return nil, nil, validation.ErrBadRoot{Msg: err.Error()}
 		}
 
 		logrus.Debug("Successfully validated root")
 		updatesToApply = append(updatesToApply, rootUpdate)
 	} else if !builder.IsLoaded(data.CanonicalRootRole) {
-		return nil, validation.ErrValidation{Msg: "no pre-existing root and no root provided in update."}
+		return nil, nil, validation.ErrValidation{Msg: "no pre-existing root and no root provided in update."}
 	}
 
 	targetsToUpdate, err := loadAndValidateTargets(gun, builder, roles, store)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	updatesToApply = append(updatesToApply, targetsToUpdate...)
 	// there's no need to load files from the database if no targets etc...
@@ -72,11 +157,19 @@ func validateUpdate(cs signed.CryptoService, gun data.GUN, updates []storage.Met
 	// At this point, root and targets must have been loaded into the repo
 	if snapshotUpdate, ok := roles[data.CanonicalSnapshotRole]; ok {
 		if err := builder.Load(data.CanonicalSnapshotRole, snapshotUpdate.Data, 1, false); err != nil {
-			return nil, validation.ErrBadSnapshot{Msg: err.Error()}
+			return nil, nil, validation.ErrBadSnapshot{Msg: err.Error()}
 		}
 		logrus.Debug("Successfully validated snapshot")
 		updatesToApply = append(updatesToApply, roles[data.CanonicalSnapshotRole])
-	} else {
+	}
+	return updatesToApply, builder, nil
+}
+
+// signServerRoles generates the timestamp, and the snapshot if requested, for
+// the roles loaded in the builder
+func signServerRoles(gun data.GUN, builder tuf.RepoBuilder, store storage.MetaStore, withSnapshot bool) ([]storage.MetaUpdate, error) {
+	var updates []storage.MetaUpdate
+	if withSnapshot {
 		// Check:
 		//   - we have a snapshot key
 		//   - it matches a snapshot key signed into the root.json
@@ -87,7 +180,7 @@ func validateUpdate(cs signed.CryptoService, gun data.GUN, updates []storage.Met
 		if err != nil {
 			return nil, err
 		}
-		updatesToApply = append(updatesToApply, *update)
+		updates = append(updates, *update)
 	}
 
 	// generate a timestamp immediately
@@ -95,7 +188,16 @@ func validateUpdate(cs signed.CryptoService, gun data.GUN, updates []storage.Met
 	if err != nil {
 		return nil, err
 	}
-	return append(updatesToApply, *update), nil
+	return append(updates, *update), nil
+}
+
+func hasRole(updates []storage.MetaUpdate, role data.RoleName) bool {
+	for _, update := range updates {
+		if update.Role == role {
+			return true
+		}
+	}
+	return false
 }
 
 func loadAndValidateTargets(gun data.GUN, builder tuf.RepoBuilder, roles map[data.RoleName]storage.MetaUpdate, store storage.MetaStore) ([]storage.MetaUpdate, error) {
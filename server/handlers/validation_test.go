@@ -1487,3 +1487,20 @@ func TestValidateTargetsRoleNotInParent(t *testing.T) {
 }
 
 // ### End target validation with delegations tests
+
+// A targets update delegating to a role with a threshold below the minimum is
+// rejected, naming the delegation
+func TestValidateMinimumThresholdDelegation(t *testing.T) {
+	var gun data.GUN = "docker.com/notary"
+	repo, _, err := testutils.EmptyRepo(gun, "targets/a")
+	require.NoError(t, err)
+	r, tg, sn, ts, err := testutils.Sign(repo)
+	require.NoError(t, err)
+	_, targets, _, _, err := getUpdates(r, tg, sn, ts)
+	require.NoError(t, err)
+
+	require.NoError(t, validateMinimumThreshold(1, []storage.MetaUpdate{targets}))
+	err = validateMinimumThreshold(2, []storage.MetaUpdate{targets})
+	require.IsType(t, validation.ErrValidation{}, err)
+	require.Contains(t, err.Error(), "gives targets/a a threshold of 1")
+}
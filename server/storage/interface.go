@@ -60,3 +60,12 @@ type MetaStore interface {
 	// The returned []Change should always be ordered oldest to newest.
 	GetChanges(changeID string, records int, filterName string) ([]Change, error)
 }
+
+// UsageReporter is implemented by stores which can report the bytes of
+// metadata they hold for each GUN, such as for quotas or chargeback
+type UsageReporter interface {
+	// StorageUsageByGUN returns the total bytes of the metadata stored for
+	// each GUN that has any.  With includeHistory, every stored version of
+	// each role is counted; otherwise only the current version is.
+	StorageUsageByGUN(includeHistory bool) (map[data.GUN]int64, error)
+}
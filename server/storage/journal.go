@@ -0,0 +1,443 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// JournalEntry is the full set of metadata updates planned by a publish
+type JournalEntry struct {
+	ID      uint64
+	GUN     data.GUN
+	Updates []MetaUpdate
+}
+
+// Journal durably records the metadata updates a JournaledStore plans to
+// apply, until they are either committed or discarded
+type Journal interface {
+	// Append records the updates planned for the GUN before they are applied,
+	// returning the ID of the new entry
+	Append(gun data.GUN, updates []MetaUpdate) (uint64, error)
+
+	// Commit marks the entry as applied
+	Commit(id uint64) error
+
+	// Discard marks the entry as not applied, and never to be applied
+	Discard(id uint64) error
+
+	// Uncommitted returns the entries which have been neither committed nor
+	// discarded, oldest first
+	Uncommitted() ([]JournalEntry, error)
+}
+
+// JournalRecoveryPolicy determines what a JournaledStore does, on startup,
+// with the publishes a crash left uncommitted in its journal
+type JournalRecoveryPolicy int
+
+// The possible ways of recovering uncommitted publishes
+const (
+	// JournalReplay applies whatever part of each uncommitted publish is not
+	// yet stored
+	JournalReplay JournalRecoveryPolicy = iota
+	// JournalDiscard discards each uncommitted publish none of which is stored
+	// yet.  A publish which was partially applied is completed instead, since
+	// a MetaStore cannot remove individual versions.
+	JournalDiscard
+)
+
+// JournaledStore wraps a MetaStore which is not transactional, writing each
+// publish's full set of metadata to a journal before applying it, so that a
+// publish interrupted by a crash can be recovered on restart
+type JournaledStore struct {
+	MetaStore
+	journal Journal
+}
+
+// NewJournaledStore wraps the store, first recovering every publish left
+// uncommitted in the journal according to the policy
+func NewJournaledStore(store MetaStore, journal Journal, policy JournalRecoveryPolicy) (*JournaledStore, error) {
+	js := &JournaledStore{MetaStore: store, journal: journal}
+	if err := js.recover(policy); err != nil {
+		return nil, err
+	}
+	return js, nil
+}
+
+// UpdateCurrent journals the update, and then updates the underlying store
+func (js *JournaledStore) UpdateCurrent(gun data.GUN, update MetaUpdate) error {
+	return js.apply(gun, []MetaUpdate{update}, func() error {
+		return js.MetaStore.UpdateCurrent(gun, update)
+	})
+}
+
+// UpdateMany journals the updates, and then updates the underlying store
+func (js *JournaledStore) UpdateMany(gun data.GUN, updates []MetaUpdate) error {
+	return js.apply(gun, updates, func() error {
+		return js.MetaStore.UpdateMany(gun, updates)
+	})
+}
+
+// apply journals the updates before applying them, and then records whether
+// they were applied.  If applying them fails, for instance with ErrOldVersion
+// because another publish stored those versions first, the entry is discarded,
+// since the caller is told that the publish failed and it must not be
+// completed on recovery.
+func (js *JournaledStore) apply(gun data.GUN, updates []MetaUpdate, update func() error) error {
+	id, err := js.journal.Append(gun, updates)
+	if err != nil {
+		return err
+	}
+	if err := update(); err != nil {
+		if discardErr := js.journal.Discard(id); discardErr != nil {
+			logrus.Errorf("unable to discard journal entry %d for %s: %v", id, gun, discardErr)
+		}
+		return err
+	}
+	return js.journal.Commit(id)
+}
+
+// recover replays or discards every uncommitted journal entry
+func (js *JournaledStore) recover(policy JournalRecoveryPolicy) error {
+	entries, err := js.journal.Uncommitted()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		pending, err := js.pendingUpdates(entry)
+		switch err.(type) {
+		case nil:
+		case errJournalConflict:
+			logrus.Warnf("discarding journaled publish %d for %s: %v", entry.ID, entry.GUN, err)
+			if err := js.journal.Discard(entry.ID); err != nil {
+				return err
+			}
+			continue
+		default:
+			return err
+		}
+		switch {
+		case len(pending) == 0:
+			logrus.Infof("journaled publish %d for %s was applied before the last shutdown", entry.ID, entry.GUN)
+		case policy == JournalDiscard && len(pending) == len(entry.Updates):
+			logrus.Warnf("discarding journaled publish %d for %s, which was not applied", entry.ID, entry.GUN)
+			if err := js.journal.Discard(entry.ID); err != nil {
+				return err
+			}
+			continue
+		default:
+			logrus.Warnf("replaying %d of the %d updates of journaled publish %d for %s",
+				len(pending), len(entry.Updates), entry.ID, entry.GUN)
+			if err := js.MetaStore.UpdateMany(entry.GUN, pending); err != nil {
+				return fmt.Errorf("unable to replay journaled publish %d for %s: %v", entry.ID, entry.GUN, err)
+			}
+		}
+		if err := js.journal.Commit(entry.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errJournalConflict is returned by pendingUpdates when the store holds a
+// version of a journaled update with different content, so some other publish
+// stored that version and the journaled one can never be applied
+type errJournalConflict struct {
+	role    data.RoleName
+	version int
+}
+
+func (err errJournalConflict) Error() string {
+	return fmt.Sprintf("version %d of %s was stored by another publish", err.version, err.role)
+}
+
+// pendingUpdates returns the updates of the entry whose versions are not yet
+// stored.  A version stored with content other than the journaled update's
+// is an errJournalConflict.
+func (js *JournaledStore) pendingUpdates(entry JournalEntry) ([]MetaUpdate, error) {
+	var pending []MetaUpdate
+	for _, update := range entry.Updates {
+		_, stored, err := js.MetaStore.GetVersion(entry.GUN, update.Role, update.Version)
+		switch err.(type) {
+		case nil:
+			if sha256.Sum256(stored) != sha256.Sum256(update.Data) {
+				return nil, errJournalConflict{role: update.Role, version: update.Version}
+			}
+		case ErrNotFound:
+			pending = append(pending, update)
+		default:
+			return nil, err
+		}
+	}
+	return pending, nil
+}
+
+// MemoryJournal is a Journal kept in memory, and so lost on a crash, for
+// testing
+type MemoryJournal struct {
+	lock    sync.Mutex
+	entries []JournalEntry
+	nextID  uint64
+}
+
+// NewMemoryJournal returns an empty MemoryJournal
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{nextID: 1}
+}
+
+// Append records the planned updates
+func (m *MemoryJournal) Append(gun data.GUN, updates []MetaUpdate) (uint64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	id := m.nextID
+	m.nextID++
+	m.entries = append(m.entries, JournalEntry{ID: id, GUN: gun, Updates: updates})
+	return id, nil
+}
+
+// Commit removes the entry
+func (m *MemoryJournal) Commit(id uint64) error {
+	return m.remove(id)
+}
+
+// Discard removes the entry
+func (m *MemoryJournal) Discard(id uint64) error {
+	return m.remove(id)
+}
+
+func (m *MemoryJournal) remove(id uint64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i, entry := range m.entries {
+		if entry.ID == id {
+			m.entries = append(m.entries[:i], m.entries[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no uncommitted journal entry %d", id)
+}
+
+// Uncommitted returns the entries not yet committed or discarded
+func (m *MemoryJournal) Uncommitted() ([]JournalEntry, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]JournalEntry(nil), m.entries...), nil
+}
+
+// The states of a file journal record
+const (
+	journalPending   = "pending"
+	journalCommitted = "committed"
+	journalDiscarded = "discarded"
+)
+
+// journalRecord is a line of a FileJournal: either a planned publish, or the
+// committing or discarding of one
+type journalRecord struct {
+	ID      uint64       `json:"id"`
+	State   string       `json:"state"`
+	GUN     data.GUN     `json:"gun,omitempty"`
+	Updates []MetaUpdate `json:"updates,omitempty"`
+}
+
+// FileJournal is a Journal appending a JSON record per line to a file, which
+// is synced to disk after every record.  The file is compacted to the
+// uncommitted entries when it is opened, and whenever committing or discarding
+// an entry leaves none uncommitted, so it only holds the records written since
+// the journal was last idle.
+type FileJournal struct {
+	lock    sync.Mutex
+	path    string
+	file    *os.File
+	pending []JournalEntry
+	nextID  uint64
+}
+
+// NewFileJournal opens the journal at the path, creating it if needed.  The
+// file is compacted to the entries which are still uncommitted, and a final
+// record which a crash left incomplete is ignored.
+func NewFileJournal(path string) (*FileJournal, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	j := &FileJournal{nextID: 1}
+	lines := bytes.Split(raw, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var record journalRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			if i == len(lines)-1 {
+				logrus.Warnf("ignoring the incomplete final record of journal %s", path)
+				break
+			}
+			return nil, fmt.Errorf("journal %s is corrupt at line %d: %v", path, i+1, err)
+		}
+		j.replay(record)
+	}
+
+	j.path = path
+	if err := j.compact(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// compact rewrites the journal to hold only the uncommitted entries, or, if
+// there are none, a record of the last ID issued, replacing the file atomically
+func (j *FileJournal) compact() error {
+	tmp := j.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	for _, entry := range j.pending {
+		if err := writeJournalRecord(w, journalRecord{
+			ID: entry.ID, State: journalPending, GUN: entry.GUN, Updates: entry.Updates}); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if len(j.pending) == 0 && j.nextID > 1 {
+		// keep the last ID issued, so that IDs are never reused
+		if err := writeJournalRecord(w, journalRecord{ID: j.nextID - 1, State: journalCommitted}); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		file.Close()
+		return err
+	}
+	// sync the directory too, so that the rename itself survives a crash
+	if err := syncDir(filepath.Dir(j.path)); err != nil {
+		file.Close()
+		return err
+	}
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file = file
+	return nil
+}
+
+// syncDir flushes the directory's entries to disk
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// replay applies a record read back from the file to the pending entries
+func (j *FileJournal) replay(record journalRecord) {
+	if record.ID >= j.nextID {
+		j.nextID = record.ID + 1
+	}
+	if record.State == journalPending {
+		j.pending = append(j.pending, JournalEntry{ID: record.ID, GUN: record.GUN, Updates: record.Updates})
+		return
+	}
+	for i, entry := range j.pending {
+		if entry.ID == record.ID {
+			j.pending = append(j.pending[:i], j.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// writeJournalRecord writes the record as a single line
+func writeJournalRecord(w *bufio.Writer, record journalRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// append durably writes the record to the journal
+func (j *FileJournal) append(record journalRecord) error {
+	w := bufio.NewWriter(j.file)
+	if err := writeJournalRecord(w, record); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	j.replay(record)
+	if record.State != journalPending && len(j.pending) == 0 {
+		// the record is already durable, so failing to compact only leaves
+		// the journal longer than it needs to be
+		if err := j.compact(); err != nil {
+			logrus.Warnf("unable to compact journal %s: %v", j.path, err)
+		}
+	}
+	return nil
+}
+
+// Append durably records the planned updates
+func (j *FileJournal) Append(gun data.GUN, updates []MetaUpdate) (uint64, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	id := j.nextID
+	if err := j.append(journalRecord{ID: id, State: journalPending, GUN: gun, Updates: updates}); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Commit durably records that the entry was applied
+func (j *FileJournal) Commit(id uint64) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.append(journalRecord{ID: id, State: journalCommitted})
+}
+
+// Discard durably records that the entry is not to be applied
+func (j *FileJournal) Discard(id uint64) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.append(journalRecord{ID: id, State: journalDiscarded})
+}
+
+// Uncommitted returns the entries not yet committed or discarded
+func (j *FileJournal) Uncommitted() ([]JournalEntry, error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return append([]JournalEntry(nil), j.pending...), nil
+}
+
+// Close closes the journal file
+func (j *FileJournal) Close() error {
+	return j.file.Close()
+}
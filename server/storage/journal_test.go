@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+var errCrash = errors.New("crashed")
+
+// crashingStore applies only the first crashAfter updates of each UpdateMany
+// to a non-transactional store, then returns failure if it is set, and
+// otherwise panics as if the server had crashed
+type crashingStore struct {
+	MetaStore
+	crashAfter int
+	failure    error
+}
+
+func (c crashingStore) UpdateMany(gun data.GUN, updates []MetaUpdate) error {
+	for _, update := range updates[:c.crashAfter] {
+		if err := c.MetaStore.UpdateCurrent(gun, update); err != nil {
+			return err
+		}
+	}
+	if c.failure != nil {
+		return c.failure
+	}
+	panic(errCrash)
+}
+
+// publishCrashing publishes the updates through a journaled store which
+// crashes after applying the first crashAfter of them
+func publishCrashing(t *testing.T, s MetaStore, journal Journal, crashAfter int, updates []MetaUpdate) {
+	js, err := NewJournaledStore(crashingStore{MetaStore: s, crashAfter: crashAfter}, journal, JournalReplay)
+	require.NoError(t, err)
+	defer func() {
+		require.Equal(t, errCrash, recover())
+	}()
+	js.UpdateMany("testGUN", updates)
+}
+
+func journalTestUpdates() []MetaUpdate {
+	return []MetaUpdate{
+		{Role: data.CanonicalTargetsRole, Version: 1, Data: []byte("targets")},
+		{Role: data.CanonicalSnapshotRole, Version: 1, Data: []byte("snapshot")},
+		{Role: data.CanonicalTimestampRole, Version: 1, Data: []byte("timestamp")},
+	}
+}
+
+// requireStored asserts whether all of the updates are stored
+func requireStored(t *testing.T, s MetaStore, updates []MetaUpdate, stored bool) {
+	for _, update := range updates {
+		_, blob, err := s.GetVersion("testGUN", update.Role, update.Version)
+		if stored {
+			require.NoError(t, err, update.Role.String())
+			require.Equal(t, update.Data, blob)
+		} else {
+			require.IsType(t, ErrNotFound{}, err, update.Role.String())
+		}
+	}
+}
+
+// A publish is committed once applied, and a rejected one is discarded
+func TestJournaledStoreCommits(t *testing.T) {
+	s := NewMemStorage()
+	journal := NewMemoryJournal()
+	js, err := NewJournaledStore(s, journal, JournalReplay)
+	require.NoError(t, err)
+
+	updates := journalTestUpdates()
+	require.NoError(t, js.UpdateMany("testGUN", updates))
+	requireStored(t, s, updates, true)
+	require.IsType(t, ErrOldVersion{}, js.UpdateCurrent("testGUN", updates[0]))
+
+	pending, err := journal.Uncommitted()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+// A publish which crashed between being journaled and being applied is
+// replayed, or discarded, on restart
+func TestJournaledStoreRecoversUnappliedPublish(t *testing.T) {
+	updates := journalTestUpdates()
+	for _, policy := range []JournalRecoveryPolicy{JournalReplay, JournalDiscard} {
+		s := NewMemStorage()
+		journal := NewMemoryJournal()
+		publishCrashing(t, s, journal, 0, updates)
+		requireStored(t, s, updates, false)
+
+		_, err := NewJournaledStore(s, journal, policy)
+		require.NoError(t, err)
+		requireStored(t, s, updates, policy == JournalReplay)
+		pending, err := journal.Uncommitted()
+		require.NoError(t, err)
+		require.Empty(t, pending)
+	}
+}
+
+// A publish which crashed part way through being applied is completed on
+// restart, whatever the policy, as it cannot be rolled back
+func TestJournaledStoreRecoversPartiallyAppliedPublish(t *testing.T) {
+	updates := journalTestUpdates()
+	for _, policy := range []JournalRecoveryPolicy{JournalReplay, JournalDiscard} {
+		s := NewMemStorage()
+		journal := NewMemoryJournal()
+		publishCrashing(t, s, journal, 1, updates)
+		requireStored(t, s, updates[:1], true)
+		requireStored(t, s, updates[1:], false)
+
+		_, err := NewJournaledStore(s, journal, policy)
+		require.NoError(t, err)
+		requireStored(t, s, updates, true)
+		_, timestamp, err := s.GetCurrent("testGUN", data.CanonicalTimestampRole)
+		require.NoError(t, err)
+		require.Equal(t, []byte("timestamp"), timestamp)
+	}
+}
+
+// A publish the store rejects, whether or not it stored part of it first, is
+// discarded rather than completed on recovery, since the caller was told it
+// failed
+func TestJournaledStoreDiscardsFailedPublish(t *testing.T) {
+	updates := journalTestUpdates()
+	failure := errors.New("disk full")
+
+	s := NewMemStorage()
+	journal := NewMemoryJournal()
+	js, err := NewJournaledStore(crashingStore{MetaStore: s, crashAfter: 1, failure: failure}, journal, JournalReplay)
+	require.NoError(t, err)
+	require.Equal(t, failure, js.UpdateMany("testGUN", updates))
+	pending, err := journal.Uncommitted()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	_, err = NewJournaledStore(s, journal, JournalReplay)
+	require.NoError(t, err)
+	requireStored(t, s, updates[1:], false)
+
+	// a publish of versions another client already stored is rejected, and
+	// none of the rest of it is stored later
+	s = NewMemStorage()
+	require.NoError(t, s.UpdateCurrent("testGUN", MetaUpdate{
+		Role: data.CanonicalTargetsRole, Version: 1, Data: []byte("other targets")}))
+	js, err = NewJournaledStore(s, journal, JournalReplay)
+	require.NoError(t, err)
+	require.IsType(t, ErrOldVersion{}, js.UpdateMany("testGUN", updates))
+	pending, err = journal.Uncommitted()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	_, err = NewJournaledStore(s, journal, JournalReplay)
+	require.NoError(t, err)
+	requireStored(t, s, updates[1:], false)
+}
+
+// Recovery discards a publish if one of its versions has since been stored
+// with other content, rather than replaying the rest of it
+func TestJournaledStoreDiscardsConflictingPublish(t *testing.T) {
+	updates := journalTestUpdates()
+	s := NewMemStorage()
+	journal := NewMemoryJournal()
+	_, err := journal.Append("testGUN", updates)
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateCurrent("testGUN", MetaUpdate{
+		Role: data.CanonicalTargetsRole, Version: 1, Data: []byte("other targets")}))
+
+	_, err = NewJournaledStore(s, journal, JournalReplay)
+	require.NoError(t, err)
+	requireStored(t, s, updates[1:], false)
+	pending, err := journal.Uncommitted()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+// A file journal is compacted whenever no publish is uncommitted, so it does
+// not grow with the number of publishes
+func TestFileJournalCompactsWhenIdle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notary-journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "publishes.log")
+
+	journal, err := NewFileJournal(path)
+	require.NoError(t, err)
+	js, err := NewJournaledStore(NewMemStorage(), journal, JournalReplay)
+	require.NoError(t, err)
+	for version := 1; version <= 20; version++ {
+		require.NoError(t, js.UpdateCurrent("testGUN",
+			MetaUpdate{Role: data.CanonicalTimestampRole, Version: version, Data: []byte("timestamp")}))
+		raw, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf(`{"id":%d,"state":"committed"}`+"\n", version), string(raw))
+	}
+	require.NoError(t, journal.Close())
+
+	// IDs carry on from the last one issued
+	journal, err = NewFileJournal(path)
+	require.NoError(t, err)
+	defer journal.Close()
+	id, err := journal.Append("testGUN", nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(21), id)
+}
+
+// A file journal keeps uncommitted publishes across a crash, ignoring a
+// final record the crash left incomplete
+func TestFileJournalRecovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notary-journal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal", "publishes.log")
+
+	s := NewMemStorage()
+	journal, err := NewFileJournal(path)
+	require.NoError(t, err)
+	js, err := NewJournaledStore(s, journal, JournalReplay)
+	require.NoError(t, err)
+	committed := []MetaUpdate{{Role: data.CanonicalRootRole, Version: 1, Data: []byte("root")}}
+	require.NoError(t, js.UpdateMany("testGUN", committed))
+
+	updates := journalTestUpdates()
+	publishCrashing(t, s, journal, 0, updates)
+	require.NoError(t, journal.Close())
+
+	// the crash also cut off the record of a further publish
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte(`{"id":4,"state":"pend`))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	journal, err = NewFileJournal(path)
+	require.NoError(t, err)
+	pending, err := journal.Uncommitted()
+	require.NoError(t, err)
+	require.Equal(t, []JournalEntry{{ID: 2, GUN: "testGUN", Updates: updates}}, pending)
+
+	_, err = NewJournaledStore(s, journal, JournalReplay)
+	require.NoError(t, err)
+	requireStored(t, s, append(committed, updates...), true)
+	require.NoError(t, journal.Close())
+
+	// once recovered, the journal is compacted away
+	journal, err = NewFileJournal(path)
+	require.NoError(t, err)
+	defer journal.Close()
+	pending, err = journal.Uncommitted()
+	require.NoError(t, err)
+	require.Empty(t, pending)
+	raw, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `{"id":2,"state":"committed"}`+"\n", string(raw))
+	id, err := journal.Append("testGUN", committed)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), id)
+}
@@ -209,6 +209,32 @@ func (st *MemStorage) Delete(gun data.GUN) error {
 	return nil
 }
 
+// StorageUsageByGUN returns the total bytes of metadata stored for each GUN.
+// The consistent copy of a version is the same blob as the version, so each
+// version is counted once.
+func (st *MemStorage) StorageUsageByGUN(includeHistory bool) (map[data.GUN]int64, error) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	usage := make(map[data.GUN]int64)
+	for gunName := range st.checksums {
+		gun := data.GUN(gunName)
+		prefix := entryKey(gun, "")
+		for id, versions := range st.tufMeta {
+			if len(versions) == 0 || !strings.HasPrefix(id, prefix) ||
+				!data.ValidRole(data.RoleName(strings.TrimPrefix(id, prefix))) {
+				continue
+			}
+			if !includeHistory {
+				versions = versions[len(versions)-1:]
+			}
+			for _, v := range versions {
+				usage[gun] += int64(len(v.data))
+			}
+		}
+	}
+	return usage, nil
+}
+
 // GetChanges returns a []Change starting from but excluding the record
 // identified by changeID. In the context of the memory store, changeID
 // is simply an index into st.changes. The ID of a change is its
@@ -101,3 +101,8 @@ func TestGetVersion(t *testing.T) {
 	s := NewMemStorage()
 	testGetVersion(t, s)
 }
+
+func TestMemoryStorageUsageByGUN(t *testing.T) {
+	s := NewMemStorage()
+	testStorageUsageByGUN(t, s)
+}
@@ -218,6 +218,56 @@ func (db *SQLStorage) GetVersion(gun data.GUN, tufRole data.RoleName, version in
 	return &(row.CreatedAt), row.Data, nil
 }
 
+// EachConsistent calls fn with the consistent name, <gun>/<role>.<sha256>, and
+// contents of every TUF file stored, ordered by GUN, role and version,
+// stopping at the first error fn returns
+func (db *SQLStorage) EachConsistent(fn func(name string, blob []byte) error) error {
+	rows, err := db.Model(&TUFFile{}).Select("gun, role, sha256, data").Order("gun, role, version").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row TUFFile
+		if err := db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(fmt.Sprintf("%s/%s.%s", row.Gun, row.Role, row.SHA256), row.Data); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// StorageUsageByGUN returns the total bytes of metadata stored for each GUN.
+// Consistent copies are served from the row of their version, so each
+// version is counted once.
+func (db *SQLStorage) StorageUsageByGUN(includeHistory bool) (map[data.GUN]int64, error) {
+	q := db.Model(&TUFFile{}).Select("gun, SUM(LENGTH(data))")
+	if !includeHistory {
+		q = q.Where(fmt.Sprintf("version = (SELECT MAX(latest.version) FROM %[1]s latest "+
+			"WHERE latest.gun = %[1]s.gun AND latest.role = %[1]s.role AND latest.deleted_at IS NULL)",
+			TUFFileTableName))
+	}
+	rows, err := q.Group("gun").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	usage := make(map[data.GUN]int64)
+	for rows.Next() {
+		var (
+			gun   string
+			total int64
+		)
+		if err := rows.Scan(&gun, &total); err != nil {
+			return nil, err
+		}
+		usage[data.GUN(gun)] = total
+	}
+	return usage, rows.Err()
+}
+
 func isReadErr(q *gorm.DB, row TUFFile) error {
 	if q.RecordNotFound() {
 		return ErrNotFound{}
@@ -3,14 +3,17 @@
 package storage
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/storage"
 	"github.com/theupdateframework/notary/tuf/data"
 )
 
@@ -254,3 +257,35 @@ func TestSQLDBGetVersion(t *testing.T) {
 
 	testGetVersion(t, dbStore)
 }
+
+func TestSQLStorageUsageByGUN(t *testing.T) {
+	dbStore, cleanup := sqldbSetup(t)
+	defer cleanup()
+	testStorageUsageByGUN(t, dbStore)
+}
+
+// Every version of every role is exported, by its consistent name
+func TestSQLExportConsistent(t *testing.T) {
+	dbStore, cleanup := sqldbSetup(t)
+	defer cleanup()
+
+	expected := make(map[string][]byte)
+	for _, gun := range []data.GUN{"docker.com/notary", "docker.com/other"} {
+		for version := 1; version <= 2; version++ {
+			tufObj := SampleCustomTUFObj(gun, data.CanonicalTimestampRole, version, nil)
+			require.NoError(t, dbStore.UpdateCurrent(gun, MakeUpdate(tufObj)))
+			expected[fmt.Sprintf("%s/timestamp.%s", gun, tufObj.SHA256)] = tufObj.Data
+		}
+	}
+
+	var archive bytes.Buffer
+	require.NoError(t, storage.ExportConsistent(dbStore, &archive))
+	imported := storage.NewMemoryStore(nil)
+	require.NoError(t, storage.ImportConsistent(imported, &archive))
+	exported := make(map[string][]byte)
+	require.NoError(t, imported.EachConsistent(func(name string, blob []byte) error {
+		exported[name] = blob
+		return nil
+	}))
+	require.Equal(t, expected, exported)
+}
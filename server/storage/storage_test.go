@@ -458,3 +458,42 @@ func testGetChanges(t *testing.T, s MetaStore) {
 	require.NotEqual(t, "alpine", c[0].GUN)
 
 }
+
+// StorageUsageByGUN totals the sizes of the stored metadata of each GUN,
+// counting only the current versions unless history is included
+func testStorageUsageByGUN(t *testing.T, s interface {
+	MetaStore
+	UsageReporter
+}) {
+	usage, err := s.StorageUsageByGUN(true)
+	require.NoError(t, err)
+	require.Empty(t, usage)
+
+	all := make(map[data.GUN]int64)
+	current := make(map[data.GUN]int64)
+	for _, gun := range []data.GUN{"docker.com/notary", "docker.com/other"} {
+		for _, role := range []data.RoleName{data.CanonicalRootRole, "targets/a"} {
+			for version := 1; version <= 3; version++ {
+				tufObj := SampleCustomTUFObj(gun, role, version, nil)
+				require.NoError(t, s.UpdateCurrent(gun, MakeUpdate(tufObj)))
+				all[gun] += int64(len(tufObj.Data))
+				if version == 3 {
+					current[gun] += int64(len(tufObj.Data))
+				}
+			}
+		}
+	}
+
+	usage, err = s.StorageUsageByGUN(true)
+	require.NoError(t, err)
+	require.Equal(t, all, usage)
+	usage, err = s.StorageUsageByGUN(false)
+	require.NoError(t, err)
+	require.Equal(t, current, usage)
+
+	require.NoError(t, s.Delete("docker.com/other"))
+	delete(current, "docker.com/other")
+	usage, err = s.StorageUsageByGUN(false)
+	require.NoError(t, err)
+	require.Equal(t, current, usage)
+}
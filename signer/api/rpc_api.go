@@ -19,11 +19,15 @@ import (
 //KeyManagementServer implements the KeyManagementServer grpc interface
 type KeyManagementServer struct {
 	CryptoServices signer.CryptoServiceIndex
+	// CreateKeyLimits limits the rate of key creation by role, if not nil
+	CreateKeyLimits *signer.RoleRateLimiter
 }
 
 //SignerServer implements the SignerServer grpc interface
 type SignerServer struct {
 	CryptoServices signer.CryptoServiceIndex
+	// SignLimits limits the rate of signing by the role of the key, if not nil
+	SignLimits *signer.RoleRateLimiter
 }
 
 //CreateKey returns a PublicKey created using KeyManagementServer's SigningService
@@ -37,6 +41,11 @@ func (s *KeyManagementServer) CreateKey(ctx context.Context, req *pb.CreateKeyRe
 		return nil, fmt.Errorf("algorithm %s not supported for create key", req.Algorithm)
 	}
 
+	if !s.CreateKeyLimits.Allow(data.RoleName(req.Role)) {
+		logger.Error("CreateKey: rate limit exceeded for role: ", req.Role)
+		return nil, grpc.Errorf(codes.ResourceExhausted, "rate limit exceeded creating %s keys, retry later", req.Role)
+	}
+
 	var tufKey data.PublicKey
 	var err error
 
@@ -94,7 +103,7 @@ func (s *KeyManagementServer) GetKeyInfo(ctx context.Context, keyID *pb.KeyID) (
 
 //Sign signs a message and returns the signature using a private key associate with the KeyID from the SignatureRequest
 func (s *SignerServer) Sign(ctx context.Context, sr *pb.SignatureRequest) (*pb.Signature, error) {
-	privKey, _, err := findKeyByID(s.CryptoServices, sr.KeyID)
+	privKey, role, err := findKeyByID(s.CryptoServices, sr.KeyID)
 
 	logger := ctxu.GetLogger(ctx)
 
@@ -110,6 +119,11 @@ func (s *SignerServer) Sign(ctx context.Context, sr *pb.SignatureRequest) (*pb.S
 
 	}
 
+	if !s.SignLimits.Allow(role) {
+		logger.Errorf("Sign: rate limit exceeded for role %s", role)
+		return nil, grpc.Errorf(codes.ResourceExhausted, "rate limit exceeded signing with %s keys, retry later", role)
+	}
+
 	sig, err := privKey.Sign(rand.Reader, sr.Content, nil)
 	if err != nil {
 		logger.Errorf("Sign: signing failed for KeyID %s on hash %s", sr.KeyID.ID, sr.Content)
@@ -0,0 +1,99 @@
+package signer
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// RateLimit allows Burst requests at once, replenished at Rate requests per
+// second.  A Burst of 0 leaves requests unlimited.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimits are the rate limits of the signer's key creation and signing
+// endpoints, by the role of the key being created or signed with.  Requests
+// for roles without a limit are unlimited.  Each role's limit is shared by
+// every caller of the signer.
+type RateLimits struct {
+	CreateKey map[data.RoleName]RateLimit
+	Sign      map[data.RoleName]RateLimit
+}
+
+// DefaultRateLimits returns the rate limits applied unless configured
+// otherwise.  Root and targets keys are rarely created or used by the signer,
+// so are strictly limited, whereas the timestamp is signed on every publish.
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		CreateKey: map[data.RoleName]RateLimit{
+			data.CanonicalRootRole:      {Rate: 1.0 / 60, Burst: 5},
+			data.CanonicalTargetsRole:   {Rate: 1.0 / 60, Burst: 5},
+			data.CanonicalSnapshotRole:  {Rate: 10, Burst: 50},
+			data.CanonicalTimestampRole: {Rate: 10, Burst: 50},
+		},
+		Sign: map[data.RoleName]RateLimit{
+			data.CanonicalRootRole:      {Rate: 1, Burst: 10},
+			data.CanonicalTargetsRole:   {Rate: 1, Burst: 10},
+			data.CanonicalSnapshotRole:  {Rate: 50, Burst: 100},
+			data.CanonicalTimestampRole: {Rate: 100, Burst: 200},
+		},
+	}
+}
+
+// tokenBucket holds the requests currently allowed for a role
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RoleRateLimiter limits the rate of requests for each role, independently of
+// the other roles.  A nil RoleRateLimiter allows every request.
+type RoleRateLimiter struct {
+	mu      sync.Mutex
+	limits  map[data.RoleName]RateLimit
+	buckets map[data.RoleName]*tokenBucket
+	now     func() time.Time
+}
+
+// NewRoleRateLimiter returns a limiter applying the given limits by role
+func NewRoleRateLimiter(limits map[data.RoleName]RateLimit) *RoleRateLimiter {
+	return &RoleRateLimiter{
+		limits:  limits,
+		buckets: make(map[data.RoleName]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow reports whether a request for the role is within its rate limit,
+// counting it against the limit if so
+func (l *RoleRateLimiter) Allow(role data.RoleName) bool {
+	if l == nil {
+		return true
+	}
+	limit, ok := l.limits[role]
+	if !ok || limit.Burst <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	bucket, ok := l.buckets[role]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[role] = bucket
+	}
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+elapsed*limit.Rate)
+	}
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
@@ -0,0 +1,44 @@
+package signer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// Each role's requests are allowed up to its burst, then replenished at its
+// rate, and roles without a limit are unlimited
+func TestRoleRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := NewRoleRateLimiter(map[data.RoleName]RateLimit{
+		data.CanonicalRootRole:      {Rate: 0.5, Burst: 2},
+		data.CanonicalTimestampRole: {Rate: 1, Burst: 0},
+	})
+	limiter.now = func() time.Time { return now }
+
+	require.True(t, limiter.Allow(data.CanonicalRootRole))
+	require.True(t, limiter.Allow(data.CanonicalRootRole))
+	require.False(t, limiter.Allow(data.CanonicalRootRole))
+
+	// a single request is replenished after two seconds, and no more than the
+	// burst after a long wait
+	now = now.Add(time.Second)
+	require.False(t, limiter.Allow(data.CanonicalRootRole))
+	now = now.Add(time.Second)
+	require.True(t, limiter.Allow(data.CanonicalRootRole))
+	require.False(t, limiter.Allow(data.CanonicalRootRole))
+	now = now.Add(time.Hour)
+	require.True(t, limiter.Allow(data.CanonicalRootRole))
+	require.True(t, limiter.Allow(data.CanonicalRootRole))
+	require.False(t, limiter.Allow(data.CanonicalRootRole))
+
+	for i := 0; i < 100; i++ {
+		require.True(t, limiter.Allow(data.CanonicalTimestampRole))
+		require.True(t, limiter.Allow(data.CanonicalSnapshotRole))
+	}
+
+	var unlimited *RoleRateLimiter
+	require.True(t, unlimited.Allow(data.CanonicalRootRole))
+}
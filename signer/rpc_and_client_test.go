@@ -13,6 +13,7 @@ import (
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 
 	"github.com/stretchr/testify/require"
 	"github.com/theupdateframework/notary"
@@ -230,6 +231,10 @@ var constPass = func(string, string, bool, int) (string, bool, error) {
 }
 
 func setUpSignerServer(t *testing.T, store trustmanager.KeyStore) *grpc.Server {
+	return setUpRateLimitedSignerServer(t, store, signer.RateLimits{})
+}
+
+func setUpRateLimitedSignerServer(t *testing.T, store trustmanager.KeyStore, limits signer.RateLimits) *grpc.Server {
 	cryptoService := cryptoservice.NewCryptoService(store)
 	cryptoServices := signer.CryptoServiceIndex{
 		data.ED25519Key: cryptoService,
@@ -240,10 +245,12 @@ func setUpSignerServer(t *testing.T, store trustmanager.KeyStore) *grpc.Server {
 	//server setup
 	grpcServer := grpc.NewServer()
 	pb.RegisterKeyManagementServer(grpcServer, &api.KeyManagementServer{
-		CryptoServices: cryptoServices,
+		CryptoServices:  cryptoServices,
+		CreateKeyLimits: signer.NewRoleRateLimiter(limits.CreateKey),
 	})
 	pb.RegisterSignerServer(grpcServer, &api.SignerServer{
 		CryptoServices: cryptoServices,
+		SignLimits:     signer.NewRoleRateLimiter(limits.Sign),
 	})
 
 	return grpcServer
@@ -303,3 +310,63 @@ func TestCryptoSignerInterfaceBehavior(t *testing.T) {
 	// can't test AddKey, because the signer does not support adding keys, and can't test listing
 	// keys because the signer doesn't support listing keys.
 }
+
+// Once the root key creation limit is exhausted, root keys are rejected with a
+// retriable error while timestamp keys can still be created and signed with
+func TestRateLimitedRootKeyCreation(t *testing.T) {
+	limits := signer.RateLimits{
+		CreateKey: map[data.RoleName]signer.RateLimit{data.CanonicalRootRole: {Rate: 0, Burst: 2}},
+	}
+	memStore := trustmanager.NewKeyMemoryStore(constPass)
+	signerClient, _, cleanup := setUpSignerClient(t, setUpRateLimitedSignerServer(t, memStore, limits))
+	defer cleanup()
+
+	for i := 0; i < 2; i++ {
+		_, err := signerClient.Create(data.CanonicalRootRole, "gun", data.ECDSAKey)
+		require.NoError(t, err)
+	}
+	_, err := signerClient.Create(data.CanonicalRootRole, "gun", data.ECDSAKey)
+	require.Error(t, err)
+	require.Equal(t, codes.ResourceExhausted, grpc.Code(err))
+
+	pubKey, err := signerClient.Create(data.CanonicalTimestampRole, "gun", data.ECDSAKey)
+	require.NoError(t, err)
+	privKey, _, err := signerClient.GetPrivateKey(pubKey.ID())
+	require.NoError(t, err)
+	msg := []byte("message!")
+	for i := 0; i < 10; i++ {
+		sig, err := privKey.Sign(rand.Reader, msg, nil)
+		require.NoError(t, err)
+		require.NoError(t, signed.Verifiers[data.ECDSASignature].Verify(pubKey, sig, msg))
+	}
+
+	// the root limit is still exhausted
+	_, err = signerClient.Create(data.CanonicalRootRole, "gun", data.ECDSAKey)
+	require.Equal(t, codes.ResourceExhausted, grpc.Code(err))
+}
+
+// Signing with a key whose role's limit is exhausted is rejected, without
+// affecting the other roles
+func TestRateLimitedSigning(t *testing.T) {
+	memStore := trustmanager.NewKeyMemoryStore(constPass)
+	signerClient, _, cleanup := setUpSignerClient(t, setUpRateLimitedSignerServer(t, memStore, signer.RateLimits{
+		Sign: map[data.RoleName]signer.RateLimit{data.CanonicalSnapshotRole: {Rate: 0, Burst: 1}},
+	}))
+	defer cleanup()
+
+	msg := []byte("message!")
+	keys := make(map[data.RoleName]data.PrivateKey)
+	for _, role := range []data.RoleName{data.CanonicalSnapshotRole, data.CanonicalTimestampRole} {
+		pubKey, err := signerClient.Create(role, "gun", data.ECDSAKey)
+		require.NoError(t, err)
+		keys[role], _, err = signerClient.GetPrivateKey(pubKey.ID())
+		require.NoError(t, err)
+	}
+
+	_, err := keys[data.CanonicalSnapshotRole].Sign(rand.Reader, msg, nil)
+	require.NoError(t, err)
+	_, err = keys[data.CanonicalSnapshotRole].Sign(rand.Reader, msg, nil)
+	require.Equal(t, codes.ResourceExhausted, grpc.Code(err))
+	_, err = keys[data.CanonicalTimestampRole].Sign(rand.Reader, msg, nil)
+	require.NoError(t, err)
+}
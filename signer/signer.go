@@ -44,4 +44,5 @@ type Config struct {
 	TLSConfig      *tls.Config
 	CryptoServices CryptoServiceIndex
 	PendingKeyFunc func(trustmanager.KeyInfo) (data.PublicKey, error)
+	RateLimits     RateLimits
 }
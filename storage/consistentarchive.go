@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/theupdateframework/notary"
+)
+
+// ConsistentLister is implemented by stores which can enumerate every
+// consistent (checksum named) blob they hold, including those of versions
+// which are no longer current
+type ConsistentLister interface {
+	// EachConsistent calls fn with the consistent name and contents of every
+	// consistent blob in the store, stopping at the first error fn returns
+	EachConsistent(fn func(name string, blob []byte) error) error
+}
+
+// ConsistentSetter is implemented by stores into which consistent blobs can
+// be restored by their consistent names
+type ConsistentSetter interface {
+	SetConsistent(name string, blob []byte) error
+}
+
+// ExportConsistent writes every consistent blob in the store to w as a tar
+// archive, with each blob stored under its consistent name.  Together with
+// ImportConsistent, this archives the full history of the metadata the store
+// holds, rather than only the current roles.
+//
+// The archive is reproducible: the blobs are written sorted by name, with
+// fixed header metadata (a zero modification time and no owner), so the
+// exports of stores holding the same blobs are byte-identical and can be
+// compared directly.
+func ExportConsistent(store ConsistentLister, w io.Writer) error {
+	var names []string
+	blobs := make(map[string][]byte)
+	if err := store.EachConsistent(func(name string, blob []byte) error {
+		names = append(names, name)
+		blobs[name] = blob
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	tw := tar.NewWriter(w)
+	for _, name := range names {
+		blob := blobs[name]
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     notary.PrivNoExecPerms,
+			Size:     int64(len(blob)),
+			ModTime:  time.Unix(0, 0),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(blob); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// ImportConsistent reads a tar archive written by ExportConsistent and sets
+// every blob in it into the store by its consistent name.  Every blob is
+// checked against the checksum in its name before anything is set.
+func ImportConsistent(store ConsistentSetter, r io.Reader) error {
+	tr := tar.NewReader(r)
+	var (
+		names []string
+		blobs = make(map[string][]byte)
+	)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Size > notary.MaxDownloadSize {
+			return fmt.Errorf("%s is larger than the maximum metadata size", header.Name)
+		}
+		blob, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := checkConsistentName(header.Name, blob); err != nil {
+			return err
+		}
+		names = append(names, header.Name)
+		blobs[header.Name] = blob
+	}
+	for _, name := range names {
+		if err := store.SetConsistent(name, blobs[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkConsistentName checks that the name ends in the hex SHA256 of the blob
+func checkConsistentName(name string, blob []byte) error {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return fmt.Errorf("%s is not a consistent name", name)
+	}
+	checksum := sha256.Sum256(blob)
+	if name[i+1:] != hex.EncodeToString(checksum[:]) {
+		return fmt.Errorf("%s does not match the checksum of its contents", name)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+func collectConsistent(t *testing.T, s ConsistentLister) map[string][]byte {
+	blobs := make(map[string][]byte)
+	require.NoError(t, s.EachConsistent(func(name string, blob []byte) error {
+		blobs[name] = blob
+		return nil
+	}))
+	return blobs
+}
+
+// Every version of every role round trips through an export and import
+func TestExportImportConsistent(t *testing.T) {
+	s := NewMemoryStore(nil)
+	expected := make(map[string][]byte)
+	for _, version := range []int{1, 2, 3} {
+		for _, name := range []string{"docker.com/notary/root", "docker.com/notary/targets"} {
+			meta := versionedMeta(version)
+			require.NoError(t, s.Set(name, meta))
+			checksum := sha256.Sum256(meta)
+			expected[utils.ConsistentName(name, checksum[:])] = meta
+		}
+	}
+	require.Equal(t, expected, collectConsistent(t, s))
+
+	var archive bytes.Buffer
+	require.NoError(t, ExportConsistent(s, &archive))
+	imported := NewMemoryStore(nil)
+	require.NoError(t, ImportConsistent(imported, bytes.NewReader(archive.Bytes())))
+	require.Equal(t, expected, collectConsistent(t, imported))
+	for name, meta := range expected {
+		got, err := imported.Get(name)
+		require.NoError(t, err)
+		require.Equal(t, meta, got)
+	}
+
+	// only the consistent copies are imported, not the current metadata
+	_, err := imported.Get("docker.com/notary/root")
+	require.IsType(t, ErrMetaNotFound{}, err)
+}
+
+// A blob that does not match the checksum in its name is not imported, and
+// neither is anything else in the archive
+func TestImportConsistentChecksumMismatch(t *testing.T) {
+	meta := versionedMeta(1)
+	checksum := sha256.Sum256(meta)
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for _, blob := range [][]byte{meta, versionedMeta(2)} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: utils.ConsistentName("docker.com/notary/root", checksum[:]), Mode: 0600, Size: int64(len(blob))}))
+		_, err := tw.Write(blob)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	imported := NewMemoryStore(nil)
+	err := ImportConsistent(imported, &archive)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not match the checksum")
+	require.Empty(t, collectConsistent(t, imported))
+}
+
+// Exports of stores holding the same blobs, set in different orders, are
+// byte-identical, with the entries sorted and no varying header metadata
+func TestExportConsistentReproducible(t *testing.T) {
+	names := []string{"docker.com/notary/targets", "docker.com/notary/root", "docker.com/notary/snapshot"}
+	first, second := NewMemoryStore(nil), NewMemoryStore(nil)
+	for _, version := range []int{1, 2} {
+		for i := range names {
+			require.NoError(t, first.Set(names[i], versionedMeta(version)))
+			require.NoError(t, second.Set(names[len(names)-1-i], versionedMeta(version)))
+		}
+	}
+
+	var firstArchive, secondArchive bytes.Buffer
+	require.NoError(t, ExportConsistent(first, &firstArchive))
+	require.NoError(t, ExportConsistent(second, &secondArchive))
+	require.Equal(t, firstArchive.Bytes(), secondArchive.Bytes())
+
+	var archived []string
+	tr := tar.NewReader(bytes.NewReader(firstArchive.Bytes()))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		archived = append(archived, header.Name)
+		require.Equal(t, int64(0), header.ModTime.Unix())
+		require.Equal(t, 0, header.Uid)
+		require.Equal(t, 0, header.Gid)
+		require.Empty(t, header.Uname)
+		require.Empty(t, header.Gname)
+	}
+	require.Len(t, archived, 6)
+	require.True(t, sort.StringsAreSorted(archived))
+}
@@ -16,6 +16,12 @@ import (
 
 // NewFileStore creates a fully configurable file store
 func NewFileStore(baseDir, fileExt string) (*FilesystemStore, error) {
+	return NewFileStoreWithPathMapper(baseDir, fileExt, IdentityPathMapper{})
+}
+
+// NewFileStoreWithPathMapper creates a file store which keeps each name at the
+// path, relative to the base directory, the mapper maps it to
+func NewFileStoreWithPathMapper(baseDir, fileExt string, mapper PathMapper) (*FilesystemStore, error) {
 	baseDir = filepath.Clean(baseDir)
 	if err := createDirectory(baseDir, notary.PrivExecPerms); err != nil {
 		return nil, err
@@ -27,6 +33,7 @@ func NewFileStore(baseDir, fileExt string) (*FilesystemStore, error) {
 	return &FilesystemStore{
 		baseDir: baseDir,
 		ext:     fileExt,
+		mapper:  mapper,
 	}, nil
 }
 
@@ -49,6 +56,15 @@ func NewPrivateSimpleFileStore(baseDir, fileExt string) (*FilesystemStore, error
 type FilesystemStore struct {
 	baseDir string
 	ext     string
+	mapper  PathMapper
+}
+
+// pathMapper returns the store's mapper, defaulting to the identity mapping
+func (f FilesystemStore) pathMapper() PathMapper {
+	if f.mapper == nil {
+		return IdentityPathMapper{}
+	}
+	return f.mapper
 }
 
 func (f *FilesystemStore) moveKeyTo0Dot4Location(file string) {
@@ -119,7 +135,7 @@ func (f *FilesystemStore) migrateTo0Dot4() {
 }
 
 func (f *FilesystemStore) getPath(name string) (string, error) {
-	fileName := fmt.Sprintf("%s%s", name, f.ext)
+	fileName := fmt.Sprintf("%s%s", mapName(f.pathMapper(), name), f.ext)
 	fullPath := filepath.Join(f.baseDir, fileName)
 
 	if !strings.HasPrefix(fullPath, f.baseDir) {
@@ -258,7 +274,11 @@ func (f FilesystemStore) ListFiles() []string {
 				return err
 			}
 			trimmed := strings.TrimSuffix(fp, f.ext)
-			files = append(files, trimmed)
+			name, ok := f.pathMapper().PathToRole(filepath.ToSlash(trimmed))
+			if !ok {
+				return nil
+			}
+			files = append(files, filepath.FromSlash(name))
 		}
 		return nil
 	})
@@ -17,10 +17,13 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"path"
+	"sort"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/theupdateframework/notary"
@@ -94,6 +97,48 @@ func (err ErrInvalidOperation) Error() string {
 	return "trust server rejected operation."
 }
 
+// ErrSetMultiPartial is returned by a best-effort SetMulti when some of the
+// metadata could not be set.  Errors holds the error for each name which was
+// not set, and every other name was set.
+type ErrSetMultiPartial struct {
+	Errors map[string]error
+}
+
+func (err ErrSetMultiPartial) Error() string {
+	names := make([]string, 0, len(err.Errors))
+	for name := range err.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	failures := make([]string, 0, len(names))
+	for _, name := range names {
+		failures = append(failures, fmt.Sprintf("%s: %s", name, err.Errors[name]))
+	}
+	return fmt.Sprintf("failed to set %d metadata files: %s", len(names), strings.Join(failures, "; "))
+}
+
+// SetMultiMode determines how an HTTPStore's SetMulti uploads a batch of
+// metadata, and so what state the server is left in if part of it is rejected
+type SetMultiMode int
+
+// The possible ways of uploading a batch of metadata
+const (
+	// SetMultiAtomic only uploads the batch through the atomic update endpoint,
+	// so the server either accepts or rejects the complete update.  This is
+	// the default.
+	SetMultiAtomic SetMultiMode = iota
+	// SetMultiAuto uploads the batch through the server's atomic update
+	// endpoint, and falls back to SetMultiBestEffort, with a warning, if the
+	// server does not support that endpoint
+	SetMultiAuto
+	// SetMultiBestEffort uploads each piece of metadata to its own URL, in
+	// dependency order with the timestamp last, setting as many as possible and
+	// returning ErrSetMultiPartial for the rest.  It only works with servers
+	// which accept a PUT of each role at its own URL; notary-server does not,
+	// and only accepts updates through the atomic endpoint.
+	SetMultiBestEffort
+)
+
 // HTTPStore manages pulling and pushing metadata from and to a remote
 // service over HTTP. It assumes the URL structure of the remote service
 // maps identically to the structure of the TUF repo:
@@ -109,6 +154,7 @@ type HTTPStore struct {
 	metaExtension string
 	keyExtension  string
 	roundTrip     http.RoundTripper
+	setMultiMode  SetMultiMode
 }
 
 // NewNotaryServerStore returns a new HTTPStore against a URL which should represent a notary
@@ -126,7 +172,16 @@ func NewNotaryServerStore(serverURL string, gun data.GUN, roundTrip http.RoundTr
 // NewHTTPStore initializes a new store against a URL and a number of configuration options.
 //
 // In case of a nil `roundTrip`, a default offline store is used instead.
+// Its SetMulti is atomic; use NewHTTPStoreWithSetMultiMode to opt in to
+// best-effort uploads.
 func NewHTTPStore(baseURL, metaPrefix, metaExtension, keyExtension string, roundTrip http.RoundTripper) (RemoteStore, error) {
+	return NewHTTPStoreWithSetMultiMode(baseURL, metaPrefix, metaExtension, keyExtension, roundTrip, SetMultiAtomic)
+}
+
+// NewHTTPStoreWithSetMultiMode initializes a new store as NewHTTPStore does,
+// whose SetMulti uploads metadata in the given mode
+func NewHTTPStoreWithSetMultiMode(baseURL, metaPrefix, metaExtension, keyExtension string, roundTrip http.RoundTripper,
+	mode SetMultiMode) (RemoteStore, error) {
 	base, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, err
@@ -143,6 +198,7 @@ func NewHTTPStore(baseURL, metaPrefix, metaExtension, keyExtension string, round
 		metaExtension: metaExtension,
 		keyExtension:  keyExtension,
 		roundTrip:     roundTrip,
+		setMultiMode:  mode,
 	}, nil
 }
 
@@ -262,7 +318,12 @@ func NewMultiPartMetaRequest(url string, metas map[string][]byte) (*http.Request
 // SetMulti does a single batch upload of multiple pieces of TUF metadata.
 // This should be preferred for updating a remote server as it enable the server
 // to remain consistent, either accepting or rejecting the complete update.
+// How a server without an atomic update endpoint is handled depends on the
+// store's SetMultiMode.
 func (s HTTPStore) SetMulti(metas map[string][]byte) error {
+	if s.setMultiMode == SetMultiBestEffort {
+		return s.setEach(metas)
+	}
 	url, err := s.buildMetaURL("")
 	if err != nil {
 		return err
@@ -276,10 +337,84 @@ func (s HTTPStore) SetMulti(metas map[string][]byte) error {
 		return NetworkError{Wrapped: err}
 	}
 	defer resp.Body.Close()
+	if s.setMultiMode == SetMultiAuto &&
+		(resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		logrus.Warnf("received HTTP status %d from the atomic update endpoint, setting metadata individually "+
+			"so the update may be partially applied", resp.StatusCode)
+		return s.setEach(metas)
+	}
 	// if this 404's something is pretty wrong
 	return translateStatusToError(resp, "POST metadata endpoint")
 }
 
+// setEach uploads each piece of metadata separately, in dependency order, so
+// that the root, targets and delegations are set before the snapshot which
+// references them, and the snapshot before the timestamp.  It carries on past
+// failures so that as much as possible is set.
+func (s HTTPStore) setEach(metas map[string][]byte) error {
+	names := make([]string, 0, len(metas))
+	for name := range metas {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if rankI, rankJ := uploadRank(names[i]), uploadRank(names[j]); rankI != rankJ {
+			return rankI < rankJ
+		}
+		return names[i] < names[j]
+	})
+
+	failed := make(map[string]error)
+	for _, name := range names {
+		if err := s.setOne(name, metas[name]); err != nil {
+			failed[name] = err
+		}
+	}
+	if len(failed) > 0 {
+		return ErrSetMultiPartial{Errors: failed}
+	}
+	return nil
+}
+
+// uploadRank orders metadata for setEach: root, targets, delegations (parents
+// before their children), then anything else, snapshot and timestamp last
+func uploadRank(name string) int {
+	switch data.RoleName(name) {
+	case data.CanonicalRootRole:
+		return 0
+	case data.CanonicalTargetsRole:
+		return 1
+	case data.CanonicalSnapshotRole:
+		return math.MaxInt32 - 1
+	case data.CanonicalTimestampRole:
+		return math.MaxInt32
+	}
+	if data.IsDelegation(data.RoleName(name)) {
+		return 1 + strings.Count(name, "/")
+	}
+	return math.MaxInt32 - 2
+}
+
+// setOne uploads a single piece of metadata to its own URL
+func (s HTTPStore) setOne(name string, blob []byte) error {
+	url, err := s.buildMetaURL(name)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", url.String(), bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	resp, err := s.roundTrip.RoundTrip(req)
+	if err != nil {
+		return NetworkError{Wrapped: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return translateStatusToError(resp, name)
+}
+
 // RemoveAll will attempt to delete all TUF metadata for a GUN
 func (s HTTPStore) RemoveAll() error {
 	url, err := s.buildMetaURL("")
@@ -427,3 +427,139 @@ func TestLocation(t *testing.T) {
 	require.NotNil(t, s)
 	require.Equal(t, s.Location(), "store.me")
 }
+
+// fakeMetaServer stores metadata uploaded individually, and through an atomic
+// update endpoint if it has one, rejecting any upload of the snapshot.  It
+// records the order in which metadata is uploaded individually.
+type fakeMetaServer struct {
+	atomic bool
+	stored map[string][]byte
+	puts   []string
+}
+
+func (f *fakeMetaServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "POST":
+		if !f.atomic {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		reader, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		updates := make(map[string][]byte)
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			updates[part.FileName()], _ = ioutil.ReadAll(part)
+		}
+		if _, ok := updates[data.CanonicalSnapshotRole.String()]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for name, blob := range updates {
+			f.stored[name] = blob
+		}
+	case r.Method == "PUT":
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/metadata/"), ".json")
+		f.puts = append(f.puts, name)
+		if name == data.CanonicalSnapshotRole.String() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.stored[name], _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// An atomic SetMulti leaves nothing set if one role is rejected, while a
+// best-effort one sets every other role and reports the one rejected
+func TestHTTPStoreSetMultiModes(t *testing.T) {
+	metas := map[string][]byte{
+		data.CanonicalRootRole.String():     []byte("root data"),
+		data.CanonicalSnapshotRole.String(): []byte("snapshot data"),
+		data.CanonicalTargetsRole.String():  []byte("targets data"),
+	}
+	partial := ErrSetMultiPartial{Errors: map[string]error{
+		data.CanonicalSnapshotRole.String(): ErrInvalidOperation{},
+	}}
+	withoutSnapshot := map[string][]byte{
+		data.CanonicalRootRole.String():    []byte("root data"),
+		data.CanonicalTargetsRole.String(): []byte("targets data"),
+	}
+
+	for _, testCase := range []struct {
+		mode     SetMultiMode
+		atomic   bool
+		expected error
+		stored   map[string][]byte
+	}{
+		{mode: SetMultiAuto, atomic: true, expected: ErrInvalidOperation{}, stored: map[string][]byte{}},
+		{mode: SetMultiAtomic, atomic: true, expected: ErrInvalidOperation{}, stored: map[string][]byte{}},
+		{mode: SetMultiBestEffort, atomic: true, expected: partial, stored: withoutSnapshot},
+		// without an atomic update endpoint, only an atomic SetMulti fails outright
+		{mode: SetMultiAuto, atomic: false, expected: partial, stored: withoutSnapshot},
+		{mode: SetMultiAtomic, atomic: false, expected: ErrServerUnavailable{code: http.StatusMethodNotAllowed},
+			stored: map[string][]byte{}},
+		{mode: SetMultiBestEffort, atomic: false, expected: partial, stored: withoutSnapshot},
+	} {
+		fake := &fakeMetaServer{atomic: testCase.atomic, stored: make(map[string][]byte)}
+		server := httptest.NewServer(fake)
+		store, err := NewHTTPStoreWithSetMultiMode(server.URL, "metadata", "json", "key", http.DefaultTransport,
+			testCase.mode)
+		require.NoError(t, err)
+
+		err = store.SetMulti(metas)
+		require.Equal(t, testCase.expected, err, "mode %d, atomic %v", testCase.mode, testCase.atomic)
+		require.Equal(t, testCase.stored, fake.stored, "mode %d, atomic %v", testCase.mode, testCase.atomic)
+
+		// a batch the server accepts is set completely
+		if testCase.atomic || testCase.mode != SetMultiAtomic {
+			fake.stored = make(map[string][]byte)
+			require.NoError(t, store.SetMulti(withoutSnapshot))
+			require.Equal(t, withoutSnapshot, fake.stored)
+		}
+		server.Close()
+	}
+
+	require.Equal(t, "failed to set 1 metadata files: snapshot: trust server rejected operation.", partial.Error())
+}
+
+// NewHTTPStore only uploads atomically, so a server without an atomic update
+// endpoint is reported as unavailable and nothing is set
+func TestHTTPStoreSetMultiDefaultsToAtomic(t *testing.T) {
+	fake := &fakeMetaServer{stored: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	store, err := NewHTTPStore(server.URL, "metadata", "json", "key", http.DefaultTransport)
+	require.NoError(t, err)
+
+	err = store.SetMulti(map[string][]byte{data.CanonicalRootRole.String(): []byte("root data")})
+	require.Equal(t, ErrServerUnavailable{code: http.StatusMethodNotAllowed}, err)
+	require.Empty(t, fake.stored)
+}
+
+// A best-effort SetMulti uploads metadata in dependency order, so the snapshot
+// and timestamp are only set after everything they reference
+func TestHTTPStoreSetMultiBestEffortOrder(t *testing.T) {
+	metas := make(map[string][]byte)
+	for _, name := range []string{"timestamp", "targets/a/b", "snapshot", "targets/b", "root", "targets", "targets/a"} {
+		metas[name] = []byte(name + " data")
+	}
+	fake := &fakeMetaServer{stored: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	store, err := NewHTTPStoreWithSetMultiMode(server.URL, "metadata", "json", "key", http.DefaultTransport,
+		SetMultiBestEffort)
+	require.NoError(t, err)
+
+	require.IsType(t, ErrSetMultiPartial{}, store.SetMulti(metas))
+	require.Equal(t, []string{"root", "targets", "targets/a", "targets/b", "targets/a/b", "snapshot", "timestamp"},
+		fake.puts)
+}
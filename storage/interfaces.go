@@ -18,6 +18,15 @@ type MetadataStore interface {
 	Location() string
 }
 
+// IterableStore is implemented by metadata stores whose entries can all be
+// read in a single pass, such as for a backup
+type IterableStore interface {
+	MetadataStore
+	// Each calls fn with the name and contents of every entry, stopping at
+	// the first error fn returns
+	Each(fn func(name string, meta []byte) error) error
+}
+
 // PublicKeyStore must be implemented by a key service
 type PublicKeyStore interface {
 	GetKey(role data.RoleName) ([]byte, error)
@@ -1,9 +1,15 @@
 package storage
 
 import (
+	"bytes"
 	"crypto/sha256"
-	"encoding/json"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/theupdateframework/notary"
 	"github.com/theupdateframework/notary/tuf/data"
@@ -13,29 +19,88 @@ import (
 // NewMemoryStore returns a MetadataStore that operates entirely in memory.
 // Very useful for testing
 func NewMemoryStore(seed map[data.RoleName][]byte) *MemoryStore {
+	return NewMemoryStoreWithPathMapper(seed, IdentityPathMapper{})
+}
+
+// NewMemoryStoreWithPathMapper returns a MemoryStore keeping each name at the
+// key the mapper maps it to
+func NewMemoryStoreWithPathMapper(seed map[data.RoleName][]byte, mapper PathMapper) *MemoryStore {
 	var (
 		consistent = make(map[string][]byte)
 		initial    = make(map[string][]byte)
+		shadows    = make(map[string][]string)
 	)
 	// add all seed meta to consistent
 	for name, d := range seed {
-		checksum := sha256.Sum256(d)
-		path := utils.ConsistentName(name.String(), checksum[:])
-		initial[name.String()] = d
-		consistent[path] = d
+		path := consistentName(name.String(), d)
+		initial[mapper.RoleToPath(name.String())] = d
+		consistent[mapper.ConsistentToPath(path)] = d
+		shadows[name.String()] = []string{path}
 	}
 
 	return &MemoryStore{
 		data:       initial,
 		consistent: consistent,
+		shadows:    shadows,
+		mapper:     mapper,
+	}
+}
+
+// BuildConsistentSet returns the consistent copy of each role's metadata, by
+// the consistent name a MemoryStore would store it under when set, so that
+// metadata built outside a store can be published under its consistent names
+// without one.
+func BuildConsistentSet(metas map[data.RoleName][]byte) map[string][]byte {
+	consistent := make(map[string][]byte, len(metas))
+	for role, meta := range metas {
+		consistent[consistentName(role.String(), meta)] = meta
 	}
+	return consistent
+}
+
+// consistentName returns the name of the metadata's consistent copy, suffixed
+// with its SHA256 checksum
+func consistentName(name string, meta []byte) string {
+	checksum := sha256.Sum256(meta)
+	return utils.ConsistentName(name, checksum[:])
 }
 
 // MemoryStore implements a mock RemoteStore entirely in memory.
-// For testing purposes only.
+// For testing purposes only.  The zero value is an empty store.
 type MemoryStore struct {
+	lock sync.RWMutex
+	// data and consistent are keyed by the mapper's keys of the names
 	data       map[string][]byte
 	consistent map[string][]byte
+	// shadows records, for each name set, the versioned and consistent
+	// names it was also stored under
+	shadows map[string][]string
+	mapper  PathMapper
+}
+
+// rolePath returns the key of a name which is not a consistent name
+func (m *MemoryStore) rolePath(name string) string {
+	if m.mapper == nil {
+		return name
+	}
+	return m.mapper.RoleToPath(name)
+}
+
+// consistentPath returns the key of a consistent name
+func (m *MemoryStore) consistentPath(name string) string {
+	if m.mapper == nil {
+		return name
+	}
+	return m.mapper.ConsistentToPath(name)
+}
+
+// pathName returns the name stored at the key
+func (m *MemoryStore) pathName(path string) string {
+	if m.mapper == nil {
+		return path
+	}
+	name, _ := m.mapper.PathToRole(path)
+	return name
 }
 
 // GetSized returns up to size bytes of data references by name.
@@ -43,8 +108,10 @@ type MemoryStore struct {
 // predefined threshold "notary.MaxDownloadSize", as we will always know the
 // size for everything but a timestamp and sometimes a root,
 // neither of which should be exceptionally large
-func (m MemoryStore) GetSized(name string, size int64) ([]byte, error) {
-	d, ok := m.data[name]
+func (m *MemoryStore) GetSized(name string, size int64) ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	d, ok := m.data[m.rolePath(name)]
 	if ok {
 		if size == NoSizeLimit {
 			size = notary.MaxDownloadSize
@@ -54,7 +121,7 @@ func (m MemoryStore) GetSized(name string, size int64) ([]byte, error) {
 		}
 		return d[:size], nil
 	}
-	d, ok = m.consistent[name]
+	d, ok = m.consistent[m.consistentPath(name)]
 	if ok {
 		if int64(len(d)) < size {
 			return d, nil
@@ -65,11 +132,13 @@ func (m MemoryStore) GetSized(name string, size int64) ([]byte, error) {
 }
 
 // Get returns the data associated with name
-func (m MemoryStore) Get(name string) ([]byte, error) {
-	if d, ok := m.data[name]; ok {
+func (m *MemoryStore) Get(name string) ([]byte, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if d, ok := m.data[m.rolePath(name)]; ok {
 		return d, nil
 	}
-	if d, ok := m.consistent[name]; ok {
+	if d, ok := m.consistent[m.consistentPath(name)]; ok {
 		return d, nil
 	}
 	return nil, ErrMetaNotFound{Resource: name}
@@ -77,61 +146,278 @@ func (m MemoryStore) Get(name string) ([]byte, error) {
 
 // Set sets the metadata value for the given name
 func (m *MemoryStore) Set(name string, meta []byte) error {
-	m.data[name] = meta
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.set(name, meta)
+	return nil
+}
+
+func (m *MemoryStore) set(name string, meta []byte) {
+	m.init()
+	m.data[m.rolePath(name)] = meta
 
 	parsedMeta := &data.SignedMeta{}
-	err := json.Unmarshal(meta, parsedMeta)
+	err := data.UnmarshalMetadata(meta, parsedMeta)
 	if err == nil {
 		// no parse error means this is metadata and not a key, so store by version
 		version := parsedMeta.Signed.Version
 		versionedName := fmt.Sprintf("%d.%s", version, name)
-		m.data[versionedName] = meta
+		m.data[m.rolePath(versionedName)] = meta
+		m.addShadow(name, versionedName)
 	}
 
-	checksum := sha256.Sum256(meta)
-	path := utils.ConsistentName(name, checksum[:])
-	m.consistent[path] = meta
-	return nil
+	path := consistentName(name, meta)
+	m.consistent[m.consistentPath(path)] = meta
+	m.addShadow(name, path)
+}
+
+// init makes the maps of a zero value store
+func (m *MemoryStore) init() {
+	if m.data == nil {
+		m.data = make(map[string][]byte)
+	}
+	if m.consistent == nil {
+		m.consistent = make(map[string][]byte)
+	}
+	if m.shadows == nil {
+		m.shadows = make(map[string][]string)
+	}
+}
+
+// addShadow records that the shadow name is stored alongside the name, unless
+// it already is
+func (m *MemoryStore) addShadow(name, shadow string) {
+	if !utils.StrSliceContains(m.shadows[name], shadow) {
+		m.shadows[name] = append(m.shadows[name], shadow)
+	}
 }
 
 // SetMulti sets multiple pieces of metadata for multiple names
 // in a single operation.
 func (m *MemoryStore) SetMulti(metas map[string][]byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	for role, blob := range metas {
-		m.Set(role, blob)
+		m.set(role, blob)
 	}
 	return nil
 }
 
-// Remove removes the metadata for a single role - if the metadata doesn't
-// exist, no error is returned
-func (m *MemoryStore) Remove(name string) error {
-	if meta, ok := m.data[name]; ok {
-		checksum := sha256.Sum256(meta)
-		path := utils.ConsistentName(name, checksum[:])
-		delete(m.data, name)
-		delete(m.consistent, path)
+// ReplaceGUN replaces all the metadata for a GUN, stored under names of the
+// form "<gun>/<role>", with the given metadata, including the versioned and
+// consistent names of each.  Names of GUNs nested under the GUN, such as
+// "<gun>/sub/root", are left alone.  The replacement is done in a single operation,
+// so readers observe either the previous metadata for the GUN or the new
+// metadata, never a mix of the two.
+func (m *MemoryStore) ReplaceGUN(gun string, metas map[data.RoleName][]byte) error {
+	if gun == "" {
+		return errors.New("cannot replace the metadata of an empty GUN")
+	}
+	prefix := strings.TrimSuffix(gun, "/") + "/"
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for name := range m.shadows {
+		if !strings.HasPrefix(name, prefix) || !data.ValidRole(data.RoleName(name[len(prefix):])) {
+			continue
+		}
+		m.remove(name)
+	}
+	for role, blob := range metas {
+		m.set(prefix+role.String(), blob)
+	}
+	return nil
+}
+
+// ExportToDir writes the metadata in the store, and its consistent copies, in
+// the layout of a FilesystemStore of JSON files rooted at dir/metadata: the
+// metadata stored as "<gun>/<role>" is written to metadata/<gun>/<role>.json,
+// and its consistent copy to metadata/<gun>/<role>.<sha256 hex>.json.
+// Versioned copies are not written.
+func (m *MemoryStore) ExportToDir(dir string) error {
+	fs, err := NewFileStore(filepath.Join(dir, "metadata"), "json")
+	if err != nil {
+		return err
+	}
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	files := make(map[string][]byte, len(m.data))
+	for name, shadows := range m.shadows {
+		if meta, ok := m.data[m.rolePath(name)]; ok {
+			files[name] = meta
+		}
+		for _, shadow := range shadows {
+			if meta, ok := m.consistent[m.consistentPath(shadow)]; ok {
+				files[shadow] = meta
+			}
+		}
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fs.Set(name, files[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Each calls fn with every name set in the store, sorted, and its current
+// contents, stopping at and returning the first error fn returns.  Versioned
+// and consistent copies are not included; see EachConsistent for the latter.
+// The store is read locked while fn is called, so the entries form a
+// consistent view of the store, and fn must not modify it.
+func (m *MemoryStore) Each(fn func(name string, meta []byte) error) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	names := make([]string, 0, len(m.shadows))
+	for name := range m.shadows {
+		if _, ok := m.data[m.rolePath(name)]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fn(name, m.data[m.rolePath(name)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EachConsistent calls fn with every consistent name in the store, sorted,
+// and its contents, including those of names that have since been set again.
+// The store is read locked while fn is called, so fn must not modify it.
+func (m *MemoryStore) EachConsistent(fn func(name string, blob []byte) error) error {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	names := make([]string, 0, len(m.consistent))
+	for path := range m.consistent {
+		names = append(names, m.pathName(path))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fn(name, m.consistent[m.consistentPath(name)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetConsistent sets a blob by its consistent name, as the consistent copy
+// of the name without the checksum, without changing that name's current
+// metadata
+func (m *MemoryStore) SetConsistent(name string, blob []byte) error {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return fmt.Errorf("%s is not a consistent name", name)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.init()
+	if _, ok := m.consistent[m.consistentPath(name)]; !ok {
+		m.shadows[name[:i]] = append(m.shadows[name[:i]], name)
 	}
+	m.consistent[m.consistentPath(name)] = blob
 	return nil
 }
 
+// Scrub repairs the consistent copies of the store's current metadata,
+// returning how many entries it fixed.  Every name set has its consistent
+// name recomputed from its current contents, and a consistent copy which is
+// missing or differs from those contents is restored.  Consistent copies
+// whose contents do not match the checksum they are named by, and which are
+// not restored, are removed, as lookups by their names would only ever return
+// the wrong contents.
+func (m *MemoryStore) Scrub() (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	fixed := 0
+	restored := make(map[string]struct{})
+	for name := range m.shadows {
+		meta, ok := m.data[m.rolePath(name)]
+		if !ok {
+			continue
+		}
+		path := consistentName(name, meta)
+		restored[path] = struct{}{}
+		if blob, ok := m.consistent[m.consistentPath(path)]; ok && bytes.Equal(blob, meta) {
+			continue
+		}
+		m.addShadow(name, path)
+		m.consistent[m.consistentPath(path)] = meta
+		fixed++
+	}
+
+	for key, blob := range m.consistent {
+		name := m.pathName(key)
+		if _, ok := restored[name]; ok {
+			continue
+		}
+		checksum, ok := consistentChecksum(name)
+		if !ok {
+			continue
+		}
+		actual := sha256.Sum256(blob)
+		if hex.EncodeToString(actual[:]) != checksum {
+			delete(m.consistent, key)
+			fixed++
+		}
+	}
+	return fixed, nil
+}
+
+// Remove removes the metadata for a single role, including its versioned and
+// consistent copies - if the metadata doesn't exist, no error is returned
+func (m *MemoryStore) Remove(name string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.remove(name)
+	return nil
+}
+
+// remove deletes the name and every versioned and consistent name it was
+// also stored under
+func (m *MemoryStore) remove(name string) {
+	if meta, ok := m.data[m.rolePath(name)]; ok {
+		delete(m.data, m.rolePath(name))
+		delete(m.consistent, m.consistentPath(consistentName(name, meta)))
+	}
+	for _, shadow := range m.shadows[name] {
+		delete(m.data, m.rolePath(shadow))
+		delete(m.consistent, m.consistentPath(shadow))
+	}
+	delete(m.shadows, name)
+}
+
 // RemoveAll clears the existing memory store by setting this store as new empty one
 func (m *MemoryStore) RemoveAll() error {
-	*m = *NewMemoryStore(nil)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.data = make(map[string][]byte)
+	m.consistent = make(map[string][]byte)
+	m.shadows = make(map[string][]string)
 	return nil
 }
 
 // Location provides a human readable name for the storage location
-func (m MemoryStore) Location() string {
+func (m *MemoryStore) Location() string {
 	return "memory"
 }
 
 // ListFiles returns a list of all files. The names returned should be
 // usable with Get directly, with no modification.
 func (m *MemoryStore) ListFiles() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	names := make([]string, 0, len(m.data))
-	for n := range m.data {
-		names = append(names, n)
+	for path := range m.data {
+		names = append(names, m.pathName(path))
 	}
 	return names
 }
@@ -2,6 +2,12 @@ package storage
 
 import (
 	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -51,6 +57,27 @@ func TestMemoryStoreMetadataOperations(t *testing.T) {
 	require.IsType(t, ErrMetaNotFound{}, err)
 }
 
+// Setting the same metadata repeatedly records each of its versioned and
+// consistent names only once
+func TestMemoryStoreSetRecordsShadowsOnce(t *testing.T) {
+	s := NewMemoryStore(nil)
+	v1 := []byte(`{"signed":{"_type":"Root","version":1},"signatures":[]}`)
+	v2 := []byte(`{"signed":{"_type":"Root","version":2},"signatures":[]}`)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.Set("root", v1))
+		require.NoError(t, s.SetMulti(map[string][]byte{"root": v1}))
+	}
+	require.Len(t, s.shadows["root"], 2)
+
+	require.NoError(t, s.Set("root", v2))
+	require.NoError(t, s.Set("root", v2))
+	require.Len(t, s.shadows["root"], 4)
+	fixed, err := s.Scrub()
+	require.NoError(t, err)
+	require.Equal(t, 0, fixed)
+	require.Len(t, s.shadows["root"], 4)
+}
+
 func TestMemoryStoreGetSized(t *testing.T) {
 	content := []byte("content")
 	s := NewMemoryStore(map[data.RoleName][]byte{"content": content})
@@ -75,3 +102,291 @@ func TestMemoryStoreGetSized(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, content, meta)
 }
+
+func versionedMeta(version int) []byte {
+	return []byte(fmt.Sprintf(`{"signed":{"_type":"Targets","version":%d},"signatures":[]}`, version))
+}
+
+func TestMemoryStoreReplaceGUN(t *testing.T) {
+	oldMeta, newMeta := versionedMeta(1), versionedMeta(2)
+	oldSum, newSum := sha256.Sum256(oldMeta), sha256.Sum256(newMeta)
+	s := NewMemoryStore(nil)
+	require.NoError(t, s.SetMulti(map[string][]byte{
+		"docker.com/notary/root":    oldMeta,
+		"docker.com/notary/targets": oldMeta,
+		"docker.com/other/targets":  oldMeta,
+	}))
+
+	require.NoError(t, s.ReplaceGUN("docker.com/notary", map[data.RoleName][]byte{
+		data.CanonicalRootRole:     newMeta,
+		data.CanonicalSnapshotRole: newMeta,
+	}))
+
+	// every name the previous metadata was stored under is gone, including the
+	// role which is not in the new set
+	for _, name := range []string{
+		"docker.com/notary/targets",
+		"1.docker.com/notary/root",
+		"1.docker.com/notary/targets",
+		utils.ConsistentName("docker.com/notary/root", oldSum[:]),
+		utils.ConsistentName("docker.com/notary/targets", oldSum[:]),
+	} {
+		_, err := s.Get(name)
+		require.IsType(t, ErrMetaNotFound{}, err, name)
+	}
+	for _, role := range []string{"root", "snapshot"} {
+		name := "docker.com/notary/" + role
+		for _, stored := range []string{name, "2." + name, utils.ConsistentName(name, newSum[:])} {
+			meta, err := s.Get(stored)
+			require.NoError(t, err, stored)
+			require.Equal(t, newMeta, meta)
+		}
+	}
+
+	// other GUNs are untouched
+	for _, name := range []string{
+		"docker.com/other/targets",
+		"1.docker.com/other/targets",
+		utils.ConsistentName("docker.com/other/targets", oldSum[:]),
+	} {
+		meta, err := s.Get(name)
+		require.NoError(t, err, name)
+		require.Equal(t, oldMeta, meta)
+	}
+
+	require.Error(t, s.ReplaceGUN("", nil))
+}
+
+// Replacing a GUN leaves the metadata of GUNs nested under it alone
+func TestMemoryStoreReplaceGUNKeepsNestedGUNs(t *testing.T) {
+	meta := versionedMeta(1)
+	s := NewMemoryStore(nil)
+	require.NoError(t, s.SetMulti(map[string][]byte{
+		"docker.com/notary/root":          meta,
+		"docker.com/notary/targets/a":     meta,
+		"docker.com/notary/sub/root":      meta,
+		"docker.com/notary/sub/targets/a": meta,
+	}))
+	require.NoError(t, s.ReplaceGUN("docker.com/notary", nil))
+
+	for _, name := range []string{"docker.com/notary/root", "docker.com/notary/targets/a"} {
+		_, err := s.Get(name)
+		require.IsType(t, ErrMetaNotFound{}, err, name)
+	}
+	for _, name := range []string{"docker.com/notary/sub/root", "docker.com/notary/sub/targets/a"} {
+		_, err := s.Get(name)
+		require.NoError(t, err, name)
+	}
+}
+
+// Removing a name removes its versioned and consistent copies too
+func TestMemoryStoreRemoveShadows(t *testing.T) {
+	v1, v2 := versionedMeta(1), versionedMeta(2)
+	v1Sum, v2Sum := sha256.Sum256(v1), sha256.Sum256(v2)
+	s := NewMemoryStore(nil)
+	require.NoError(t, s.Set("targets", v1))
+	require.NoError(t, s.Set("targets", v2))
+	require.NoError(t, s.Remove("targets"))
+
+	for _, name := range []string{"targets", "1.targets", "2.targets",
+		utils.ConsistentName("targets", v1Sum[:]), utils.ConsistentName("targets", v2Sum[:])} {
+		_, err := s.Get(name)
+		require.IsType(t, ErrMetaNotFound{}, err, name)
+	}
+	require.Empty(t, s.ListFiles())
+}
+
+// The zero value MemoryStore is an empty store, which can be used at once
+func TestMemoryStoreZeroValue(t *testing.T) {
+	var s MemoryStore
+	_, err := s.Get("root")
+	require.IsType(t, ErrMetaNotFound{}, err)
+	require.NoError(t, s.Set("root", versionedMeta(1)))
+	meta, err := s.Get("1.root")
+	require.NoError(t, err)
+	require.Equal(t, versionedMeta(1), meta)
+	require.NoError(t, s.Remove("root"))
+}
+
+// While one GUN's contents are repeatedly swapped between two sets of
+// metadata, a reader only ever sees one complete set or the other
+func TestMemoryStoreReplaceGUNAtomic(t *testing.T) {
+	gun := "docker.com/notary"
+	sets := []map[data.RoleName][]byte{
+		{data.CanonicalRootRole: versionedMeta(1), data.CanonicalTargetsRole: versionedMeta(1)},
+		{data.CanonicalRootRole: versionedMeta(2), data.CanonicalSnapshotRole: versionedMeta(2)},
+	}
+	s := NewMemoryStore(nil)
+	require.NoError(t, s.ReplaceGUN(gun, sets[0]))
+	expected := make([][]string, len(sets))
+	for i, set := range sets {
+		require.NoError(t, s.ReplaceGUN(gun, set))
+		expected[i] = s.ListFiles()
+		sort.Strings(expected[i])
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			require.NoError(t, s.ReplaceGUN(gun, sets[i%2]))
+		}
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			wg.Wait()
+			return
+		default:
+		}
+		files := s.ListFiles()
+		sort.Strings(files)
+		require.Contains(t, expected, files)
+	}
+}
+
+func TestMemoryStoreExportToDir(t *testing.T) {
+	rootMeta, targetsMeta := versionedMeta(1), versionedMeta(3)
+	s := NewMemoryStore(nil)
+	require.NoError(t, s.SetMulti(map[string][]byte{
+		"docker.com/notary/root":    rootMeta,
+		"docker.com/notary/targets": targetsMeta,
+	}))
+
+	dir, err := ioutil.TempDir("", "memorystore-export")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, s.ExportToDir(dir))
+
+	for role, meta := range map[string][]byte{"root": rootMeta, "targets": targetsMeta} {
+		checksum := sha256.Sum256(meta)
+		for _, name := range []string{role, utils.ConsistentName(role, checksum[:])} {
+			exported, err := ioutil.ReadFile(filepath.Join(dir, "metadata", "docker.com", "notary", name+".json"))
+			require.NoError(t, err)
+			require.Equal(t, meta, exported)
+		}
+		// versioned copies are not exported
+		_, err := os.Stat(filepath.Join(dir, "metadata", "docker.com", "notary", "1."+role+".json"))
+		require.True(t, os.IsNotExist(err))
+	}
+
+	// the export can be read back as a filesystem store
+	fs, err := NewFileStore(filepath.Join(dir, "metadata"), "json")
+	require.NoError(t, err)
+	exported, err := fs.Get("docker.com/notary/targets")
+	require.NoError(t, err)
+	require.Equal(t, targetsMeta, exported)
+}
+
+// Each visits the current contents of every name set, but not their versioned
+// or consistent copies, and stops at the first error
+func TestMemoryStoreEach(t *testing.T) {
+	s := NewMemoryStore(map[data.RoleName][]byte{"seeded": []byte("seed")})
+	root := []byte(`{"signed": {"_type": "Root", "version": 2}, "signatures": []}`)
+	require.NoError(t, s.Set("gun/root", root))
+	require.NoError(t, s.SetMulti(map[string][]byte{"gun/targets": []byte("targets"), "other": []byte("old")}))
+	require.NoError(t, s.Set("other", []byte("new")))
+	require.NoError(t, s.Set("removed", []byte("removed")))
+	require.NoError(t, s.Remove("removed"))
+
+	var iterable IterableStore = s
+	entries := make(map[string][]byte)
+	var names []string
+	require.NoError(t, iterable.Each(func(name string, meta []byte) error {
+		names = append(names, name)
+		entries[name] = meta
+		return nil
+	}))
+	require.Equal(t, []string{"gun/root", "gun/targets", "other", "seeded"}, names)
+	require.Equal(t, map[string][]byte{
+		"gun/root":    root,
+		"gun/targets": []byte("targets"),
+		"other":       []byte("new"),
+		"seeded":      []byte("seed"),
+	}, entries)
+
+	stop := fmt.Errorf("stop")
+	visited := 0
+	err := s.Each(func(name string, meta []byte) error {
+		visited++
+		return stop
+	})
+	require.Equal(t, stop, err)
+	require.Equal(t, 1, visited)
+}
+
+func TestBuildConsistentSet(t *testing.T) {
+	metas := map[data.RoleName][]byte{
+		"docker.com/notary/root":      []byte(`{"signed": {"_type": "Root", "version": 1}, "signatures": []}`),
+		"docker.com/notary/targets":   []byte(`{"signed": {"_type": "Targets", "version": 3}, "signatures": []}`),
+		"docker.com/notary/targets/a": []byte("not metadata"),
+	}
+	s := NewMemoryStore(nil)
+	for role, meta := range metas {
+		require.NoError(t, s.Set(role.String(), meta))
+	}
+	stored := make(map[string][]byte)
+	require.NoError(t, s.EachConsistent(func(name string, blob []byte) error {
+		stored[name] = blob
+		return nil
+	}))
+
+	consistent := BuildConsistentSet(metas)
+	require.Equal(t, stored, consistent)
+	for name, meta := range consistent {
+		fromStore, err := s.GetSized(name, int64(len(meta)))
+		require.NoError(t, err)
+		require.Equal(t, fromStore, meta)
+	}
+	require.Empty(t, BuildConsistentSet(nil))
+}
+
+// Scrub restores missing and corrupted consistent copies of current
+// metadata, and removes consistent copies which do not match their names
+func TestMemoryStoreScrub(t *testing.T) {
+	s := NewMemoryStore(map[data.RoleName][]byte{"seeded": []byte("seed")})
+	root := []byte(`{"signed": {"_type": "Root", "version": 2}, "signatures": []}`)
+	require.NoError(t, s.Set("gun/root", root))
+	require.NoError(t, s.Set("gun/targets", []byte("old targets")))
+	require.NoError(t, s.Set("gun/targets", []byte("targets")))
+
+	expected := make(map[string][]byte)
+	require.NoError(t, s.EachConsistent(func(name string, blob []byte) error {
+		expected[name] = blob
+		return nil
+	}))
+	fixed, err := s.Scrub()
+	require.NoError(t, err)
+	require.Equal(t, 0, fixed)
+
+	rootName := consistentName("gun/root", root)
+	targetsName := consistentName("gun/targets", []byte("targets"))
+	oldTargetsName := consistentName("gun/targets", []byte("old targets"))
+	delete(s.consistent, rootName)
+	s.consistent[targetsName] = []byte("corrupted")
+	s.consistent[oldTargetsName] = []byte("corrupted")
+	delete(expected, oldTargetsName)
+
+	fixed, err = s.Scrub()
+	require.NoError(t, err)
+	require.Equal(t, 3, fixed)
+	scrubbed := make(map[string][]byte)
+	require.NoError(t, s.EachConsistent(func(name string, blob []byte) error {
+		scrubbed[name] = blob
+		return nil
+	}))
+	require.Equal(t, expected, scrubbed)
+
+	meta, err := s.Get(rootName)
+	require.NoError(t, err)
+	require.Equal(t, root, meta)
+	_, err = s.Get(oldTargetsName)
+	require.IsType(t, ErrMetaNotFound{}, err)
+
+	fixed, err = s.Scrub()
+	require.NoError(t, err)
+	require.Equal(t, 0, fixed)
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// PathMapper maps the names metadata is stored under, such as "<gun>/<role>",
+// "<version>.<gun>/<role>" or a consistent "<gun>/<role>.<sha256 hex>", to the
+// keys, such as relative paths, a store keeps it at, and back.  For every name
+// n and consistent name c, PathToRole(RoleToPath(n)) and
+// PathToRole(ConsistentToPath(c)) must return n and c respectively.
+type PathMapper interface {
+	// RoleToPath returns the key of the metadata stored under a name which
+	// is not a consistent name
+	RoleToPath(name string) string
+	// ConsistentToPath returns the key of the blob stored under a consistent
+	// name
+	ConsistentToPath(name string) string
+	// PathToRole returns the name stored at the key, and false if the key is
+	// not one this mapper produces
+	PathToRole(path string) (string, bool)
+}
+
+// IdentityPathMapper stores every name at the key equal to the name.  This is
+// the mapping stores use by default.
+type IdentityPathMapper struct{}
+
+// RoleToPath returns the name
+func (IdentityPathMapper) RoleToPath(name string) string {
+	return name
+}
+
+// ConsistentToPath returns the consistent name
+func (IdentityPathMapper) ConsistentToPath(name string) string {
+	return name
+}
+
+// PathToRole returns the key, which is always one this mapper produces
+func (IdentityPathMapper) PathToRole(path string) (string, bool) {
+	return path, true
+}
+
+// MaxShardLevels is the most shard directories a ShardedPathMapper can use,
+// one for each byte of a SHA256
+const MaxShardLevels = sha256.Size
+
+// ErrInvalidShardLevels is returned when a ShardedPathMapper is created with a
+// negative number of levels, or more than MaxShardLevels
+type ErrInvalidShardLevels struct {
+	Levels int
+}
+
+func (err ErrInvalidShardLevels) Error() string {
+	return fmt.Sprintf("invalid number of shard levels %d, must be between 0 and %d", err.Levels, MaxShardLevels)
+}
+
+// ShardedPathMapper stores every name under a number of directories of two
+// hex characters each, such as "ab/cd/<name>", so that no single directory
+// of a filesystem store holds a huge number of files.  Consistent names are
+// sharded by the checksum they end in, and other names by the SHA256 of the
+// name.  The zero value uses no shard directories.
+type ShardedPathMapper struct {
+	levels int
+}
+
+// NewShardedPathMapper returns a ShardedPathMapper storing names under the
+// given number of shard directories, or ErrInvalidShardLevels if that is
+// negative or above MaxShardLevels
+func NewShardedPathMapper(levels int) (ShardedPathMapper, error) {
+	if levels < 0 || levels > MaxShardLevels {
+		return ShardedPathMapper{}, ErrInvalidShardLevels{Levels: levels}
+	}
+	return ShardedPathMapper{levels: levels}, nil
+}
+
+// RoleToPath shards the name by its SHA256
+func (s ShardedPathMapper) RoleToPath(name string) string {
+	checksum := sha256.Sum256([]byte(name))
+	return s.shard(hex.EncodeToString(checksum[:]), name)
+}
+
+// ConsistentToPath shards the consistent name by the checksum it ends in
+func (s ShardedPathMapper) ConsistentToPath(name string) string {
+	checksum, ok := consistentChecksum(name)
+	if !ok {
+		return s.RoleToPath(name)
+	}
+	return s.shard(checksum, name)
+}
+
+// PathToRole strips the shard directories from the key, checking they are
+// those the name is sharded into
+func (s ShardedPathMapper) PathToRole(path string) (string, bool) {
+	parts := strings.SplitN(path, "/", s.levels+1)
+	if len(parts) != s.levels+1 {
+		return "", false
+	}
+	name := parts[s.levels]
+	if path != s.RoleToPath(name) && path != s.ConsistentToPath(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// shard prefixes the name with a directory for each level, named by the
+// next two hex characters of the checksum
+func (s ShardedPathMapper) shard(checksum, name string) string {
+	dirs := make([]string, 0, s.levels+1)
+	for level := 0; level < s.levels; level++ {
+		dirs = append(dirs, checksum[2*level:2*level+2])
+	}
+	return strings.Join(append(dirs, name), "/")
+}
+
+// consistentChecksum returns the hex SHA256 a consistent name ends in, and
+// false if the name does not end in one
+func consistentChecksum(name string) (string, bool) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return "", false
+	}
+	checksum := name[i+1:]
+	if len(checksum) != 2*sha256.Size {
+		return "", false
+	}
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", false
+	}
+	return checksum, true
+}
+
+// mapName returns the key of the name, whether or not it is a consistent name
+func mapName(mapper PathMapper, name string) string {
+	if _, ok := consistentChecksum(name); ok {
+		return mapper.ConsistentToPath(name)
+	}
+	return mapper.RoleToPath(name)
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+var testMeta = []byte(`{"signed": {"_type": "Targets", "version": 2}, "signatures": []}`)
+
+func testConsistentName(name string, meta []byte) string {
+	checksum := sha256.Sum256(meta)
+	return utils.ConsistentName(name, checksum[:])
+}
+
+func testShardedPathMapper(t *testing.T, levels int) ShardedPathMapper {
+	mapper, err := NewShardedPathMapper(levels)
+	require.NoError(t, err)
+	return mapper
+}
+
+// Every mapper maps each name to a key which maps back to the name
+func TestPathMappersRoundTrip(t *testing.T) {
+	names := []string{"root", "docker.com/notary/targets", "3.docker.com/notary/targets/releases", "a.b"}
+	consistent := []string{testConsistentName("docker.com/notary/root", testMeta), testConsistentName("timestamp", nil)}
+	mappers := []PathMapper{IdentityPathMapper{}, ShardedPathMapper{}}
+	for _, levels := range []int{1, 2, MaxShardLevels} {
+		mappers = append(mappers, testShardedPathMapper(t, levels))
+	}
+	for _, mapper := range mappers {
+		for _, name := range names {
+			back, ok := mapper.PathToRole(mapper.RoleToPath(name))
+			require.True(t, ok, "%#v %s", mapper, name)
+			require.Equal(t, name, back, "%#v", mapper)
+		}
+		for _, name := range consistent {
+			back, ok := mapper.PathToRole(mapper.ConsistentToPath(name))
+			require.True(t, ok, "%#v %s", mapper, name)
+			require.Equal(t, name, back, "%#v", mapper)
+		}
+	}
+}
+
+// A sharded mapper shards names under directories named by their checksums,
+// and only maps back the keys it produces
+func TestShardedPathMapper(t *testing.T) {
+	mapper := testShardedPathMapper(t, 2)
+
+	name := "docker.com/notary/targets"
+	nameSum := sha256.Sum256([]byte(name))
+	nameHex := hex.EncodeToString(nameSum[:])
+	require.Equal(t, nameHex[0:2]+"/"+nameHex[2:4]+"/"+name, mapper.RoleToPath(name))
+
+	metaSum := sha256.Sum256(testMeta)
+	metaHex := hex.EncodeToString(metaSum[:])
+	consistent := name + "." + metaHex
+	require.Equal(t, metaHex[0:2]+"/"+metaHex[2:4]+"/"+consistent, mapper.ConsistentToPath(consistent))
+
+	for _, invalid := range []string{name, "zz/00/" + name, "ab/" + name, nameHex[0:2] + "/" + name} {
+		_, ok := mapper.PathToRole(invalid)
+		require.False(t, ok, invalid)
+	}
+}
+
+// A filesystem store with a sharded mapper keeps files in shard directories,
+// and resolves and lists them by name
+// A sharded mapper can't be created with more levels than a SHA256 has bytes,
+// or fewer than none
+func TestNewShardedPathMapperInvalidLevels(t *testing.T) {
+	for _, levels := range []int{-1, MaxShardLevels + 1} {
+		_, err := NewShardedPathMapper(levels)
+		require.Equal(t, ErrInvalidShardLevels{Levels: levels}, err)
+	}
+}
+
+func TestFilesystemStoreShardedLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notary-pathmapper")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	mapper := testShardedPathMapper(t, 2)
+	s, err := NewFileStoreWithPathMapper(dir, "json", mapper)
+	require.NoError(t, err)
+
+	name := "docker.com/notary/targets"
+	consistent := testConsistentName(name, testMeta)
+	require.NoError(t, s.Set(name, testMeta))
+	require.NoError(t, s.Set(consistent, testMeta))
+
+	for _, stored := range []string{name, consistent} {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(mapName(mapper, stored))+".json"))
+		require.NoError(t, err, stored)
+		meta, err := s.GetSized(stored, NoSizeLimit)
+		require.NoError(t, err)
+		require.Equal(t, testMeta, meta)
+	}
+	// nothing is kept at the unsharded path
+	_, err = os.Stat(filepath.Join(dir, filepath.FromSlash(name)+".json"))
+	require.True(t, os.IsNotExist(err))
+
+	// a file outside the sharded layout is not listed
+	unsharded, err := NewFileStore(dir, "json")
+	require.NoError(t, err)
+	require.NoError(t, unsharded.Set("stray", testMeta))
+
+	listed := s.ListFiles()
+	sort.Strings(listed)
+	expected := []string{filepath.FromSlash(name), filepath.FromSlash(consistent)}
+	sort.Strings(expected)
+	require.Equal(t, expected, listed)
+
+	require.NoError(t, s.Remove(name))
+	_, err = s.Get(name)
+	require.IsType(t, ErrMetaNotFound{}, err)
+}
+
+// A memory store behaves the same whatever its mapper, keeping its entries
+// at the mapped keys
+func TestMemoryStoreShardedMapper(t *testing.T) {
+	mapper := testShardedPathMapper(t, 1)
+	seed := map[data.RoleName][]byte{"docker.com/notary/root": testMeta}
+	for _, s := range []*MemoryStore{NewMemoryStore(seed), NewMemoryStoreWithPathMapper(seed, mapper)} {
+		require.NoError(t, s.Set("docker.com/notary/targets", testMeta))
+		for _, name := range []string{"docker.com/notary/root", "docker.com/notary/targets",
+			"2.docker.com/notary/targets", testConsistentName("docker.com/notary/targets", testMeta)} {
+			meta, err := s.Get(name)
+			require.NoError(t, err, name)
+			require.Equal(t, testMeta, meta)
+		}
+
+		var names []string
+		require.NoError(t, s.Each(func(name string, meta []byte) error {
+			names = append(names, name)
+			return nil
+		}))
+		require.Equal(t, []string{"docker.com/notary/root", "docker.com/notary/targets"}, names)
+
+		var consistent []string
+		require.NoError(t, s.EachConsistent(func(name string, blob []byte) error {
+			consistent = append(consistent, name)
+			return nil
+		}))
+		require.Equal(t, []string{testConsistentName("docker.com/notary/root", testMeta),
+			testConsistentName("docker.com/notary/targets", testMeta)}, consistent)
+
+		listed := s.ListFiles()
+		sort.Strings(listed)
+		require.Equal(t, []string{"2.docker.com/notary/targets", "docker.com/notary/root", "docker.com/notary/targets"}, listed)
+	}
+
+	s := NewMemoryStoreWithPathMapper(nil, mapper)
+	require.NoError(t, s.Set("root", testMeta))
+	require.Equal(t, 2, len(s.data))
+	for _, name := range []string{"root", "2.root"} {
+		_, ok := s.data[mapper.RoleToPath(name)]
+		require.True(t, ok, name)
+	}
+	require.Equal(t, 1, len(s.consistent))
+	_, ok := s.consistent[mapper.ConsistentToPath(testConsistentName("root", testMeta))]
+	require.True(t, ok)
+}
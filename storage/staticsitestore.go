@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+const (
+	staticSiteMetaDir   = "metadata"
+	staticSiteExtension = "json"
+	staticSiteIndex     = "index"
+)
+
+// StaticSiteIndex lists, for every role stored in a StaticSiteStore, the names
+// of the current, versioned and consistent files a static web server serves
+// for it.  It is written to <gun>/metadata/index.json.
+type StaticSiteIndex struct {
+	Roles map[string]StaticSiteIndexEntry `json:"roles"`
+}
+
+// StaticSiteIndexEntry describes the files stored for a single role
+type StaticSiteIndexEntry struct {
+	Current    string   `json:"current"`
+	Consistent []string `json:"consistent"`
+	Versioned  []string `json:"versioned,omitempty"`
+}
+
+// StaticSiteStore is a MetadataStore that lays out metadata for a single GUN
+// in a directory tree that a plain static web server or CDN can serve directly
+// to an HTTPStore:
+//   <baseDir>/<gun>/metadata/<role>.json
+//   <baseDir>/<gun>/metadata/<version>.<role>.json
+//   <baseDir>/<gun>/metadata/<role>.<sha256 hex>.json
+// An index of all files is maintained at <baseDir>/<gun>/metadata/index.json.
+type StaticSiteStore struct {
+	files *FilesystemStore
+	index StaticSiteIndex
+}
+
+// NewStaticSiteStore creates a StaticSiteStore for the given GUN rooted at
+// baseDir.  An existing index under baseDir is loaded, so that a store can be
+// reopened and extended.
+func NewStaticSiteStore(baseDir string, gun data.GUN) (*StaticSiteStore, error) {
+	files, err := NewFileStore(
+		filepath.Join(baseDir, filepath.FromSlash(gun.String()), staticSiteMetaDir),
+		staticSiteExtension,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s := &StaticSiteStore{
+		files: files,
+		index: StaticSiteIndex{Roles: make(map[string]StaticSiteIndexEntry)},
+	}
+
+	indexJSON, err := files.Get(staticSiteIndex)
+	switch err.(type) {
+	case nil:
+		if err := json.Unmarshal(indexJSON, &s.index); err != nil {
+			return nil, fmt.Errorf("unable to parse static site index: %v", err)
+		}
+		if s.index.Roles == nil {
+			s.index.Roles = make(map[string]StaticSiteIndexEntry)
+		}
+	case ErrMetaNotFound:
+	default:
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetSized returns up to size bytes of the named file, which may be a role
+// name, a versioned name or a consistent name
+func (s *StaticSiteStore) GetSized(name string, size int64) ([]byte, error) {
+	return s.files.GetSized(name, size)
+}
+
+// Set writes the metadata for a role, along with its versioned (if the blob
+// is TUF metadata) and consistent copies, and updates the index
+func (s *StaticSiteStore) Set(name string, blob []byte) error {
+	if name == staticSiteIndex {
+		return fmt.Errorf("%s is reserved for the static site index", name)
+	}
+	if err := s.set(name, blob); err != nil {
+		return err
+	}
+	return s.writeIndex()
+}
+
+// SetMulti writes the metadata for multiple roles, updating the index once
+func (s *StaticSiteStore) SetMulti(metas map[string][]byte) error {
+	for name, blob := range metas {
+		if name == staticSiteIndex {
+			return fmt.Errorf("%s is reserved for the static site index", name)
+		}
+		if err := s.set(name, blob); err != nil {
+			return err
+		}
+	}
+	return s.writeIndex()
+}
+
+func (s *StaticSiteStore) set(name string, blob []byte) error {
+	entry := s.index.Roles[name]
+
+	checksum := sha256.Sum256(blob)
+	consistentName := utils.ConsistentName(name, checksum[:])
+	toWrite := map[string][]byte{name: blob, consistentName: blob}
+	entry.Current = consistentName
+	entry.Consistent = appendUnique(entry.Consistent, consistentName)
+
+	parsedMeta := &data.SignedMeta{}
+	if err := data.UnmarshalMetadata(blob, parsedMeta); err == nil {
+		// no parse error means this is metadata and not a key, so store by version
+		versionedName := fmt.Sprintf("%d.%s", parsedMeta.Signed.Version, name)
+		toWrite[versionedName] = blob
+		entry.Versioned = appendUnique(entry.Versioned, versionedName)
+	}
+
+	for fileName, content := range toWrite {
+		if err := s.files.Set(fileName, content); err != nil {
+			return err
+		}
+	}
+	s.index.Roles[name] = entry
+	return nil
+}
+
+// Remove removes the current, versioned and consistent files for a role - if
+// the role doesn't exist, no error is returned
+func (s *StaticSiteStore) Remove(name string) error {
+	entry, ok := s.index.Roles[name]
+	if !ok {
+		return s.files.Remove(name)
+	}
+	for _, fileName := range append(append([]string{name}, entry.Consistent...), entry.Versioned...) {
+		if err := s.files.Remove(fileName); err != nil {
+			return err
+		}
+	}
+	delete(s.index.Roles, name)
+	return s.writeIndex()
+}
+
+// RemoveAll removes the entire metadata directory for the GUN
+func (s *StaticSiteStore) RemoveAll() error {
+	s.index.Roles = make(map[string]StaticSiteIndexEntry)
+	return s.files.RemoveAll()
+}
+
+// Location returns a human readable name for the storage location
+func (s *StaticSiteStore) Location() string {
+	return s.files.Location()
+}
+
+// Index returns the index of all the files stored for each role
+func (s *StaticSiteStore) Index() StaticSiteIndex {
+	return s.index
+}
+
+func (s *StaticSiteStore) writeIndex() error {
+	indexJSON, err := json.Marshal(s.index)
+	if err != nil {
+		return err
+	}
+	return s.files.Set(staticSiteIndex, indexJSON)
+}
+
+// appendUnique appends name to names, keeping names sorted and free of duplicates
+func appendUnique(names []string, name string) []string {
+	i := sort.SearchStrings(names, name)
+	if i < len(names) && names[i] == name {
+		return names
+	}
+	names = append(names, "")
+	copy(names[i+1:], names[i:])
+	names[i] = name
+	return names
+}
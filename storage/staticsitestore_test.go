@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/tuf/utils"
+)
+
+const testDelegation = `{"signed":{"_type":"Targets","delegations":{"keys":{},"roles":[]},"expires":"2025-07-17T16:19:21.101698314-07:00","targets":{},"version":3},"signatures":[]}`
+
+func TestStaticSiteStoreMetadata(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "staticsite")
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	factory := func() MetadataStore {
+		s, err := NewStaticSiteStore(testDir, "docker.com/notary")
+		require.NoError(t, err)
+		return s
+	}
+
+	testGetSetMeta(t, factory)
+	testRemove(t, factory)
+}
+
+// The static site store writes everything an HTTPStore requests to the paths it
+// requests them from, so that a plain file server can serve them
+func TestStaticSiteStoreServedByFileServer(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "staticsite")
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	s, err := NewStaticSiteStore(testDir, "docker.com/notary")
+	require.NoError(t, err)
+	require.NoError(t, s.SetMulti(map[string][]byte{
+		"root":      []byte(testRoot),
+		"targets/a": []byte(testDelegation),
+	}))
+
+	ts := httptest.NewServer(http.FileServer(http.Dir(testDir)))
+	defer ts.Close()
+	remote, err := NewHTTPStore(ts.URL+"/docker.com/notary/metadata/", "", "json", "key", http.DefaultTransport)
+	require.NoError(t, err)
+
+	for name, content := range map[string]string{"root": testRoot, "targets/a": testDelegation} {
+		checksum := sha256.Sum256([]byte(content))
+		var versioned string
+		switch name {
+		case "root":
+			versioned = "2.root"
+		default:
+			versioned = "3.targets/a"
+		}
+		for _, fileName := range []string{name, versioned, utils.ConsistentName(name, checksum[:])} {
+			fetched, err := remote.GetSized(fileName, NoSizeLimit)
+			require.NoError(t, err, "unable to fetch %s", fileName)
+			require.Equal(t, content, string(fetched))
+		}
+	}
+
+	// the index is served too, and lists every file
+	indexJSON, err := remote.GetSized("index", NoSizeLimit)
+	require.NoError(t, err)
+	var index StaticSiteIndex
+	require.NoError(t, json.Unmarshal(indexJSON, &index))
+	require.Equal(t, s.Index(), index)
+	require.Len(t, index.Roles, 2)
+	rootChecksum := sha256.Sum256([]byte(testRoot))
+	require.Equal(t, StaticSiteIndexEntry{
+		Current:    utils.ConsistentName("root", rootChecksum[:]),
+		Consistent: []string{utils.ConsistentName("root", rootChecksum[:])},
+		Versioned:  []string{"2.root"},
+	}, index.Roles["root"])
+
+	_, err = remote.GetSized("snapshot", NoSizeLimit)
+	require.IsType(t, ErrMetaNotFound{}, err)
+}
+
+// Reopening a static site store loads its index, and removing a role removes all
+// of its files
+func TestStaticSiteStoreReopenAndRemove(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "staticsite")
+	require.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	s, err := NewStaticSiteStore(testDir, "docker.com/notary")
+	require.NoError(t, err)
+	require.NoError(t, s.Set("root", []byte(testRoot)))
+	require.Error(t, s.Set("index", []byte("{}")))
+
+	reopened, err := NewStaticSiteStore(testDir, "docker.com/notary")
+	require.NoError(t, err)
+	require.Equal(t, s.Index(), reopened.Index())
+
+	require.NoError(t, reopened.Remove("root"))
+	require.Empty(t, reopened.Index().Roles)
+	files, err := filepath.Glob(filepath.Join(testDir, "docker.com", "notary", "metadata", "*root*"))
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// ConnectionPoolConfig limits the connections a transport opens and keeps idle
+// for each remote host, so that when talking to multiple notary servers or
+// mirrors, one slow host can't monopolize connections.  Zero values leave the
+// base transport's corresponding setting unchanged.
+type ConnectionPoolConfig struct {
+	// MaxConnsPerHost limits the total number of connections, including those
+	// in use, to each host.  Requests beyond the limit wait for a connection.
+	MaxConnsPerHost int
+	// MaxIdleConnsPerHost limits the number of idle connections kept open for
+	// reuse to each host
+	MaxIdleConnsPerHost int
+}
+
+// NewPooledTransport returns a copy of the base transport with the per-host
+// connection limits applied.  If base is nil, a copy of http.DefaultTransport
+// is used.  The returned transport can be passed to NewHTTPStore and
+// NewNotaryServerStore.
+func NewPooledTransport(base *http.Transport, pool ConnectionPoolConfig) *http.Transport {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	// http.Transport.Clone needs Go 1.13, so the fields are copied explicitly
+	pooled := &http.Transport{
+		Proxy:                  base.Proxy,
+		DialContext:            base.DialContext,
+		Dial:                   base.Dial,
+		DialTLS:                base.DialTLS,
+		TLSHandshakeTimeout:    base.TLSHandshakeTimeout,
+		DisableKeepAlives:      base.DisableKeepAlives,
+		DisableCompression:     base.DisableCompression,
+		MaxIdleConns:           base.MaxIdleConns,
+		MaxIdleConnsPerHost:    base.MaxIdleConnsPerHost,
+		MaxConnsPerHost:        base.MaxConnsPerHost,
+		IdleConnTimeout:        base.IdleConnTimeout,
+		ResponseHeaderTimeout:  base.ResponseHeaderTimeout,
+		ExpectContinueTimeout:  base.ExpectContinueTimeout,
+		ProxyConnectHeader:     base.ProxyConnectHeader,
+		MaxResponseHeaderBytes: base.MaxResponseHeaderBytes,
+	}
+	if base.TLSClientConfig != nil {
+		pooled.TLSClientConfig = base.TLSClientConfig.Clone()
+	}
+	if base.TLSNextProto != nil {
+		pooled.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper, len(base.TLSNextProto))
+		for proto, upgrade := range base.TLSNextProto {
+			pooled.TLSNextProto[proto] = upgrade
+		}
+	}
+	if pool.MaxConnsPerHost > 0 {
+		pooled.MaxConnsPerHost = pool.MaxConnsPerHost
+	}
+	if pool.MaxIdleConnsPerHost > 0 {
+		pooled.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	}
+	return pooled
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyServer serves testRoot, recording the maximum number of requests
+// it has handled concurrently.  Requests block until release is closed.
+type concurrencyServer struct {
+	*httptest.Server
+	current, max int32
+	release      chan struct{}
+}
+
+func newConcurrencyServer(t *testing.T) *concurrencyServer {
+	s := &concurrencyServer{release: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&s.current, 1)
+		defer atomic.AddInt32(&s.current, -1)
+		for {
+			max := atomic.LoadInt32(&s.max)
+			if current <= max || atomic.CompareAndSwapInt32(&s.max, max, current) {
+				break
+			}
+		}
+		<-s.release
+		w.Write([]byte(testRoot))
+	}))
+	return s
+}
+
+func TestNewPooledTransport(t *testing.T) {
+	pooled := NewPooledTransport(nil, ConnectionPoolConfig{MaxConnsPerHost: 3, MaxIdleConnsPerHost: 2})
+	require.Equal(t, 3, pooled.MaxConnsPerHost)
+	require.Equal(t, 2, pooled.MaxIdleConnsPerHost)
+	// the shared default transport is not modified
+	require.Equal(t, 0, http.DefaultTransport.(*http.Transport).MaxConnsPerHost)
+
+	// unset limits keep the base transport's values
+	base := &http.Transport{MaxConnsPerHost: 5, DisableKeepAlives: true}
+	pooled = NewPooledTransport(base, ConnectionPoolConfig{MaxIdleConnsPerHost: 1})
+	require.Equal(t, 5, pooled.MaxConnsPerHost)
+	require.Equal(t, 1, pooled.MaxIdleConnsPerHost)
+	require.True(t, pooled.DisableKeepAlives)
+
+	// the rest of the base transport's configuration is copied, and the TLS
+	// configuration is not shared with it
+	base = &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:     &tls.Config{ServerName: "notary-server"},
+	}
+	pooled = NewPooledTransport(base, ConnectionPoolConfig{})
+	require.NotNil(t, pooled.Proxy)
+	require.Equal(t, 10*time.Second, pooled.TLSHandshakeTimeout)
+	require.Equal(t, "notary-server", pooled.TLSClientConfig.ServerName)
+	pooled.TLSClientConfig.ServerName = "other"
+	require.Equal(t, "notary-server", base.TLSClientConfig.ServerName)
+}
+
+// With a transport shared between two hosts, concurrent fetches from a host
+// that never responds are limited to its own connections, and do not prevent
+// fetches from the other host from completing.
+func TestPooledTransportLimitsConnectionsPerHost(t *testing.T) {
+	const maxConns = 2
+	slow, fast := newConcurrencyServer(t), newConcurrencyServer(t)
+	defer slow.Close()
+	defer fast.Close()
+	close(fast.release)
+
+	pooled := NewPooledTransport(nil, ConnectionPoolConfig{MaxConnsPerHost: maxConns, MaxIdleConnsPerHost: maxConns})
+	slowStore, err := NewHTTPStore(slow.URL+"/metadata/", "", "json", "key", pooled)
+	require.NoError(t, err)
+	fastStore, err := NewHTTPStore(fast.URL+"/metadata/", "", "json", "key", pooled)
+	require.NoError(t, err)
+
+	var slowFetches sync.WaitGroup
+	for i := 0; i < 5*maxConns; i++ {
+		slowFetches.Add(1)
+		go func() {
+			defer slowFetches.Done()
+			_, err := slowStore.GetSized("root", NoSizeLimit)
+			require.NoError(t, err)
+		}()
+	}
+	// wait for the slow host's connections to be saturated
+	for deadline := time.Now().Add(5 * time.Second); atomic.LoadInt32(&slow.current) < maxConns; {
+		require.True(t, time.Now().Before(deadline), "slow host never received %d connections", maxConns)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var fastFetches sync.WaitGroup
+	for i := 0; i < 5*maxConns; i++ {
+		fastFetches.Add(1)
+		go func() {
+			defer fastFetches.Done()
+			_, err := fastStore.GetSized("root", NoSizeLimit)
+			require.NoError(t, err)
+		}()
+	}
+	fastFetches.Wait()
+
+	close(slow.release)
+	slowFetches.Wait()
+	require.Equal(t, int32(maxConns), atomic.LoadInt32(&slow.max))
+	require.True(t, atomic.LoadInt32(&fast.max) <= maxConns)
+}
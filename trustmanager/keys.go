@@ -217,6 +217,10 @@ func checkValidity(block *pem.Block) (string, error) {
 	}
 
 	loc, ok := block.Headers["path"]
+	// a path which is a key ID is stored under the ID's canonical lowercase form
+	if keyID, err := tufdata.CanonicalKeyID(loc); err == nil {
+		loc = keyID
+	}
 	// only if the path isn't specified do we get into this parsing path logic
 	if !ok || loc == "" {
 		// if the path isn't specified, we will try to infer the path rel to trust dir from the role (and then gun)
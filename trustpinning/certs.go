@@ -4,6 +4,7 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -26,6 +27,16 @@ func (err ErrValidationFail) Error() string {
 	return fmt.Sprintf("could not validate the path to a trusted root: %s", err.Reason)
 }
 
+// ErrTOFURejected is returned when the TOFUApprover does not approve trusting
+// the first root seen for a GUN
+type ErrTOFURejected struct {
+	GUN data.GUN
+}
+
+func (err ErrTOFURejected) Error() string {
+	return fmt.Sprintf("trust on first use of the root for %s was not approved", err.GUN)
+}
+
 // ErrRootRotationFail is returned when we fail to do a full root key rotation
 // by either failing to add the new root certificate, or delete the old ones
 type ErrRootRotationFail struct {
@@ -172,6 +183,21 @@ func ValidateRoot(prevRoot *data.SignedRoot, root *data.Signed, gun data.GUN, tr
 		return nil, &ErrValidationFail{Reason: "failed to validate integrity of roots"}
 	}
 
+	if !havePrevRoot && trustPinning.TOFUApprover != nil && !trustPinning.pinsGUN(gun) {
+		fingerprints := make([]string, 0, len(certsFromRoot))
+		for id := range certsFromRoot {
+			fingerprints = append(fingerprints, id)
+		}
+		sort.Strings(fingerprints)
+		approved, err := trustPinning.TOFUApprover(gun.String(), fingerprints)
+		if err != nil {
+			return nil, &ErrValidationFail{Reason: fmt.Sprintf("unable to approve trust on first use: %v", err)}
+		}
+		if !approved {
+			return nil, &ErrTOFURejected{GUN: gun}
+		}
+	}
+
 	logrus.Debugf("root validation succeeded for %s", gun)
 	// Call RootFromSigned to make sure we pick up on the IsValid markings from VerifySignatures
 	return data.RootFromSigned(root)
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -233,6 +234,47 @@ func TestValidateRootWithoutTOFUS(t *testing.T) {
 	require.Error(t, err)
 }
 
+// The TOFU approver is asked to approve the first root seen for a GUN whose
+// trust is not pinned, and the root is rejected if it does not approve
+func TestValidateRootWithTOFUApprover(t *testing.T) {
+	var asked [][]string
+	approve := func(approved bool, err error) trustpinning.TOFUApprover {
+		return func(gun string, rootCertFingerprints []string) (bool, error) {
+			require.Equal(t, "docker.com/notary", gun)
+			asked = append(asked, rootCertFingerprints)
+			return approved, err
+		}
+	}
+
+	_, err := trustpinning.ValidateRoot(nil, sampleRootData(t).rootMeta, "docker.com/notary",
+		trustpinning.TrustPinConfig{TOFUApprover: approve(true, nil)})
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{sampleRootData(t).rootPubKeyID}}, asked)
+
+	_, err = trustpinning.ValidateRoot(nil, sampleRootData(t).rootMeta, "docker.com/notary",
+		trustpinning.TrustPinConfig{TOFUApprover: approve(false, nil)})
+	require.Equal(t, &trustpinning.ErrTOFURejected{GUN: "docker.com/notary"}, err)
+
+	_, err = trustpinning.ValidateRoot(nil, sampleRootData(t).rootMeta, "docker.com/notary",
+		trustpinning.TrustPinConfig{TOFUApprover: approve(false, errors.New("no terminal"))})
+	require.IsType(t, &trustpinning.ErrValidationFail{}, err)
+	require.Len(t, asked, 3)
+
+	// the approver is not asked if trust is pinned, or if there is a previous root
+	_, err = trustpinning.ValidateRoot(nil, sampleRootData(t).rootMeta, "docker.com/notary",
+		trustpinning.TrustPinConfig{
+			Certs:        map[string][]string{"docker.com/notary": {sampleRootData(t).rootPubKeyID}},
+			TOFUApprover: approve(false, nil),
+		})
+	require.NoError(t, err)
+	prevRoot, err := data.RootFromSigned(sampleRootData(t).rootMeta)
+	require.NoError(t, err)
+	_, err = trustpinning.ValidateRoot(prevRoot, sampleRootData(t).rootMeta, "docker.com/notary",
+		trustpinning.TrustPinConfig{TOFUApprover: approve(false, nil)})
+	require.NoError(t, err)
+	require.Len(t, asked, 3)
+}
+
 func TestValidateRootWithPinnedCert(t *testing.T) {
 	typedSignedRoot, err := data.RootFromSigned(sampleRootData(t).rootMeta)
 	require.NoError(t, err)
@@ -31,8 +31,18 @@ type TrustPinConfig struct {
 	// DisableTOFU, when true, disables "Trust On First Use" of new key data
 	// This is false by default, which means new key data will always be trusted the first time it is seen.
 	DisableTOFU bool
+	// TOFUApprover, if set, is asked to approve a root the first time it is
+	// seen for a GUN whose trust is not pinned by certs or a CA.  If it does
+	// not approve, the root is rejected with ErrTOFURejected.  If nil, the
+	// first root seen is trusted without asking.
+	TOFUApprover TOFUApprover
 }
 
+// TOFUApprover decides whether to trust the first root seen for a GUN.  It is
+// passed the sorted IDs of the root's valid leaf certificates, which are the
+// certificate IDs that can be pinned in TrustPinConfig.Certs.
+type TOFUApprover func(gun string, rootCertFingerprints []string) (bool, error)
+
 type trustPinChecker struct {
 	gun           data.GUN
 	config        TrustPinConfig
@@ -92,6 +102,19 @@ func NewTrustPinChecker(trustPinConfig TrustPinConfig, gun data.GUN, firstBootst
 	return t.tofusCheck, nil
 }
 
+// pinsGUN returns whether the trust of the GUN is pinned by certs or a CA,
+// rather than trusted on first use
+func (t TrustPinConfig) pinsGUN(gun data.GUN) bool {
+	if _, ok := t.Certs[gun.String()]; ok {
+		return true
+	}
+	if _, ok := wildcardMatch(gun, t.Certs); ok {
+		return true
+	}
+	_, err := getPinnedCAFilepathByPrefix(gun, t)
+	return err == nil
+}
+
 func (t trustPinChecker) certsCheck(leafCert *x509.Certificate, intCerts []*x509.Certificate) bool {
 	// reconstruct the leaf + intermediate cert chain, which is bundled as {leaf, intermediates...},
 	// in order to get the matching id in the root file
@@ -2,6 +2,7 @@ package tuf
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/docker/go/canonical/json"
 	"github.com/theupdateframework/notary"
@@ -101,6 +102,42 @@ func NewRepoBuilder(gun data.GUN, cs signed.CryptoService, trustpin trustpinning
 	return NewBuilderFromRepo(gun, NewRepo(cs), trustpin)
 }
 
+// NewRepoBuilderWithClock returns a RepoBuilder which checks metadata expiry
+// against the time returned by now instead of the system clock.  Builders
+// bootstrapped from it use the same clock.
+func NewRepoBuilderWithClock(gun data.GUN, cs signed.CryptoService, trustpin trustpinning.TrustPinConfig,
+	now func() time.Time) RepoBuilder {
+	rbw := NewBuilderFromRepo(gun, NewRepo(cs), trustpin).(*repoBuilderWrapper)
+	rbw.RepoBuilder.(*repoBuilder).now = now
+	return rbw
+}
+
+// WithMinThresholds makes a pre-built RepoBuilder require, for each role in
+// minimums, at least that many valid signatures, even if the role's threshold
+// in the metadata is lower.  Minimums never lower a role's threshold.  Builders
+// bootstrapped from it require the same minimums.
+func WithMinThresholds(rb RepoBuilder, minimums map[data.RoleName]int) RepoBuilder {
+	if rbw, ok := rb.(*repoBuilderWrapper); ok {
+		if builder, ok := rbw.RepoBuilder.(*repoBuilder); ok {
+			builder.minThresholds = minimums
+		}
+	}
+	return rb
+}
+
+// WithMinThreshold makes a pre-built RepoBuilder require at least minimum valid
+// signatures for every role not given its own minimum by WithMinThresholds,
+// even if the role's threshold in the metadata is lower.  Builders
+// bootstrapped from it require the same minimum.
+func WithMinThreshold(rb RepoBuilder, minimum int) RepoBuilder {
+	if rbw, ok := rb.(*repoBuilderWrapper); ok {
+		if builder, ok := rbw.RepoBuilder.(*repoBuilder); ok {
+			builder.minThreshold = minimum
+		}
+	}
+	return rb
+}
+
 // NewBuilderFromRepo allows us to bootstrap a builder given existing repo data.
 // YOU PROBABLY SHOULDN'T BE USING THIS OUTSIDE OF TESTING CODE!!!
 func NewBuilderFromRepo(gun data.GUN, repo *Repo, trustpin trustpinning.TrustPinConfig) RepoBuilder {
@@ -115,6 +152,20 @@ func NewBuilderFromRepo(gun data.GUN, repo *Repo, trustpin trustpinning.TrustPin
 	}
 }
 
+// WithLenientRoleTypes makes a pre-built RepoBuilder skip checking, before
+// anything else, that the _type declared by metadata matches the role it is
+// loaded as.  Mismatched metadata is then only rejected once it is parsed as
+// the role, after its checksum and signatures have been verified.  Builders
+// bootstrapped from it are lenient too.
+func WithLenientRoleTypes(rb RepoBuilder) RepoBuilder {
+	if rbw, ok := rb.(*repoBuilderWrapper); ok {
+		if builder, ok := rbw.RepoBuilder.(*repoBuilder); ok {
+			builder.lenientRoleTypes = true
+		}
+	}
+	return rb
+}
+
 // repoBuilderWrapper embeds a repoBuilder, but once Finish is called, swaps
 // the embed out with a finishedBuilder
 type repoBuilderWrapper struct {
@@ -152,6 +203,24 @@ type repoBuilder struct {
 
 	// for bootstrapping the next builder
 	nextRootChecksum *data.FileMeta
+
+	// the clock to check expiry against, if not the system clock
+	now func() time.Time
+
+	// the client enforced minimum thresholds, by role, and for any other role
+	minThresholds map[data.RoleName]int
+	minThreshold  int
+
+	// whether metadata's _type is not checked against its role up front
+	lenientRoleTypes bool
+}
+
+// currentTime returns the time to check metadata expiry against
+func (rb *repoBuilder) currentTime() time.Time {
+	if rb.now != nil {
+		return rb.now()
+	}
+	return time.Now()
 }
 
 func (rb *repoBuilder) Finish() (*Repo, *Repo, error) {
@@ -165,6 +234,10 @@ func (rb *repoBuilder) BootstrapNewBuilder() RepoBuilder {
 		gun:                  rb.gun,
 		loadedNotChecksummed: make(map[data.RoleName][]byte),
 		trustpin:             rb.trustpin,
+		now:                  rb.now,
+		minThresholds:        rb.minThresholds,
+		minThreshold:         rb.minThreshold,
+		lenientRoleTypes:     rb.lenientRoleTypes,
 
 		prevRoot:                 rb.repo.Root,
 		bootstrappedRootChecksum: rb.nextRootChecksum,
@@ -177,6 +250,10 @@ func (rb *repoBuilder) BootstrapNewBuilderWithNewTrustpin(trustpin trustpinning.
 		gun:                  rb.gun,
 		loadedNotChecksummed: make(map[data.RoleName][]byte),
 		trustpin:             trustpin,
+		now:                  rb.now,
+		minThresholds:        rb.minThresholds,
+		minThreshold:         rb.minThreshold,
+		lenientRoleTypes:     rb.lenientRoleTypes,
 
 		prevRoot:                 rb.repo.Root,
 		bootstrappedRootChecksum: rb.nextRootChecksum,
@@ -443,13 +520,16 @@ func (rb *repoBuilder) loadRoot(content []byte, minVersion int, allowExpired, sk
 	if err != nil {
 		return err
 	}
+	if err := rb.verifyMinThreshold(signedObj, signedRoot); err != nil {
+		return err
+	}
 
 	if err := signed.VerifyVersion(&(signedRoot.Signed.SignedCommon), minVersion); err != nil {
 		return err
 	}
 
 	if !allowExpired { // check must go at the end because all other validation should pass
-		if err := signed.VerifyExpiry(&(signedRoot.Signed.SignedCommon), roleName); err != nil {
+		if err := signed.VerifyExpiryAt(&(signedRoot.Signed.SignedCommon), roleName, rb.currentTime()); err != nil {
 			return err
 		}
 	}
@@ -486,7 +566,7 @@ func (rb *repoBuilder) loadTimestamp(content []byte, minVersion int, allowExpire
 	}
 
 	if !allowExpired { // check must go at the end because all other validation should pass
-		if err := signed.VerifyExpiry(&(signedTimestamp.Signed.SignedCommon), roleName); err != nil {
+		if err := signed.VerifyExpiryAt(&(signedTimestamp.Signed.SignedCommon), roleName, rb.currentTime()); err != nil {
 			return err
 		}
 	}
@@ -522,7 +602,7 @@ func (rb *repoBuilder) loadSnapshot(content []byte, minVersion int, allowExpired
 	}
 
 	if !allowExpired { // check must go at the end because all other validation should pass
-		if err := signed.VerifyExpiry(&(signedSnapshot.Signed.SignedCommon), roleName); err != nil {
+		if err := signed.VerifyExpiryAt(&(signedSnapshot.Signed.SignedCommon), roleName, rb.currentTime()); err != nil {
 			return err
 		}
 	}
@@ -565,7 +645,7 @@ func (rb *repoBuilder) loadTargets(content []byte, minVersion int, allowExpired
 	}
 
 	if !allowExpired { // check must go at the end because all other validation should pass
-		if err := signed.VerifyExpiry(&(signedTargets.Signed.SignedCommon), roleName); err != nil {
+		if err := signed.VerifyExpiryAt(&(signedTargets.Signed.SignedCommon), roleName, rb.currentTime()); err != nil {
 			return err
 		}
 	}
@@ -598,13 +678,13 @@ func (rb *repoBuilder) loadDelegation(roleName data.RoleName, content []byte, mi
 	}
 
 	// verify signature
-	if err := signed.VerifySignatures(signedObj, delegationRole.BaseRole); err != nil {
+	if err := rb.verifySignatures(signedObj, delegationRole.BaseRole); err != nil {
 		rb.invalidRoles.Targets[roleName] = signedTargets
 		return err
 	}
 
 	if !allowExpired { // check must go at the end because all other validation should pass
-		if err := signed.VerifyExpiry(&(signedTargets.Signed.SignedCommon), roleName); err != nil {
+		if err := signed.VerifyExpiryAt(&(signedTargets.Signed.SignedCommon), roleName, rb.currentTime()); err != nil {
 			rb.invalidRoles.Targets[roleName] = signedTargets
 			return err
 		}
@@ -675,7 +755,16 @@ func (rb *repoBuilder) validateChecksumFor(content []byte, roleName data.RoleNam
 // Checksums the given bytes, and if they validate, convert to a data.Signed object.
 // If a checksums are nil (as opposed to empty), adds the bytes to the list of roles that
 // haven't been checksummed (unless it's a timestamp, which has no checksum reference).
+// Unless the builder is lenient, the declared type of the metadata is checked
+// against the role first, so that metadata for one role served as another is
+// reported as such.
 func (rb *repoBuilder) bytesToSigned(content []byte, roleName data.RoleName, skipChecksum bool) (*data.Signed, error) {
+	if !rb.lenientRoleTypes {
+		if err := checkRoleType(content, roleName); err != nil {
+			return nil, err
+		}
+	}
+
 	if !skipChecksum {
 		if err := rb.validateChecksumFor(content, roleName); err != nil {
 			return nil, err
@@ -684,13 +773,31 @@ func (rb *repoBuilder) bytesToSigned(content []byte, roleName data.RoleName, ski
 
 	// unmarshal to signed
 	signedObj := &data.Signed{}
-	if err := json.Unmarshal(content, signedObj); err != nil {
+	if err := data.UnmarshalMetadata(content, signedObj); err != nil {
 		return nil, err
 	}
 
 	return signedObj, nil
 }
 
+// checkRoleType returns ErrRoleTypeMismatch if the metadata's declared type is
+// not the type of the role.  Metadata which cannot be parsed is left to fail
+// when it is unmarshalled.
+func checkRoleType(content []byte, roleName data.RoleName) error {
+	var declared struct {
+		Signed struct {
+			Type string `json:"_type"`
+		} `json:"signed"`
+	}
+	if err := json.Unmarshal(content, &declared); err != nil {
+		return nil
+	}
+	if !data.ValidTUFType(declared.Signed.Type, roleName) {
+		return data.ErrRoleTypeMismatch{Role: roleName, Type: declared.Signed.Type}
+	}
+	return nil
+}
+
 func (rb *repoBuilder) bytesToSignedAndValidateSigs(role data.BaseRole, content []byte) (*data.Signed, error) {
 
 	signedObj, err := rb.bytesToSigned(content, role.Name, false)
@@ -699,13 +806,55 @@ func (rb *repoBuilder) bytesToSignedAndValidateSigs(role data.BaseRole, content
 	}
 
 	// verify signature
-	if err := signed.VerifySignatures(signedObj, role); err != nil {
+	if err := rb.verifySignatures(signedObj, role); err != nil {
 		return nil, err
 	}
 
 	return signedObj, nil
 }
 
+// verifySignatures verifies the signatures against the role, raising the
+// role's threshold to the client enforced minimum if that is higher
+func (rb *repoBuilder) verifySignatures(signedObj *data.Signed, role data.BaseRole) error {
+	minimum := rb.minThresholdFor(role.Name)
+	if minimum <= role.Threshold {
+		return signed.VerifySignatures(signedObj, role)
+	}
+	metadataThreshold := role.Threshold
+	role.Threshold = minimum
+	if err := signed.VerifySignatures(signedObj, role); err != nil {
+		if _, ok := err.(signed.ErrRoleThreshold); ok {
+			return signed.ErrRoleThreshold{Msg: fmt.Sprintf(
+				"valid signatures did not meet the client minimum threshold of %d for %s (metadata threshold is %d)",
+				minimum, role.Name, metadataThreshold)}
+		}
+		return err
+	}
+	return nil
+}
+
+// minThresholdFor returns the client enforced minimum threshold for the role
+func (rb *repoBuilder) minThresholdFor(role data.RoleName) int {
+	if minimum, ok := rb.minThresholds[role]; ok {
+		return minimum
+	}
+	return rb.minThreshold
+}
+
+// verifyMinThreshold checks a validated root's signatures against the client
+// enforced minimum threshold for the root role, since ValidateRoot only checks
+// the root's own threshold
+func (rb *repoBuilder) verifyMinThreshold(signedObj *data.Signed, signedRoot *data.SignedRoot) error {
+	rootRole, err := signedRoot.BuildBaseRole(data.CanonicalRootRole)
+	if err != nil {
+		return err
+	}
+	if rb.minThresholdFor(data.CanonicalRootRole) <= rootRole.Threshold {
+		return nil
+	}
+	return rb.verifySignatures(signedObj, rootRole)
+}
+
 // If the checksum reference (the loaded timestamp for the snapshot role, and
 // the loaded snapshot for every other role except timestamp and snapshot) is nil,
 // then return nil for the checksums, meaning that the checksum is not yet
@@ -46,6 +46,19 @@ func TestBuilderLoadsValidRolesOnly(t *testing.T) {
 	require.Contains(t, err.Error(), "is an invalid role")
 }
 
+// Metadata with data appended after its JSON is rejected, even if it would
+// otherwise be valid
+func TestBuilderRejectsTrailingData(t *testing.T) {
+	meta, gun := getSampleMeta(t)
+	builder := tuf.NewRepoBuilder(gun, nil, trustpinning.TrustPinConfig{})
+	root := append(append([]byte{}, meta[data.CanonicalRootRole]...), "garbage"...)
+	err := builder.Load(data.CanonicalRootRole, root, 1, false)
+	require.IsType(t, data.ErrTrailingData{}, err)
+	require.False(t, builder.IsLoaded(data.CanonicalRootRole))
+
+	require.NoError(t, builder.Load(data.CanonicalRootRole, meta[data.CanonicalRootRole], 1, false))
+}
+
 func TestBuilderOnlyAcceptsRootFirstWhenLoading(t *testing.T) {
 	meta, gun := getSampleMeta(t)
 	builder := tuf.NewRepoBuilder(gun, nil, trustpinning.TrustPinConfig{})
@@ -813,3 +826,80 @@ func TestSnapshotLoadedAfterChecksumsOthersRetroactively(t *testing.T) {
 	require.Error(t, err)
 	require.IsType(t, data.ErrMissingMeta{}, err)
 }
+
+// A client minimum threshold rejects a role which meets its threshold of 1 in
+// the metadata, but accepts it once it is signed by enough of its keys
+func TestBuilderMinThresholds(t *testing.T) {
+	var gun data.GUN = "docker.com/notary"
+	repo, cs, err := testutils.EmptyRepo(gun)
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(repo)
+	require.NoError(t, err)
+
+	load := func(meta map[data.RoleName][]byte, minimums map[data.RoleName]int) error {
+		builder := tuf.WithMinThresholds(tuf.NewRepoBuilder(gun, nil, trustpinning.TrustPinConfig{}), minimums)
+		for _, role := range data.BaseRoles {
+			if err := builder.Load(role, meta[role], 1, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	require.NoError(t, load(meta, nil))
+	require.NoError(t, load(meta, map[data.RoleName]int{data.CanonicalTargetsRole: 1}))
+	for _, role := range data.BaseRoles {
+		err := load(meta, map[data.RoleName]int{role: 2})
+		require.IsType(t, signed.ErrRoleThreshold{}, err, role.String())
+		require.Contains(t, err.Error(), "client minimum threshold of 2")
+	}
+
+	// a second targets key, still with a threshold of 1, meets the minimum
+	key, err := testutils.CreateKey(cs, gun, data.CanonicalTargetsRole, data.ECDSAKey)
+	require.NoError(t, err)
+	require.NoError(t, repo.AddBaseKeys(data.CanonicalTargetsRole, key))
+	meta, err = testutils.SignAndSerialize(repo)
+	require.NoError(t, err)
+	require.NoError(t, load(meta, map[data.RoleName]int{data.CanonicalTargetsRole: 2}))
+	require.Error(t, load(meta, map[data.RoleName]int{data.CanonicalTargetsRole: 3}))
+
+	// a minimum for every role applies to the roles without their own minimum
+	builder := tuf.WithMinThreshold(tuf.WithMinThresholds(tuf.NewRepoBuilder(gun, nil, trustpinning.TrustPinConfig{}),
+		map[data.RoleName]int{data.CanonicalRootRole: 1}), 2)
+	require.NoError(t, builder.Load(data.CanonicalRootRole, meta[data.CanonicalRootRole], 1, false))
+	err = builder.Load(data.CanonicalTimestampRole, meta[data.CanonicalTimestampRole], 1, false)
+	require.IsType(t, signed.ErrRoleThreshold{}, err)
+}
+
+// Metadata of one role loaded as another is rejected before anything else is
+// verified, unless the builder is lenient about role types
+func TestBuilderRoleTypeMismatch(t *testing.T) {
+	meta, gun := getSampleMeta(t)
+
+	for _, lenient := range []bool{false, true} {
+		builder := tuf.NewRepoBuilder(gun, nil, trustpinning.TrustPinConfig{})
+		if lenient {
+			builder = tuf.WithLenientRoleTypes(builder)
+		}
+		require.NoError(t, builder.Load(data.CanonicalRootRole, meta[data.CanonicalRootRole], 1, false))
+		require.NoError(t, builder.Load(data.CanonicalTimestampRole, meta[data.CanonicalTimestampRole], 1, false))
+
+		err := builder.Load(data.CanonicalSnapshotRole, meta[data.CanonicalTimestampRole], 1, false)
+		if lenient {
+			require.IsType(t, data.ErrMismatchedChecksum{}, err)
+		} else {
+			require.Equal(t, data.ErrRoleTypeMismatch{Role: data.CanonicalSnapshotRole, Type: "Timestamp"}, err)
+		}
+		require.False(t, builder.IsLoaded(data.CanonicalSnapshotRole))
+
+		// delegations must declare the targets type
+		require.NoError(t, builder.Load(data.CanonicalSnapshotRole, meta[data.CanonicalSnapshotRole], 1, false))
+		require.NoError(t, builder.Load(data.CanonicalTargetsRole, meta[data.CanonicalTargetsRole], 1, false))
+		err = builder.Load("targets/a", meta[data.CanonicalSnapshotRole], 1, false)
+		if !lenient {
+			require.Equal(t, data.ErrRoleTypeMismatch{Role: "targets/a", Type: "Snapshot"}, err)
+		}
+		require.Error(t, err)
+		require.NoError(t, builder.Load("targets/a", meta["targets/a"], 1, false))
+	}
+}
@@ -0,0 +1,87 @@
+// Package cryptobackend routes the signature operations on TUF metadata
+// through a single Backend, so that the crypto implementation they use can be
+// replaced in one place.  The only backend is the Go standard library.
+package cryptobackend
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+// Backend performs the signature operations on TUF metadata.  Digests are
+// of the signed message with the given hash; the ed25519 operations take the
+// message itself.
+type Backend interface {
+	// Name is a short name identifying the backend, such as "standard"
+	Name() string
+
+	// VerifyECDSA reports whether r, s is a valid signature of the digest
+	VerifyECDSA(pub *ecdsa.PublicKey, digest []byte, r, s *big.Int) bool
+	// VerifyPKCS1v15 verifies an RSA PKCS#1 v1.5 signature of the digest
+	VerifyPKCS1v15(pub *rsa.PublicKey, hash crypto.Hash, digest, sig []byte) error
+	// VerifyPSS verifies an RSA PSS signature of the digest
+	VerifyPSS(pub *rsa.PublicKey, hash crypto.Hash, digest, sig []byte, opts *rsa.PSSOptions) error
+	// VerifyEd25519 reports whether sig is a valid signature of msg
+	VerifyEd25519(pub, msg, sig []byte) bool
+
+	// SignECDSA returns an ASN.1 encoded ECDSA signature of the digest
+	SignECDSA(rand io.Reader, priv *ecdsa.PrivateKey, digest []byte) ([]byte, error)
+	// SignRSA returns an RSA signature of the digest, PSS if opts are
+	// *rsa.PSSOptions and PKCS#1 v1.5 otherwise
+	SignRSA(rand io.Reader, priv *rsa.PrivateKey, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+	// SignEd25519 returns an ed25519 signature of msg
+	SignEd25519(priv, msg []byte) []byte
+}
+
+// Default is the backend through which all signing and verification of TUF
+// metadata is done
+var Default Backend = Standard{}
+
+// Standard performs the signature operations with the Go standard library,
+// and golang.org/x/crypto for ed25519
+type Standard struct{}
+
+// Name returns "standard"
+func (Standard) Name() string {
+	return "standard"
+}
+
+// VerifyECDSA verifies with crypto/ecdsa
+func (Standard) VerifyECDSA(pub *ecdsa.PublicKey, digest []byte, r, s *big.Int) bool {
+	return ecdsa.Verify(pub, digest, r, s)
+}
+
+// VerifyPKCS1v15 verifies with crypto/rsa
+func (Standard) VerifyPKCS1v15(pub *rsa.PublicKey, hash crypto.Hash, digest, sig []byte) error {
+	return rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+}
+
+// VerifyPSS verifies with crypto/rsa
+func (Standard) VerifyPSS(pub *rsa.PublicKey, hash crypto.Hash, digest, sig []byte, opts *rsa.PSSOptions) error {
+	return rsa.VerifyPSS(pub, hash, digest, sig, opts)
+}
+
+// VerifyEd25519 verifies with golang.org/x/crypto/ed25519
+func (Standard) VerifyEd25519(pub, msg, sig []byte) bool {
+	return ed25519.Verify(ed25519.PublicKey(pub), msg, sig)
+}
+
+// SignECDSA signs with crypto/ecdsa
+func (Standard) SignECDSA(rand io.Reader, priv *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	return priv.Sign(rand, digest, nil)
+}
+
+// SignRSA signs with crypto/rsa
+func (Standard) SignRSA(rand io.Reader, priv *rsa.PrivateKey, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return priv.Sign(rand, digest, opts)
+}
+
+// SignEd25519 signs with golang.org/x/crypto/ed25519
+func (Standard) SignEd25519(priv, msg []byte) []byte {
+	return ed25519.Sign(ed25519.PrivateKey(priv), msg)
+}
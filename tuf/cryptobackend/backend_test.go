@@ -0,0 +1,83 @@
+package cryptobackend
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+var msg = []byte("signed metadata")
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// The ed25519 operations reproduce the first test vector of RFC 8032
+func TestEd25519KnownAnswer(t *testing.T) {
+	seed := mustDecodeHex(t, "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	pub := mustDecodeHex(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	expected := mustDecodeHex(t, "e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555"+
+		"fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b")
+	priv := append(append([]byte{}, seed...), pub...)
+
+	sig := Default.SignEd25519(priv, []byte{})
+	require.Equal(t, expected, sig)
+	require.True(t, Default.VerifyEd25519(pub, []byte{}, sig))
+	require.False(t, Default.VerifyEd25519(pub, msg, sig))
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	require.True(t, Default.VerifyEd25519(pub, msg, Default.SignEd25519(priv, msg)))
+}
+
+// ECDSA signatures verify against the signed digest only
+func TestECDSASignAndVerify(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	digest := sha256.Sum256(msg)
+	sig, err := Default.SignECDSA(rand.Reader, priv, digest[:])
+	require.NoError(t, err)
+
+	parsed := struct{ R, S *big.Int }{}
+	_, err = asn1.Unmarshal(sig, &parsed)
+	require.NoError(t, err)
+	require.True(t, Default.VerifyECDSA(&priv.PublicKey, digest[:], parsed.R, parsed.S))
+	tampered := sha256.Sum256([]byte("tampered"))
+	require.False(t, Default.VerifyECDSA(&priv.PublicKey, tampered[:], parsed.R, parsed.S))
+}
+
+// RSA signs PSS or PKCS#1 v1.5 according to the options, and each verifies
+// only as its own scheme
+func TestRSASignAndVerify(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	digest := sha256.Sum256(msg)
+	pssOpts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+
+	pssSig, err := Default.SignRSA(rand.Reader, priv, digest[:], pssOpts)
+	require.NoError(t, err)
+	require.NoError(t, Default.VerifyPSS(&priv.PublicKey, crypto.SHA256, digest[:], pssSig, pssOpts))
+	require.Error(t, Default.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, digest[:], pssSig))
+
+	pkcs1Sig, err := Default.SignRSA(rand.Reader, priv, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	require.NoError(t, Default.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, digest[:], pkcs1Sig))
+	require.Error(t, Default.VerifyPSS(&priv.PublicKey, crypto.SHA256, digest[:], pkcs1Sig, pssOpts))
+
+	// PKCS#1 v1.5 signatures are deterministic
+	again, err := Default.SignRSA(rand.Reader, priv, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	require.Equal(t, pkcs1Sig, again)
+}
@@ -1,6 +1,9 @@
 package data
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ErrInvalidMetadata is the error to be returned when metadata is invalid
 type ErrInvalidMetadata struct {
@@ -12,6 +15,27 @@ func (e ErrInvalidMetadata) Error() string {
 	return fmt.Sprintf("%s type metadata invalid: %s", e.role.String(), e.msg)
 }
 
+// ErrTrailingData is the error to be returned when metadata JSON is followed
+// by trailing data
+type ErrTrailingData struct {
+	Length int
+}
+
+func (e ErrTrailingData) Error() string {
+	return fmt.Sprintf("metadata is followed by %d bytes of trailing data", e.Length)
+}
+
+// ErrRoleTypeMismatch is the error to be returned when metadata fetched as
+// one role declares, in its _type field, a type which is not that role's
+type ErrRoleTypeMismatch struct {
+	Role RoleName
+	Type string
+}
+
+func (e ErrRoleTypeMismatch) Error() string {
+	return fmt.Sprintf("metadata fetched as %s declares type %q", e.Role.String(), e.Type)
+}
+
 // ErrMissingMeta - couldn't find the FileMeta object for the given Role, or
 // the FileMeta object contained no supported checksums
 type ErrMissingMeta struct {
@@ -51,3 +75,32 @@ type ErrCertExpired struct {
 func (e ErrCertExpired) Error() string {
 	return fmt.Sprintf("certificate with CN %s is expired", e.CN)
 }
+
+// ErrInvalidKeyID is the error to be returned when a key ID is not a SHA256
+// hex digest
+type ErrInvalidKeyID struct {
+	KeyID  string
+	Reason string
+}
+
+func (e ErrInvalidKeyID) Error() string {
+	return fmt.Sprintf("invalid key ID %q: %s", e.KeyID, e.Reason)
+}
+
+// ErrDelegationCycle is the error to be returned when resolving delegations
+// reaches a role which delegates, directly or through other delegations, back
+// to itself.  Roles is the cycle, starting and ending with the repeated role.
+// Delegations are only followed to children named under their parent, so this
+// guards against a walk being given delegations which are not, and is not
+// expected from metadata loaded by a client.
+type ErrDelegationCycle struct {
+	Roles []RoleName
+}
+
+func (e ErrDelegationCycle) Error() string {
+	names := make([]string, 0, len(e.Roles))
+	for _, role := range e.Roles {
+		names = append(names, role.String())
+	}
+	return fmt.Sprintf("delegation cycle: %s", strings.Join(names, " -> "))
+}
@@ -0,0 +1,40 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/theupdateframework/notary"
+)
+
+// CanonicalKeyID returns the key ID in the form key IDs are computed and
+// stored in, as a lowercase SHA256 hex digest, regardless of the case it was
+// supplied in.  Anything which is not a SHA256 hex digest is rejected, rather
+// than being looked up as a key ID which can never exist.
+func CanonicalKeyID(raw string) (string, error) {
+	if len(raw) != notary.SHA256HexSize {
+		return "", ErrInvalidKeyID{KeyID: raw,
+			Reason: fmt.Sprintf("expected %d hex characters, got %d characters", notary.SHA256HexSize, len(raw))}
+	}
+	keyID := strings.ToLower(raw)
+	for _, c := range keyID {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", ErrInvalidKeyID{KeyID: raw, Reason: fmt.Sprintf("%q is not a hex character", c)}
+		}
+	}
+	return keyID, nil
+}
+
+// CanonicalKeyIDs returns the canonical form of each key ID, as
+// CanonicalKeyID does, failing on the first key ID which is malformed
+func CanonicalKeyIDs(raw []string) ([]string, error) {
+	keyIDs := make([]string, 0, len(raw))
+	for _, r := range raw {
+		keyID, err := CanonicalKeyID(r)
+		if err != nil {
+			return nil, err
+		}
+		keyIDs = append(keyIDs, keyID)
+	}
+	return keyIDs, nil
+}
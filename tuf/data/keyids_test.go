@@ -0,0 +1,44 @@
+package data
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+)
+
+// Key IDs in any case are canonicalized to lowercase, and anything which is
+// not a SHA256 hex digest is rejected
+func TestCanonicalKeyID(t *testing.T) {
+	lower := strings.Repeat("0123456789abcdef", 4)
+	for _, raw := range []string{lower, strings.ToUpper(lower), "0123456789ABCDEF" + lower[16:]} {
+		keyID, err := CanonicalKeyID(raw)
+		require.NoError(t, err)
+		require.Equal(t, lower, keyID)
+	}
+
+	for _, raw := range []string{"", "abc", lower + "0", " " + lower[1:], strings.Repeat("g", 64), lower[1:] + "-"} {
+		_, err := CanonicalKeyID(raw)
+		require.IsType(t, ErrInvalidKeyID{}, err, raw)
+	}
+
+	keyIDs, err := CanonicalKeyIDs([]string{strings.ToUpper(lower), lower})
+	require.NoError(t, err)
+	require.Equal(t, []string{lower, lower}, keyIDs)
+	_, err = CanonicalKeyIDs([]string{lower, "abc"})
+	require.IsType(t, ErrInvalidKeyID{}, err)
+}
+
+// Signatures attached with uppercase key IDs are read with the canonical key
+// ID, and signatures with malformed key IDs are read as they are
+func TestSignatureKeyIDCanonicalized(t *testing.T) {
+	lower := strings.Repeat("0123456789abcdef", 4)
+	var sig Signature
+	require.NoError(t, json.Unmarshal([]byte(`{"keyid":"`+strings.ToUpper(lower)+`","method":"ECDSA","sig":""}`), &sig))
+	require.Equal(t, lower, sig.KeyID)
+	require.Equal(t, ECDSASignature, sig.Method)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"keyid":"NotAKeyID","method":"ecdsa","sig":""}`), &sig))
+	require.Equal(t, "NotAKeyID", sig.KeyID)
+}
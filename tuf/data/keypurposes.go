@@ -0,0 +1,90 @@
+package data
+
+import (
+	"sort"
+
+	"github.com/docker/go/canonical/json"
+)
+
+// KeyPurposesCustomKey is the reserved key in a role's custom metadata under
+// which the purpose tags of keys are stored.  Other custom metadata should not
+// use this key.
+const KeyPurposesCustomKey = "notary.key_purposes"
+
+// KeyPurposes returns the purpose tags of keys, such as "primary", "backup" or
+// "ceremony", by key ID, stored in the reserved namespace of the metadata's
+// custom field.  Metadata without purpose tags returns an empty map.  The tags
+// are informational, and play no part in verification.
+func (c SignedCommon) KeyPurposes() (map[string][]string, error) {
+	custom, err := c.customToMap()
+	if err != nil {
+		return nil, err
+	}
+	purposes := make(map[string][]string)
+	raw, ok := custom[KeyPurposesCustomKey]
+	if !ok || raw == nil {
+		return purposes, nil
+	}
+	if err := json.Unmarshal(*raw, &purposes); err != nil {
+		return nil, ErrInvalidMetadata{role: RoleName(c.Type), msg: "invalid key purposes: " + err.Error()}
+	}
+	return purposes, nil
+}
+
+// SetKeyPurposes replaces the purpose tags of the key, which are stored sorted,
+// preserving the tags of other keys and any other custom metadata.  No
+// purposes removes the key's tags, and the reserved namespace is removed once
+// no key has tags.
+func (c *SignedCommon) SetKeyPurposes(keyID string, purposes []string) error {
+	all, err := c.KeyPurposes()
+	if err != nil {
+		return err
+	}
+	if len(purposes) == 0 {
+		delete(all, keyID)
+	} else {
+		sorted := append([]string(nil), purposes...)
+		sort.Strings(sorted)
+		all[keyID] = sorted
+	}
+
+	custom, err := c.customToMap()
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		delete(custom, KeyPurposesCustomKey)
+	} else {
+		purposesJSON, err := json.MarshalCanonical(all)
+		if err != nil {
+			return err
+		}
+		rawPurposes := json.RawMessage(purposesJSON)
+		custom[KeyPurposesCustomKey] = &rawPurposes
+	}
+
+	if len(custom) == 0 {
+		c.Custom = nil
+		return nil
+	}
+	customJSON, err := json.MarshalCanonical(custom)
+	if err != nil {
+		return err
+	}
+	rawCustom := json.RawMessage(customJSON)
+	c.Custom = &rawCustom
+	return nil
+}
+
+// customToMap parses the custom metadata as a JSON object.  Absent custom
+// metadata is treated as an empty object.
+func (c SignedCommon) customToMap() (map[string]*json.RawMessage, error) {
+	parsed := make(map[string]*json.RawMessage)
+	if c.Custom == nil {
+		return parsed, nil
+	}
+	if err := json.Unmarshal(*c.Custom, &parsed); err != nil {
+		return nil, ErrInvalidMetadata{role: RoleName(c.Type), msg: "custom metadata is not a JSON object: " + err.Error()}
+	}
+	return parsed, nil
+}
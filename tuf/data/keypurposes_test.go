@@ -0,0 +1,46 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/docker/go/canonical/json"
+	"github.com/stretchr/testify/require"
+)
+
+// Setting key purposes preserves the other custom metadata and the tags of
+// other keys, and removing all of them leaves only the other custom metadata
+func TestKeyPurposes(t *testing.T) {
+	other := json.RawMessage(`{"owner":"ops"}`)
+	common := SignedCommon{Type: TUFTypes[CanonicalRootRole], Custom: &other}
+
+	purposes, err := common.KeyPurposes()
+	require.NoError(t, err)
+	require.Empty(t, purposes)
+
+	require.NoError(t, common.SetKeyPurposes("key1", []string{"primary", "ceremony"}))
+	require.NoError(t, common.SetKeyPurposes("key2", []string{"backup"}))
+	purposes, err = common.KeyPurposes()
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"key1": {"ceremony", "primary"}, "key2": {"backup"}}, purposes)
+
+	require.NoError(t, common.SetKeyPurposes("key1", nil))
+	require.NoError(t, common.SetKeyPurposes("key2", nil))
+	require.Equal(t, `{"owner":"ops"}`, string(*common.Custom))
+
+	common.Custom = nil
+	require.NoError(t, common.SetKeyPurposes("key1", []string{"primary"}))
+	require.NoError(t, common.SetKeyPurposes("key1", nil))
+	require.Nil(t, common.Custom)
+}
+
+// Custom metadata which is not an object, or key purposes which are not lists
+// of tags, are invalid
+func TestKeyPurposesInvalid(t *testing.T) {
+	for _, custom := range []string{`["primary"]`, `{"notary.key_purposes":{"key1":"primary"}}`} {
+		raw := json.RawMessage(custom)
+		common := SignedCommon{Type: TUFTypes[CanonicalRootRole], Custom: &raw}
+		_, err := common.KeyPurposes()
+		require.IsType(t, ErrInvalidMetadata{}, err, custom)
+		require.IsType(t, ErrInvalidMetadata{}, common.SetKeyPurposes("key1", []string{"primary"}), custom)
+	}
+}
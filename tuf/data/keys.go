@@ -14,6 +14,7 @@ import (
 
 	"github.com/docker/go/canonical/json"
 	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf/cryptobackend"
 	"golang.org/x/crypto/ed25519"
 )
 
@@ -448,7 +449,7 @@ func (k ECDSAPrivateKey) Sign(rand io.Reader, msg []byte, opts crypto.SignerOpts
 		return nil, errors.New("signer was based on the wrong key type")
 	}
 	hashed := sha256.Sum256(msg)
-	sigASN1, err := ecdsaPrivKey.Sign(rand, hashed[:], opts)
+	sigASN1, err := cryptobackend.Default.SignECDSA(rand, ecdsaPrivKey, hashed[:])
 	if err != nil {
 		return nil, err
 	}
@@ -479,7 +480,11 @@ func (k RSAPrivateKey) Sign(rand io.Reader, msg []byte, opts crypto.SignerOpts)
 			Hash:       crypto.SHA256,
 		}
 	}
-	return k.CryptoSigner().Sign(rand, hashed[:], opts)
+	rsaPrivKey, ok := k.CryptoSigner().(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signer was based on the wrong key type")
+	}
+	return cryptobackend.Default.SignRSA(rand, rsaPrivKey, hashed[:], opts)
 }
 
 // Sign creates an ed25519 signature
@@ -487,7 +492,7 @@ func (k ED25519PrivateKey) Sign(rand io.Reader, msg []byte, opts crypto.SignerOp
 	priv := make([]byte, ed25519.PrivateKeySize)
 	// The ed25519 key is serialized as public key then private key, so just use private key here.
 	copy(priv, k.private[ed25519.PublicKeySize:])
-	return ed25519.Sign(ed25519.PrivateKey(priv), msg)[:], nil
+	return cryptobackend.Default.SignEd25519(priv, msg), nil
 }
 
 // Sign on an UnknownPrivateKey raises an error because the client does not
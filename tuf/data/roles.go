@@ -203,6 +203,21 @@ func (d DelegationRole) IsParentOf(child DelegationRole) bool {
 	return path.Dir(child.Name.String()) == d.Name.String()
 }
 
+// ExtendDelegationChain returns the chain of delegations leading to the child,
+// given the chain leading to, and ending with, its parent.  The chain is not
+// modified.  ErrDelegationCycle is returned if the child is already in the
+// chain, which delegations named as children of their parents, as verified
+// metadata requires, can never be.
+func ExtendDelegationChain(chain []RoleName, child RoleName) ([]RoleName, error) {
+	for i, role := range chain {
+		if role == child {
+			cycle := append(append([]RoleName(nil), chain[i:]...), child)
+			return nil, ErrDelegationCycle{Roles: cycle}
+		}
+	}
+	return append(append(make([]RoleName, 0, len(chain)+1), chain...), child), nil
+}
+
 // CheckPaths checks if a given path is valid for the role
 func (d DelegationRole) CheckPaths(path string) bool {
 	return checkPaths(path, d.Paths)
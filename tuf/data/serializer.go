@@ -1,6 +1,12 @@
 package data
 
-import "github.com/docker/go/canonical/json"
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/docker/go/canonical/json"
+)
 
 // Serializer is an interface that can marshal and unmarshal TUF data.  This
 // is expected to be a canonical JSON marshaller
@@ -23,9 +29,10 @@ func (c canonicalJSON) Marshal(from interface{}) ([]byte, error) {
 	return json.Marshal(from)
 }
 
-// Unmarshal unmarshals some JSON bytes
+// Unmarshal unmarshals some JSON bytes, rejecting any data after the JSON
+// value
 func (c canonicalJSON) Unmarshal(from []byte, to interface{}) error {
-	return json.Unmarshal(from, to)
+	return UnmarshalMetadata(from, to)
 }
 
 // defaultSerializer is a canonical JSON serializer
@@ -34,3 +41,32 @@ var defaultSerializer serializer = canonicalJSON{}
 func setDefaultSerializer(s serializer) {
 	defaultSerializer = s
 }
+
+// UnmarshalMetadata decodes a single JSON value from the bytes, which are
+// expected to be TUF metadata or part of it, returning ErrTrailingData if a
+// JSON object is followed by anything other than whitespace.  There is no
+// legitimate reason for metadata to carry trailing data, and parsers
+// disagreeing on whether to tolerate it could disagree on the metadata's
+// contents.
+func UnmarshalMetadata(from []byte, to interface{}) error {
+	err := json.Unmarshal(from, to)
+	if _, ok := err.(*json.SyntaxError); !ok {
+		return err
+	}
+
+	// the data may be a complete JSON object followed by trailing data, rather
+	// than invalid JSON, in which case the type of the error is different
+	r := bytes.NewReader(from)
+	dec := json.NewDecoder(r)
+	var first json.RawMessage
+	if dec.Decode(&first) != nil || !bytes.HasPrefix(bytes.TrimLeft(first, jsonWhitespace), []byte("{")) {
+		return err
+	}
+	rest, readErr := ioutil.ReadAll(io.MultiReader(dec.Buffered(), r))
+	if readErr != nil {
+		return readErr
+	}
+	return ErrTrailingData{Length: len(bytes.TrimLeft(rest, jsonWhitespace))}
+}
+
+const jsonWhitespace = " \t\r\n"
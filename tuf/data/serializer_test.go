@@ -0,0 +1,68 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// signedTemplates returns valid signed metadata for each role, along with a
+// function to parse that role's metadata
+func signedTemplates(t *testing.T) map[RoleName]struct {
+	signed *Signed
+	parse  func(*Signed) error
+} {
+	root, err := validRootTemplate().ToSigned()
+	require.NoError(t, err)
+	targets, err := validTargetsTemplate().ToSigned()
+	require.NoError(t, err)
+	snapshot, err := validSnapshotTemplate().ToSigned()
+	require.NoError(t, err)
+	timestamp, err := validTimestampTemplate().ToSigned()
+	require.NoError(t, err)
+
+	return map[RoleName]struct {
+		signed *Signed
+		parse  func(*Signed) error
+	}{
+		CanonicalRootRole: {root, func(s *Signed) error {
+			_, err := RootFromSigned(s)
+			return err
+		}},
+		CanonicalTargetsRole: {targets, func(s *Signed) error {
+			_, err := TargetsFromSigned(s, CanonicalTargetsRole)
+			return err
+		}},
+		CanonicalSnapshotRole: {snapshot, func(s *Signed) error {
+			_, err := SnapshotFromSigned(s)
+			return err
+		}},
+		CanonicalTimestampRole: {timestamp, func(s *Signed) error {
+			_, err := TimestampFromSigned(s)
+			return err
+		}},
+	}
+}
+
+// Metadata followed by anything but whitespace is rejected, both for the
+// signed envelope and for the signed portion of every role
+func TestUnmarshalMetadataRejectsTrailingData(t *testing.T) {
+	for roleName, template := range signedTemplates(t) {
+		serialized, err := defaultSerializer.Marshal(template.signed)
+		require.NoError(t, err)
+
+		parsed := &Signed{}
+		require.NoError(t, UnmarshalMetadata(append(serialized, " \n"...), parsed), roleName.String())
+		require.NoError(t, template.parse(parsed), roleName.String())
+
+		// trailing data, and its length excluding leading whitespace
+		for trailing, length := range map[string]int{"garbage": 7, "{}": 2, "\n{\"signed\": {}}": 14, "]": 1} {
+			err := UnmarshalMetadata(append(serialized, trailing...), &Signed{})
+			require.Equal(t, ErrTrailingData{Length: length}, err, roleName.String())
+
+			inner := append(*template.signed.Signed, trailing...)
+			err = template.parse(&Signed{Signed: &inner, Signatures: template.signed.Signatures})
+			require.IsType(t, ErrTrailingData{}, err, roleName.String())
+		}
+	}
+}
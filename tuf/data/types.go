@@ -148,6 +148,13 @@ type SignedCommon struct {
 	Type    string    `json:"_type"`
 	Expires time.Time `json:"expires"`
 	Version int       `json:"version"`
+	// Issued is the time the metadata was signed.  It is absent from metadata
+	// signed before it was recorded.
+	Issued *time.Time `json:"issued,omitempty"`
+	// Custom is arbitrary data about the metadata, such as the purposes of
+	// the role's keys.  It is signed with the metadata, but otherwise plays
+	// no part in verification.
+	Custom *json.RawMessage `json:"custom,omitempty"`
 }
 
 // SignedMeta is used in server validation where we only need signatures
@@ -385,6 +392,10 @@ func (s *Signature) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	uSignature.Method = SigAlgorithm(strings.ToLower(string(uSignature.Method)))
+	// signatures attached by other tools may give key IDs in uppercase
+	if keyID, err := CanonicalKeyID(uSignature.KeyID); err == nil {
+		uSignature.KeyID = keyID
+	}
 	*s = Signature(uSignature)
 	return nil
 }
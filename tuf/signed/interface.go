@@ -1,6 +1,10 @@
 package signed
 
-import "github.com/theupdateframework/notary/tuf/data"
+import (
+	"io"
+
+	"github.com/theupdateframework/notary/tuf/data"
+)
 
 // KeyService provides management of keys locally. It will never
 // accept or provide private keys. Communication between the KeyService
@@ -45,3 +49,10 @@ type CryptoService interface {
 type Verifier interface {
 	Verify(key data.PublicKey, sig []byte, msg []byte) error
 }
+
+// RandSourcer is optionally implemented by a KeyService that supplies its own
+// source of randomness for key generation and signing
+type RandSourcer interface {
+	// RandSource returns the source of randomness to use
+	RandSource() io.Reader
+}
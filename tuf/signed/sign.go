@@ -13,6 +13,7 @@ package signed
 
 import (
 	"crypto/rand"
+	"io"
 
 	"github.com/sirupsen/logrus"
 	"github.com/theupdateframework/notary/trustmanager"
@@ -29,9 +30,23 @@ import (
 // existing signatures by those keys.  For instance, if you want to call Sign
 // multiple times with different sets of signing keys without undoing removing
 // signatures produced by the previous call to Sign.
+// If the service implements RandSourcer, its source of randomness is used for
+// signature schemes which require it (ECDSA and RSA-PSS).
 func Sign(service CryptoService, s *data.Signed, signingKeys []data.PublicKey,
 	minSignatures int, otherWhitelistedKeys []data.PublicKey) error {
 
+	random := io.Reader(rand.Reader)
+	if sourcer, ok := service.(RandSourcer); ok && sourcer.RandSource() != nil {
+		random = sourcer.RandSource()
+	}
+	return SignWithRand(random, service, s, signingKeys, minSignatures, otherWhitelistedKeys)
+}
+
+// SignWithRand behaves like Sign, but uses the provided source of randomness
+// for signature schemes which require it (ECDSA and RSA-PSS)
+func SignWithRand(random io.Reader, service CryptoService, s *data.Signed, signingKeys []data.PublicKey,
+	minSignatures int, otherWhitelistedKeys []data.PublicKey) error {
+
 	logrus.Debugf("sign called with %d/%d required keys", minSignatures, len(signingKeys))
 	signatures := make([]data.Signature, 0, len(s.Signatures)+1)
 	signingKeyIDs := make(map[string]struct{})
@@ -74,7 +89,7 @@ func Sign(service CryptoService, s *data.Signed, signingKeys []data.PublicKey,
 	emptyStruct := struct{}{}
 	// Do signing and generate list of signatures
 	for keyID, pk := range privKeys {
-		sig, err := pk.Sign(rand.Reader, *s.Signed, nil)
+		sig, err := pk.Sign(random, *s.Signed, nil)
 		if err != nil {
 			logrus.Debugf("Failed to sign with key: %s. Reason: %v", keyID, err)
 			return err
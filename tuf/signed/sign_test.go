@@ -12,6 +12,7 @@ import (
 	"github.com/docker/go/canonical/json"
 	"github.com/stretchr/testify/require"
 	"github.com/theupdateframework/notary/cryptoservice"
+	"github.com/theupdateframework/notary/passphrase"
 	"github.com/theupdateframework/notary/trustmanager"
 	"github.com/theupdateframework/notary/tuf/data"
 	"github.com/theupdateframework/notary/tuf/utils"
@@ -134,6 +135,40 @@ func TestBasicSign(t *testing.T) {
 	}
 }
 
+// countingReader counts the bytes read from an underlying source of randomness
+type countingReader struct {
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := rand.Reader.Read(p)
+	c.read += n
+	return n, err
+}
+
+// ECDSA signatures use the source of randomness of the crypto service if it
+// provides one, or the one explicitly passed to SignWithRand
+func TestSignUsesRandSource(t *testing.T) {
+	random := &countingReader{}
+	cs := cryptoservice.NewCryptoServiceWithRand(random, trustmanager.NewKeyMemoryStore(passphrase.ConstantRetriever("pass")))
+	key, err := cs.Create(data.CanonicalTargetsRole, "", data.ECDSAKey)
+	require.NoError(t, err)
+	require.True(t, random.read > 0)
+
+	testData := data.Signed{Signed: &json.RawMessage{}}
+	random.read = 0
+	require.NoError(t, Sign(cs, &testData, []data.PublicKey{key}, 1, nil))
+	require.True(t, random.read > 0)
+	require.NoError(t, VerifySignature(*testData.Signed, &testData.Signatures[0], key))
+
+	explicit := &countingReader{}
+	random.read = 0
+	require.NoError(t, SignWithRand(explicit, cs, &testData, []data.PublicKey{key}, 1, nil))
+	require.True(t, explicit.read > 0)
+	require.Equal(t, 0, random.read)
+	require.NoError(t, VerifySignature(*testData.Signed, &testData.Signatures[0], key))
+}
+
 // Signing with the same key multiple times should not produce multiple sigs
 // with the same key ID
 func TestReSign(t *testing.T) {
@@ -11,6 +11,7 @@ import (
 	"math/big"
 
 	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary/tuf/cryptobackend"
 	"github.com/theupdateframework/notary/tuf/data"
 	"golang.org/x/crypto/ed25519"
 )
@@ -58,7 +59,7 @@ func (v Ed25519Verifier) Verify(key data.PublicKey, sig []byte, msg []byte) erro
 		return ErrInvalid
 	}
 
-	if !ed25519.Verify(ed25519.PublicKey(keyBytes), msg, sigBytes) {
+	if !cryptobackend.Default.VerifyEd25519(keyBytes, msg, sigBytes) {
 		logrus.Debugf("failed ed25519 verification")
 		return ErrInvalid
 	}
@@ -83,7 +84,7 @@ func verifyPSS(key interface{}, digest, sig []byte) error {
 	}
 
 	opts := rsa.PSSOptions{SaltLength: sha256.Size, Hash: crypto.SHA256}
-	if err := rsa.VerifyPSS(rsaPub, crypto.SHA256, digest[:], sig, &opts); err != nil {
+	if err := cryptobackend.Default.VerifyPSS(rsaPub, crypto.SHA256, digest[:], sig, &opts); err != nil {
 		logrus.Debugf("failed RSAPSS verification: %s", err)
 		return ErrInvalid
 	}
@@ -167,7 +168,7 @@ func (v RSAPKCS1v15Verifier) Verify(key data.PublicKey, sig []byte, msg []byte)
 		return ErrInvalid
 	}
 
-	if err = rsa.VerifyPKCS1v15(rsaPub, crypto.SHA256, digest[:], sig); err != nil {
+	if err = cryptobackend.Default.VerifyPKCS1v15(rsaPub, crypto.SHA256, digest[:], sig); err != nil {
 		logrus.Errorf("Failed verification: %s", err.Error())
 		return ErrInvalid
 	}
@@ -255,7 +256,7 @@ func (v ECDSAVerifier) Verify(key data.PublicKey, sig []byte, msg []byte) error
 
 	digest := sha256.Sum256(msg)
 
-	if !ecdsa.Verify(ecdsaPubKey, digest[:], r, s) {
+	if !cryptobackend.Default.VerifyECDSA(ecdsaPubKey, digest[:], r, s) {
 		logrus.Debugf("failed ECDSA signature validation")
 		return ErrInvalid
 	}
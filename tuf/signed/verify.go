@@ -3,7 +3,10 @@ package signed
 import (
 	"errors"
 	"fmt"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/go/canonical/json"
@@ -21,12 +24,23 @@ var (
 
 // IsExpired checks if the given time passed before the present time
 func IsExpired(t time.Time) bool {
-	return t.Before(time.Now())
+	return IsExpiredAt(t, time.Now())
+}
+
+// IsExpiredAt checks if the given time passed before the given current time
+func IsExpiredAt(t, now time.Time) bool {
+	return t.Before(now)
 }
 
 // VerifyExpiry returns ErrExpired if the metadata is expired
 func VerifyExpiry(s *data.SignedCommon, role data.RoleName) error {
-	if IsExpired(s.Expires) {
+	return VerifyExpiryAt(s, role, time.Now())
+}
+
+// VerifyExpiryAt returns ErrExpired if the metadata is expired at the given
+// current time, for clients which do not trust the system clock
+func VerifyExpiryAt(s *data.SignedCommon, role data.RoleName, now time.Time) error {
+	if IsExpiredAt(s.Expires, now) {
 		logrus.Errorf("Metadata for %s expired", role)
 		return ErrExpired{Role: role, Expired: s.Expires.Format("Mon Jan 2 15:04:05 MST 2006")}
 	}
@@ -43,6 +57,22 @@ func VerifyVersion(s *data.SignedCommon, minVersion int) error {
 
 // VerifySignatures checks the we have sufficient valid signatures for the given role
 func VerifySignatures(s *data.Signed, roleData data.BaseRole) error {
+	return verifySignatures(s, roleData, 1)
+}
+
+// VerifySignaturesParallel checks, like VerifySignatures, that we have
+// sufficient valid signatures for the given role, but verifies the signatures
+// concurrently with up to workers goroutines.  A workers value less than 1
+// uses one goroutine per CPU.  It accepts and rejects exactly the same data as
+// VerifySignatures, with the same error.
+func VerifySignaturesParallel(s *data.Signed, roleData data.BaseRole, workers int) error {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	return verifySignatures(s, roleData, workers)
+}
+
+func verifySignatures(s *data.Signed, roleData data.BaseRole, workers int) error {
 	if len(s.Signatures) == 0 {
 		return ErrNoSignatures
 	}
@@ -52,21 +82,48 @@ func VerifySignatures(s *data.Signed, roleData data.BaseRole) error {
 	}
 	logrus.Debugf("%s role has key IDs: %s", roleData.Name, strings.Join(roleData.ListKeyIDs(), ","))
 
+	valid, err := validSignatureKeyIDs(s, roleData, workers)
+	if err != nil {
+		return err
+	}
+	if len(valid) < roleData.Threshold {
+		return ErrRoleThreshold{
+			Msg: fmt.Sprintf("valid signatures did not meet threshold for %s", roleData.Name),
+		}
+	}
+
+	return nil
+}
+
+// ValidSignatureKeyIDs returns the sorted IDs of the role's keys that produced
+// a valid signature over the signed data.  Signatures by keys not in the role,
+// and invalid signatures, are ignored.  It does not check the threshold.
+func ValidSignatureKeyIDs(s *data.Signed, roleData data.BaseRole) ([]string, error) {
+	return validSignatureKeyIDs(s, roleData, 1)
+}
+
+// validSignatureKeyIDs verifies the signatures using up to workers
+// goroutines.  Signatures are checked in order for a key ID which does not
+// match the content ID of its key, and only those before the first such
+// signature are verified, so that the results and the signatures marked as
+// valid do not depend on the number of workers.
+func validSignatureKeyIDs(s *data.Signed, roleData data.BaseRole, workers int) ([]string, error) {
 	// remarshal the signed part so we can verify the signature, since the signature has
 	// to be of a canonically marshalled signed object
 	var decoded map[string]interface{}
 	if err := json.Unmarshal(*s.Signed, &decoded); err != nil {
-		return err
+		return nil, err
 	}
 	msg, err := json.MarshalCanonical(decoded)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	valid := make(map[string]struct{})
-	for i := range s.Signatures {
-		sig := &(s.Signatures[i])
-		logrus.Debug("verifying signature for key ID: ", sig.KeyID)
+	var (
+		toVerify []int
+		keyIDErr error
+	)
+	for i, sig := range s.Signatures {
 		key, ok := roleData.Keys[sig.KeyID]
 		if !ok {
 			logrus.Debugf("continuing b/c keyid lookup was nil: %s\n", sig.KeyID)
@@ -74,21 +131,64 @@ func VerifySignatures(s *data.Signed, roleData data.BaseRole) error {
 		}
 		// Check that the signature key ID actually matches the content ID of the key
 		if key.ID() != sig.KeyID {
-			return ErrInvalidKeyID{}
+			keyIDErr = ErrInvalidKeyID{}
+			break
 		}
-		if err := VerifySignature(msg, sig, key); err != nil {
+		toVerify = append(toVerify, i)
+	}
+
+	// each signature is only written to by the goroutine verifying it
+	verified := make([]bool, len(s.Signatures))
+	verify := func(i int) {
+		sig := &(s.Signatures[i])
+		logrus.Debug("verifying signature for key ID: ", sig.KeyID)
+		if err := VerifySignature(msg, sig, roleData.Keys[sig.KeyID]); err != nil {
 			logrus.Debugf("continuing b/c %s", err.Error())
-			continue
+			return
 		}
-		valid[sig.KeyID] = struct{}{}
+		verified[i] = true
 	}
-	if len(valid) < roleData.Threshold {
-		return ErrRoleThreshold{
-			Msg: fmt.Sprintf("valid signatures did not meet threshold for %s", roleData.Name),
+	if workers > len(toVerify) {
+		workers = len(toVerify)
+	}
+	if workers <= 1 {
+		for _, i := range toVerify {
+			verify(i)
 		}
+	} else {
+		indices := make(chan int)
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				for i := range indices {
+					verify(i)
+				}
+			}()
+		}
+		for _, i := range toVerify {
+			indices <- i
+		}
+		close(indices)
+		wg.Wait()
+	}
+	if keyIDErr != nil {
+		return nil, keyIDErr
 	}
 
-	return nil
+	valid := make(map[string]struct{})
+	for i, ok := range verified {
+		if ok {
+			valid[s.Signatures[i].KeyID] = struct{}{}
+		}
+	}
+	validIDs := make([]string, 0, len(valid))
+	for keyID := range valid {
+		validIDs = append(validIDs, keyID)
+	}
+	sort.Strings(validIDs)
+	return validIDs, nil
 }
 
 // VerifySignature checks a single signature and public key against a payload
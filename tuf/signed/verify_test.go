@@ -130,6 +130,31 @@ func TestMoreThanEnoughSigs(t *testing.T) {
 	require.True(t, s.Signatures[1].IsValid)
 }
 
+func TestValidSignatureKeyIDsBelowThreshold(t *testing.T) {
+	cs := NewEd25519()
+	k1, err := cs.Create("root", "", data.ED25519Key)
+	require.NoError(t, err)
+	k2, err := cs.Create("root", "", data.ED25519Key)
+	require.NoError(t, err)
+	k3, err := cs.Create("root", "", data.ED25519Key)
+	require.NoError(t, err)
+	roleWithKeys := data.BaseRole{Name: "root", Keys: data.Keys{k1.ID(): k1, k2.ID(): k2, k3.ID(): k3}, Threshold: 2}
+
+	meta := &data.SignedCommon{Type: "Root", Version: 1, Expires: data.DefaultExpires("root")}
+
+	b, err := json.MarshalCanonical(meta)
+	require.NoError(t, err)
+	s := &data.Signed{Signed: (*json.RawMessage)(&b)}
+	require.NoError(t, Sign(cs, s, []data.PublicKey{k1}, 1, nil))
+
+	// signatures are checked without enforcing the threshold
+	validIDs, err := ValidSignatureKeyIDs(s, roleWithKeys)
+	require.NoError(t, err)
+	require.Equal(t, []string{k1.ID()}, validIDs)
+
+	require.IsType(t, ErrRoleThreshold{}, VerifySignatures(s, roleWithKeys))
+}
+
 func TestValidSigWithIncorrectKeyID(t *testing.T) {
 	cs := NewEd25519()
 	k1, err := cs.Create("root", "", data.ED25519Key)
@@ -197,6 +222,94 @@ func TestUnknownKeyBelowThreshold(t *testing.T) {
 	}
 }
 
+// signedByManyKeys returns metadata signed by n keys, and a role made up of
+// those keys with the given threshold
+func signedByManyKeys(t testing.TB, n, threshold int) (*data.Signed, data.BaseRole) {
+	cs := NewEd25519()
+	var keys []data.PublicKey
+	for i := 0; i < n; i++ {
+		k, err := cs.Create(data.CanonicalRootRole, "", data.ED25519Key)
+		require.NoError(t, err)
+		keys = append(keys, k)
+	}
+	meta := &data.SignedCommon{Type: "Root", Version: 1, Expires: data.DefaultExpires("root")}
+	b, err := json.MarshalCanonical(meta)
+	require.NoError(t, err)
+	s := &data.Signed{Signed: (*json.RawMessage)(&b)}
+	require.NoError(t, Sign(cs, s, keys, n, nil))
+	return s, data.NewBaseRole(data.CanonicalRootRole, threshold, keys...)
+}
+
+// copySigned copies the signatures, so that their valid markings can be compared
+func copySigned(s *data.Signed) *data.Signed {
+	return &data.Signed{Signed: s.Signed, Signatures: append([]data.Signature{}, s.Signatures...)}
+}
+
+// The parallel path makes the same decisions as the serial path, returns the
+// same errors, and marks the same signatures as valid, however many workers
+// are used
+func TestVerifySignaturesParallelMatchesSerial(t *testing.T) {
+	s, role := signedByManyKeys(t, 20, 15)
+	sigs := s.Signatures
+	// invalidate 4 signatures, and drop one key from the role
+	for _, i := range []int{1, 5, 9, 13} {
+		sigs[i].Signature = append([]byte{}, sigs[i].Signature...)
+		sigs[i].Signature[0] ^= 0xff
+	}
+	delete(role.Keys, sigs[17].KeyID)
+
+	for _, threshold := range []int{1, 14, 15, 16, 20} {
+		role.Threshold = threshold
+		serial := copySigned(s)
+		serialErr := VerifySignatures(serial, role)
+		serialIDs, err := ValidSignatureKeyIDs(copySigned(s), role)
+		require.NoError(t, err)
+		for _, workers := range []int{0, 1, 2, 7, 64} {
+			parallel := copySigned(s)
+			require.Equal(t, serialErr, VerifySignaturesParallel(parallel, role, workers),
+				"threshold %d, %d workers", threshold, workers)
+			require.Equal(t, serial.Signatures, parallel.Signatures)
+			parallelIDs, err := validSignatureKeyIDs(copySigned(s), role, workers)
+			require.NoError(t, err)
+			require.Equal(t, serialIDs, parallelIDs)
+		}
+	}
+
+	// a key ID that does not match its key fails both paths, and signatures
+	// after it are not verified by either
+	role.Threshold = 1
+	badID := copySigned(s)
+	role.Keys["invalidID"] = role.Keys[badID.Signatures[10].KeyID]
+	badID.Signatures[10].KeyID = "invalidID"
+	serial := copySigned(badID)
+	require.IsType(t, ErrInvalidKeyID{}, VerifySignatures(serial, role))
+	for _, workers := range []int{0, 2, 64} {
+		parallel := copySigned(badID)
+		require.IsType(t, ErrInvalidKeyID{}, VerifySignaturesParallel(parallel, role, workers))
+		require.Equal(t, serial.Signatures, parallel.Signatures)
+	}
+	require.True(t, serial.Signatures[0].IsValid)
+	require.False(t, serial.Signatures[11].IsValid)
+}
+
+func benchmarkVerifySignatures(b *testing.B, workers int) {
+	s, role := signedByManyKeys(b, 200, 200)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := VerifySignaturesParallel(copySigned(s), role, workers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVerifySignaturesSerial(b *testing.B) {
+	benchmarkVerifySignatures(b, 1)
+}
+
+func BenchmarkVerifySignaturesParallel(b *testing.B) {
+	benchmarkVerifySignatures(b, 0)
+}
+
 func TestVerifyVersion(t *testing.T) {
 	tufType := data.TUFTypes[data.CanonicalRootRole]
 	meta := data.SignedCommon{Type: tufType, Version: 1, Expires: data.DefaultExpires(data.CanonicalRootRole)}
@@ -7,14 +7,14 @@ import (
 
 // CorruptingMemoryStore corrupts all data returned by GetMeta
 type CorruptingMemoryStore struct {
-	store.MemoryStore
+	*store.MemoryStore
 }
 
 // NewCorruptingMemoryStore returns a new instance of memory store that
 // corrupts all data requested from it.
 func NewCorruptingMemoryStore(meta map[data.RoleName][]byte) *CorruptingMemoryStore {
 	s := store.NewMemoryStore(meta)
-	return &CorruptingMemoryStore{MemoryStore: *s}
+	return &CorruptingMemoryStore{MemoryStore: s}
 }
 
 // GetSized returns up to size bytes of meta identified by string. It will
@@ -30,14 +30,14 @@ func (cm CorruptingMemoryStore) GetSized(name string, size int64) ([]byte, error
 
 // LongMemoryStore corrupts all data returned by GetMeta
 type LongMemoryStore struct {
-	store.MemoryStore
+	*store.MemoryStore
 }
 
 // NewLongMemoryStore returns a new instance of memory store that
 // returns one byte too much data on any request to GetMeta
 func NewLongMemoryStore(meta map[data.RoleName][]byte) *LongMemoryStore {
 	s := store.NewMemoryStore(meta)
-	return &LongMemoryStore{MemoryStore: *s}
+	return &LongMemoryStore{MemoryStore: s}
 }
 
 // GetSized returns one byte too much
@@ -52,14 +52,14 @@ func (lm LongMemoryStore) GetSized(name string, size int64) ([]byte, error) {
 
 // ShortMemoryStore corrupts all data returned by GetMeta
 type ShortMemoryStore struct {
-	store.MemoryStore
+	*store.MemoryStore
 }
 
 // NewShortMemoryStore returns a new instance of memory store that
 // returns one byte too little data on any request to GetMeta
 func NewShortMemoryStore(meta map[data.RoleName][]byte) *ShortMemoryStore {
 	s := store.NewMemoryStore(meta)
-	return &ShortMemoryStore{MemoryStore: *s}
+	return &ShortMemoryStore{MemoryStore: s}
 }
 
 // GetSized returns one byte too few
@@ -4,7 +4,9 @@ package tuf
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -68,6 +70,10 @@ type Repo struct {
 	// If we know what the original was, we'll if and how to handle root
 	// rotations.
 	originalRootRole data.BaseRole
+
+	// delegationPriority, if set, orders sibling delegations when walking
+	// the targets tree, in place of their order of declaration
+	delegationPriority func(a, b data.Role) int
 }
 
 // NewRepo initializes a Repo instance with a CryptoService.
@@ -506,6 +512,60 @@ func (tr *Repo) UpdateDelegationPaths(roleName data.RoleName, addPaths, removePa
 	return nil
 }
 
+// SetThreshold sets the number of signatures required by a base role, in
+// the root, or by a delegation, in its parent.  The threshold must be at least
+// 1 and no more than the number of keys of the role.
+func (tr *Repo) SetThreshold(roleName data.RoleName, threshold int) error {
+	if threshold < notary.MinThreshold {
+		return data.ErrInvalidRole{Role: roleName, Reason: fmt.Sprintf("threshold must be at least %d", notary.MinThreshold)}
+	}
+	if !data.IsDelegation(roleName) {
+		if tr.Root == nil {
+			return ErrNotLoaded{Role: data.CanonicalRootRole}
+		}
+		role, ok := tr.Root.Signed.Roles[roleName]
+		if !ok {
+			return data.ErrInvalidRole{Role: roleName, Reason: "not a base role"}
+		}
+		if threshold > len(role.KeyIDs) {
+			return data.ErrInvalidRole{Role: roleName,
+				Reason: fmt.Sprintf("threshold of %d exceeds its %d keys", threshold, len(role.KeyIDs))}
+		}
+		role.Threshold = threshold
+		tr.Root.Dirty = true
+		tr.markRoleDirty(roleName)
+		return nil
+	}
+
+	parent := roleName.Parent()
+	if err := tr.VerifyCanSign(parent); err != nil {
+		return err
+	}
+	found := false
+	err := tr.WalkTargets("", parent, func(tgt *data.SignedTargets, validRole data.DelegationRole) interface{} {
+		foundAt := utils.FindRoleIndex(tgt.Signed.Delegations.Roles, roleName)
+		if foundAt < 0 {
+			return StopWalk{}
+		}
+		role := tgt.Signed.Delegations.Roles[foundAt]
+		if threshold > len(role.KeyIDs) {
+			return data.ErrInvalidRole{Role: roleName,
+				Reason: fmt.Sprintf("threshold of %d exceeds its %d keys", threshold, len(role.KeyIDs))}
+		}
+		role.Threshold = threshold
+		tgt.Dirty = true
+		found = true
+		return StopWalk{}
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return data.ErrInvalidRole{Role: roleName, Reason: "no valid delegated role exists"}
+	}
+	return nil
+}
+
 // DeleteDelegation removes a delegated targets role from its parent
 // targets object. It also deletes the delegation from the snapshot.
 // DeleteDelegation will only make use of the role Name field.
@@ -695,12 +755,43 @@ func (tr *Repo) VerifyCanSign(roleName data.RoleName) error {
 	return signed.ErrNoKeys{KeyIDs: canonicalKeyIDs}
 }
 
+// SetDelegationPriority sets a comparator which orders the sibling delegations
+// of each role when walking the targets tree, so that those comparing lower
+// are visited, and so take priority, first.  Siblings comparing equal keep
+// their order of declaration, which is the order used if the comparator is
+// nil.  This only changes the order in which targets are resolved, not which
+// delegations are trusted or which paths they may sign.
+func (tr *Repo) SetDelegationPriority(compare func(a, b data.Role) int) {
+	tr.delegationPriority = compare
+}
+
+// validDelegations returns the valid delegations of a role, in priority order
+func (tr *Repo) validDelegations(signedTgt *data.SignedTargets, role data.DelegationRole) []data.DelegationRole {
+	children := signedTgt.GetValidDelegations(role)
+	if tr.delegationPriority == nil {
+		return children
+	}
+	roles := make(map[data.RoleName]data.Role, len(children))
+	for _, child := range children {
+		roles[child.Name] = data.Role{
+			RootRole: data.RootRole{KeyIDs: child.ListKeyIDs(), Threshold: child.Threshold},
+			Name:     child.Name,
+			Paths:    child.Paths,
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool {
+		return tr.delegationPriority(roles[children[i].Name], roles[children[j].Name]) < 0
+	})
+	return children
+}
+
 // used for walking the targets/delegations tree, potentially modifying the underlying SignedTargets for the repo
 type walkVisitorFunc func(*data.SignedTargets, data.DelegationRole) interface{}
 
 // WalkTargets will apply the specified visitor function to iteratively walk the targets/delegation metadata tree,
 // until receiving a StopWalk.  The walk starts from the base "targets" role, and searches for the correct targetPath and/or rolePath
-// to call the visitor function on.  Any roles passed into skipRoles will be excluded from the walk, as well as roles in those subtrees
+// to call the visitor function on.  Any roles passed into skipRoles will be excluded from the walk, as well as roles in those subtrees.
+// If a role delegates back to itself, the walk stops with data.ErrDelegationCycle.
 func (tr *Repo) WalkTargets(targetPath string, rolePath data.RoleName, visitTargets walkVisitorFunc, skipRoles ...data.RoleName) error {
 	// Start with the base targets role, which implicitly has the "" targets path
 	targetsRole, err := tr.GetBaseRole(data.CanonicalTargetsRole)
@@ -708,28 +799,22 @@ func (tr *Repo) WalkTargets(targetPath string, rolePath data.RoleName, visitTarg
 		return err
 	}
 	// Make the targets role have the empty path, when we treat it as a delegation role
-	roles := []data.DelegationRole{
-		{
-			BaseRole: targetsRole,
-			Paths:    []string{""},
-		},
+	start := data.DelegationRole{
+		BaseRole: targetsRole,
+		Paths:    []string{""},
 	}
 
-	for len(roles) > 0 {
-		role := roles[0]
-		roles = roles[1:]
-
+	return walkDelegations(start, func(role data.DelegationRole) ([]data.DelegationRole, error) {
 		// Check the role metadata
 		signedTgt, ok := tr.Targets[role.Name]
 		if !ok {
 			// The role meta doesn't exist in the repo so continue onward
-			continue
+			return nil, nil
 		}
 
 		// We're at a prefix of the desired role subtree, so add its delegation role children and continue walking
 		if strings.HasPrefix(rolePath.String(), role.Name.String()+"/") {
-			roles = append(roles, signedTgt.GetValidDelegations(role)...)
-			continue
+			return tr.validDelegations(signedTgt, role), nil
 		}
 
 		// Determine whether to visit this role or not:
@@ -740,19 +825,55 @@ func (tr *Repo) WalkTargets(targetPath string, rolePath data.RoleName, visitTarg
 			res := visitTargets(signedTgt, role)
 			switch typedRes := res.(type) {
 			case StopWalk:
-				// If the visitor function signalled a stop, return nil to finish the walk
-				return nil
+				// If the visitor function signalled a stop, finish the walk
+				return nil, errStopWalk
 			case nil:
 				// If the visitor function signalled to continue, add this role's delegation to the walk
-				roles = append(roles, signedTgt.GetValidDelegations(role)...)
+				return tr.validDelegations(signedTgt, role), nil
 			case error:
 				// Propagate any errors from the visitor
-				return typedRes
+				return nil, typedRes
 			default:
 				// Return out with an error if we got a different result
-				return fmt.Errorf("unexpected return while walking: %v", res)
+				return nil, fmt.Errorf("unexpected return while walking: %v", res)
 			}
+		}
+		return nil, nil
+	})
+}
 
+// errStopWalk is returned by the visitor of walkDelegations to finish the walk
+var errStopWalk = errors.New("stop walking delegations")
+
+// walkDelegations visits delegations breadth first from the start role,
+// queueing the children the visitor returns for each role.  The chain of
+// delegations leading to each role is tracked, so that a child which is
+// already in its own chain stops the walk with data.ErrDelegationCycle
+// rather than being walked forever.  The walk finishes without error when
+// the visitor returns errStopWalk.
+func walkDelegations(start data.DelegationRole, visit func(data.DelegationRole) ([]data.DelegationRole, error)) error {
+	type queuedRole struct {
+		role  data.DelegationRole
+		chain []data.RoleName
+	}
+	toVisit := []queuedRole{{role: start, chain: []data.RoleName{start.Name}}}
+	for len(toVisit) > 0 {
+		next := toVisit[0]
+		toVisit = toVisit[1:]
+
+		children, err := visit(next.role)
+		if err == errStopWalk {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			chain, err := data.ExtendDelegationChain(next.chain, child.Name)
+			if err != nil {
+				return err
+			}
+			toVisit = append(toVisit, queuedRole{role: child, chain: chain})
 		}
 	}
 	return nil
@@ -889,6 +1010,12 @@ func (tr *Repo) UpdateTimestamp(s *data.Signed) error {
 	return nil
 }
 
+// issuedNow returns the issued time to record in newly signed metadata
+func issuedNow() *time.Time {
+	now := time.Now().UTC()
+	return &now
+}
+
 // SignRoot signs the root, using all keys from the "root" role (i.e. currently trusted)
 // as well as available keys used to sign the previous version, if the public part is
 // carried in tr.Root.Keys and the private key is available (i.e. probably previously
@@ -923,6 +1050,7 @@ func (tr *Repo) SignRoot(expires time.Time, extraSigningKeys data.KeyList) (*dat
 
 	tempRoot.Signed.Expires = expires
 	tempRoot.Signed.Version++
+	tempRoot.Signed.Issued = issuedNow()
 	rolesToSignWith = append(rolesToSignWith, currRoot)
 
 	signed, err := tempRoot.ToSigned()
@@ -956,6 +1084,7 @@ func (tr *Repo) SignTargets(role data.RoleName, expires time.Time) (*data.Signed
 	}
 	tr.Targets[role].Signed.Expires = expires
 	tr.Targets[role].Signed.Version++
+	tr.Targets[role].Signed.Issued = issuedNow()
 	signed, err := tr.Targets[role].ToSigned()
 	if err != nil {
 		logrus.Debug("errored getting targets data.Signed object")
@@ -1010,6 +1139,7 @@ func (tr *Repo) SignSnapshot(expires time.Time) (*data.Signed, error) {
 	}
 	tr.Snapshot.Signed.Expires = expires
 	tr.Snapshot.Signed.Version++
+	tr.Snapshot.Signed.Issued = issuedNow()
 	signed, err := tr.Snapshot.ToSigned()
 	if err != nil {
 		return nil, err
@@ -1039,6 +1169,7 @@ func (tr *Repo) SignTimestamp(expires time.Time) (*data.Signed, error) {
 	}
 	tr.Timestamp.Signed.Expires = expires
 	tr.Timestamp.Signed.Version++
+	tr.Timestamp.Signed.Issued = issuedNow()
 	signed, err := tr.Timestamp.ToSigned()
 	if err != nil {
 		return nil, err
@@ -124,6 +124,37 @@ func TestInitRepo(t *testing.T) {
 	require.Error(t, err)
 }
 
+// Thresholds of base roles and delegations can be set to any number from 1
+// up to the number of keys of the role
+func TestSetThreshold(t *testing.T) {
+	ed25519 := signed.NewEd25519()
+	repo := initRepo(t, ed25519)
+
+	testKeys := make([]data.PublicKey, 2)
+	for i := range testKeys {
+		key, err := ed25519.Create("targets/test", testGUN, data.ED25519Key)
+		require.NoError(t, err)
+		testKeys[i] = key
+	}
+	require.NoError(t, repo.UpdateDelegationKeys("targets/test", testKeys, []string{}, 1))
+	repo.Targets[data.CanonicalTargetsRole].Dirty = false
+
+	require.NoError(t, repo.SetThreshold("targets/test", 2))
+	role, err := repo.GetDelegationRole("targets/test")
+	require.NoError(t, err)
+	require.Equal(t, 2, role.Threshold)
+	require.True(t, repo.Targets[data.CanonicalTargetsRole].Dirty)
+
+	require.IsType(t, data.ErrInvalidRole{}, repo.SetThreshold("targets/test", 3))
+	require.IsType(t, data.ErrInvalidRole{}, repo.SetThreshold("targets/test", 0))
+	require.IsType(t, data.ErrInvalidRole{}, repo.SetThreshold("targets/missing", 1))
+
+	repo.Root.Dirty = false
+	require.NoError(t, repo.SetThreshold(data.CanonicalSnapshotRole, 1))
+	require.True(t, repo.Root.Dirty)
+	require.IsType(t, data.ErrInvalidRole{}, repo.SetThreshold(data.CanonicalSnapshotRole, 2))
+}
+
 func TestUpdateDelegations(t *testing.T) {
 	ed25519 := signed.NewEd25519()
 	repo := initRepo(t, ed25519)
@@ -1528,3 +1559,66 @@ func TestRootKeyRotation(t *testing.T) {
 	}
 	verifySignatureList(t, signedObj, expectedSigningKeys...)
 }
+
+// Signing any role records the time it was signed
+func TestSignRecordsIssuedTime(t *testing.T) {
+	repo := initRepo(t, cryptoservice.NewCryptoService(trustmanager.NewKeyMemoryStore(passphrase.ConstantRetriever("pass"))))
+	before := time.Now()
+
+	signedRoles := map[data.RoleName]func() (*data.Signed, error){
+		data.CanonicalRootRole: func() (*data.Signed, error) {
+			return repo.SignRoot(data.DefaultExpires(data.CanonicalRootRole), nil)
+		},
+		data.CanonicalTargetsRole: func() (*data.Signed, error) {
+			return repo.SignTargets(data.CanonicalTargetsRole, data.DefaultExpires(data.CanonicalTargetsRole))
+		},
+		data.CanonicalSnapshotRole: func() (*data.Signed, error) {
+			return repo.SignSnapshot(data.DefaultExpires(data.CanonicalSnapshotRole))
+		},
+		data.CanonicalTimestampRole: func() (*data.Signed, error) {
+			return repo.SignTimestamp(data.DefaultExpires(data.CanonicalTimestampRole))
+		},
+	}
+	for _, role := range data.BaseRoles {
+		s, err := signedRoles[role]()
+		require.NoError(t, err)
+		common := data.SignedCommon{}
+		require.NoError(t, json.Unmarshal(*s.Signed, &common))
+		require.NotNil(t, common.Issued, role.String())
+		require.False(t, common.Issued.Before(before), role.String())
+		require.False(t, common.Issued.After(time.Now()), role.String())
+	}
+}
+
+// Walking delegations where two roles delegate to each other terminates with
+// the cycle, rather than walking forever
+func TestWalkDelegationsCycle(t *testing.T) {
+	roleA := data.DelegationRole{BaseRole: data.BaseRole{Name: "targets/a"}}
+	roleB := data.DelegationRole{BaseRole: data.BaseRole{Name: "targets/b"}}
+	children := map[data.RoleName][]data.DelegationRole{
+		data.CanonicalTargetsRole: {roleA},
+		roleA.Name:                {roleB},
+		roleB.Name:                {roleA},
+	}
+
+	visits := 0
+	err := walkDelegations(data.DelegationRole{BaseRole: data.BaseRole{Name: data.CanonicalTargetsRole}},
+		func(role data.DelegationRole) ([]data.DelegationRole, error) {
+			visits++
+			require.True(t, visits <= len(children), "walked past the cycle")
+			return children[role.Name], nil
+		})
+	require.Equal(t, data.ErrDelegationCycle{Roles: []data.RoleName{roleA.Name, roleB.Name, roleA.Name}}, err)
+	require.EqualError(t, err, "delegation cycle: targets/a -> targets/b -> targets/a")
+
+	// the same role delegated to by siblings is not a cycle
+	children[roleB.Name] = nil
+	children[data.CanonicalTargetsRole] = []data.DelegationRole{roleA, roleB}
+	visits = 0
+	require.NoError(t, walkDelegations(data.DelegationRole{BaseRole: data.BaseRole{Name: data.CanonicalTargetsRole}},
+		func(role data.DelegationRole) ([]data.DelegationRole, error) {
+			visits++
+			return children[role.Name], nil
+		}))
+	require.Equal(t, 4, visits)
+}
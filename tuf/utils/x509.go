@@ -341,11 +341,18 @@ func ValidateCertificate(c *x509.Certificate, checkExpiry bool) error {
 // GenerateKey returns a new private key using the provided algorithm or an
 // error detailing why the key could not be generated
 func GenerateKey(algorithm string) (data.PrivateKey, error) {
+	return GenerateKeyWithRand(algorithm, rand.Reader)
+}
+
+// GenerateKeyWithRand returns a new private key using the provided algorithm
+// and source of randomness, or an error detailing why the key could not be
+// generated
+func GenerateKeyWithRand(algorithm string, random io.Reader) (data.PrivateKey, error) {
 	switch algorithm {
 	case data.ECDSAKey:
-		return GenerateECDSAKey(rand.Reader)
+		return GenerateECDSAKey(random)
 	case data.ED25519Key:
-		return GenerateED25519Key(rand.Reader)
+		return GenerateED25519Key(random)
 	}
 	return nil, fmt.Errorf("private key type not supported for key generation: %s", algorithm)
 }
@@ -0,0 +1,195 @@
+package tuf
+
+import (
+	"fmt"
+
+	"github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// ErrCorruptedMetadata is returned by a VerifyingReadStore when the metadata
+// in the backing store is not consistent with the rest of the repository
+type ErrCorruptedMetadata struct {
+	Resource string
+	Reason   string
+}
+
+func (err ErrCorruptedMetadata) Error() string {
+	return fmt.Sprintf("refusing to serve %s, which failed verification: %s", err.Resource, err.Reason)
+}
+
+// VerifyingReadStore wraps the MetadataStore of a single GUN, and re-verifies
+// the metadata of known roles read from it before returning it, as a defense
+// against corruption of, or tampering with, the backing store:
+//   - root must be signed by its own root keys
+//   - timestamp must be signed by the timestamp keys listed in root
+//   - snapshot must be signed by the snapshot keys listed in root, and match
+//     the checksum listed in the timestamp
+//   - targets and delegations must match the checksum listed in the snapshot,
+//     and targets must also be signed by the targets keys listed in root
+//
+// The root, timestamp and snapshot used to verify are read from the backing
+// store, and are verified themselves.  Names which are not role names, such as
+// consistent or versioned names, are returned unverified.
+//
+// Verification reads and parses up to three other files for every read, so
+// it can be disabled, in which case every read is passed straight through.
+type VerifyingReadStore struct {
+	storage.MetadataStore
+	verify bool
+}
+
+// NewVerifyingReadStore wraps the backing store, verifying every read of
+// role metadata if verify is true
+func NewVerifyingReadStore(backing storage.MetadataStore, verify bool) *VerifyingReadStore {
+	return &VerifyingReadStore{MetadataStore: backing, verify: verify}
+}
+
+// SetVerify enables or disables verifying reads
+func (s *VerifyingReadStore) SetVerify(verify bool) {
+	s.verify = verify
+}
+
+// Get returns the named metadata, verified if it is the metadata of a role
+func (s *VerifyingReadStore) Get(name string) ([]byte, error) {
+	return s.GetSized(name, storage.NoSizeLimit)
+}
+
+// GetSized returns up to size bytes of the named metadata, verified if it is
+// the metadata of a role.  Metadata that fails verification is never returned.
+func (s *VerifyingReadStore) GetSized(name string, size int64) ([]byte, error) {
+	meta, err := s.MetadataStore.GetSized(name, size)
+	if err != nil || !s.verify || !data.ValidRole(data.RoleName(name)) {
+		return meta, err
+	}
+	if err := s.verifyRole(data.RoleName(name), meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// verifyRole checks the metadata of a role against the root, timestamp and
+// snapshot in the backing store
+func (s *VerifyingReadStore) verifyRole(role data.RoleName, meta []byte) error {
+	rootMeta := meta
+	if role != data.CanonicalRootRole {
+		var err error
+		if rootMeta, err = s.MetadataStore.GetSized(data.CanonicalRootRole.String(), storage.NoSizeLimit); err != nil {
+			return corrupted(role, fmt.Sprintf("unable to read root: %v", err))
+		}
+	}
+	root, err := verifiedRoot(rootMeta)
+	if err != nil {
+		return corrupted(role, fmt.Sprintf("root is invalid: %v", err))
+	}
+	if role == data.CanonicalRootRole {
+		return nil
+	}
+
+	if role == data.CanonicalTimestampRole {
+		if _, err := verifiedSigned(root, role, meta); err != nil {
+			return corrupted(role, err.Error())
+		}
+		return nil
+	}
+
+	timestampMeta, err := s.readVerified(root, data.CanonicalTimestampRole)
+	if err != nil {
+		return corrupted(role, err.Error())
+	}
+	timestamp, err := data.TimestampFromSigned(timestampMeta)
+	if err != nil {
+		return corrupted(role, fmt.Sprintf("timestamp is invalid: %v", err))
+	}
+	snapshotChecksum, err := timestamp.GetSnapshot()
+	if err != nil {
+		return corrupted(role, fmt.Sprintf("timestamp is invalid: %v", err))
+	}
+	if role == data.CanonicalSnapshotRole {
+		if err := data.CheckHashes(meta, role.String(), snapshotChecksum.Hashes); err != nil {
+			return corrupted(role, err.Error())
+		}
+		if _, err := verifiedSigned(root, role, meta); err != nil {
+			return corrupted(role, err.Error())
+		}
+		return nil
+	}
+
+	snapshotMeta, err := s.MetadataStore.GetSized(data.CanonicalSnapshotRole.String(), storage.NoSizeLimit)
+	if err != nil {
+		return corrupted(role, fmt.Sprintf("unable to read snapshot: %v", err))
+	}
+	if err := data.CheckHashes(snapshotMeta, data.CanonicalSnapshotRole.String(), snapshotChecksum.Hashes); err != nil {
+		return corrupted(role, fmt.Sprintf("snapshot is invalid: %v", err))
+	}
+	snapshotSigned, err := verifiedSigned(root, data.CanonicalSnapshotRole, snapshotMeta)
+	if err != nil {
+		return corrupted(role, err.Error())
+	}
+	snapshot, err := data.SnapshotFromSigned(snapshotSigned)
+	if err != nil {
+		return corrupted(role, fmt.Sprintf("snapshot is invalid: %v", err))
+	}
+	checksum, err := snapshot.GetMeta(role)
+	if err != nil {
+		return corrupted(role, "not listed in the snapshot")
+	}
+	if err := data.CheckHashes(meta, role.String(), checksum.Hashes); err != nil {
+		return corrupted(role, err.Error())
+	}
+	if role == data.CanonicalTargetsRole {
+		if _, err := verifiedSigned(root, role, meta); err != nil {
+			return corrupted(role, err.Error())
+		}
+	}
+	return nil
+}
+
+// readVerified reads the metadata of a base role from the backing store, and
+// verifies its signatures against root
+func (s *VerifyingReadStore) readVerified(root *data.SignedRoot, role data.RoleName) (*data.Signed, error) {
+	meta, err := s.MetadataStore.GetSized(role.String(), storage.NoSizeLimit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", role, err)
+	}
+	return verifiedSigned(root, role, meta)
+}
+
+// verifiedRoot parses root metadata and verifies it is signed by its own root
+// keys
+func verifiedRoot(meta []byte) (*data.SignedRoot, error) {
+	s := &data.Signed{}
+	if err := data.UnmarshalMetadata(meta, s); err != nil {
+		return nil, err
+	}
+	root, err := data.RootFromSigned(s)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := verifiedSigned(root, data.CanonicalRootRole, meta); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// verifiedSigned parses the metadata of a base role and verifies its
+// signatures against the role's keys in root
+func verifiedSigned(root *data.SignedRoot, role data.RoleName, meta []byte) (*data.Signed, error) {
+	s := &data.Signed{}
+	if err := data.UnmarshalMetadata(meta, s); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", role, err)
+	}
+	baseRole, err := root.BuildBaseRole(role)
+	if err != nil {
+		return nil, err
+	}
+	if err := signed.VerifySignatures(s, baseRole); err != nil {
+		return nil, fmt.Errorf("%s signatures are invalid: %v", role, err)
+	}
+	return s, nil
+}
+
+func corrupted(role data.RoleName, reason string) error {
+	return ErrCorruptedMetadata{Resource: role.String(), Reason: reason}
+}
@@ -0,0 +1,71 @@
+package tuf_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/tuf"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/testutils"
+)
+
+func newVerifyingTestStore(t *testing.T) (*testutils.MetadataSwizzler, map[data.RoleName][]byte) {
+	gun := data.GUN("docker.com/notary")
+	repo, cs, err := testutils.EmptyRepo(gun, "targets/a")
+	require.NoError(t, err)
+	_, err = repo.InitTargets("targets/a")
+	require.NoError(t, err)
+	meta, err := testutils.SignAndSerialize(repo)
+	require.NoError(t, err)
+	return testutils.NewMetadataSwizzler(gun, testutils.CopyRepoMetadata(meta), cs), meta
+}
+
+func TestVerifyingReadStoreServesValidMetadata(t *testing.T) {
+	swizzler, meta := newVerifyingTestStore(t)
+	s := tuf.NewVerifyingReadStore(swizzler.MetadataCache, true)
+	for role, expected := range meta {
+		served, err := s.GetSized(role.String(), storage.NoSizeLimit)
+		require.NoError(t, err, role.String())
+		require.Equal(t, expected, served)
+	}
+
+	// names which are not role names are not verified
+	_, err := s.Get("not a role")
+	require.IsType(t, storage.ErrMetaNotFound{}, err)
+}
+
+// Metadata which is validly signed but does not match the checksum it is listed
+// with is not served, nor is anything depending on a root with bad signatures,
+// unless verification is disabled
+func TestVerifyingReadStoreRefusesCorruptedMetadata(t *testing.T) {
+	swizzler, _ := newVerifyingTestStore(t)
+	s := tuf.NewVerifyingReadStore(swizzler.MetadataCache, true)
+
+	for _, role := range []data.RoleName{data.CanonicalTargetsRole, "targets/a"} {
+		require.NoError(t, swizzler.OffsetMetadataVersion(role, 1))
+		served, err := s.Get(role.String())
+		require.IsType(t, tuf.ErrCorruptedMetadata{}, err, role.String())
+		require.Nil(t, served)
+	}
+
+	require.NoError(t, swizzler.OffsetMetadataVersion(data.CanonicalSnapshotRole, 1))
+	_, err := s.Get(data.CanonicalSnapshotRole.String())
+	require.IsType(t, tuf.ErrCorruptedMetadata{}, err)
+	require.NoError(t, swizzler.UpdateTimestampHash())
+	_, err = s.Get(data.CanonicalSnapshotRole.String())
+	require.NoError(t, err)
+
+	require.NoError(t, swizzler.InvalidateMetadataSignatures(data.CanonicalRootRole))
+	for _, role := range data.BaseRoles {
+		_, err := s.Get(role.String())
+		require.IsType(t, tuf.ErrCorruptedMetadata{}, err, role.String())
+	}
+
+	s.SetVerify(false)
+	served, err := s.Get(data.CanonicalTargetsRole.String())
+	require.NoError(t, err)
+	expected, err := swizzler.MetadataCache.GetSized(data.CanonicalTargetsRole.String(), storage.NoSizeLimit)
+	require.NoError(t, err)
+	require.Equal(t, expected, served)
+}